@@ -37,6 +37,12 @@ CONTAINER_BINARY="$CONTAINER_OUTPUT_DIR/$OUTPUT_NAME"
 
 mkdir -p "$HOST_OUTPUT_DIR"
 
+BUILDINFO_PKG="github.com/ming-go/lab/get-container-id/buildinfo"
+BUILD_COMMIT="$(git rev-parse HEAD 2>/dev/null || echo unknown)"
+BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+LDFLAGS="-X $BUILDINFO_PKG.Version=$BUILD_TAG -X $BUILDINFO_PKG.Commit=$BUILD_COMMIT -X $BUILDINFO_PKG.Date=$BUILD_DATE"
+echo "Build Commit:     $BUILD_COMMIT"
+
 # 1) build binary inside golang container
 docker run --rm \
   -v "$BUILD_PATH":"$CONTAINER_WORKDIR" \
@@ -45,7 +51,7 @@ docker run --rm \
   -e GOOS="$GOOS" \
   -e GOARCH="$GOARCH" \
   golang:"$GOLANG_VERSION" \
-  go build -v -a -installsuffix cgo -o "$CONTAINER_BINARY" .
+  go build -v -a -installsuffix cgo -ldflags "$LDFLAGS" -o "$CONTAINER_BINARY" ./cmd/get-container-id
 
 cd "$BUILD_PATH"
 
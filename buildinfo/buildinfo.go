@@ -0,0 +1,108 @@
+// Package buildinfo reports which version and commit produced the running
+// binary.
+//
+// Values set at link time take precedence:
+//
+//	go build -ldflags "-X github.com/ming-go/lab/get-container-id/buildinfo.Version=v1.2.3 \
+//	  -X github.com/ming-go/lab/get-container-id/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/ming-go/lab/get-container-id/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Anything left empty is filled from the module and VCS information the Go
+// toolchain embeds (see runtime/debug.ReadBuildInfo).
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set with -ldflags "-X ..." at build time.
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// Info describes the build of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+}
+
+var (
+	once   sync.Once
+	cached Info
+
+	readBuildInfo = debug.ReadBuildInfo
+)
+
+// Get returns the build information. It is computed once per process.
+func Get() Info {
+	once.Do(func() {
+		cached = load()
+	})
+	return cached
+}
+
+func load() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := readBuildInfo(); ok {
+		info.Module = bi.Main.Path
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "devel"
+	}
+	return info
+}
+
+// String formats the build information on one line, for example
+// "v1.2.3 (commit 0123abc, built 2024-05-01T10:00:00Z, go1.22.3)".
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		commit := i.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if i.Modified {
+			commit += "-dirty"
+		}
+		details = append(details, "commit "+commit)
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	details = append(details, i.GoVersion)
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
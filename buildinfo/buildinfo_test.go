@@ -0,0 +1,76 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func stubBuildInfo(t *testing.T, bi *debug.BuildInfo, version, commit, date string) {
+	t.Helper()
+	origRead, origVersion, origCommit, origDate := readBuildInfo, Version, Commit, Date
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return bi, bi != nil }
+	Version, Commit, Date = version, commit, date
+	t.Cleanup(func() {
+		readBuildInfo, Version, Commit, Date = origRead, origVersion, origCommit, origDate
+	})
+}
+
+// Test load fills fields from the embedded VCS information
+func TestLoad_FromBuildInfo(t *testing.T) {
+	stubBuildInfo(t, &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/mod", Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}, "", "", "")
+
+	got := load()
+	want := Info{
+		Version:   "v1.4.0",
+		Commit:    "0123456789abcdef0123",
+		Date:      "2024-05-01T10:00:00Z",
+		Modified:  true,
+		GoVersion: runtime.Version(),
+		Module:    "example.com/mod",
+	}
+	if got != want {
+		t.Errorf("load() = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "v1.4.0 (commit 0123456789ab-dirty, built 2024-05-01T10:00:00Z, "+runtime.Version()+")" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+// Test link-time values take precedence over embedded information
+func TestLoad_LdflagsWin(t *testing.T) {
+	stubBuildInfo(t, &debug.BuildInfo{
+		Main:     debug.Module{Version: "v0.0.1"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "fromvcs"}},
+	}, "v2.0.0", "fromldflags", "2025-01-01")
+
+	got := load()
+	if got.Version != "v2.0.0" || got.Commit != "fromldflags" || got.Date != "2025-01-01" {
+		t.Errorf("load() = %+v, want ldflags values", got)
+	}
+}
+
+// Test a development build without any information
+func TestLoad_Devel(t *testing.T) {
+	stubBuildInfo(t, &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, "", "", "")
+
+	got := load()
+	if got.Version != "devel" {
+		t.Errorf("Version = %q, want %q", got.Version, "devel")
+	}
+	if s := got.String(); s != "devel ("+runtime.Version()+")" {
+		t.Errorf("String() = %q", s)
+	}
+
+	stubBuildInfo(t, nil, "", "", "")
+	if got := load(); got.Version != "devel" || got.Module != "" {
+		t.Errorf("load() without build info = %+v", got)
+	}
+}
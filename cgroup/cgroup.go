@@ -0,0 +1,249 @@
+// Package cgroup inspects the control groups of the current process: the
+// cgroup version, the hierarchies it belongs to, where they are mounted, and
+// the memory and CPU limits set on them.
+//
+// It reads /proc/self/cgroup and /proc/self/mountinfo, then resolves each
+// membership to its directory under the mounted hierarchy, so the limits are
+// found both inside a cgroup namespace and on hosts without one.
+package cgroup
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+const (
+	// CgroupPath is the cgroup membership file of the current process.
+	CgroupPath = "/proc/self/cgroup"
+
+	// MountInfoPath is the mount table of the current process.
+	MountInfoPath = mountinfo.Path
+
+	fsTypeV1 = "cgroup"
+	fsTypeV2 = "cgroup2"
+)
+
+var (
+	// rootFS is the host filesystem that Inspect reads from by default.
+	rootFS fs.FS = os.DirFS("/")
+
+	// mountOptions are the cgroup v1 super options that are not controllers.
+	mountOptions = map[string]bool{
+		"rw": true, "ro": true, "none": true, "xattr": true, "noprefix": true,
+		"clone_children": true, "cpuset_v2_mode": true, "favordynmods": true,
+	}
+)
+
+// Info describes the cgroups of the current process.
+type Info struct {
+	// Version is 2 when the process only belongs to the unified hierarchy,
+	// and 1 on cgroup v1 and hybrid hosts.
+	Version int          `json:"version"`
+	Cgroups []Membership `json:"cgroups"`
+	Mounts  []Mount      `json:"mounts,omitempty"`
+	Limits  Limits       `json:"limits"`
+}
+
+// Membership is one line of /proc/self/cgroup.
+type Membership struct {
+	HierarchyID int `json:"hierarchy_id"`
+	// Controllers is empty for the cgroup v2 unified hierarchy.
+	Controllers []string `json:"controllers,omitempty"`
+	// Path is the cgroup path relative to the root of the hierarchy, as
+	// seen from the process's cgroup namespace.
+	Path string `json:"path"`
+	// Dir is the directory of the cgroup in the mounted hierarchy, or empty
+	// if the hierarchy is not mounted.
+	Dir string `json:"dir,omitempty"`
+}
+
+// Mount is a mounted cgroup hierarchy from /proc/self/mountinfo.
+type Mount struct {
+	MountPoint string `json:"mount_point"`
+	// Root is the cgroup the mount point shows, relative to the root of the
+	// hierarchy.
+	Root        string   `json:"root"`
+	Version     int      `json:"version"`
+	Controllers []string `json:"controllers,omitempty"`
+}
+
+// Option configures a call to Inspect.
+type Option func(*options)
+
+type options struct {
+	fsys    fs.FS
+	procDir string
+}
+
+// WithFS makes Inspect read every file through fsys instead of the host
+// root, for example a testing/fstest.MapFS.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithProcDir makes Inspect read the cgroup and mount files from dir
+// instead of proc/self, for example "4242" to inspect another process
+// through WithFS(os.DirFS("/host/proc")).
+func WithProcDir(dir string) Option {
+	return func(o *options) {
+		o.procDir = dir
+	}
+}
+
+// Inspect returns the cgroups of the current process and their limits. It
+// fails if the cgroup membership or the mount table cannot be read; limits
+// that are not set or not readable are left zero.
+func Inspect(opts ...Option) (Info, error) {
+	o := options{fsys: rootFS, procDir: path.Dir(fsName(CgroupPath))}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	memberships, err := readMemberships(o.fsys, path.Join(o.procDir, path.Base(CgroupPath)))
+	if err != nil {
+		return Info{}, err
+	}
+	mounts, err := readMounts(o.fsys, path.Join(o.procDir, path.Base(MountInfoPath)))
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{Version: 2, Cgroups: memberships, Mounts: mounts}
+	for i, m := range info.Cgroups {
+		if m.HierarchyID != 0 {
+			info.Version = 1
+		}
+		info.Cgroups[i].Dir = resolveDir(m, mounts)
+	}
+	info.Limits = readLimits(o.fsys, info)
+	return info, nil
+}
+
+func readMemberships(fsys fs.FS, name string) ([]Membership, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	defer file.Close()
+
+	var memberships []Membership
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		c, err := containerid.ParseCgroupLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, Membership{HierarchyID: c.HierarchyID, Controllers: c.Controllers, Path: c.Path})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading cgroup: %w", err)
+	}
+	return memberships, nil
+}
+
+// readMounts returns the cgroup v1 and v2 mounts in the mount table.
+// Malformed lines are skipped.
+func readMounts(fsys fs.FS, name string) ([]Mount, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mountinfo: %w", err)
+	}
+	defer file.Close()
+
+	var mounts []Mount
+	err = mountinfo.Scan(file, func(m mountinfo.Mount) bool {
+		switch m.FSType {
+		case fsTypeV2:
+			mounts = append(mounts, Mount{MountPoint: m.MountPoint, Root: m.Root, Version: 2})
+		case fsTypeV1:
+			mounts = append(mounts, Mount{MountPoint: m.MountPoint, Root: m.Root, Version: 1, Controllers: v1Controllers(m.SuperOptions)})
+		}
+		return false
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading mountinfo: %w", err)
+	}
+	return mounts, nil
+}
+
+// v1Controllers returns the controllers named in the super options of a
+// cgroup v1 mount, e.g. "rw,cpu,cpuacct" or "rw,name=systemd".
+func v1Controllers(superOptions string) []string {
+	var controllers []string
+	for _, opt := range strings.Split(superOptions, ",") {
+		switch {
+		case mountOptions[opt] || strings.HasPrefix(opt, "release_agent="):
+		case strings.HasPrefix(opt, "name="):
+			controllers = append(controllers, opt)
+		case !strings.Contains(opt, "="):
+			controllers = append(controllers, opt)
+		}
+	}
+	return controllers
+}
+
+// resolveDir returns the directory of m under the mount of its hierarchy.
+// The path is made relative to the mount's root; when the path lies outside
+// it, as when the mount was taken in another cgroup namespace, the mount
+// point itself is used.
+func resolveDir(m Membership, mounts []Mount) string {
+	for _, mnt := range mounts {
+		if !mnt.serves(m) {
+			continue
+		}
+		rel, ok := strings.CutPrefix(m.Path, mnt.Root)
+		if !ok || (rel != "" && !strings.HasPrefix(rel, "/") && mnt.Root != "/") {
+			return mnt.MountPoint
+		}
+		return path.Join(mnt.MountPoint, rel)
+	}
+	return ""
+}
+
+// serves reports whether mnt mounts the hierarchy of m.
+func (mnt Mount) serves(m Membership) bool {
+	if m.HierarchyID == 0 {
+		return mnt.Version == 2
+	}
+	if mnt.Version != 1 || len(m.Controllers) == 0 {
+		return false
+	}
+	for _, c := range m.Controllers {
+		if !slices.Contains(mnt.Controllers, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// dir returns the mounted directory of the hierarchy holding controller, or
+// of the unified hierarchy for an empty controller.
+func (info Info) dir(controller string) string {
+	for _, m := range info.Cgroups {
+		if m.Dir == "" {
+			continue
+		}
+		if controller == "" && m.HierarchyID == 0 || controller != "" && slices.Contains(m.Controllers, controller) {
+			return m.Dir
+		}
+	}
+	return ""
+}
+
+// fsName converts an absolute path to an fs.FS name.
+func fsName(p string) string {
+	return strings.TrimPrefix(p, "/")
+}
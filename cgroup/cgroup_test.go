@@ -0,0 +1,175 @@
+package cgroup
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	mountinfoV2 = "30 24 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime - cgroup2 cgroup2 rw,nsdelegate\n"
+
+	mountinfoV1 = "30 24 0:26 / /sys/fs/cgroup ro,nosuid,nodev,noexec - tmpfs tmpfs ro,mode=755\n" +
+		"31 30 0:27 /docker/abc /sys/fs/cgroup/memory ro,nosuid,nodev,noexec,relatime - cgroup cgroup rw,memory\n" +
+		"32 30 0:28 /docker/abc /sys/fs/cgroup/cpu,cpuacct ro,nosuid,nodev,noexec,relatime - cgroup cgroup rw,cpu,cpuacct\n" +
+		"33 30 0:29 /docker/abc /sys/fs/cgroup/systemd ro,nosuid,nodev,noexec,relatime - cgroup cgroup rw,xattr,name=systemd\n"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name        string
+		fsys        fstest.MapFS
+		wantVersion int
+		wantDirs    []string
+		wantLimits  Limits
+	}{
+		{
+			name: "v2 namespaced",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":           {Data: []byte("0::/\n")},
+				"proc/self/mountinfo":        {Data: []byte(mountinfoV2)},
+				"sys/fs/cgroup/memory.max":   {Data: []byte("268435456\n")},
+				"sys/fs/cgroup/cpu.max":      {Data: []byte("50000 100000\n")},
+				"sys/fs/cgroup/cgroup.procs": {Data: []byte("1\n")},
+			},
+			wantVersion: 2,
+			wantDirs:    []string{"/sys/fs/cgroup"},
+			wantLimits:  Limits{MemoryBytes: 268435456, CPUQuotaMicros: 50000, CPUPeriodMicros: 100000},
+		},
+		{
+			name: "v2 host namespace",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":    {Data: []byte("0::/system.slice/docker-abc.scope\n")},
+				"proc/self/mountinfo": {Data: []byte(mountinfoV2)},
+				"sys/fs/cgroup/system.slice/docker-abc.scope/memory.max": {Data: []byte("max\n")},
+				"sys/fs/cgroup/system.slice/docker-abc.scope/cpu.max":    {Data: []byte("max 100000\n")},
+			},
+			wantVersion: 2,
+			wantDirs:    []string{"/sys/fs/cgroup/system.slice/docker-abc.scope"},
+		},
+		{
+			name: "v1",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup": {Data: []byte("12:memory:/docker/abc\n" +
+					"11:cpu,cpuacct:/docker/abc\n" +
+					"1:name=systemd:/docker/abc\n" +
+					"0::/system.slice/containerd.service\n")},
+				"proc/self/mountinfo":                         {Data: []byte(mountinfoV1)},
+				"sys/fs/cgroup/memory/memory.limit_in_bytes":  {Data: []byte("536870912\n")},
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  {Data: []byte("150000\n")},
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": {Data: []byte("100000\n")},
+			},
+			wantVersion: 1,
+			wantDirs:    []string{"/sys/fs/cgroup/memory", "/sys/fs/cgroup/cpu,cpuacct", "/sys/fs/cgroup/systemd", ""},
+			wantLimits:  Limits{MemoryBytes: 536870912, CPUQuotaMicros: 150000, CPUPeriodMicros: 100000},
+		},
+		{
+			name: "v1 unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                            {Data: []byte("12:memory:/docker/abc\n11:cpu,cpuacct:/docker/abc\n")},
+				"proc/self/mountinfo":                         {Data: []byte(mountinfoV1)},
+				"sys/fs/cgroup/memory/memory.limit_in_bytes":  {Data: []byte("9223372036854771712\n")},
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  {Data: []byte("-1\n")},
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": {Data: []byte("100000\n")},
+			},
+			wantVersion: 1,
+			wantDirs:    []string{"/sys/fs/cgroup/memory", "/sys/fs/cgroup/cpu,cpuacct"},
+		},
+		{
+			name: "not mounted",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":    {Data: []byte("0::/\n")},
+				"proc/self/mountinfo": {Data: []byte("22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw\n")},
+			},
+			wantVersion: 2,
+			wantDirs:    []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Inspect(WithFS(tt.fsys))
+			if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+			if info.Version != tt.wantVersion {
+				t.Errorf("Version = %d, want %d", info.Version, tt.wantVersion)
+			}
+			var dirs []string
+			for _, m := range info.Cgroups {
+				dirs = append(dirs, m.Dir)
+			}
+			if !reflect.DeepEqual(dirs, tt.wantDirs) {
+				t.Errorf("dirs = %q, want %q", dirs, tt.wantDirs)
+			}
+			if info.Limits != tt.wantLimits {
+				t.Errorf("Limits = %+v, want %+v", info.Limits, tt.wantLimits)
+			}
+		})
+	}
+}
+
+func TestInspectMounts(t *testing.T) {
+	info, err := Inspect(WithFS(fstest.MapFS{
+		"proc/self/cgroup":    {Data: []byte("12:memory:/docker/abc\n")},
+		"proc/self/mountinfo": {Data: []byte(mountinfoV1)},
+	}))
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	want := []Mount{
+		{MountPoint: "/sys/fs/cgroup/memory", Root: "/docker/abc", Version: 1, Controllers: []string{"memory"}},
+		{MountPoint: "/sys/fs/cgroup/cpu,cpuacct", Root: "/docker/abc", Version: 1, Controllers: []string{"cpu", "cpuacct"}},
+		{MountPoint: "/sys/fs/cgroup/systemd", Root: "/docker/abc", Version: 1, Controllers: []string{"name=systemd"}},
+	}
+	if !reflect.DeepEqual(info.Mounts, want) {
+		t.Errorf("Mounts = %+v, want %+v", info.Mounts, want)
+	}
+}
+
+func TestInspectMissingFiles(t *testing.T) {
+	if _, err := Inspect(WithFS(fstest.MapFS{})); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Inspect() without cgroup error = %v, want fs.ErrNotExist", err)
+	}
+	_, err := Inspect(WithFS(fstest.MapFS{"proc/self/cgroup": {Data: []byte("0::/\n")}}))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Inspect() without mountinfo error = %v, want fs.ErrNotExist", err)
+	}
+	_, err = Inspect(WithFS(fstest.MapFS{"proc/self/cgroup": {Data: []byte("bogus\n")}}))
+	if err == nil {
+		t.Error("Inspect() with malformed cgroup error = nil, want error")
+	}
+}
+
+func TestInspectProcDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"4242/cgroup":    {Data: []byte("0::/kubepods/pod1/abc\n")},
+		"4242/mountinfo": {Data: []byte(mountinfoV2)},
+	}
+	info, err := Inspect(WithFS(fsys), WithProcDir("4242"))
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	want := []Membership{{Path: "/kubepods/pod1/abc", Dir: "/sys/fs/cgroup/kubepods/pod1/abc"}}
+	if !reflect.DeepEqual(info.Cgroups, want) {
+		t.Errorf("Cgroups = %+v, want %+v", info.Cgroups, want)
+	}
+}
+
+func TestResolveDirOutsideRoot(t *testing.T) {
+	mounts := []Mount{{MountPoint: "/sys/fs/cgroup", Root: "/kubepods/pod1", Version: 2}}
+	tests := []struct {
+		path, want string
+	}{
+		{"/kubepods/pod1/abc", "/sys/fs/cgroup/abc"},
+		{"/kubepods/pod1", "/sys/fs/cgroup"},
+		{"/kubepods/pod10", "/sys/fs/cgroup"},
+		{"/", "/sys/fs/cgroup"},
+	}
+	for _, tt := range tests {
+		if got := resolveDir(Membership{Path: tt.path}, mounts); got != tt.want {
+			t.Errorf("resolveDir(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
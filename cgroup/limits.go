@@ -0,0 +1,127 @@
+package cgroup
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// unlimitedMemoryV1 is the smallest value cgroup v1 reports for "no limit"
+// (PAGE_COUNTER_MAX rounded to pages, depending on architecture).
+const unlimitedMemoryV1 = 1 << 62
+
+// Limits are the memory and CPU limits of the process's cgroups. Zero means
+// no limit is set or it could not be read.
+type Limits struct {
+	MemoryBytes int64 `json:"memory_bytes,omitempty"`
+	// CPUQuotaMicros and CPUPeriodMicros are the CFS bandwidth limit.
+	CPUQuotaMicros  int64 `json:"cpu_quota_us,omitempty"`
+	CPUPeriodMicros int64 `json:"cpu_period_us,omitempty"`
+}
+
+// CPUs returns the CPU limit as the quota divided by the period, or 0 if no
+// CPU limit is set.
+func (l Limits) CPUs() float64 {
+	if l.CPUQuotaMicros <= 0 || l.CPUPeriodMicros <= 0 {
+		return 0
+	}
+	return float64(l.CPUQuotaMicros) / float64(l.CPUPeriodMicros)
+}
+
+// readLimits reads memory.max and cpu.max on cgroup v2, and
+// memory.limit_in_bytes and cpu.cfs_quota_us/cpu.cfs_period_us on v1.
+func readLimits(fsys fs.FS, info Info) Limits {
+	var l Limits
+	if info.Version == 2 {
+		dir := info.dir("")
+		if dir == "" {
+			return l
+		}
+		l.MemoryBytes, _ = readMemory(fsys, path.Join(dir, "memory.max"))
+		l.CPUQuotaMicros, l.CPUPeriodMicros, _ = readCPUMax(fsys, path.Join(dir, "cpu.max"))
+		return l
+	}
+
+	if dir := info.dir("memory"); dir != "" {
+		l.MemoryBytes, _ = readMemory(fsys, path.Join(dir, "memory.limit_in_bytes"))
+	}
+	if dir := info.dir("cpu"); dir != "" {
+		l.CPUQuotaMicros, l.CPUPeriodMicros, _ = readCFSQuota(fsys, path.Join(dir, "cpu.cfs_quota_us"), path.Join(dir, "cpu.cfs_period_us"))
+	}
+	return l
+}
+
+// readMemory reads a memory limit file, returning 0 for "max" and for the
+// v1 unlimited value.
+func readMemory(fsys fs.FS, name string) (int64, error) {
+	value, err := readValue(fsys, name)
+	if err != nil || value == "max" {
+		return 0, err
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if limit >= unlimitedMemoryV1 {
+		return 0, nil
+	}
+	return limit, nil
+}
+
+// readCPUMax reads a v2 cpu.max file, "<quota> <period>" or "max <period>".
+func readCPUMax(fsys fs.FS, name string) (quota, period int64, err error) {
+	value, err := readValue(fsys, name)
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("failed to parse %s: %q", name, value)
+	}
+	if fields[0] == "max" {
+		return 0, 0, nil
+	}
+	return parseQuota(name, fields[0], fields[1])
+}
+
+// readCFSQuota reads the v1 CFS quota and period files. A quota of -1 means
+// no limit.
+func readCFSQuota(fsys fs.FS, quotaName, periodName string) (quota, period int64, err error) {
+	q, err := readValue(fsys, quotaName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if q == "-1" {
+		return 0, 0, nil
+	}
+	p, err := readValue(fsys, periodName)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseQuota(quotaName, q, p)
+}
+
+func parseQuota(name, quotaStr, periodStr string) (int64, int64, error) {
+	quota, err := strconv.ParseInt(quotaStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	period, err := strconv.ParseInt(periodStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if quota <= 0 || period <= 0 {
+		return 0, 0, fmt.Errorf("failed to parse %s: quota %d, period %d", name, quota, period)
+	}
+	return quota, period, nil
+}
+
+func readValue(fsys fs.FS, name string) (string, error) {
+	b, err := fs.ReadFile(fsys, fsName(name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,63 @@
+package cgroup
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLimitsCPUs(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   float64
+	}{
+		{Limits{}, 0},
+		{Limits{CPUQuotaMicros: 50000, CPUPeriodMicros: 100000}, 0.5},
+		{Limits{CPUQuotaMicros: 250000, CPUPeriodMicros: 100000}, 2.5},
+	}
+	for _, tt := range tests {
+		if got := tt.limits.CPUs(); got != tt.want {
+			t.Errorf("%+v.CPUs() = %v, want %v", tt.limits, got, tt.want)
+		}
+	}
+}
+
+func TestReadCPUMax(t *testing.T) {
+	tests := []struct {
+		data                  string
+		wantQuota, wantPeriod int64
+		wantErr               bool
+	}{
+		{"150000 100000\n", 150000, 100000, false},
+		{"max 100000\n", 0, 0, false},
+		{"150000\n", 0, 0, true},
+		{"abc 100000\n", 0, 0, true},
+		{"0 100000\n", 0, 0, true},
+	}
+	for _, tt := range tests {
+		fsys := fstest.MapFS{"cpu.max": {Data: []byte(tt.data)}}
+		quota, period, err := readCPUMax(fsys, "/cpu.max")
+		if quota != tt.wantQuota || period != tt.wantPeriod || (err != nil) != tt.wantErr {
+			t.Errorf("readCPUMax(%q) = %d, %d, %v, want %d, %d, error %v", tt.data, quota, period, err, tt.wantQuota, tt.wantPeriod, tt.wantErr)
+		}
+	}
+}
+
+func TestReadMemory(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    int64
+		wantErr bool
+	}{
+		{"268435456\n", 268435456, false},
+		{"max\n", 0, false},
+		{"9223372036854771712\n", 0, false},
+		{"lots\n", 0, true},
+	}
+	for _, tt := range tests {
+		fsys := fstest.MapFS{"memory.max": {Data: []byte(tt.data)}}
+		got, err := readMemory(fsys, "/memory.max")
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("readMemory(%q) = %d, %v, want %d, error %v", tt.data, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
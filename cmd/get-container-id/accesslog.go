@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// accessLogConfig controls which requests the access log records and what
+// it includes.
+type accessLogConfig struct {
+	// SampleRate is the fraction of requests (0..1) that are logged.
+	SampleRate float64
+	// MaxBodyBytes is how much of the request body is logged; zero logs none.
+	MaxBodyBytes int64
+	// AllowHeaders, when not empty, are the only request headers logged.
+	AllowHeaders []string
+	// DenyHeaders are logged as [REDACTED].
+	DenyHeaders []string
+	// ExcludePaths are path prefixes that are never logged.
+	ExcludePaths []string
+}
+
+func (c accessLogConfig) validate() error {
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("access log sample rate must be between 0 and 1, got %v", c.SampleRate)
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("access log max body size must not be negative, got %d", c.MaxBodyBytes)
+	}
+	return nil
+}
+
+// accessLogger logs one record per sampled request once it has been served.
+type accessLogger struct {
+	logger *slog.Logger
+	config accessLogConfig
+	allow  map[string]bool
+	deny   map[string]bool
+	random func() float64
+}
+
+func newAccessLogger(logger *slog.Logger, config accessLogConfig) *accessLogger {
+	return &accessLogger{
+		logger: logger,
+		config: config,
+		allow:  canonicalHeaderSet(config.AllowHeaders),
+		deny:   canonicalHeaderSet(config.DenyHeaders),
+		random: rand.Float64,
+	}
+}
+
+func canonicalHeaderSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// sampled reports whether the request to path is logged.
+func (a *accessLogger) sampled(path string) bool {
+	for _, prefix := range a.config.ExcludePaths {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return a.config.SampleRate >= 1 || a.random() < a.config.SampleRate
+}
+
+// middleware logs the request with the response status, size and latency.
+// Up to MaxBodyBytes of the request body are read ahead and logged as text;
+// the handler still reads the full body.
+func (a *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.sampled(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		var truncated bool
+		if a.config.MaxBodyBytes > 0 && r.Body != nil && r.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, a.config.MaxBodyBytes+1))
+			if err != nil {
+				httpapi.WriteError(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			if int64(len(body)) > a.config.MaxBodyBytes {
+				body, truncated = body[:a.config.MaxBodyBytes], true
+			}
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		attrs := []slog.Attr{
+			slog.String("request_method", r.Method),
+			slog.String("request_url", getRequestURL(r)),
+			slog.String("request_url_path", r.URL.Path),
+			slog.String("request_protocol", r.Proto),
+			slog.Any("request_header", a.headers(r.Header)),
+			slog.String("remote_address", r.RemoteAddr),
+			slog.Int("response_status", sw.status),
+			slog.Int64("response_bytes", sw.bytes),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if a.config.MaxBodyBytes > 0 {
+			attrs = append(attrs, slog.String("request_body", string(body)), slog.Bool("request_body_truncated", truncated))
+		}
+		requestLogger(r.Context(), a.logger).LogAttrs(r.Context(), slog.LevelInfo, "IncomeLog", attrs...)
+	})
+}
+
+// headers returns the request headers to log: the allowed ones if an allow
+// list is set, with denied ones redacted.
+func (a *accessLogger) headers(h http.Header) http.Header {
+	logged := make(http.Header, len(h))
+	for name, values := range h {
+		switch {
+		case len(a.allow) > 0 && !a.allow[name]:
+		case a.deny[name]:
+			logged[name] = []string{redacted}
+		default:
+			logged[name] = values
+		}
+	}
+	return logged
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveLogged sends req through the access log and returns the handler's
+// view of the body and the decoded log record, or nil if none was written.
+func serveLogged(t *testing.T, config accessLogConfig, req *http.Request) (string, map[string]any) {
+	t.Helper()
+	var logs bytes.Buffer
+	a := newAccessLogger(slog.New(slog.NewJSONHandler(&logs, nil)), config)
+
+	var body []byte
+	h := a.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if logs.Len() == 0 {
+		return string(body), nil
+	}
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("log unmarshal error: %v", err)
+	}
+	return string(body), record
+}
+
+// Test the access log records the response and a truncated text body
+func TestAccessLogger_Record(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello, world"))
+	body, record := serveLogged(t, accessLogConfig{SampleRate: 1, MaxBodyBytes: 5}, req)
+
+	if body != "hello, world" {
+		t.Errorf("handler read %q, want the full body", body)
+	}
+	if record == nil {
+		t.Fatal("no access log record written")
+	}
+	checks := map[string]any{
+		"msg":                    "IncomeLog",
+		"request_method":         http.MethodPost,
+		"request_url_path":       "/echo",
+		"response_status":        float64(http.StatusTeapot),
+		"response_bytes":         float64(len("short and stout")),
+		"request_body":           "hello",
+		"request_body_truncated": true,
+	}
+	for key, want := range checks {
+		if record[key] != want {
+			t.Errorf("record[%q] = %v, want %v", key, record[key], want)
+		}
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("record has no latency")
+	}
+}
+
+// Test the access log omits the body unless MaxBodyBytes is set
+func TestAccessLogger_NoBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("secret"))
+	body, record := serveLogged(t, accessLogConfig{SampleRate: 1}, req)
+
+	if body != "secret" {
+		t.Errorf("handler read %q, want %q", body, "secret")
+	}
+	if _, ok := record["request_body"]; ok {
+		t.Errorf("record = %v, want no request_body", record)
+	}
+}
+
+// Test header allow and deny lists
+func TestAccessLogger_Headers(t *testing.T) {
+	tests := []struct {
+		name   string
+		config accessLogConfig
+		want   map[string]any
+	}{
+		{"deny", accessLogConfig{SampleRate: 1, DenyHeaders: []string{"authorization"}}, map[string]any{
+			"Authorization": []any{redacted},
+			"User-Agent":    []any{"test"},
+			"X-Trace":       []any{"abc"},
+		}},
+		{"allow", accessLogConfig{SampleRate: 1, AllowHeaders: []string{"x-trace", "Authorization"}, DenyHeaders: []string{"Authorization"}}, map[string]any{
+			"Authorization": []any{redacted},
+			"X-Trace":       []any{"abc"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer s3cret")
+			req.Header.Set("User-Agent", "test")
+			req.Header.Set("X-Trace", "abc")
+
+			_, record := serveLogged(t, tt.config, req)
+			got, _ := record["request_header"].(map[string]any)
+			if len(got) != len(tt.want) {
+				t.Errorf("request_header = %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if gotValues, _ := json.Marshal(got[name]); string(gotValues) != mustMarshal(t, want) {
+					t.Errorf("request_header[%q] = %s, want %s", name, gotValues, mustMarshal(t, want))
+				}
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// Test sampling and excluded paths
+func TestAccessLogger_Sampled(t *testing.T) {
+	a := newAccessLogger(slog.Default(), accessLogConfig{SampleRate: 0.5, ExcludePaths: []string{"/livez"}})
+
+	a.random = func() float64 { return 0.4 }
+	if !a.sampled("/echo") {
+		t.Error("sampled(/echo) with 0.4 < 0.5 = false, want true")
+	}
+	if a.sampled("/livez") {
+		t.Error("sampled(/livez) = true, want excluded")
+	}
+	a.random = func() float64 { return 0.6 }
+	if a.sampled("/echo") {
+		t.Error("sampled(/echo) with 0.6 >= 0.5 = true, want false")
+	}
+
+	_, record := serveLogged(t, accessLogConfig{SampleRate: 0}, httptest.NewRequest(http.MethodGet, "/", nil))
+	if record != nil {
+		t.Errorf("record with sample rate 0 = %v, want none", record)
+	}
+}
+
+// Test accessLogConfig validation
+func TestAccessLogConfig_Validate(t *testing.T) {
+	for _, c := range []accessLogConfig{{SampleRate: -0.1}, {SampleRate: 1.1}, {SampleRate: 1, MaxBodyBytes: -1}} {
+		if err := c.validate(); err == nil {
+			t.Errorf("validate(%+v) = nil, want error", c)
+		}
+	}
+	if err := (accessLogConfig{SampleRate: 0.5, MaxBodyBytes: 10}).validate(); err != nil {
+		t.Errorf("validate() = %v, want nil", err)
+	}
+}
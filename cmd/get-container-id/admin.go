@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// adminSignal is recorded as the shutdown signal when /admin/shutdown
+// starts the shutdown.
+type adminSignal struct{}
+
+func (adminSignal) String() string { return "admin" }
+func (adminSignal) Signal()        {}
+
+// shutdownRequest starts the graceful shutdown with the given drain period.
+type shutdownRequest struct {
+	signal os.Signal
+	drain  time.Duration
+}
+
+// adminShutdown serves POST /admin/shutdown, which runs the same graceful
+// shutdown as SIGTERM for environments without an orchestrator.
+type adminShutdown struct {
+	token       string
+	drainPeriod time.Duration
+	shutdown    *shutdownState
+
+	// requests receives at most one accepted shutdown request.
+	requests chan shutdownRequest
+}
+
+func newAdminShutdown(token string, drainPeriod time.Duration, shutdown *shutdownState) *adminShutdown {
+	return &adminShutdown{
+		token:       token,
+		drainPeriod: drainPeriod,
+		shutdown:    shutdown,
+		requests:    make(chan shutdownRequest, 1),
+	}
+}
+
+// authorized reports whether r carries the admin token as a bearer token.
+func (a *adminShutdown) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// handleShutdown starts the shutdown, draining for ?drain= (default
+// -drainPeriod).
+func (a *adminShutdown) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	if a.token == "" {
+		httpapi.WriteError(w, "admin endpoints are disabled (start with -adminToken)", http.StatusForbidden)
+		return
+	}
+	if !a.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		httpapi.WriteError(w, "invalid or missing admin token", http.StatusUnauthorized)
+		return
+	}
+
+	drain := a.drainPeriod
+	if s := r.URL.Query().Get("drain"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			httpapi.WriteError(w, "drain must be a non-negative duration, e.g. 30s", http.StatusBadRequest)
+			return
+		}
+		drain = d
+	}
+
+	if !a.shutdown.begin(adminSignal{}) {
+		httpapi.WriteError(w, "shutdown already in progress", http.StatusConflict)
+		return
+	}
+	a.requests <- shutdownRequest{signal: adminSignal{}, drain: drain}
+
+	httpapi.WriteJSON(w, httpapi.Response{Data: map[string]string{"drain_period": drain.String()}}, http.StatusAccepted)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test /admin/shutdown rejects requests without the token
+func TestAdminShutdown_Auth(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		auth  string
+		query string
+		want  int
+	}{
+		{"disabled", "", "Bearer s3cret", "", http.StatusForbidden},
+		{"missing token", "s3cret", "", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer nope", "", http.StatusUnauthorized},
+		{"basic auth", "s3cret", "Basic s3cret", "", http.StatusUnauthorized},
+		{"invalid drain", "s3cret", "Bearer s3cret", "?drain=soon", http.StatusBadRequest},
+		{"negative drain", "s3cret", "Bearer s3cret", "?drain=-1s", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown := newShutdownState()
+			a := newAdminShutdown(tt.token, time.Second, shutdown)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/shutdown"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			a.handleShutdown(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body: %s)", w.Code, tt.want, w.Body.String())
+			}
+			if shutdown.draining() {
+				t.Error("rejected request started the shutdown")
+			}
+		})
+	}
+}
+
+// Test /admin/shutdown starts the shutdown once with the requested drain
+func TestAdminShutdown(t *testing.T) {
+	shutdown := newShutdownState()
+	a := newAdminShutdown("s3cret", time.Second, shutdown)
+
+	post := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/shutdown"+query, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		w := httptest.NewRecorder()
+		a.handleShutdown(w, req)
+		return w
+	}
+
+	w := post("?drain=30s")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d (body: %s)", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			DrainPeriod string `json:"drain_period"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if resp.Data.DrainPeriod != "30s" {
+		t.Errorf("drain_period = %q, want 30s", resp.Data.DrainPeriod)
+	}
+
+	select {
+	case req := <-a.requests:
+		if req.drain != 30*time.Second || req.signal.String() != "admin" {
+			t.Errorf("shutdown request = %+v, want admin with 30s drain", req)
+		}
+	default:
+		t.Fatal("no shutdown request was sent")
+	}
+	if st := shutdown.status(); st.Signal != "admin" {
+		t.Errorf("shutdown signal = %q, want admin", st.Signal)
+	}
+
+	if w := post(""); w.Code != http.StatusConflict {
+		t.Errorf("second request status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+// Test /admin/shutdown defaults to -drainPeriod
+func TestAdminShutdown_DefaultDrain(t *testing.T) {
+	a := newAdminShutdown("s3cret", 15*time.Second, newShutdownState())
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/shutdown", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	a.handleShutdown(httptest.NewRecorder(), req)
+
+	if req := <-a.requests; req.drain != 15*time.Second {
+		t.Errorf("drain = %v, want the 15s default", req.drain)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	// defaultAuthProtect is the default -authProtect: the endpoints that
+	// reveal configuration or internals.
+	defaultAuthProtect = "/env,/debug,/config,/configz,/requests"
+
+	authRealm = "get-container-id"
+)
+
+// authConfig protects sensitive endpoints with a bearer token, HTTP basic
+// auth or both. Entries are paths such as /env, which also cover the paths
+// below them, as for endpointConfig.
+type authConfig struct {
+	Token         string
+	BasicUser     string
+	BasicPassword string
+
+	// Protect lists the endpoints that need credentials.
+	Protect []string
+	// Public lists endpoints that stay open even below a protected entry,
+	// e.g. /debug/detection under /debug.
+	Public []string
+}
+
+// parseBasicAuth splits a -basicAuth value of the form user:pass.
+func parseBasicAuth(s string) (user, password string, err error) {
+	if s == "" {
+		return "", "", nil
+	}
+	user, password, ok := strings.Cut(s, ":")
+	if !ok || user == "" || password == "" {
+		return "", "", errors.New("basic auth must be user:pass with a non-empty user and password")
+	}
+	return user, password, nil
+}
+
+func (c authConfig) enabled() bool {
+	return c.Token != "" || c.BasicUser != ""
+}
+
+func (c authConfig) validate() error {
+	for _, entry := range append(c.Protect[:len(c.Protect):len(c.Protect)], c.Public...) {
+		if !strings.HasPrefix(entry, "/") {
+			return fmt.Errorf("auth endpoint %q must be a path starting with /", entry)
+		}
+	}
+	return nil
+}
+
+// protected reports whether requests for path need credentials.
+func (c authConfig) protected(path string) bool {
+	for _, entry := range c.Public {
+		if endpointMatches(entry, path) {
+			return false
+		}
+	}
+	for _, entry := range c.Protect {
+		if endpointMatches(entry, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorized reports whether r carries the bearer token or the basic auth
+// credentials.
+func (c authConfig) authorized(r *http.Request) bool {
+	if c.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1 {
+			return true
+		}
+	}
+	if c.BasicUser != "" {
+		if user, password, ok := r.BasicAuth(); ok {
+			userOK := subtle.ConstantTimeCompare([]byte(user), []byte(c.BasicUser))
+			passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(c.BasicPassword))
+			return userOK&passwordOK == 1
+		}
+	}
+	return false
+}
+
+// middleware answers unauthenticated requests for protected endpoints with
+// 401 and a challenge for each configured scheme. Without credentials
+// configured every endpoint is open.
+func (c authConfig) middleware(next http.Handler) http.Handler {
+	if !c.enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.protected(r.URL.Path) && !c.authorized(r) {
+			if c.Token != "" {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+			}
+			if c.BasicUser != "" {
+				w.Header().Add("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			}
+			httpapi.WriteError(w, "endpoint "+r.URL.Path+" requires authentication", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test parseBasicAuth requires a user and a password
+func TestParseBasicAuth(t *testing.T) {
+	if user, password, err := parseBasicAuth("admin:s3cr:et"); err != nil || user != "admin" || password != "s3cr:et" {
+		t.Errorf("parseBasicAuth() = %q, %q, %v; want admin, s3cr:et", user, password, err)
+	}
+	if user, _, err := parseBasicAuth(""); err != nil || user != "" {
+		t.Errorf("parseBasicAuth(\"\") = %q, %v; want no credentials", user, err)
+	}
+	for _, bad := range []string{"admin", "admin:", ":pass"} {
+		if _, _, err := parseBasicAuth(bad); err == nil {
+			t.Errorf("parseBasicAuth(%q) error = nil, want an error", bad)
+		}
+	}
+}
+
+// Test the auth middleware protects only the configured endpoints
+func TestAuthMiddleware(t *testing.T) {
+	c := authConfig{
+		Token:         "t0ken",
+		BasicUser:     "admin",
+		BasicPassword: "pass",
+		Protect:       splitList(defaultAuthProtect),
+		Public:        []string{"/debug/detection"},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := c.middleware(ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		bearer     string
+		user, pass string
+		wantStatus int
+	}{
+		{name: "open endpoint", path: "/livez", wantStatus: http.StatusOK},
+		{name: "identity endpoint", path: "/container_id", wantStatus: http.StatusOK},
+		{name: "protected without credentials", path: "/env", wantStatus: http.StatusUnauthorized},
+		{name: "protected subpath", path: "/debug/pprof/heap", wantStatus: http.StatusUnauthorized},
+		{name: "public below protected", path: "/debug/detection", wantStatus: http.StatusOK},
+		{name: "bearer token", path: "/config", bearer: "t0ken", wantStatus: http.StatusOK},
+		{name: "wrong bearer token", path: "/config", bearer: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "basic auth", path: "/configz", user: "admin", pass: "pass", wantStatus: http.StatusOK},
+		{name: "wrong basic password", path: "/configz", user: "admin", pass: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "similar prefix stays open", path: "/environment", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+			}
+			if w.Code == http.StatusUnauthorized && len(w.Header().Values("WWW-Authenticate")) != 2 {
+				t.Errorf("WWW-Authenticate = %q, want a Bearer and a Basic challenge", w.Header().Values("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// Test the auth middleware leaves everything open without credentials
+func TestAuthMiddleware_Disabled(t *testing.T) {
+	h := authConfig{Protect: []string{"/env"}}.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/env", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /env status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+// Test authConfig.validate rejects entries that are not paths
+func TestAuthConfigValidate(t *testing.T) {
+	if err := (authConfig{Protect: []string{"/env"}, Public: []string{"/debug/detection"}}).validate(); err != nil {
+		t.Errorf("validate() error = %v, want nil", err)
+	}
+	if err := (authConfig{Protect: []string{"env"}}).validate(); err == nil {
+		t.Error("validate() error = nil, want an error for a relative entry")
+	}
+}
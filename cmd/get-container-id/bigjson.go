@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	defaultBigJSONItems      = 1000
+	maxBigJSONItems          = 10_000_000
+	defaultBigJSONFieldBytes = 64
+	maxBigJSONFieldBytes     = 1 << 20
+	defaultBigJSONDepth      = 1
+	maxBigJSONDepth          = 64
+)
+
+const bigJSONAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// bigJSONField returns a deterministic ASCII string of n bytes for seed.
+func bigJSONField(seed, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = bigJSONAlphabet[(seed+i)%len(bigJSONAlphabet)]
+	}
+	return b
+}
+
+// writeBigJSONItem writes one item whose "nested" chain is depth levels deep.
+func writeBigJSONItem(w *bufio.Writer, id, fieldBytes, depth int) {
+	w.WriteString(`{"id":`)
+	w.WriteString(strconv.Itoa(id))
+	w.WriteString(`,"name":"item-`)
+	w.WriteString(strconv.Itoa(id))
+	w.WriteString(`","payload":"`)
+	w.Write(bigJSONField(id, fieldBytes))
+	w.WriteString(`"`)
+
+	for level := 1; level <= depth; level++ {
+		w.WriteString(`,"nested":{"level":`)
+		w.WriteString(strconv.Itoa(level))
+		w.WriteString(`,"value":"`)
+		w.Write(bigJSONField(id+level, min(fieldBytes, 16)))
+		w.WriteString(`"`)
+	}
+	for level := 0; level < depth; level++ {
+		w.WriteByte('}')
+	}
+	w.WriteByte('}')
+}
+
+// handleBigJSON streams a deterministic JSON document with ?items= entries,
+// each carrying a ?fieldBytes= payload and a ?depth= level nested chain.
+// The same parameters always produce byte-identical output.
+func handleBigJSON(w http.ResponseWriter, r *http.Request) {
+	items, ok := queryInt(r, "items", defaultBigJSONItems, 0, maxBigJSONItems)
+	if !ok {
+		httpapi.WriteError(w, "items must be an integer between 0 and "+strconv.Itoa(maxBigJSONItems), http.StatusBadRequest)
+		return
+	}
+	fieldBytes, ok := queryInt(r, "fieldBytes", defaultBigJSONFieldBytes, 0, maxBigJSONFieldBytes)
+	if !ok {
+		httpapi.WriteError(w, "fieldBytes must be an integer between 0 and "+strconv.Itoa(maxBigJSONFieldBytes), http.StatusBadRequest)
+		return
+	}
+	depth, ok := queryInt(r, "depth", defaultBigJSONDepth, 0, maxBigJSONDepth)
+	if !ok {
+		httpapi.WriteError(w, "depth must be an integer between 0 and "+strconv.Itoa(maxBigJSONDepth), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+
+	bw := bufio.NewWriterSize(w, 32*1024)
+	bw.WriteString(`{"data":{"count":`)
+	bw.WriteString(strconv.FormatInt(items, 10))
+	bw.WriteString(`,"items":[`)
+	for i := 0; i < int(items); i++ {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		writeBigJSONItem(bw, i, int(fieldBytes), int(depth))
+	}
+	bw.WriteString("]}}")
+	bw.Flush()
+}
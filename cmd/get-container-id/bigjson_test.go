@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test handleBigJSON produces valid, deterministic JSON of the requested shape
+func TestHandleBigJSON(t *testing.T) {
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handleBigJSON(w, httptest.NewRequest(http.MethodGet, "/bigjson?items=25&fieldBytes=100&depth=3", nil))
+		return w
+	}
+
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleBigJSON() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data struct {
+			Count int `json:"count"`
+			Items []struct {
+				ID      int            `json:"id"`
+				Payload string         `json:"payload"`
+				Nested  map[string]any `json:"nested"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("handleBigJSON() produced invalid JSON: %v", err)
+	}
+
+	if resp.Data.Count != 25 || len(resp.Data.Items) != 25 {
+		t.Fatalf("handleBigJSON() count = %d, items = %d, want 25", resp.Data.Count, len(resp.Data.Items))
+	}
+
+	item := resp.Data.Items[7]
+	if item.ID != 7 || len(item.Payload) != 100 {
+		t.Errorf("handleBigJSON() item = id %d, payload %d bytes; want id 7, 100 bytes", item.ID, len(item.Payload))
+	}
+
+	depth := 0
+	for nested := item.Nested; nested != nil; depth++ {
+		nested, _ = nested["nested"].(map[string]any)
+	}
+	if depth != 3 {
+		t.Errorf("handleBigJSON() nesting depth = %d, want 3", depth)
+	}
+
+	if !bytes.Equal(w.Body.Bytes(), get().Body.Bytes()) {
+		t.Error("handleBigJSON() output is not deterministic")
+	}
+}
+
+// Test handleBigJSON validates parameters
+func TestHandleBigJSON_Invalid(t *testing.T) {
+	for _, query := range []string{"items=-1", "fieldBytes=x", "depth=1000"} {
+		w := httptest.NewRecorder()
+		handleBigJSON(w, httptest.NewRequest(http.MethodGet, "/bigjson?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("handleBigJSON(%q) status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
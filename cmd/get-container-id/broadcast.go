@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	// subscriberBuffer is the number of events queued per SSE client before
+	// further events are dropped for that client.
+	subscriberBuffer = 16
+
+	// defaultEventsHeartbeat is the default ?heartbeat= interval of /events,
+	// in seconds.
+	defaultEventsHeartbeat = 15
+)
+
+// broadcastEvent is one message fanned out to every subscriber.
+type broadcastEvent struct {
+	Seq        uint64 `json:"seq"`
+	Message    string `json:"message"`
+	InstanceID string `json:"instance_id"`
+	Time       string `json:"time"`
+}
+
+// eventsHeartbeat is the data of the periodic heartbeat events of /events.
+// Counter is the current /counter value, so clients can watch the replica
+// they are pinned to serve traffic.
+type eventsHeartbeat struct {
+	heartbeatRecord
+	Counter uint64 `json:"counter"`
+}
+
+// broadcastHub fans messages out to all SSE clients connected to this replica.
+type broadcastHub struct {
+	counter *hitCounter
+
+	mu          sync.Mutex
+	subscribers map[chan broadcastEvent]struct{}
+	seq         uint64
+	closed      bool
+}
+
+// newBroadcastHub returns a hub whose heartbeats report counter; it may be
+// nil, in which case the counter is always zero.
+func newBroadcastHub(counter *hitCounter) *broadcastHub {
+	return &broadcastHub{counter: counter, subscribers: make(map[chan broadcastEvent]struct{})}
+}
+
+// subscribe registers a new client. The returned channel is closed when the
+// client unsubscribes or the hub shuts down.
+func (h *broadcastHub) subscribe() (<-chan broadcastEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan broadcastEvent, subscriberBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[ch]; ok {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends message to every subscriber and returns how many received it.
+// Slow subscribers with a full buffer miss the event rather than blocking.
+func (h *broadcastHub) publish(message string) (broadcastEvent, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := broadcastEvent{
+		Seq:        h.seq,
+		Message:    message,
+		InstanceID: instanceID,
+		Time:       time.Now().Format(time.RFC3339Nano),
+	}
+
+	delivered := 0
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+			delivered++
+		default:
+		}
+	}
+	return event, delivered
+}
+
+// close disconnects all subscribers; used when the server shuts down.
+func (h *broadcastHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (h *broadcastHub) handleBroadcast(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		httpapi.WriteError(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	event, delivered := h.publish(string(body))
+	httpapi.WriteSuccess(w, map[string]any{
+		"event":     event,
+		"delivered": delivered,
+	})
+}
+
+// heartbeat returns heartbeat number seq of a connection.
+func (h *broadcastHub) heartbeat(seq uint64, containerID, podID string) eventsHeartbeat {
+	var counter uint64
+	if h.counter != nil {
+		counter = h.counter.total.Load()
+	}
+	return eventsHeartbeat{
+		heartbeatRecord: heartbeatRecord{
+			Seq:         seq,
+			Time:        time.Now().Format(time.RFC3339Nano),
+			InstanceID:  instanceID,
+			ContainerID: containerID,
+			PodID:       podID,
+		},
+		Counter: counter,
+	}
+}
+
+// handleEvents streams broadcast messages to the client as Server-Sent Events.
+// A heartbeat event identifying this replica is sent on connect and then
+// every ?heartbeat= seconds; 0 disables heartbeats.
+func (h *broadcastHub) handleEvents(w http.ResponseWriter, r *http.Request) {
+	interval, ok := queryInt(r, "heartbeat", defaultEventsHeartbeat, 0, int64(time.Hour/time.Second))
+	if !ok {
+		httpapi.WriteError(w, "heartbeat must be an integer between 0 and "+strconv.Itoa(int(time.Hour/time.Second)), http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Long-lived stream: lift the server-wide write timeout for this response.
+	rc.SetWriteDeadline(time.Time{})
+
+	events, unsubscribe := h.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set(headerContentType, "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, ": connected to %s\n\n", instanceID)
+
+	// A nil channel never fires, leaving heartbeats disabled.
+	var ticks <-chan time.Time
+	var beats uint64
+	var containerID, podID string
+	if interval > 0 {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		ticks = ticker.C
+
+		containerID, _ = getContainerID(r.Context())
+		podID, _ = podid.GetContext(r.Context())
+		beats++
+		if err := writeSSE(w, "heartbeat", 0, h.heartbeat(beats, containerID, podID)); err != nil {
+			return
+		}
+	}
+	rc.Flush()
+
+	// Heartbeats repeat the id of the last message so a reconnecting client's
+	// Last-Event-ID still refers to a broadcast.
+	var lastID uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticks:
+			beats++
+			if err := writeSSE(w, "heartbeat", lastID, h.heartbeat(beats, containerID, podID)); err != nil {
+				return
+			}
+			rc.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			lastID = event.Seq
+			if err := writeSSE(w, "message", event.Seq, event); err != nil {
+				return
+			}
+			rc.Flush()
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event with a JSON-encoded data field.
+func writeSSE(w io.Writer, event string, id uint64, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event, id, b)
+	return err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test broadcastHub delivers published messages to subscribers
+func TestBroadcastHub(t *testing.T) {
+	h := newBroadcastHub(nil)
+
+	ch1, unsubscribe1 := h.subscribe()
+	ch2, unsubscribe2 := h.subscribe()
+	defer unsubscribe2()
+
+	event, delivered := h.publish("hello")
+	if delivered != 2 {
+		t.Errorf("publish() delivered = %d, want 2", delivered)
+	}
+	if event.Seq != 1 || event.Message != "hello" || event.InstanceID != instanceID {
+		t.Errorf("publish() event = %+v", event)
+	}
+
+	for _, ch := range []<-chan broadcastEvent{ch1, ch2} {
+		if got := <-ch; got != event {
+			t.Errorf("subscriber received %+v, want %+v", got, event)
+		}
+	}
+
+	unsubscribe1()
+	if _, delivered := h.publish("again"); delivered != 1 {
+		t.Errorf("publish() after unsubscribe delivered = %d, want 1", delivered)
+	}
+
+	h.close()
+	<-ch2 // buffered "again"
+	if _, ok := <-ch2; ok {
+		t.Error("subscriber channel should be closed after close()")
+	}
+}
+
+// Test /events streams messages posted to /broadcast
+func TestBroadcastHubHandlers(t *testing.T) {
+	h := newBroadcastHub(nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /broadcast", h.handleBroadcast)
+	mux.HandleFunc("GET /events", h.handleEvents)
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer h.close()
+
+	resp, err := http.Get(srv.URL + "/events?heartbeat=0")
+	if err != nil {
+		t.Fatalf("GET /events error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("GET /events Content-Type = %q, want text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("GET /events first line = %q, want connection comment", line)
+	}
+
+	// Wait until the subscription is registered before publishing.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.mu.Lock()
+		n := len(h.subscribers)
+		h.mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	post, err := http.Post(srv.URL+"/broadcast", "text/plain", strings.NewReader("ping"))
+	if err != nil {
+		t.Fatalf("POST /broadcast error: %v", err)
+	}
+	post.Body.Close()
+
+	var data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		if d, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			data = d
+			break
+		}
+	}
+
+	var event broadcastEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("event data unmarshal error: %v", err)
+	}
+	if event.Message != "ping" {
+		t.Errorf("event message = %q, want %q", event.Message, "ping")
+	}
+}
+
+// Test /events sends heartbeats with the instance ID and counter value
+func TestBroadcastHubHeartbeat(t *testing.T) {
+	origInstance := instanceID
+	defer func() { instanceID = origInstance }()
+	instanceID = "test-instance"
+
+	counter := &hitCounter{}
+	counter.inc()
+	counter.inc()
+	h := newBroadcastHub(counter)
+	defer h.close()
+
+	srv := httptest.NewServer(http.HandlerFunc(h.handleEvents))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?heartbeat=1")
+	if err != nil {
+		t.Fatalf("GET /events error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for seq := uint64(1); seq <= 2; seq++ {
+		var event, data string
+		for data == "" {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading event stream: %v", err)
+			}
+			line = strings.TrimSpace(line)
+			if e, ok := strings.CutPrefix(line, "event: "); ok {
+				event = e
+			}
+			if d, ok := strings.CutPrefix(line, "data: "); ok {
+				data = d
+			}
+		}
+		if event != "heartbeat" {
+			t.Fatalf("event %d = %q, want heartbeat", seq, event)
+		}
+
+		var beat eventsHeartbeat
+		if err := json.Unmarshal([]byte(data), &beat); err != nil {
+			t.Fatalf("heartbeat data unmarshal error: %v", err)
+		}
+		if beat.Seq != seq || beat.InstanceID != "test-instance" || beat.Counter != 2 {
+			t.Errorf("heartbeat = %+v, want seq %d, instance test-instance and counter 2", beat, seq)
+		}
+	}
+}
+
+// Test /events rejects an invalid heartbeat interval
+func TestBroadcastHubHeartbeat_Invalid(t *testing.T) {
+	h := newBroadcastHub(nil)
+	defer h.close()
+
+	for _, q := range []string{"-1", "abc", "3601"} {
+		rec := httptest.NewRecorder()
+		h.handleEvents(rec, httptest.NewRequest(http.MethodGet, "/events?heartbeat="+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /events?heartbeat=%s status = %d, want %d", q, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
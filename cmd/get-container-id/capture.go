@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// captureConfig controls which requests the capture buffer keeps.
+type captureConfig struct {
+	// Size is how many of the latest requests are kept; zero disables
+	// capturing.
+	Size int
+	// MaxBodyBytes is how much of each request body is kept.
+	MaxBodyBytes int64
+	// DenyHeaders are kept as [REDACTED].
+	DenyHeaders []string
+	// ExcludePaths are path prefixes that are never captured.
+	ExcludePaths []string
+}
+
+func (c captureConfig) validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("capture size must not be negative, got %d", c.Size)
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("capture max body size must not be negative, got %d", c.MaxBodyBytes)
+	}
+	return nil
+}
+
+// capturedRequest is a request as it arrived at the pod, with the status it
+// was answered with.
+type capturedRequest struct {
+	ID         uint64    `json:"id"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Proto      string    `json:"proto"`
+	Host       string    `json:"host"`
+	RemoteAddr string    `json:"remote_addr"`
+	TLS        bool      `json:"tls"`
+
+	Header  http.Header `json:"header"`
+	Trailer http.Header `json:"trailer,omitempty"`
+	Body    string      `json:"body"`
+	// BodyEncoding is base64 for bodies that are not valid UTF-8.
+	BodyEncoding  string `json:"body_encoding,omitempty"`
+	BodyTruncated bool   `json:"body_truncated"`
+
+	Status        int     `json:"status"`
+	ResponseBytes int64   `json:"response_bytes"`
+	DurationMS    float64 `json:"duration_ms"`
+
+	// Curl replays the request with curl; it leaves out bodies that were
+	// truncated or are not text.
+	Curl string `json:"curl"`
+}
+
+// captureSummary is the entry of a captured request in the /requests list.
+type captureSummary struct {
+	ID         uint64    `json:"id"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Status     int       `json:"status"`
+	DurationMS float64   `json:"duration_ms"`
+}
+
+// requestCapture keeps the latest requests in a ring buffer.
+type requestCapture struct {
+	config captureConfig
+	deny   map[string]bool
+
+	mu sync.Mutex
+	// entries is the ring; the request with ID n is at (n-1) % Size, and
+	// free slots have ID 0.
+	entries []capturedRequest
+	lastID  uint64
+}
+
+func newRequestCapture(config captureConfig) *requestCapture {
+	return &requestCapture{
+		config:  config,
+		deny:    canonicalHeaderSet(config.DenyHeaders),
+		entries: make([]capturedRequest, config.Size),
+	}
+}
+
+// add stores req under the next ID, replacing the oldest request once the
+// buffer is full.
+func (c *requestCapture) add(req capturedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastID++
+	req.ID = c.lastID
+	c.entries[(req.ID-1)%uint64(len(c.entries))] = req
+}
+
+// list returns the kept requests, newest first.
+func (c *requestCapture) list() []capturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]capturedRequest, 0, len(c.entries))
+	for _, req := range c.entries {
+		if req.ID != 0 {
+			list = append(list, req)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list
+}
+
+// get returns the request with the given capture ID or, failing that, the
+// latest one with that request ID.
+func (c *requestCapture) get(id string) (capturedRequest, bool) {
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil && n > 0 {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if len(c.entries) > 0 {
+			if req := c.entries[(n-1)%uint64(len(c.entries))]; req.ID == n {
+				return req, true
+			}
+		}
+		return capturedRequest{}, false
+	}
+	for _, req := range c.list() {
+		if req.RequestID == id {
+			return req, true
+		}
+	}
+	return capturedRequest{}, false
+}
+
+func (c *requestCapture) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (c *requestCapture) captured(path string) bool {
+	for _, prefix := range c.config.ExcludePaths {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// middleware keeps every request that is not excluded, once it has been
+// served. As for the access log, up to MaxBodyBytes of the body are read
+// ahead and the handler still reads the full body.
+func (c *requestCapture) middleware(next http.Handler) http.Handler {
+	if c.config.Size == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.captured(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		var truncated bool
+		if c.config.MaxBodyBytes > 0 && r.Body != nil && r.Body != http.NoBody {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, c.config.MaxBodyBytes+1))
+			if err != nil {
+				httpapi.WriteError(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			if int64(len(body)) > c.config.MaxBodyBytes {
+				body, truncated = body[:c.config.MaxBodyBytes], true
+			}
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		req := capturedRequest{
+			Time:          start,
+			RequestID:     requestID(r.Context()),
+			Method:        r.Method,
+			URL:           r.RequestURI,
+			Proto:         r.Proto,
+			Host:          r.Host,
+			RemoteAddr:    r.RemoteAddr,
+			TLS:           r.TLS != nil,
+			Header:        c.headers(r.Header),
+			Trailer:       c.headers(r.Trailer),
+			Body:          string(body),
+			BodyTruncated: truncated,
+			Status:        sw.status,
+			ResponseBytes: sw.bytes,
+			DurationMS:    float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if !utf8.Valid(body) {
+			req.Body, req.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+		}
+		req.Curl = curlCommand(getRequestURL(r), req)
+		c.add(req)
+	})
+}
+
+// headers copies h with the denied headers redacted.
+func (c *requestCapture) headers(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	kept := make(http.Header, len(h))
+	for name, values := range h {
+		if c.deny[name] {
+			kept[name] = []string{redacted}
+			continue
+		}
+		kept[name] = values
+	}
+	return kept
+}
+
+// curlCommand returns a curl command line that sends req to url again.
+func curlCommand(url string, req capturedRequest) string {
+	args := []string{"curl"}
+	if req.Method != http.MethodGet {
+		args = append(args, "-X", req.Method)
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// curl sets the length itself.
+		if name == "Content-Length" {
+			continue
+		}
+		for _, v := range req.Header[name] {
+			args = append(args, "-H", shellQuote(name+": "+v))
+		}
+	}
+	if req.Body != "" && req.BodyEncoding == "" && !req.BodyTruncated {
+		args = append(args, "--data-binary", shellQuote(req.Body))
+	}
+	return strings.Join(append(args, shellQuote(url)), " ")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// handleList serves GET /requests: a summary of the kept requests, newest
+// first.
+func (c *requestCapture) handleList(w http.ResponseWriter, r *http.Request) {
+	list := c.list()
+	summaries := make([]captureSummary, len(list))
+	for i, req := range list {
+		summaries[i] = captureSummary{
+			ID:         req.ID,
+			Time:       req.Time,
+			RequestID:  req.RequestID,
+			Method:     req.Method,
+			URL:        req.URL,
+			Status:     req.Status,
+			DurationMS: req.DurationMS,
+		}
+	}
+	c.mu.Lock()
+	total := c.lastID
+	c.mu.Unlock()
+	httpapi.WriteSuccess(w, map[string]any{
+		"capacity": c.config.Size,
+		"total":    total,
+		"requests": summaries,
+	})
+}
+
+// handleGet serves GET /requests/{id}: the full capture of a request, by
+// its capture ID or its request ID.
+func (c *requestCapture) handleGet(w http.ResponseWriter, r *http.Request) {
+	req, ok := c.get(r.PathValue("id"))
+	if !ok {
+		httpapi.WriteError(w, "request "+r.PathValue("id")+" not found", http.StatusNotFound)
+		return
+	}
+	httpapi.WriteSuccess(w, req)
+}
+
+// handleClear serves DELETE /requests: it drops the kept requests.
+func (c *requestCapture) handleClear(w http.ResponseWriter, r *http.Request) {
+	c.clear()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveCaptured serves req through the capture middleware with a handler
+// that answers 201 with the request body.
+func serveCaptured(c *requestCapture, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})).ServeHTTP(rec, req)
+	return rec
+}
+
+// Test the capture buffer keeps only the latest requests, newest first
+func TestCaptureRing(t *testing.T) {
+	c := newRequestCapture(captureConfig{Size: 3, MaxBodyBytes: 16})
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		serveCaptured(c, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var got []string
+	for _, req := range c.list() {
+		got = append(got, req.URL)
+	}
+	if strings.Join(got, ",") != "/e,/d,/c" {
+		t.Errorf("list = %v, want /e,/d,/c", got)
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"5", "/e"},
+		{"3", "/c"},
+		{"2", ""},
+		{"6", ""},
+		{"0", ""},
+		{"nope", ""},
+	}
+	for _, tt := range tests {
+		req, ok := c.get(tt.id)
+		if ok != (tt.want != "") || req.URL != tt.want {
+			t.Errorf("get(%q) = %q, %v; want %q", tt.id, req.URL, ok, tt.want)
+		}
+	}
+
+	c.clear()
+	if len(c.list()) != 0 {
+		t.Error("clear kept requests")
+	}
+	serveCaptured(c, httptest.NewRequest(http.MethodGet, "/f", nil))
+	if req, ok := c.get("6"); !ok || req.URL != "/f" {
+		t.Errorf("get(6) after clear = %q, %v; want /f", req.URL, ok)
+	}
+}
+
+// Test captures keep headers, a truncated body and the response status,
+// without taking the body from the handler
+func TestCaptureRequest(t *testing.T) {
+	c := newRequestCapture(captureConfig{
+		Size:         10,
+		MaxBodyBytes: 4,
+		DenyHeaders:  []string{"authorization"},
+		ExcludePaths: []string{"/requests"},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo?x=1", strings.NewReader("hello world"))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if rec := serveCaptured(c, req); rec.Body.String() != "hello world" {
+		t.Errorf("handler read %q, want the full body", rec.Body.String())
+	}
+
+	got, ok := c.get("1")
+	if !ok {
+		t.Fatal("request not captured")
+	}
+	if got.Method != http.MethodPost || got.URL != "/echo?x=1" || got.Status != http.StatusCreated || got.ResponseBytes != 11 {
+		t.Errorf("capture = %+v", got)
+	}
+	if got.Body != "hell" || !got.BodyTruncated {
+		t.Errorf("body = %q, truncated %v; want hell, true", got.Body, got.BodyTruncated)
+	}
+	if got.Header.Get("Authorization") != redacted || got.Header.Get("X-Forwarded-For") != "203.0.113.7" {
+		t.Errorf("header = %v, want Authorization redacted", got.Header)
+	}
+	if strings.Contains(got.Curl, "--data-binary") || !strings.Contains(got.Curl, "-X POST") {
+		t.Errorf("curl = %q, want POST without the truncated body", got.Curl)
+	}
+
+	serveCaptured(c, httptest.NewRequest(http.MethodGet, "/requests/1", nil))
+	serveCaptured(c, httptest.NewRequest(http.MethodPost, "/bin", strings.NewReader("\xff\x00")))
+	if got := c.list(); len(got) != 2 || got[0].BodyEncoding != "base64" || got[0].Body != "/wA=" {
+		t.Errorf("captures = %+v, want /requests excluded and a base64 body", got)
+	}
+}
+
+// Test curlCommand quotes headers, body and URL for the shell
+func TestCurlCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		req  capturedRequest
+		want string
+	}{
+		{
+			name: "get",
+			req:  capturedRequest{Method: http.MethodGet, Header: http.Header{"Accept": {"*/*"}}},
+			want: `curl -H 'Accept: */*' 'http://host/p'`,
+		},
+		{
+			name: "post with quote",
+			req: capturedRequest{
+				Method: http.MethodPost,
+				Header: http.Header{"Content-Length": {"9"}, "X-A": {"1", "2"}},
+				Body:   "it's ok",
+			},
+			want: `curl -X POST -H 'X-A: 1' -H 'X-A: 2' --data-binary 'it'\''s ok' 'http://host/p'`,
+		},
+		{
+			name: "binary body",
+			req:  capturedRequest{Method: http.MethodPut, Body: "/wA=", BodyEncoding: "base64"},
+			want: `curl -X PUT 'http://host/p'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := curlCommand("http://host/p", tt.req); got != tt.want {
+				t.Errorf("curlCommand = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test /requests lists summaries and /requests/{id} answers 404 for
+// unknown requests
+func TestCaptureHandlers(t *testing.T) {
+	c := newRequestCapture(captureConfig{Size: 5})
+	serveCaptured(c, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /requests", c.handleList)
+	mux.HandleFunc("GET /requests/{id}", c.handleGet)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/requests", nil))
+	var list struct {
+		Data struct {
+			Capacity int              `json:"capacity"`
+			Total    int              `json:"total"`
+			Requests []captureSummary `json:"requests"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if list.Data.Capacity != 5 || list.Data.Total != 1 || len(list.Data.Requests) != 1 || list.Data.Requests[0].URL != "/hello" {
+		t.Errorf("list = %+v", list.Data)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/requests/1", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"curl":`) {
+		t.Errorf("GET /requests/1 = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/requests/9", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /requests/9 = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// inspectCgroups resolves the cgroup hierarchies and limits; tests replace
+// it. Every limit the server reports comes from it, so /cgroup, /limits,
+// /runtime_info and the GOMAXPROCS adjustment agree.
+var inspectCgroups = cgroup.Inspect
+
+// runtimeInfo describes the platform and the CPUs available to the process.
+type runtimeInfo struct {
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	// CPUQuotaMicros and CPUPeriodMicros are the CFS bandwidth limit, if any.
+	CPUQuotaMicros  int64 `json:"cpu_quota_us,omitempty"`
+	CPUPeriodMicros int64 `json:"cpu_period_us,omitempty"`
+	// EffectiveCPUs is the quota divided by the period, capped at NumCPU.
+	EffectiveCPUs float64 `json:"effective_cpus"`
+}
+
+// newRuntimeInfo gathers the runtime information and the CPU limit in
+// limits.
+func newRuntimeInfo(limits cgroup.Limits) runtimeInfo {
+	info := runtimeInfo{
+		GOOS:            runtime.GOOS,
+		GOARCH:          runtime.GOARCH,
+		NumCPU:          runtime.NumCPU(),
+		GOMAXPROCS:      runtime.GOMAXPROCS(0),
+		CPUQuotaMicros:  limits.CPUQuotaMicros,
+		CPUPeriodMicros: limits.CPUPeriodMicros,
+		EffectiveCPUs:   float64(runtime.NumCPU()),
+	}
+	if cpus := limits.CPUs(); cpus > 0 {
+		info.EffectiveCPUs = min(cpus, info.EffectiveCPUs)
+	}
+	return info
+}
+
+// handleCgroup reports the cgroup version, membership with the mounted
+// directory of each hierarchy, and resource limits. Only the runtime
+// information is reported if the cgroups cannot be read.
+func handleCgroup(w http.ResponseWriter, r *http.Request) {
+	info, err := inspectCgroups()
+	resp := map[string]any{
+		"runtime": newRuntimeInfo(info.Limits),
+	}
+	if err == nil {
+		resp["version"] = info.Version
+		resp["cgroups"] = info.Cgroups
+		resp["mounts"] = info.Mounts
+		resp["limits"] = info.Limits
+		if info.Limits.MemoryBytes > 0 {
+			resp["memory_limit_bytes"] = info.Limits.MemoryBytes
+		}
+	}
+
+	httpapi.WriteSuccess(w, resp)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+)
+
+// stubCgroups makes the cgroups unreadable until a test stubs inspectCgroups.
+func stubCgroups(t *testing.T) {
+	t.Helper()
+	orig := inspectCgroups
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) { return cgroup.Info{}, fs.ErrNotExist }
+	t.Cleanup(func() { inspectCgroups = orig })
+}
+
+// Test newRuntimeInfo derives effective CPUs from the quota
+func TestNewRuntimeInfo(t *testing.T) {
+	info := newRuntimeInfo(cgroup.Limits{})
+	if info.GOOS != runtime.GOOS || info.GOARCH != runtime.GOARCH {
+		t.Errorf("GOOS/GOARCH = %s/%s, want %s/%s", info.GOOS, info.GOARCH, runtime.GOOS, runtime.GOARCH)
+	}
+	if info.EffectiveCPUs != float64(runtime.NumCPU()) {
+		t.Errorf("EffectiveCPUs without quota = %v, want NumCPU %d", info.EffectiveCPUs, runtime.NumCPU())
+	}
+
+	info = newRuntimeInfo(cgroup.Limits{CPUQuotaMicros: 50000, CPUPeriodMicros: 100000})
+	if info.EffectiveCPUs != 0.5 || info.CPUQuotaMicros != 50000 || info.CPUPeriodMicros != 100000 {
+		t.Errorf("newRuntimeInfo() = %+v, want 0.5 effective CPUs", info)
+	}
+
+	// A quota above the machine size is capped at NumCPU.
+	if got := newRuntimeInfo(cgroup.Limits{CPUQuotaMicros: 100000000, CPUPeriodMicros: 100000}).EffectiveCPUs; got != float64(runtime.NumCPU()) {
+		t.Errorf("EffectiveCPUs = %v, want capped at %d", got, runtime.NumCPU())
+	}
+}
+
+// Test handleCgroup reports only the runtime when the cgroups are unreadable
+func TestHandleCgroup_Unreadable(t *testing.T) {
+	stubCgroups(t)
+
+	w := httptest.NewRecorder()
+	handleCgroup(w, httptest.NewRequest(http.MethodGet, "/cgroup", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleCgroup() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if _, ok := resp.Data["runtime"]; !ok || len(resp.Data) != 1 {
+		t.Errorf("data = %s, want only runtime", w.Body.String())
+	}
+}
+
+// Test handleCgroup reports the mounted directories and limits of the cgroup package
+func TestHandleCgroup(t *testing.T) {
+	stubCgroups(t)
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) {
+		return cgroup.Inspect(cgroup.WithFS(fstest.MapFS{
+			"proc/self/cgroup":         {Data: []byte("0::/\n")},
+			"proc/self/mountinfo":      {Data: []byte("30 24 0:26 / /sys/fs/cgroup rw - cgroup2 cgroup2 rw\n")},
+			"sys/fs/cgroup/memory.max": {Data: []byte("268435456\n")},
+			"sys/fs/cgroup/cpu.max":    {Data: []byte("50000 100000\n")},
+		}))
+	}
+
+	w := httptest.NewRecorder()
+	handleCgroup(w, httptest.NewRequest(http.MethodGet, "/cgroup", nil))
+
+	var resp struct {
+		Data struct {
+			Version          int                 `json:"version"`
+			Cgroups          []cgroup.Membership `json:"cgroups"`
+			Mounts           []cgroup.Mount      `json:"mounts"`
+			Limits           cgroup.Limits       `json:"limits"`
+			MemoryLimitBytes int64               `json:"memory_limit_bytes"`
+			Runtime          runtimeInfo         `json:"runtime"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if resp.Data.Version != 2 {
+		t.Errorf("version = %d, want 2", resp.Data.Version)
+	}
+	if len(resp.Data.Cgroups) != 1 || resp.Data.Cgroups[0].Dir != "/sys/fs/cgroup" {
+		t.Errorf("cgroups = %+v, want one entry in /sys/fs/cgroup", resp.Data.Cgroups)
+	}
+	if len(resp.Data.Mounts) != 1 || resp.Data.Mounts[0].Version != 2 {
+		t.Errorf("mounts = %+v, want one cgroup2 mount", resp.Data.Mounts)
+	}
+	want := cgroup.Limits{MemoryBytes: 268435456, CPUQuotaMicros: 50000, CPUPeriodMicros: 100000}
+	if resp.Data.Limits != want {
+		t.Errorf("limits = %+v, want %+v", resp.Data.Limits, want)
+	}
+	if resp.Data.MemoryLimitBytes != want.MemoryBytes || resp.Data.Runtime.CPUQuotaMicros != want.CPUQuotaMicros {
+		t.Errorf("memory_limit_bytes = %d, runtime.cpu_quota_us = %d, want them to match limits", resp.Data.MemoryLimitBytes, resp.Data.Runtime.CPUQuotaMicros)
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	defaultLeakMBPerMin = 10
+	maxLeakMBPerMin     = 1 << 16
+	leakTick            = time.Second
+
+	defaultBlockSeconds = 120
+	maxBlockSeconds     = 24 * 60 * 60
+)
+
+// requireChaos guards destructive endpoints behind the -chaos flag.
+func requireChaos(enabled bool, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enabled {
+			httpapi.WriteError(w, "chaos endpoints are disabled (start with -chaos)", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// leakStatus reports the state of the memory leak simulation.
+type leakStatus struct {
+	Running       bool  `json:"running"`
+	MBPerMin      int64 `json:"mb_per_min"`
+	RetainedBytes int64 `json:"retained_bytes"`
+}
+
+// memoryLeak grows retained memory at a steady rate until stopped.
+type memoryLeak struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	mbPerMin int64
+	retained [][]byte
+	size     int64
+}
+
+func (l *memoryLeak) status() leakStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return leakStatus{Running: l.cancel != nil, MBPerMin: l.mbPerMin, RetainedBytes: l.size}
+}
+
+// start begins (or re-rates) the leak. Memory retained so far is kept.
+func (l *memoryLeak) start(mbPerMin int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.mbPerMin = mbPerMin
+
+	go l.run(ctx, mbPerMin<<20/int64(time.Minute/leakTick))
+}
+
+func (l *memoryLeak) run(ctx context.Context, bytesPerTick int64) {
+	ticker := time.NewTicker(leakTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		chunk := make([]byte, bytesPerTick)
+		// Touch every page so the allocation counts towards RSS.
+		for i := 0; i < len(chunk); i += 4096 {
+			chunk[i] = 1
+		}
+
+		l.mu.Lock()
+		if ctx.Err() == nil {
+			l.retained = append(l.retained, chunk)
+			l.size += int64(len(chunk))
+		}
+		l.mu.Unlock()
+	}
+}
+
+// stop halts the leak and releases the retained memory back to the OS.
+func (l *memoryLeak) stop() {
+	l.mu.Lock()
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+	l.mbPerMin = 0
+	l.retained = nil
+	l.size = 0
+	l.mu.Unlock()
+
+	debug.FreeOSMemory()
+}
+
+func (l *memoryLeak) handleStart(w http.ResponseWriter, r *http.Request) {
+	mbPerMin, ok := queryInt(r, "mbPerMin", defaultLeakMBPerMin, 1, maxLeakMBPerMin)
+	if !ok {
+		httpapi.WriteError(w, "mbPerMin must be an integer between 1 and "+strconv.Itoa(maxLeakMBPerMin), http.StatusBadRequest)
+		return
+	}
+
+	l.start(mbPerMin)
+	httpapi.WriteSuccess(w, l.status())
+}
+
+func (l *memoryLeak) handleStop(w http.ResponseWriter, r *http.Request) {
+	l.stop()
+	httpapi.WriteSuccess(w, l.status())
+}
+
+// livenessBlock is a lock shared with /livez. Holding it for writing wedges
+// every liveness probe, simulating a deadlocked application.
+type livenessBlock struct {
+	mu sync.RWMutex
+
+	// untilMu guards blocked, set from the start of a hold until it is
+	// released, and until, the time the current hold ends.
+	untilMu sync.Mutex
+	blocked bool
+	until   time.Time
+}
+
+// wait blocks while the liveness lock is held by /block.
+func (b *livenessBlock) wait() {
+	b.mu.RLock()
+	b.mu.RUnlock()
+}
+
+// hold acquires the lock and releases it after d. If another hold is
+// active it returns false and the time that hold ends, without waiting;
+// otherwise it waits for /livez probes in progress to finish.
+func (b *livenessBlock) hold(d time.Duration) (time.Time, bool) {
+	b.untilMu.Lock()
+	if b.blocked {
+		defer b.untilMu.Unlock()
+		return b.until, false
+	}
+	b.blocked = true
+	b.until = time.Now().Add(d)
+	until := b.until
+	b.untilMu.Unlock()
+
+	b.mu.Lock()
+	time.AfterFunc(time.Until(until), b.release)
+	return until, true
+}
+
+// release ends the current hold.
+func (b *livenessBlock) release() {
+	b.untilMu.Lock()
+	b.blocked = false
+	b.untilMu.Unlock()
+	b.mu.Unlock()
+}
+
+func (b *livenessBlock) handleBlock(w http.ResponseWriter, r *http.Request) {
+	seconds, ok := queryInt(r, "seconds", defaultBlockSeconds, 1, maxBlockSeconds)
+	if !ok {
+		httpapi.WriteError(w, "seconds must be an integer between 1 and "+strconv.Itoa(maxBlockSeconds), http.StatusBadRequest)
+		return
+	}
+
+	until, ok := b.hold(time.Duration(seconds) * time.Second)
+	if !ok {
+		remaining := time.Until(until).Round(time.Second)
+		httpapi.WriteError(w, fmt.Sprintf("already blocked for another %s, until %s", remaining, until.Format(time.RFC3339)), http.StatusConflict)
+		return
+	}
+
+	httpapi.WriteSuccess(w, map[string]any{
+		"blocked_until": until.Format(time.RFC3339),
+	})
+}
+
+// ErrNoMemoryLimit is reported by /oom when no cgroup memory limit is set.
+var ErrNoMemoryLimit = errors.New("no cgroup memory limit set")
+
+// allocate retains n bytes, touching every page so they count towards RSS.
+func allocate(n int64) [][]byte {
+	const chunkSize = 16 << 20
+
+	var retained [][]byte
+	for n > 0 {
+		chunk := make([]byte, min(n, chunkSize))
+		for i := 0; i < len(chunk); i += 4096 {
+			chunk[i] = 1
+		}
+		retained = append(retained, chunk)
+		n -= int64(len(chunk))
+	}
+	return retained
+}
+
+// handleOOM allocates just past the cgroup memory limit so the kernel OOM
+// killer terminates the process. It refuses to run without a limit.
+func handleOOM(w http.ResponseWriter, r *http.Request) {
+	info, err := inspectCgroups()
+	if err != nil {
+		httpapi.WriteError(w, "failed to read cgroup memory limit: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	limit := info.Limits.MemoryBytes
+	if limit == 0 {
+		httpapi.WriteError(w, ErrNoMemoryLimit.Error(), http.StatusConflict)
+		return
+	}
+
+	target := limit + limit/10
+	httpapi.WriteJSON(w, httpapi.Response{Data: map[string]any{
+		"memory_limit_bytes": limit,
+		"allocating_bytes":   target,
+	}}, http.StatusAccepted)
+	http.NewResponseController(w).Flush()
+
+	go func() {
+		retained := allocate(target)
+		runtime.KeepAlive(retained)
+	}()
+}
+
+const (
+	maxStormGoroutines = 1_000_000
+
+	// maxStormThreads stays well below the runtime's default limit of 10000
+	// OS threads, beyond which the process is aborted.
+	maxStormThreads = 5000
+)
+
+// goroutineStorm parks goroutines (optionally locked to OS threads) until stopped.
+type goroutineStorm struct {
+	mu         sync.Mutex
+	release    chan struct{}
+	goroutines int
+	threads    int
+}
+
+// stormStatus reports the parked goroutines alongside the runtime totals.
+type stormStatus struct {
+	Goroutines     int `json:"goroutines"`
+	Threads        int `json:"threads"`
+	NumGoroutine   int `json:"num_goroutine"`
+	ThreadsCreated int `json:"threads_created"`
+}
+
+// stormStarted is the /goroutines response: the status and how many of the
+// requested threads were locked before the storm reached maxStormThreads.
+type stormStarted struct {
+	stormStatus
+	GrantedThreads int `json:"granted_threads"`
+}
+
+func (s *goroutineStorm) status() stormStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	threads, _ := runtime.ThreadCreateProfile(nil)
+	return stormStatus{
+		Goroutines:     s.goroutines,
+		Threads:        s.threads,
+		NumGoroutine:   runtime.NumGoroutine(),
+		ThreadsCreated: threads,
+	}
+}
+
+// start parks count goroutines, threads of which are locked to their own OS
+// thread. threads is clamped so the storm never holds more than
+// maxStormThreads; start returns how many threads it locked.
+func (s *goroutineStorm) start(count, threads int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	threads = min(threads, maxStormThreads-s.threads)
+	if s.release == nil {
+		s.release = make(chan struct{})
+	}
+	release := s.release
+
+	for i := 0; i < count; i++ {
+		lock := i < threads
+		go func() {
+			if lock {
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+			}
+			<-release
+		}()
+	}
+
+	s.goroutines += count
+	s.threads += threads
+	return threads
+}
+
+// stop releases every parked goroutine.
+func (s *goroutineStorm) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.release != nil {
+		close(s.release)
+		s.release = nil
+	}
+	s.goroutines = 0
+	s.threads = 0
+}
+
+func (s *goroutineStorm) handleStart(w http.ResponseWriter, r *http.Request) {
+	count, ok := queryInt(r, "count", 0, 1, maxStormGoroutines)
+	if !ok || count == 0 {
+		httpapi.WriteError(w, "count must be an integer between 1 and "+strconv.Itoa(maxStormGoroutines), http.StatusBadRequest)
+		return
+	}
+
+	threads, ok := queryInt(r, "threads", 0, 0, maxStormThreads)
+	if !ok || threads > count {
+		httpapi.WriteError(w, fmt.Sprintf("threads must be an integer between 0 and min(count, %d)", maxStormThreads), http.StatusBadRequest)
+		return
+	}
+
+	granted := s.start(int(count), int(threads))
+	httpapi.WriteSuccess(w, stormStarted{stormStatus: s.status(), GrantedThreads: granted})
+}
+
+func (s *goroutineStorm) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.stop()
+	httpapi.WriteSuccess(w, s.status())
+}
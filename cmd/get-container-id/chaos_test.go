@@ -0,0 +1,278 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// Test requireChaos rejects requests when chaos mode is disabled
+func TestRequireChaos(t *testing.T) {
+	called := false
+	h := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		httpapi.WriteSuccess(w, "ok")
+	}
+
+	w := httptest.NewRecorder()
+	requireChaos(false, h)(w, httptest.NewRequest(http.MethodGet, "/leak", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("requireChaos(false) status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if called {
+		t.Error("requireChaos(false) should not call the handler")
+	}
+
+	w = httptest.NewRecorder()
+	requireChaos(true, h)(w, httptest.NewRequest(http.MethodGet, "/leak", nil))
+	if w.Code != http.StatusOK || !called {
+		t.Errorf("requireChaos(true) status = %d, called = %v", w.Code, called)
+	}
+}
+
+// Test memoryLeak grows retained memory and releases it on stop
+func TestMemoryLeak(t *testing.T) {
+	l := &memoryLeak{}
+	defer l.stop()
+
+	w := httptest.NewRecorder()
+	l.handleStart(w, httptest.NewRequest(http.MethodPost, "/leak?mbPerMin=60", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleStart() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	deadline := time.Now().Add(3 * leakTick)
+	for l.status().RetainedBytes == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	st := l.status()
+	if !st.Running || st.MBPerMin != 60 {
+		t.Errorf("status() = %+v, want running at 60 MB/min", st)
+	}
+	if st.RetainedBytes == 0 {
+		t.Error("status() retained no memory after one tick")
+	}
+
+	l.stop()
+	if st := l.status(); st.Running || st.RetainedBytes != 0 {
+		t.Errorf("status() after stop = %+v, want stopped and empty", st)
+	}
+}
+
+// Test handleStart validates mbPerMin
+func TestMemoryLeak_InvalidRate(t *testing.T) {
+	l := &memoryLeak{}
+
+	w := httptest.NewRecorder()
+	l.handleStart(w, httptest.NewRequest(http.MethodPost, "/leak?mbPerMin=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleStart() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if l.status().Running {
+		t.Error("handleStart() with invalid rate should not start the leak")
+	}
+}
+
+// Test livenessBlock wedges waiters until the hold expires
+func TestLivenessBlock(t *testing.T) {
+	b := &livenessBlock{}
+	if _, ok := b.hold(200 * time.Millisecond); !ok {
+		t.Fatal("hold() on a free lock = false, want true")
+	}
+
+	start := time.Now()
+	b.wait()
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("wait() returned after %v, want at least 150ms", elapsed)
+	}
+
+	start = time.Now()
+	b.wait()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("wait() after release took %v, want immediate", elapsed)
+	}
+}
+
+// Test a /livez probe in progress delays the hold instead of failing it
+func TestLivenessBlock_ProbeInProgress(t *testing.T) {
+	b := &livenessBlock{}
+	b.mu.RLock()
+	time.AfterFunc(50*time.Millisecond, b.mu.RUnlock)
+
+	until, ok := b.hold(100 * time.Millisecond)
+	if !ok {
+		t.Fatal("hold() during a probe = false, want true")
+	}
+	if until.Before(time.Now()) {
+		t.Errorf("hold() until = %v, want a time in the future", until)
+	}
+
+	if _, ok := b.hold(time.Second); ok {
+		t.Error("second hold() = true, want false while the first is active")
+	}
+	b.wait()
+	if _, ok := b.hold(10 * time.Millisecond); !ok {
+		t.Error("hold() after release = false, want true")
+	}
+	b.wait()
+}
+
+// Test handleBlock validates seconds
+func TestLivenessBlock_InvalidSeconds(t *testing.T) {
+	b := &livenessBlock{}
+
+	w := httptest.NewRecorder()
+	b.handleBlock(w, httptest.NewRequest(http.MethodPost, "/block?seconds=-5", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleBlock() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// Test a second concurrent /block answers 409 instead of waiting for the first
+func TestLivenessBlock_Concurrent(t *testing.T) {
+	b := &livenessBlock{}
+
+	codes := make(chan int, 2)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			b.handleBlock(w, httptest.NewRequest(http.MethodPost, "/block?seconds=1", nil))
+			codes <- w.Code
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("concurrent /block requests did not both return promptly")
+	}
+	close(codes)
+
+	got := map[int]int{}
+	for code := range codes {
+		got[code]++
+	}
+	if got[http.StatusOK] != 1 || got[http.StatusConflict] != 1 {
+		t.Errorf("status codes = %v, want one 200 and one 409", got)
+	}
+}
+
+// Test handleOOM refuses to run without a memory limit
+func TestHandleOOM_NoLimit(t *testing.T) {
+	stubCgroups(t)
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) { return cgroup.Info{Version: 2}, nil }
+
+	w := httptest.NewRecorder()
+	handleOOM(w, httptest.NewRequest(http.MethodPost, "/oom", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("handleOOM() status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	stubCgroups(t)
+	w = httptest.NewRecorder()
+	handleOOM(w, httptest.NewRequest(http.MethodPost, "/oom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("handleOOM() with unreadable cgroups status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+// Test allocate retains the requested number of bytes
+func TestAllocate(t *testing.T) {
+	var total int
+	for _, chunk := range allocate(40 << 20) {
+		total += len(chunk)
+	}
+	if total != 40<<20 {
+		t.Errorf("allocate() retained %d bytes, want %d", total, 40<<20)
+	}
+}
+
+// Test goroutineStorm parks goroutines until stopped
+func TestGoroutineStorm(t *testing.T) {
+	s := &goroutineStorm{}
+	defer s.stop()
+
+	before := runtime.NumGoroutine()
+
+	w := httptest.NewRecorder()
+	s.handleStart(w, httptest.NewRequest(http.MethodPost, "/goroutines?count=1000&threads=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleStart() status = %d, want %d, body %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	st := s.status()
+	if st.Goroutines != 1000 || st.Threads != 2 {
+		t.Errorf("status() = %+v, want 1000 goroutines and 2 threads", st)
+	}
+	if st.NumGoroutine < before+1000 {
+		t.Errorf("status() NumGoroutine = %d, want at least %d", st.NumGoroutine, before+1000)
+	}
+
+	s.stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() >= before+1000 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n >= before+1000 {
+		t.Errorf("NumGoroutine() after stop = %d, want below %d", n, before+1000)
+	}
+}
+
+// Test concurrent starts never lock more than maxStormThreads threads together
+func TestGoroutineStorm_ThreadCap(t *testing.T) {
+	s := &goroutineStorm{}
+	defer s.stop()
+	// Pretend the storm already holds all but 10 threads, rather than
+	// locking thousands of OS threads in the test.
+	s.threads = maxStormThreads - 10
+
+	granted := make(chan int, 2)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			granted <- s.start(10, 10)
+		}()
+	}
+	wg.Wait()
+	close(granted)
+
+	total := 0
+	for n := range granted {
+		total += n
+	}
+	if total != 10 {
+		t.Errorf("start() granted %d threads in total, want 10", total)
+	}
+	if st := s.status(); st.Threads != maxStormThreads || st.Goroutines != 20 {
+		t.Errorf("status() = %+v, want %d threads and 20 goroutines", st, maxStormThreads)
+	}
+}
+
+// Test goroutineStorm validates parameters
+func TestGoroutineStorm_Invalid(t *testing.T) {
+	s := &goroutineStorm{}
+	defer s.stop()
+
+	for _, query := range []string{"", "count=0", "count=10&threads=11", "count=10&threads=-1", "count=10000&threads=9000"} {
+		w := httptest.NewRecorder()
+		s.handleStart(w, httptest.NewRequest(http.MethodPost, "/goroutines?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("handleStart(%q) status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
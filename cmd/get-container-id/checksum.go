@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// checksumAlgorithms lists the digests /checksum can compute.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha256": sha256.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+const defaultChecksumAlgorithms = "md5,sha256,crc32"
+
+// checksumResult is the /checksum response document.
+type checksumResult struct {
+	Bytes   int64             `json:"bytes"`
+	Digests map[string]string `json:"digests"`
+}
+
+// newChecksumHashes parses a comma-separated algorithm list.
+func newChecksumHashes(list string) (map[string]hash.Hash, bool) {
+	hashes := make(map[string]hash.Hash)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		newHash, ok := checksumAlgorithms[name]
+		if !ok {
+			return nil, false
+		}
+		hashes[name] = newHash()
+	}
+	return hashes, true
+}
+
+// handleChecksum streams the request body through the algorithms selected
+// with ?algo= (default: all) without buffering it, and returns the digests
+// and the number of bytes received.
+func handleChecksum(w http.ResponseWriter, r *http.Request) {
+	list := r.URL.Query().Get("algo")
+	if list == "" {
+		list = defaultChecksumAlgorithms
+	}
+
+	hashes, ok := newChecksumHashes(list)
+	if !ok {
+		names := make([]string, 0, len(checksumAlgorithms))
+		for name := range checksumAlgorithms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		httpapi.WriteError(w, "algo must be a comma-separated list of "+strings.Join(names, ", "), http.StatusBadRequest)
+		return
+	}
+
+	writers := make([]io.Writer, 0, len(hashes))
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+
+	n, err := io.Copy(io.MultiWriter(writers...), r.Body)
+	if err != nil {
+		httpapi.WriteError(w, "failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := checksumResult{Bytes: n, Digests: make(map[string]string, len(hashes))}
+	for name, h := range hashes {
+		res.Digests[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	httpapi.WriteSuccess(w, res)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test handleChecksum computes digests of the request body
+func TestHandleChecksum(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string
+	}{
+		{
+			name:  "all algorithms",
+			query: "",
+			want: map[string]string{
+				"md5":    "5d41402abc4b2a76b9719d911017c592",
+				"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+				"crc32":  "3610a686",
+			},
+		},
+		{
+			name:  "selected algorithm",
+			query: "?algo=SHA256",
+			want: map[string]string{
+				"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handleChecksum(w, httptest.NewRequest(http.MethodPost, "/checksum"+tt.query, strings.NewReader("hello")))
+			if w.Code != http.StatusOK {
+				t.Fatalf("handleChecksum() status = %d, body %s", w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Data checksumResult `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("handleChecksum() body unmarshal error: %v", err)
+			}
+			if resp.Data.Bytes != 5 {
+				t.Errorf("handleChecksum() bytes = %d, want 5", resp.Data.Bytes)
+			}
+			if len(resp.Data.Digests) != len(tt.want) {
+				t.Errorf("handleChecksum() digests = %v, want %v", resp.Data.Digests, tt.want)
+			}
+			for name, want := range tt.want {
+				if got := resp.Data.Digests[name]; got != want {
+					t.Errorf("handleChecksum() %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Test handleChecksum rejects unknown algorithms
+func TestHandleChecksum_UnknownAlgorithm(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleChecksum(w, httptest.NewRequest(http.MethodPost, "/checksum?algo=sha1", strings.NewReader("hello")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleChecksum() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
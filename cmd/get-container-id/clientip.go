@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// The sources /ip takes the client IP from.
+const (
+	clientIPSourceRemote    = "remote_addr"
+	clientIPSourceForwarded = "forwarded"
+	clientIPSourceXFF       = "x-forwarded-for"
+	clientIPSourceRealIP    = "x-real-ip"
+)
+
+// interfaceAddrs lists the pod's own addresses; tests replace it.
+var interfaceAddrs = net.InterfaceAddrs
+
+// clientIPResolver finds the real client IP of a request behind proxies it
+// trusts.
+type clientIPResolver struct {
+	Trusted []netip.Prefix
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs and IPs; a
+// bare IP trusts that address only.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range splitList(s) {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// trusted reports whether ip is one of the trusted proxies.
+func (c clientIPResolver) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range c.Trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP walks chain, the hops a request passed through ordered from the
+// client to the hop in front of remote, backwards from remote and returns
+// the first address that is not a trusted proxy. If every hop is trusted it
+// returns the first one.
+func (c clientIPResolver) clientIP(remote string, chain []string) string {
+	ip := remote
+	for i := len(chain) - 1; i >= 0 && c.trusted(ip); i-- {
+		ip = chain[i]
+	}
+	return ip
+}
+
+// forwardedElement is one element of an RFC 7239 Forwarded header.
+type forwardedElement struct {
+	For   string `json:"for,omitempty"`
+	By    string `json:"by,omitempty"`
+	Host  string `json:"host,omitempty"`
+	Proto string `json:"proto,omitempty"`
+}
+
+// parseForwarded parses the Forwarded header values into their elements.
+// Quoted values are unquoted, and the node of for and by is stripped of its
+// port and IPv6 brackets, so it reads as a plain IP.
+func parseForwarded(values []string) []forwardedElement {
+	var elements []forwardedElement
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			var e forwardedElement
+			for _, pair := range strings.Split(element, ";") {
+				key, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				v = strings.Trim(v, `"`)
+				switch strings.ToLower(key) {
+				case "for":
+					e.For = forwardedNode(v)
+				case "by":
+					e.By = forwardedNode(v)
+				case "host":
+					e.Host = v
+				case "proto":
+					e.Proto = v
+				}
+			}
+			if e != (forwardedElement{}) {
+				elements = append(elements, e)
+			}
+		}
+	}
+	return elements
+}
+
+// forwardedNode strips the port and IPv6 brackets from a Forwarded node,
+// e.g. "[2001:db8::1]:4711" becomes "2001:db8::1". Obfuscated identifiers
+// and "unknown" are kept as they are.
+func forwardedNode(node string) string {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+}
+
+// splitForwardedFor parses the X-Forwarded-For header values into one list
+// of addresses.
+func splitForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				chain = append(chain, ip)
+			}
+		}
+	}
+	return chain
+}
+
+// clientIPDocument is the answer of /ip.
+type clientIPDocument struct {
+	RemoteAddr string `json:"remote_addr"`
+	RemoteIP   string `json:"remote_ip"`
+	// RemoteTrusted reports whether the peer is a trusted proxy, the
+	// precondition for believing any forwarding header.
+	RemoteTrusted  bool               `json:"remote_trusted"`
+	ClientIP       string             `json:"client_ip"`
+	ClientIPSource string             `json:"client_ip_source"`
+	XForwardedFor  []string           `json:"x_forwarded_for,omitempty"`
+	Forwarded      []forwardedElement `json:"forwarded,omitempty"`
+	XRealIP        string             `json:"x_real_ip,omitempty"`
+	TrustedProxies []string           `json:"trusted_proxies"`
+	PodAddresses   []string           `json:"pod_addresses,omitempty"`
+}
+
+// resolve reports the forwarding headers of r and the client IP they yield.
+// The Forwarded header is preferred over X-Forwarded-For, and X-Real-IP is
+// used only when neither is present; none of them is believed unless the
+// peer is a trusted proxy.
+func (c clientIPResolver) resolve(r *http.Request) clientIPDocument {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+
+	doc := clientIPDocument{
+		RemoteAddr:     r.RemoteAddr,
+		RemoteIP:       remoteIP,
+		RemoteTrusted:  c.trusted(remoteIP),
+		ClientIP:       remoteIP,
+		ClientIPSource: clientIPSourceRemote,
+		XForwardedFor:  splitForwardedFor(r.Header.Values("X-Forwarded-For")),
+		Forwarded:      parseForwarded(r.Header.Values("Forwarded")),
+		XRealIP:        strings.TrimSpace(r.Header.Get("X-Real-IP")),
+		TrustedProxies: make([]string, len(c.Trusted)),
+	}
+	for i, prefix := range c.Trusted {
+		doc.TrustedProxies[i] = prefix.String()
+	}
+	if !doc.RemoteTrusted {
+		return doc
+	}
+
+	switch {
+	case len(doc.Forwarded) > 0:
+		chain := make([]string, len(doc.Forwarded))
+		for i, e := range doc.Forwarded {
+			chain[i] = e.For
+		}
+		doc.ClientIP, doc.ClientIPSource = c.clientIP(remoteIP, chain), clientIPSourceForwarded
+	case len(doc.XForwardedFor) > 0:
+		doc.ClientIP, doc.ClientIPSource = c.clientIP(remoteIP, doc.XForwardedFor), clientIPSourceXFF
+	case doc.XRealIP != "":
+		doc.ClientIP, doc.ClientIPSource = doc.XRealIP, clientIPSourceRealIP
+	}
+	return doc
+}
+
+// handleIP serves GET /ip: the remote address, the parsed forwarding
+// headers, the client IP derived from them with the trusted proxy list, and
+// the pod's own interface addresses.
+func (c clientIPResolver) handleIP(w http.ResponseWriter, r *http.Request) {
+	doc := c.resolve(r)
+	if addrs, err := interfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			doc.PodAddresses = append(doc.PodAddresses, addr.String())
+		}
+	}
+	httpapi.WriteSuccess(w, doc)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Test parseTrustedProxies accepts CIDRs and bare IPs
+func TestParseTrustedProxies(t *testing.T) {
+	got, err := parseTrustedProxies("10.0.0.0/8, 192.168.1.7,fd00::/8, 10.1.2.3/16")
+	if err != nil {
+		t.Fatalf("parseTrustedProxies() error = %v", err)
+	}
+	var strs []string
+	for _, p := range got {
+		strs = append(strs, p.String())
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.7/32", "fd00::/8", "10.1.0.0/16"}
+	if !reflect.DeepEqual(strs, want) {
+		t.Errorf("parseTrustedProxies() = %v, want %v", strs, want)
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := parseTrustedProxies(bad); err == nil {
+			t.Errorf("parseTrustedProxies(%q) error = nil, want an error", bad)
+		}
+	}
+}
+
+// Test parseForwarded reads the for, by, host and proto of each element
+func TestParseForwarded(t *testing.T) {
+	got := parseForwarded([]string{
+		`for=192.0.2.60;proto=http;by=203.0.113.43`,
+		`For="[2001:db8:cafe::17]:4711", for=unknown;host=example.com`,
+	})
+	want := []forwardedElement{
+		{For: "192.0.2.60", By: "203.0.113.43", Proto: "http"},
+		{For: "2001:db8:cafe::17"},
+		{For: "unknown", Host: "example.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseForwarded() = %+v, want %+v", got, want)
+	}
+}
+
+// Test the client IP is taken from forwarding headers only behind trusted proxies
+func TestClientIPResolver(t *testing.T) {
+	trusted, err := parseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := clientIPResolver{Trusted: trusted}
+
+	tests := []struct {
+		name       string
+		remote     string
+		headers    map[string]string
+		wantIP     string
+		wantSource string
+	}{
+		{
+			name:       "no headers",
+			remote:     "10.0.0.5:1234",
+			wantIP:     "10.0.0.5",
+			wantSource: clientIPSourceRemote,
+		},
+		{
+			name:       "untrusted peer",
+			remote:     "198.51.100.9:1234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			wantIP:     "198.51.100.9",
+			wantSource: clientIPSourceRemote,
+		},
+		{
+			name:       "x-forwarded-for skips trusted hops",
+			remote:     "10.0.0.5:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.1, 10.0.0.9"},
+			wantIP:     "203.0.113.1",
+			wantSource: clientIPSourceXFF,
+		},
+		{
+			name:       "all hops trusted",
+			remote:     "10.0.0.5:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.7, 10.0.0.9"},
+			wantIP:     "10.0.0.7",
+			wantSource: clientIPSourceXFF,
+		},
+		{
+			name:   "forwarded wins over x-forwarded-for",
+			remote: "10.0.0.5:1234",
+			headers: map[string]string{
+				"Forwarded":       `for=203.0.113.2;proto=https`,
+				"X-Forwarded-For": "203.0.113.1",
+			},
+			wantIP:     "203.0.113.2",
+			wantSource: clientIPSourceForwarded,
+		},
+		{
+			name:       "x-real-ip",
+			remote:     "[::ffff:10.0.0.5]:1234",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.3"},
+			wantIP:     "203.0.113.3",
+			wantSource: clientIPSourceRealIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			doc := c.resolve(r)
+			if doc.ClientIP != tt.wantIP || doc.ClientIPSource != tt.wantSource {
+				t.Errorf("client IP = %q from %s, want %q from %s", doc.ClientIP, doc.ClientIPSource, tt.wantIP, tt.wantSource)
+			}
+		})
+	}
+}
+
+// Test /ip reports the parsed headers and the pod's addresses
+func TestHandleIP(t *testing.T) {
+	orig := interfaceAddrs
+	defer func() { interfaceAddrs = orig }()
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP("10.244.1.7"), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	r.RemoteAddr = "192.0.2.1:5555"
+	r.Header.Add("X-Forwarded-For", "203.0.113.1")
+	r.Header.Add("X-Forwarded-For", "198.51.100.2")
+	w := httptest.NewRecorder()
+	clientIPResolver{}.handleIP(w, r)
+
+	var resp struct {
+		Data clientIPDocument `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := clientIPDocument{
+		RemoteAddr:     "192.0.2.1:5555",
+		RemoteIP:       "192.0.2.1",
+		ClientIP:       "192.0.2.1",
+		ClientIPSource: clientIPSourceRemote,
+		XForwardedFor:  []string{"203.0.113.1", "198.51.100.2"},
+		TrustedProxies: []string{},
+		PodAddresses:   []string{"10.244.1.7/24"},
+	}
+	if !reflect.DeepEqual(resp.Data, want) {
+		t.Errorf("/ip = %+v, want %+v", resp.Data, want)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	defaultCompressTestBytes = 64 * 1024
+	maxCompressTestBytes     = 64 << 20 // 64MB
+)
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response and switches to gzip
+// once it grows past minSize. Smaller responses are sent uncompressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+
+	minSize int
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.decided {
+		g.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if g.status == 0 {
+		g.status = code
+	}
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	if g.decided {
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= g.minSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide commits the response headers, compressed or not, and writes out
+// anything buffered so far.
+func (g *gzipResponseWriter) decide(compress bool) error {
+	g.decided = true
+
+	h := g.Header()
+	status := g.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	// Handlers opt out by setting their own Content-Encoding.
+	if h.Get("Content-Encoding") != "" || status == http.StatusNoContent || status == http.StatusNotModified {
+		compress = false
+	}
+
+	if compress {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.ResponseWriter.WriteHeader(status)
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+		_, err := g.gz.Write(g.buf)
+		g.buf = nil
+		return err
+	}
+
+	g.ResponseWriter.WriteHeader(status)
+	_, err := g.ResponseWriter.Write(g.buf)
+	g.buf = nil
+	return err
+}
+
+// Flush sends buffered data immediately; a response flushed before reaching
+// minSize (e.g. an event stream) is sent uncompressed.
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close finishes the response once the handler returns.
+func (g *gzipResponseWriter) close() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			// Nothing was written; let net/http send its default response.
+			return
+		}
+		g.decide(len(g.buf) >= g.minSize)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+	}
+}
+
+// compressMiddleware gzips responses of at least minSize bytes for clients
+// that accept it. Requests whose path starts with one of exclude are served
+// uncompressed.
+func compressMiddleware(minSize int, exclude []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range exclude {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize}
+		defer gw.close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// handleCompressTest returns ?bytes= of highly compressible text.
+func handleCompressTest(w http.ResponseWriter, r *http.Request) {
+	n, ok := queryInt(r, "bytes", defaultCompressTestBytes, 0, maxCompressTestBytes)
+	if !ok {
+		httpapi.WriteError(w, "bytes must be an integer between 0 and "+strconv.Itoa(maxCompressTestBytes), http.StatusBadRequest)
+		return
+	}
+
+	line := []byte("The quick brown fox jumps over the lazy dog. " + instanceID + "\n")
+	w.Header().Set(headerContentType, "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	for remaining := int(n); remaining > 0; remaining -= len(line) {
+		w.Write(line[:min(remaining, len(line))])
+	}
+}
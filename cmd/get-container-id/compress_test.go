@@ -0,0 +1,121 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test acceptsGzip parses Accept-Encoding
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "gzip", want: true},
+		{header: "deflate, gzip;q=0.8", want: true},
+		{header: "GZIP", want: true},
+		{header: "gzip;q=0", want: false},
+		{header: "*", want: true},
+		{header: "br, deflate", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// Test compressMiddleware compresses large responses only
+func TestCompressMiddleware(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	handler := compressMiddleware(1024, []string{"/events"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := 2048
+		if r.URL.Query().Get("small") != "" {
+			size = 10
+		}
+		w.Header().Set(headerContentType, "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, body[:size])
+	}))
+
+	tests := []struct {
+		name           string
+		target         string
+		acceptEncoding string
+		wantGzip       bool
+		wantLen        int
+	}{
+		{name: "large accepted", target: "/big", acceptEncoding: "gzip", wantGzip: true, wantLen: 2048},
+		{name: "large not accepted", target: "/big", acceptEncoding: "", wantLen: 2048},
+		{name: "small accepted", target: "/big?small=1", acceptEncoding: "gzip", wantLen: 10},
+		{name: "excluded path", target: "/events", acceptEncoding: "gzip", wantLen: 2048},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+			}
+
+			gotGzip := w.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tt.wantGzip {
+				t.Fatalf("Content-Encoding gzip = %v, want %v", gotGzip, tt.wantGzip)
+			}
+
+			var reader io.Reader = w.Body
+			if gotGzip {
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader error: %v", err)
+				}
+				reader = gz
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("ReadAll error: %v", err)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("body length = %d, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+// Test compressMiddleware leaves responses with an explicit Content-Encoding alone
+func TestCompressMiddleware_OptOut(t *testing.T) {
+	handler := compressMiddleware(1, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "identity")
+		io.WriteString(w, "plain body")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Body.String(); got != "plain body" {
+		t.Errorf("body = %q, want %q", got, "plain body")
+	}
+}
+
+// Test handleCompressTest returns the requested number of bytes
+func TestHandleCompressTest(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleCompressTest(w, httptest.NewRequest(http.MethodGet, "/compress-test?bytes=1000", nil))
+	if w.Body.Len() != 1000 {
+		t.Errorf("handleCompressTest() body length = %d, want 1000", w.Body.Len())
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// configSourceFile prefixes the source of settings read from -config.
+const configSourceFile = "file"
+
+// configFile is a -config file that has been applied to the flags.
+type configFile struct {
+	Path string
+
+	// Applied are the flags the file set; flags given on the command line
+	// or through their environment variable keep those values.
+	Applied map[string]bool
+}
+
+// loadConfigFile reads the YAML file at path and sets every flag it names
+// that was not given on the command line and whose environment variable is
+// unset, so the precedence is flag, then environment, then file, then
+// default.
+func loadConfigFile(fs *flag.FlagSet, path string, getenv func(string) string) (*configFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	values, err := parseConfigYAML(f)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	cf := &configFile{Path: path, Applied: make(map[string]bool)}
+	var errs []error
+	for name, value := range values {
+		switch env := flagEnv[name]; {
+		case fs.Lookup(name) == nil:
+			errs = append(errs, fmt.Errorf("unknown setting %q", name))
+		case name == "config":
+			errs = append(errs, errors.New("the config setting cannot be set from a config file"))
+		case set[name], env != "" && getenv(env) != "":
+		default:
+			if err := fs.Set(name, value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", value, name, err))
+				continue
+			}
+			cf.Applied[name] = true
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return cf, nil
+}
+
+// parseConfigYAML parses the subset of YAML the config file uses: a flat
+// mapping of flag names to scalars, where a block list such as
+//
+//	compressExclude:
+//	  - /events
+//	  - /random
+//
+// is joined with commas, the form list flags take. Nested mappings are
+// rejected.
+func parseConfigYAML(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	var listKey string
+	var list []string
+	flush := func() {
+		if listKey != "" {
+			values[listKey] = strings.Join(list, ",")
+		}
+		listKey, list = "", nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || (n == 1 && trimmed == "---") {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			item, ok := strings.CutPrefix(trimmed, "- ")
+			if !ok && trimmed == "-" {
+				item, ok = "", true
+			}
+			if !ok || listKey == "" {
+				return nil, fmt.Errorf("line %d: nested mappings are not supported", n)
+			}
+			v, err := configScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			list = append(list, v)
+			continue
+		}
+		flush()
+
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: want \"name: value\", got %q", n, line)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate setting %q", n, key)
+		}
+		v, err := configScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if v == "" && strings.TrimSpace(value) == "" {
+			// A bare key starts a block list, or sets an empty value.
+			listKey = key
+		}
+		values[key] = v
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// configScalar decodes a plain, single-quoted or double-quoted YAML scalar
+// and drops a trailing comment.
+func configScalar(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := closingQuote(s)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		if err := trailingComment(s[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			if err := trailingComment(s[i+1:]); err != nil {
+				return "", err
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'"), nil
+		}
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if strings.HasPrefix(s, "#") {
+		return "", nil
+	}
+	return s, nil
+}
+
+// closingQuote returns the index of the quote ending the double-quoted
+// string at the start of s, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// trailingComment checks that only a comment follows a quoted scalar.
+func trailingComment(rest string) error {
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after quoted string", rest)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test parseConfigYAML reads scalars, quoted strings and block lists
+func TestParseConfigYAML(t *testing.T) {
+	input := `---
+# server
+httpPort: 9000
+bindAddr: "0.0.0.0" # all addresses
+sessionSecret: 'it''s secret'
+natsURL: nats://nats:4222#frag
+compressExclude:
+  - /events
+  - "/random"
+chaos: true
+volumePaths:
+`
+	got, err := parseConfigYAML(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseConfigYAML() error = %v", err)
+	}
+	want := map[string]string{
+		"httpPort":        "9000",
+		"bindAddr":        "0.0.0.0",
+		"sessionSecret":   "it's secret",
+		"natsURL":         "nats://nats:4222#frag",
+		"compressExclude": "/events,/random",
+		"chaos":           "true",
+		"volumePaths":     "",
+	}
+	if len(got) != len(want) {
+		t.Errorf("parseConfigYAML() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// Test parseConfigYAML rejects what it does not support
+func TestParseConfigYAML_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"nested mapping": "tls:\n  cert: /a\n",
+		"missing colon":  "httpPort 9000\n",
+		"duplicate":      "httpPort: 1\nhttpPort: 2\n",
+		"unterminated":   "bindAddr: \"0.0.0.0\n",
+		"stray text":     "bindAddr: \"a\" b\n",
+		"orphan item":    "  - /events\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseConfigYAML(strings.NewReader(input)); err == nil {
+				t.Errorf("parseConfigYAML(%q) error = nil, want an error", input)
+			}
+		})
+	}
+}
+
+// Test loadConfigFile ranks flags, then environment, then file, then default
+func TestLoadConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "httpPort: 9000\nbindAddr: 10.0.0.1\nchaos: true\ncompressMinBytes: 64\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"BIND_ADDR": "10.0.0.2"}
+	getenv := func(key string) string { return env[key] }
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	httpPort := fs.String("httpPort", "8080", "")
+	bindAddr := fs.String("bindAddr", getenv("BIND_ADDR"), "")
+	chaos := fs.Bool("chaos", false, "")
+	minBytes := fs.Int("compressMinBytes", 1024, "")
+	fs.String("redisURL", "", "")
+	if err := fs.Parse([]string{"-chaos=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := loadConfigFile(fs, path, getenv)
+	if err != nil {
+		t.Fatalf("loadConfigFile() error = %v", err)
+	}
+	if *httpPort != "9000" || *bindAddr != "10.0.0.2" || *chaos || *minBytes != 64 {
+		t.Errorf("httpPort, bindAddr, chaos, compressMinBytes = %s, %s, %v, %d; want 9000 (file), 10.0.0.2 (env), false (flag), 64 (file)", *httpPort, *bindAddr, *chaos, *minBytes)
+	}
+
+	config := effectiveConfig(fs, getenv, file)
+	want := map[string]string{
+		"httpPort":         "file:" + path,
+		"bindAddr":         "env:BIND_ADDR",
+		"chaos":            "flag",
+		"compressMinBytes": "file:" + path,
+		"redisURL":         "default",
+	}
+	for name, source := range want {
+		if got := config[name].Source; got != source {
+			t.Errorf("config[%s].Source = %q, want %q", name, got, source)
+		}
+	}
+}
+
+// Test loadConfigFile reports unknown settings and invalid values
+func TestLoadConfigFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown setting": "nope: 1\n",
+		"invalid value":   "chaos: maybe\n",
+		"nested config":   "config: other.yaml\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.Bool("chaos", false, "")
+			fs.String("config", "", "")
+			if _, err := loadConfigFile(fs, path, func(string) string { return "" }); err == nil {
+				t.Errorf("loadConfigFile(%q) error = nil, want an error", content)
+			}
+		})
+	}
+
+	if _, err := loadConfigFile(flag.NewFlagSet("test", flag.ContinueOnError), filepath.Join(t.TempDir(), "missing.yaml"), os.Getenv); err == nil {
+		t.Error("loadConfigFile() of a missing file error = nil, want an error")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	configSourceFlag    = "flag"
+	configSourceEnv     = "env"
+	configSourceDefault = "default"
+
+	redacted = "[REDACTED]"
+)
+
+var (
+	// flagEnv maps flags to the environment variable that sets their default.
+	flagEnv = map[string]string{
+		"httpPort":      "PORT",
+		"bindAddr":      "BIND_ADDR",
+		"sessionSecret": "SESSION_SECRET",
+		"procRoot":      "PROC_ROOT",
+		"adminToken":    "ADMIN_TOKEN",
+
+		"requireContainerID": "REQUIRE_CONTAINER_ID",
+		"requirePodID":       "REQUIRE_POD_ID",
+		"enableK8sAPI":       "ENABLE_K8S_API",
+		"envRedact":          "ENV_REDACT",
+		"tlsCert":            "TLS_CERT",
+		"tlsKey":             "TLS_KEY",
+		"tlsClientCA":        "TLS_CLIENT_CA",
+		"grpcPort":           "GRPC_PORT",
+		"config":             "CONFIG_FILE",
+		"logLevel":           "LOG_LEVEL",
+		"enableEndpoints":    "ENABLE_ENDPOINTS",
+		"disableEndpoints":   "DISABLE_ENDPOINTS",
+		"identityHeaders":    "IDENTITY_HEADERS",
+		"color":              "COLOR",
+		"trustedProxies":     "TRUSTED_PROXIES",
+		"authToken":          "AUTH_TOKEN",
+		"basicAuth":          "BASIC_AUTH",
+		"corsOrigins":        "CORS_ORIGINS",
+		"corsMethods":        "CORS_METHODS",
+		"corsHeaders":        "CORS_HEADERS",
+		"corsMaxAge":         "CORS_MAX_AGE",
+	}
+
+	// envOnly are settings read from the environment without a flag.
+	envOnly = []string{"INSTANCE_ID", "NODE_NAME"}
+
+	// secretFlags are never reported in clear text.
+	secretFlags = map[string]bool{
+		"sessionSecret": true,
+		"adminToken":    true,
+		"authToken":     true,
+		"basicAuth":     true,
+	}
+)
+
+// configValue is one effective setting and where it came from: "flag",
+// "env:<NAME>" or "default".
+type configValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// loadedConfigFile is the -config file applied at startup, if any.
+var loadedConfigFile *configFile
+
+// effectiveConfig returns the value and source of every flag in fs, plus the
+// environment-only settings that are set. Flags set by file are attributed
+// to it; file may be nil. Secrets and URL passwords are redacted.
+func effectiveConfig(fs *flag.FlagSet, getenv func(string) string, file *configFile) map[string]configValue {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	config := make(map[string]configValue)
+	fs.VisitAll(func(f *flag.Flag) {
+		v := configValue{Value: f.Value.String(), Source: configSourceDefault}
+		switch env := flagEnv[f.Name]; {
+		case file != nil && file.Applied[f.Name]:
+			v.Source = configSourceFile + ":" + file.Path
+		case set[f.Name]:
+			v.Source = configSourceFlag
+		case env != "" && getenv(env) != "":
+			v.Source = configSourceEnv + ":" + env
+		}
+		v.Value = redact(f.Name, v.Value)
+		config[f.Name] = v
+	})
+
+	for _, env := range envOnly {
+		if value := getenv(env); value != "" {
+			config[env] = configValue{Value: value, Source: configSourceEnv + ":" + env}
+		}
+	}
+	return config
+}
+
+// redact hides secret flag values and the password of URL values.
+func redact(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretFlags[name] {
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+// handleConfigz serves GET /config and /configz: the effective configuration
+// of the command line flags.
+func handleConfigz(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, effectiveConfig(flag.CommandLine, os.Getenv, loadedConfigFile))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCORSMethods = "GET,POST,PUT,PATCH,DELETE"
+	defaultCORSHeaders = "Authorization,Content-Type,X-Request-ID"
+	defaultCORSMaxAge  = 10 * time.Minute
+)
+
+// corsExposedHeaders are the response headers scripts may read, so a
+// dashboard can show which replica served it.
+var corsExposedHeaders = strings.Join([]string{headerRequestID, headerContainerID, headerPodID, headerInstanceID}, ", ")
+
+// corsConfig lets browsers on other origins call the API.
+type corsConfig struct {
+	// Origins are the allowed origins, such as https://dash.example.com.
+	// * allows any origin and https://*.example.com any subdomain.
+	Origins []string
+	Methods []string
+	Headers []string
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+func (c corsConfig) validate() error {
+	for _, origin := range c.Origins {
+		if origin != "*" && !strings.Contains(origin, "://") {
+			return fmt.Errorf("CORS origin %q must be * or scheme://host[:port]", origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("CORS max age must not be negative, got %s", c.MaxAge)
+	}
+	return nil
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or
+// "" if it is not allowed.
+func (c corsConfig) allowOrigin(origin string) string {
+	for _, allowed := range c.Origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+		// https://*.example.com matches https://a.example.com but not
+		// https://example.com.
+		if scheme, domain, ok := strings.Cut(strings.ToLower(allowed), "*."); ok {
+			host, ok := strings.CutPrefix(strings.ToLower(origin), scheme)
+			if ok && len(host) > len(domain)+1 && strings.HasSuffix(host, "."+domain) {
+				return origin
+			}
+		}
+	}
+	return ""
+}
+
+// middleware adds the CORS headers to responses for allowed origins and
+// answers their preflight requests with 204. Requests from other origins
+// are served without CORS headers, so the browser blocks them.
+func (c corsConfig) middleware(next http.Handler) http.Handler {
+	if len(c.Origins) == 0 {
+		return next
+	}
+	methods := strings.Join(c.Methods, ", ")
+	headers := strings.Join(c.Headers, ", ")
+	maxAge := strconv.Itoa(int(c.MaxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		allowed := c.allowOrigin(origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if allowed == "" {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", allowed)
+		if !preflight {
+			header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if method := r.Header.Get("Access-Control-Request-Method"); !slices.Contains(c.Methods, method) && method != http.MethodGet && method != http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			header.Set("Access-Control-Allow-Headers", headers)
+		}
+		header.Set("Access-Control-Max-Age", maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test allowOrigin matches exact, wildcard subdomain and * origins
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		want    string
+	}{
+		{"exact", []string{"https://dash.example.com"}, "https://dash.example.com", "https://dash.example.com"},
+		{"exact ignores case", []string{"https://Dash.example.com"}, "https://dash.example.com", "https://dash.example.com"},
+		{"other port", []string{"http://localhost:3000"}, "http://localhost:5173", ""},
+		{"subdomain", []string{"https://*.example.com"}, "https://a.b.example.com", "https://a.b.example.com"},
+		{"subdomain excludes apex", []string{"https://*.example.com"}, "https://example.com", ""},
+		{"subdomain checks scheme", []string{"https://*.example.com"}, "http://a.example.com", ""},
+		{"subdomain checks suffix", []string{"https://*.example.com"}, "https://evilexample.com", ""},
+		{"any", []string{"*"}, "https://anything.test", "*"},
+		{"none", nil, "https://dash.example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (corsConfig{Origins: tt.origins}).allowOrigin(tt.origin); got != tt.want {
+				t.Errorf("allowOrigin(%q) = %q, want %q", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+// Test validate rejects origins without a scheme and a negative max age
+func TestCORSValidate(t *testing.T) {
+	if err := (corsConfig{Origins: []string{"*", "https://*.example.com", "http://localhost:3000"}}).validate(); err != nil {
+		t.Errorf("validate() error = %v, want nil", err)
+	}
+	if err := (corsConfig{Origins: []string{"dash.example.com"}}).validate(); err == nil {
+		t.Error("validate() of an origin without scheme error = nil, want an error")
+	}
+	if err := (corsConfig{MaxAge: -time.Second}).validate(); err == nil {
+		t.Error("validate() of a negative max age error = nil, want an error")
+	}
+}
+
+// Test the CORS middleware answers preflights and decorates allowed requests
+func TestCORSMiddleware(t *testing.T) {
+	c := corsConfig{
+		Origins: []string{"https://dash.example.com"},
+		Methods: splitList(defaultCORSMethods),
+		Headers: splitList(defaultCORSHeaders),
+		MaxAge:  defaultCORSMaxAge,
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := c.middleware(ok)
+
+	tests := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string
+		wantStatus    int
+		wantOrigin    string
+		wantMethods   string
+		wantMaxAge    string
+		wantExpose    bool
+	}{
+		{name: "no origin", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "allowed simple", method: http.MethodGet, origin: "https://dash.example.com", wantStatus: http.StatusOK, wantOrigin: "https://dash.example.com", wantExpose: true},
+		{name: "disallowed simple", method: http.MethodGet, origin: "https://evil.test", wantStatus: http.StatusOK},
+		{name: "allowed preflight", method: http.MethodOptions, origin: "https://dash.example.com", requestMethod: http.MethodPost, wantStatus: http.StatusNoContent, wantOrigin: "https://dash.example.com", wantMethods: "GET, POST, PUT, PATCH, DELETE", wantMaxAge: "600"},
+		{name: "disallowed preflight", method: http.MethodOptions, origin: "https://evil.test", requestMethod: http.MethodPost, wantStatus: http.StatusForbidden},
+		{name: "preflight for other method", method: http.MethodOptions, origin: "https://dash.example.com", requestMethod: "PROPFIND", wantStatus: http.StatusForbidden, wantOrigin: "https://dash.example.com"},
+		{name: "plain options", method: http.MethodOptions, origin: "https://dash.example.com", wantStatus: http.StatusOK, wantOrigin: "https://dash.example.com", wantExpose: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/container_id", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			header := rec.Header()
+			if got := header.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := header.Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if got := header.Get("Access-Control-Max-Age"); got != tt.wantMaxAge {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tt.wantMaxAge)
+			}
+			if got := header.Get("Access-Control-Expose-Headers") != ""; got != tt.wantExpose {
+				t.Errorf("Access-Control-Expose-Headers set = %v, want %v", got, tt.wantExpose)
+			}
+			if tt.origin != "" && header.Get("Vary") != "Origin" {
+				t.Errorf("Vary = %q, want Origin", header.Get("Vary"))
+			}
+		})
+	}
+}
+
+// Test the CORS middleware is a no-op without allowed origins
+func TestCORSMiddlewareDisabled(t *testing.T) {
+	h := corsConfig{}.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(rec.Header()) != 0 {
+		t.Errorf("got %d with headers %v, want the request passed through untouched", rec.Code, rec.Header())
+	}
+}
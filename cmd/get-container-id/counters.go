@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+var counterNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// counterRegistry holds independent named counters that are safe for
+// concurrent use.
+type counterRegistry struct {
+	mu       sync.RWMutex
+	counters map[string]*atomic.Uint64
+}
+
+func newCounterRegistry() *counterRegistry {
+	return &counterRegistry{counters: make(map[string]*atomic.Uint64)}
+}
+
+func (c *counterRegistry) counter(name string) *atomic.Uint64 {
+	c.mu.RLock()
+	counter, ok := c.counters[name]
+	c.mu.RUnlock()
+	if ok {
+		return counter
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if counter, ok = c.counters[name]; !ok {
+		counter = &atomic.Uint64{}
+		c.counters[name] = counter
+	}
+	return counter
+}
+
+// add increments the named counter by n and returns the new value.
+func (c *counterRegistry) add(name string, n uint64) uint64 {
+	return c.counter(name).Add(n)
+}
+
+// get returns the value of the named counter and whether it exists.
+func (c *counterRegistry) get(name string) (uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counter, ok := c.counters[name]
+	if !ok {
+		return 0, false
+	}
+	return counter.Load(), true
+}
+
+// reset removes the named counter and reports whether it existed.
+func (c *counterRegistry) reset(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.counters[name]
+	delete(c.counters, name)
+	return ok
+}
+
+// snapshot returns the current value of every counter.
+func (c *counterRegistry) snapshot() map[string]uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	values := make(map[string]uint64, len(c.counters))
+	for name, counter := range c.counters {
+		values[name] = counter.Load()
+	}
+	return values
+}
+
+type counterValue struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+// pathCounterName validates the {name} path value, writing a 400 on failure.
+func pathCounterName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if !counterNameRegex.MatchString(name) {
+		httpapi.WriteError(w, "counter name must match "+counterNameRegex.String(), http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+func (c *counterRegistry) handleList(w http.ResponseWriter, r *http.Request) {
+	list := []counterValue{}
+	for name, v := range c.snapshot() {
+		list = append(list, counterValue{Name: name, Value: v})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	httpapi.WriteSuccess(w, list)
+}
+
+func (c *counterRegistry) handleGet(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathCounterName(w, r)
+	if !ok {
+		return
+	}
+
+	v, ok := c.get(name)
+	if !ok {
+		httpapi.WriteError(w, "counter not found", http.StatusNotFound)
+		return
+	}
+	httpapi.WriteSuccess(w, counterValue{Name: name, Value: v})
+}
+
+// handleIncrement adds ?by= (default 1) to the counter, creating it if needed.
+func (c *counterRegistry) handleIncrement(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathCounterName(w, r)
+	if !ok {
+		return
+	}
+
+	by := uint64(1)
+	if s := r.URL.Query().Get("by"); s != "" {
+		var err error
+		if by, err = strconv.ParseUint(s, 10, 64); err != nil {
+			httpapi.WriteError(w, "by must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	httpapi.WriteSuccess(w, counterValue{Name: name, Value: c.add(name, by)})
+}
+
+func (c *counterRegistry) handleReset(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathCounterName(w, r)
+	if !ok {
+		return
+	}
+
+	if !c.reset(name) {
+		httpapi.WriteError(w, "counter not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func newCountersMux(c *counterRegistry) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /counters", c.handleList)
+	mux.HandleFunc("GET /counters/{name}", c.handleGet)
+	mux.HandleFunc("POST /counters/{name}", c.handleIncrement)
+	mux.HandleFunc("DELETE /counters/{name}", c.handleReset)
+	return mux
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+// Test the named counters API lifecycle
+func TestCounterRegistryHandlers(t *testing.T) {
+	mux := newCountersMux(newCounterRegistry())
+
+	if w := serve(mux, http.MethodGet, "/counters/requests"); w.Code != http.StatusNotFound {
+		t.Errorf("GET unknown counter status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	serve(mux, http.MethodPost, "/counters/requests")
+	w := serve(mux, http.MethodPost, "/counters/requests?by=4")
+
+	var resp struct {
+		Data counterValue `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("POST body unmarshal error: %v", err)
+	}
+	if resp.Data.Value != 5 {
+		t.Errorf("POST value = %d, want 5", resp.Data.Value)
+	}
+
+	serve(mux, http.MethodPost, "/counters/errors")
+
+	w = serve(mux, http.MethodGet, "/counters")
+	var list struct {
+		Data []counterValue `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("GET list body unmarshal error: %v", err)
+	}
+	want := []counterValue{{Name: "errors", Value: 1}, {Name: "requests", Value: 5}}
+	if len(list.Data) != len(want) || list.Data[0] != want[0] || list.Data[1] != want[1] {
+		t.Errorf("GET list = %+v, want %+v", list.Data, want)
+	}
+
+	if w := serve(mux, http.MethodDelete, "/counters/requests"); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w := serve(mux, http.MethodGet, "/counters/requests"); w.Code != http.StatusNotFound {
+		t.Errorf("GET after DELETE status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// Test the named counters API rejects invalid input
+func TestCounterRegistryHandlers_Invalid(t *testing.T) {
+	mux := newCountersMux(newCounterRegistry())
+
+	if w := serve(mux, http.MethodPost, "/counters/bad%20name"); w.Code != http.StatusBadRequest {
+		t.Errorf("POST invalid name status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := serve(mux, http.MethodPost, "/counters/ok?by=-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("POST invalid by status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// Test counterRegistry is safe for concurrent increments
+func TestCounterRegistry_Concurrent(t *testing.T) {
+	c := newCounterRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.add("hits", 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, _ := c.get("hits"); v != 5000 {
+		t.Errorf("get() = %d, want 5000", v)
+	}
+}
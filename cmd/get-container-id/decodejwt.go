@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/internal/jwt"
+)
+
+// The places /decode_jwt takes the token from.
+const (
+	jwtSourceAuthorization = "authorization"
+	jwtSourceBody          = "body"
+)
+
+// jwtNow is the clock token expiry is measured against; tests replace it.
+var jwtNow = time.Now
+
+// decodedJWT is the answer of /decode_jwt.
+type decodedJWT struct {
+	// Verified is always false: the signature is not checked.
+	Verified bool           `json:"verified"`
+	Source   string         `json:"source"`
+	Header   map[string]any `json:"header"`
+	Claims   map[string]any `json:"claims"`
+
+	IssuedAt         *time.Time `json:"issued_at,omitempty"`
+	NotBefore        *time.Time `json:"not_before,omitempty"`
+	Expiry           *time.Time `json:"expiry,omitempty"`
+	ExpiresInSeconds *float64   `json:"expires_in_seconds,omitempty"`
+	Expired          bool       `json:"expired"`
+	// NotYetValid reports an nbf in the future.
+	NotYetValid bool `json:"not_yet_valid,omitempty"`
+}
+
+// requestJWT returns the token of a request: the bearer token of the
+// Authorization header, or else the body, which is either the bare token or
+// a JSON object with a token field.
+func requestJWT(w http.ResponseWriter, r *http.Request) (token, source string, err error) {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), jwtSourceAuthorization, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return "", "", errors.New("failed to read request body")
+	}
+	token = strings.TrimSpace(string(body))
+	if strings.HasPrefix(token, "{") {
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", "", fmt.Errorf("invalid JSON body: %w", err)
+		}
+		token = strings.TrimSpace(req.Token)
+	}
+	if token == "" {
+		return "", "", errors.New("no token: send an Authorization: Bearer header or the token in the body")
+	}
+	return strings.TrimPrefix(token, "Bearer "), jwtSourceBody, nil
+}
+
+// handleDecodeJWT serves /decode_jwt: it decodes the header and claims of
+// a JWT, e.g. one injected by a mesh or gateway, and reports its validity
+// period. The signature is not verified, which the response states with
+// "verified": false.
+func handleDecodeJWT(w http.ResponseWriter, r *http.Request) (any, error) {
+	raw, source, err := requestJWT(w, r)
+	if err != nil {
+		return nil, httpapi.NewError(http.StatusBadRequest, err)
+	}
+	token, err := jwt.Decode(raw)
+	if err != nil {
+		return nil, httpapi.NewError(http.StatusBadRequest, err)
+	}
+
+	now := jwtNow()
+	doc := decodedJWT{Source: source, Header: token.Header, Claims: token.Claims}
+	if t, ok := token.Time("iat"); ok {
+		doc.IssuedAt = &t
+	}
+	if t, ok := token.Time("nbf"); ok {
+		doc.NotBefore = &t
+		doc.NotYetValid = t.After(now)
+	}
+	if t, ok := token.Time("exp"); ok {
+		remaining := t.Sub(now).Seconds()
+		doc.Expiry, doc.ExpiresInSeconds = &t, &remaining
+		doc.Expired = remaining <= 0
+	}
+	return doc, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// testJWT builds a token with the given claims and a dummy signature.
+func testJWT(claims string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims)) + "." + enc.EncodeToString([]byte("signature"))
+}
+
+// Test /decode_jwt decodes tokens from the Authorization header and the body
+func TestHandleDecodeJWT(t *testing.T) {
+	orig := jwtNow
+	defer func() { jwtNow = orig }()
+	jwtNow = func() time.Time { return time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC) }
+
+	valid := testJWT(`{"sub":"spiffe://cluster.local/ns/default/sa/web","iat":1792051200,"exp":1792054800}`)
+	expired := testJWT(`{"sub":"web","exp":1792047600,"nbf":1792054800}`)
+
+	tests := []struct {
+		name        string
+		header      string
+		body        string
+		wantSource  string
+		wantExpires float64
+		wantExpired bool
+		wantNotYet  bool
+	}{
+		{name: "bearer header", header: "Bearer " + valid, wantSource: jwtSourceAuthorization, wantExpires: 3600},
+		{name: "lowercase scheme", header: "bearer " + valid, wantSource: jwtSourceAuthorization, wantExpires: 3600},
+		{name: "raw body", body: valid + "\n", wantSource: jwtSourceBody, wantExpires: 3600},
+		{name: "json body", body: `{"token":"` + expired + `"}`, wantSource: jwtSourceBody, wantExpires: -3600, wantExpired: true, wantNotYet: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/decode_jwt", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			httpapi.HandlerFunc(handleDecodeJWT).ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			var resp struct {
+				Data decodedJWT `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got := resp.Data
+			if got.Verified || got.Source != tt.wantSource || got.Header["alg"] != "RS256" || got.Claims["sub"] == nil {
+				t.Errorf("decoded = %+v, want an unverified RS256 token from %s", got, tt.wantSource)
+			}
+			if got.ExpiresInSeconds == nil || *got.ExpiresInSeconds != tt.wantExpires || got.Expired != tt.wantExpired || got.NotYetValid != tt.wantNotYet {
+				t.Errorf("expires_in_seconds = %v, expired = %v, not_yet_valid = %v; want %v, %v, %v", got.ExpiresInSeconds, got.Expired, got.NotYetValid, tt.wantExpires, tt.wantExpired, tt.wantNotYet)
+			}
+		})
+	}
+}
+
+// Test /decode_jwt rejects requests without a well-formed token
+func TestHandleDecodeJWT_Invalid(t *testing.T) {
+	tests := map[string]struct{ header, body string }{
+		"no token":      {},
+		"basic auth":    {header: "Basic dXNlcjpwYXNz"},
+		"malformed":     {body: "not.a.jwt"},
+		"bad json":      {body: `{"token":`},
+		"empty json":    {body: `{}`},
+		"opaque bearer": {header: "Bearer abc123"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/decode_jwt", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			httpapi.HandlerFunc(handleDecodeJWT).ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
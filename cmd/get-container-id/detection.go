@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// The detection diagnostics; tests replace them.
+var (
+	diagnoseContainerFunc = func(ctx context.Context) []containerid.ProviderResult { return containerid.Diagnose(ctx) }
+	diagnosePodFunc       = func(ctx context.Context) []podid.ProviderResult { return podid.Diagnose(ctx) }
+
+	detectContainerFunc = detectContainerID
+	detectPodFunc       = func(ctx context.Context) (podid.Detection, error) { return podid.GetDetailed(ctx) }
+	getPodIDFunc        = func(ctx context.Context) (string, error) { return podid.GetContext(ctx) }
+)
+
+// idSource is the ?source=true form of /container_id and /pod_id: the ID
+// and where it was found, for reporting a wrong or missing ID.
+type idSource struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Line     string `json:"line,omitempty"`
+	// CgroupVersion is 1 or 2 for an ID read from a cgroup path.
+	CgroupVersion int `json:"cgroup_version,omitempty"`
+}
+
+// handleContainerID serves /container_id?short=true&source=true.
+func handleContainerID(w http.ResponseWriter, r *http.Request) (any, error) {
+	q := r.URL.Query()
+	source := q.Get("source") == "true"
+	d, err := detectContainerFunc(r.Context(), source)
+	if errors.Is(err, containerid.ErrIDUnavailable) {
+		return nil, httpapi.NewError(http.StatusServiceUnavailable, err)
+	}
+	if errors.Is(err, ErrContainerIDNotFound) {
+		return nil, httpapi.NewError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if q.Get("short") == "true" {
+		d.ID = containerid.Short(d.ID)
+	}
+	if !source {
+		return d.ID, nil
+	}
+	return idSource{ID: d.ID, Provider: d.Provider, Source: d.Source, Line: d.Line, CgroupVersion: d.CgroupVersion}, nil
+}
+
+// handlePodID serves /pod_id?source=true.
+func handlePodID(w http.ResponseWriter, r *http.Request) (any, error) {
+	if r.URL.Query().Get("source") != "true" {
+		pid, err := getPodIDFunc(r.Context())
+		if errors.Is(err, podid.ErrPodIDNotFound) {
+			return nil, httpapi.NewError(http.StatusNotFound, err)
+		}
+		return pid, err
+	}
+
+	d, err := detectPodFunc(r.Context())
+	if errors.Is(err, podid.ErrPodIDNotFound) {
+		return nil, httpapi.NewError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return idSource{ID: d.ID, Provider: d.Provider, Source: d.Source, Line: d.Line}, nil
+}
+
+// detectionResult is the outcome of one detection source.
+type detectionResult struct {
+	Provider  string  `json:"provider,omitempty"`
+	What      string  `json:"what,omitempty"`
+	Source    string  `json:"source"`
+	Value     string  `json:"value,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	ElapsedMS float64 `json:"elapsed_ms"`
+}
+
+// detectionReport is the /debug/detection document.
+type detectionReport struct {
+	ContainerID []detectionResult `json:"container_id"`
+	Pod         []detectionResult `json:"pod"`
+}
+
+func newDetectionResult(source, value string, err error, elapsed time.Duration) detectionResult {
+	r := detectionResult{Source: source, Value: value, ElapsedMS: float64(elapsed) / float64(time.Millisecond)}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// handleDetection serves GET /debug/detection: it runs every container ID
+// provider and reads every pod source, bypassing the caches, and reports
+// what each found, why it failed and how long it took.
+func handleDetection(w http.ResponseWriter, r *http.Request) {
+	report := detectionReport{ContainerID: []detectionResult{}, Pod: []detectionResult{}}
+	for _, res := range diagnoseContainerFunc(r.Context()) {
+		d := newDetectionResult(res.Source, res.ID, res.Err, res.Elapsed)
+		d.Provider = res.Provider
+		report.ContainerID = append(report.ContainerID, d)
+	}
+	for _, res := range diagnosePodFunc(r.Context()) {
+		d := newDetectionResult(res.Source, res.Value, res.Err, res.Elapsed)
+		d.What = res.What
+		report.Pod = append(report.Pod, d)
+	}
+	httpapi.WriteSuccess(w, report)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// Test /debug/detection reports every provider with its error and timing
+func TestHandleDetection(t *testing.T) {
+	origContainer, origPod := diagnoseContainerFunc, diagnosePodFunc
+	defer func() { diagnoseContainerFunc, diagnosePodFunc = origContainer, origPod }()
+	diagnoseContainerFunc = func(context.Context) []containerid.ProviderResult {
+		return []containerid.ProviderResult{
+			{Provider: "cpuset", Source: containerid.CpusetPath, Err: containerid.ErrContainerIDNotFound, Elapsed: time.Millisecond},
+			{Provider: "mountinfo", Source: containerid.MountInfoPath, ID: "abc", Elapsed: 2 * time.Millisecond},
+		}
+	}
+	diagnosePodFunc = func(context.Context) []podid.ProviderResult {
+		return []podid.ProviderResult{{What: "pod ID", Source: podid.MountInfoPath, Err: errors.New("boom")}}
+	}
+
+	w := httptest.NewRecorder()
+	handleDetection(w, httptest.NewRequest(http.MethodGet, "/debug/detection", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data detectionReport `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := detectionReport{
+		ContainerID: []detectionResult{
+			{Provider: "cpuset", Source: containerid.CpusetPath, Error: containerid.ErrContainerIDNotFound.Error(), ElapsedMS: 1},
+			{Provider: "mountinfo", Source: containerid.MountInfoPath, Value: "abc", ElapsedMS: 2},
+		},
+		Pod: []detectionResult{{What: "pod ID", Source: podid.MountInfoPath, Error: "boom"}},
+	}
+	if len(resp.Data.ContainerID) != 2 || len(resp.Data.Pod) != 1 {
+		t.Fatalf("report = %+v, want %+v", resp.Data, want)
+	}
+	for i, r := range want.ContainerID {
+		if resp.Data.ContainerID[i] != r {
+			t.Errorf("container_id[%d] = %+v, want %+v", i, resp.Data.ContainerID[i], r)
+		}
+	}
+	if resp.Data.Pod[0] != want.Pod[0] {
+		t.Errorf("pod[0] = %+v, want %+v", resp.Data.Pod[0], want.Pod[0])
+	}
+}
+
+// Test /container_id and /pod_id report their source only when asked
+func TestHandleIDSource(t *testing.T) {
+	origContainer, origPod, origPodID := detectContainerFunc, detectPodFunc, getPodIDFunc
+	defer func() { detectContainerFunc, detectPodFunc, getPodIDFunc = origContainer, origPod, origPodID }()
+	id := strings.Repeat("ab", 32)
+	detectContainerFunc = func(_ context.Context, detailed bool) (containerid.Detection, error) {
+		d := containerid.Detection{ID: id}
+		if detailed {
+			d.Provider, d.Source, d.Line, d.CgroupVersion = "cgroup", containerid.CgroupPath, "0::/docker/"+id, 2
+		}
+		return d, nil
+	}
+	detectPodFunc = func(context.Context) (podid.Detection, error) { return podid.Detection{}, podid.ErrPodIDNotFound }
+	getPodIDFunc = func(context.Context) (string, error) { return "036da4f7-d553-4eb6-9802-90f81041a412", nil }
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantData   string
+	}{
+		{target: "/container_id", wantStatus: http.StatusOK, wantData: `"` + id + `"`},
+		{target: "/container_id?short=true", wantStatus: http.StatusOK, wantData: `"` + id[:12] + `"`},
+		{
+			target:     "/container_id?source=true&short=true",
+			wantStatus: http.StatusOK,
+			wantData:   `{"id":"` + id[:12] + `","provider":"cgroup","source":"/proc/self/cgroup","line":"0::/docker/` + id + `","cgroup_version":2}`,
+		},
+		{target: "/pod_id", wantStatus: http.StatusOK, wantData: `"036da4f7-d553-4eb6-9802-90f81041a412"`},
+		{target: "/pod_id?source=true", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		h := httpapi.HandlerFunc(handleContainerID)
+		if strings.HasPrefix(tt.target, "/pod_id") {
+			h = httpapi.HandlerFunc(handlePodID)
+		}
+		w := serve(h, http.MethodGet, tt.target)
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET %s status = %d, want %d", tt.target, w.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantData == "" {
+			continue
+		}
+		var resp struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if string(resp.Data) != tt.wantData {
+			t.Errorf("GET %s data = %s, want %s", tt.target, resp.Data, tt.wantData)
+		}
+	}
+}
+
+// Test /container_id answers 404 outside a container and 503 when the ID is hidden
+func TestHandleContainerIDErrors(t *testing.T) {
+	orig := detectContainerFunc
+	defer func() { detectContainerFunc = orig }()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   httpapi.Code
+	}{
+		{name: "not in a container", err: ErrContainerIDNotFound, wantStatus: http.StatusNotFound, wantCode: httpapi.CodeNotFound},
+		{
+			name:       "ID hidden",
+			err:        fmt.Errorf("%w: %w", ErrContainerIDNotFound, containerid.ErrIDUnavailable),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   httpapi.CodeUnavailable,
+		},
+		{name: "other", err: errors.New("boom"), wantStatus: http.StatusInternalServerError, wantCode: httpapi.CodeInternal},
+	}
+	for _, tt := range tests {
+		detectContainerFunc = func(context.Context, bool) (containerid.Detection, error) {
+			return containerid.Detection{}, tt.err
+		}
+		w := serve(httpapi.HandlerFunc(handleContainerID), http.MethodGet, "/container_id")
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		var resp struct {
+			Errors httpapi.ErrorBody `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if resp.Errors.Code != tt.wantCode {
+			t.Errorf("%s: code = %q, want %q", tt.name, resp.Errors.Code, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// echoConfig configures /echo.
+type echoConfig struct {
+	// MaxBody is the largest request body /echo accepts, in bytes.
+	MaxBody int64
+}
+
+// echoFile describes a file uploaded in a multipart body. Its content is
+// not echoed, only its size and digest.
+type echoFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// echoBody is the request body as /echo reports it.
+type echoBody struct {
+	// raw is the body, except for multipart bodies, which are reported by
+	// form and files instead.
+	raw   []byte
+	size  int64
+	form  url.Values
+	files []echoFile
+}
+
+// byteCounter counts the bytes read through it.
+type byteCounter struct {
+	r io.Reader
+	n int64
+}
+
+func (c *byteCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readEchoBody reads the body of r. URL-encoded forms are also parsed into
+// form. Multipart bodies are read part by part: fields go to form and files
+// are hashed as they stream by rather than kept.
+func readEchoBody(r *http.Request, body io.Reader) (echoBody, error) {
+	counter := &byteCounter{r: body}
+	var res echoBody
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		if err := readMultipart(&res, multipart.NewReader(counter, params["boundary"])); err != nil {
+			return echoBody{}, err
+		}
+	} else {
+		var err error
+		if res.raw, err = io.ReadAll(counter); err != nil {
+			return echoBody{}, err
+		}
+		if mediaType == "application/x-www-form-urlencoded" {
+			// Keep what parses, as a server would.
+			res.form, _ = url.ParseQuery(string(res.raw))
+		}
+	}
+
+	// Trailers are only available once the body has been read to the end,
+	// which a multipart body may not be after its closing boundary.
+	if _, err := io.Copy(io.Discard, counter); err != nil {
+		return echoBody{}, err
+	}
+	res.size = counter.n
+	return res, nil
+}
+
+func readMultipart(res *echoBody, mr *multipart.Reader) error {
+	res.form = url.Values{}
+	res.files = []echoFile{}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			res.form.Add(part.FormName(), string(value))
+			continue
+		}
+
+		h := sha256.New()
+		n, err := io.Copy(h, part)
+		if err != nil {
+			return err
+		}
+		res.files = append(res.files, echoFile{
+			Field:       part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get(headerContentType),
+			Size:        n,
+			SHA256:      hex.EncodeToString(h.Sum(nil)),
+		})
+	}
+}
+
+// readBody reads the body of an /echo request. It answers requests whose
+// body cannot be read itself and returns false.
+func (c echoConfig) readBody(w http.ResponseWriter, r *http.Request) (echoBody, bool) {
+	body, err := readEchoBody(r, http.MaxBytesReader(w, r.Body, c.MaxBody))
+	_ = r.Body.Close()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		httpapi.WriteError(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return echoBody{}, false
+	}
+	if err != nil {
+		httpapi.WriteError(w, "failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return echoBody{}, false
+	}
+	return body, true
+}
+
+// echoDocument describes the request: its method, URL, headers, body and
+// the connection it arrived on. Bodies that are not valid UTF-8 are given in
+// base64 with body_encoding set, and form bodies are also reported parsed.
+func echoDocument(r *http.Request, body echoBody) map[string]any {
+	doc := map[string]any{
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"query":      r.URL.RawQuery,
+		"header":     r.Header,
+		"host":       r.Host,
+		"remote":     r.RemoteAddr,
+		"body":       string(body.raw),
+		"body_size":  body.size,
+		"request_id": requestID(r.Context()),
+		"tls":        newTLSInfo(r.TLS),
+		"protocol":   newProtocolInfo(r),
+		"trailer":    r.Trailer,
+	}
+	if !utf8.Valid(body.raw) {
+		doc["body"] = base64.StdEncoding.EncodeToString(body.raw)
+		doc["body_encoding"] = "base64"
+	}
+	if body.form != nil {
+		doc["form"] = body.form
+	}
+	if body.files != nil {
+		doc["files"] = body.files
+	}
+	return doc
+}
+
+// handleEcho serves /echo: it answers with the echoDocument of the request.
+// Bodies larger than MaxBody are rejected with 413.
+func (c echoConfig) handleEcho(w http.ResponseWriter, r *http.Request) {
+	body, ok := c.readBody(w, r)
+	if !ok {
+		return
+	}
+	httpapi.WriteSuccess(w, echoDocument(r, body))
+}
+
+// echoHeaderPrefix marks the query parameters of /echo/{path...} that set a
+// response header, e.g. header.Cache-Control=no-store.
+const echoHeaderPrefix = "header."
+
+// echoMock is the response a request to /echo/{path...} asks for.
+type echoMock struct {
+	// status holds the status codes to pick from, weighted as for
+	// /status/{codes}; none means 200.
+	status []statusChoice
+	delay  time.Duration
+	header http.Header
+	// body replaces the echo document when hasBody is set.
+	body    string
+	hasBody bool
+}
+
+// parseEchoMock reads the status, delay, body and header.<Name> query
+// parameters. Other parameters are left alone, so they can route or tag
+// requests in a test.
+func parseEchoMock(query url.Values) (echoMock, error) {
+	m := echoMock{header: http.Header{}}
+	if s := query.Get("status"); s != "" {
+		choices, err := parseStatusChoices(s)
+		if err != nil {
+			return echoMock{}, err
+		}
+		m.status = choices
+	}
+	if s := query.Get("delay"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return echoMock{}, fmt.Errorf("invalid delay %q: must be a non-negative duration such as 250ms or 2s", s)
+		}
+		m.delay = min(d, maxDelay)
+	}
+	if query.Has("body") {
+		m.body, m.hasBody = query.Get("body"), true
+	}
+	for key, values := range query {
+		name, ok := strings.CutPrefix(key, echoHeaderPrefix)
+		if !ok {
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return echoMock{}, fmt.Errorf("invalid header name %q", name)
+		}
+		for _, v := range values {
+			m.header.Add(name, v)
+		}
+	}
+	return m, nil
+}
+
+// handleEchoMock serves /echo/{path...}, a scriptable mock backend: the
+// query parameters set the status, the delay, response headers and the
+// body, which otherwise is the echoDocument of the request.
+func (c echoConfig) handleEchoMock(w http.ResponseWriter, r *http.Request) {
+	mock, err := parseEchoMock(r.URL.Query())
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, ok := c.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	if mock.delay > 0 {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(mock.delay + 5*time.Second))
+		if err := sleepContext(r.Context(), mock.delay); err != nil {
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if mock.status != nil {
+		status = pickStatus(mock.status, statusRandom())
+	}
+	for name, values := range mock.header {
+		w.Header()[name] = values
+	}
+	switch {
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		// These responses must not have a body.
+		w.WriteHeader(status)
+	case mock.hasBody:
+		if w.Header().Get(headerContentType) == "" {
+			w.Header().Set(headerContentType, "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(mock.body))
+	default:
+		b, err := json.Marshal(httpapi.Response{Data: echoDocument(r, body)})
+		if err != nil {
+			httpapi.WriteError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Unlike httpapi.WriteJSON, keep a Content-Type the query asked for.
+		if w.Header().Get(headerContentType) == "" {
+			w.Header().Set(headerContentType, contentTypeJSON)
+		}
+		w.WriteHeader(status)
+		w.Write(b)
+	}
+}
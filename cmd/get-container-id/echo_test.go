@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoResponse is the part of the /echo response the tests check.
+type echoResponse struct {
+	Data struct {
+		Method       string              `json:"method"`
+		Body         string              `json:"body"`
+		BodySize     int64               `json:"body_size"`
+		BodyEncoding string              `json:"body_encoding"`
+		Form         map[string][]string `json:"form"`
+		Files        []echoFile          `json:"files"`
+	} `json:"data"`
+}
+
+func serveEcho(t *testing.T, c echoConfig, req *http.Request) (*httptest.ResponseRecorder, echoResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.handleEcho(rec, req)
+	var resp echoResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+// Test /echo returns text bodies as they are and parses URL-encoded forms
+func TestEchoTextAndForm(t *testing.T) {
+	c := echoConfig{MaxBody: maxBodySize}
+
+	_, resp := serveEcho(t, c, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello")))
+	if resp.Data.Body != "hello" || resp.Data.BodySize != 5 || resp.Data.BodyEncoding != "" || resp.Data.Form != nil {
+		t.Errorf("text body = %+v, want hello as is", resp.Data)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("a=1&b=x+y&a=2"))
+	req.Header.Set(headerContentType, "application/x-www-form-urlencoded")
+	_, resp = serveEcho(t, c, req)
+	if resp.Data.Body != "a=1&b=x+y&a=2" {
+		t.Errorf("form body = %q, want it kept", resp.Data.Body)
+	}
+	if got := resp.Data.Form; len(got["a"]) != 2 || got["a"][1] != "2" || got["b"][0] != "x y" {
+		t.Errorf("form = %v, want a=[1 2] b=[x y]", got)
+	}
+}
+
+// Test /echo base64-encodes bodies that are not UTF-8
+func TestEchoBinary(t *testing.T) {
+	body := []byte{0x00, 0xff, 0xfe, 'a'}
+	_, resp := serveEcho(t, echoConfig{MaxBody: maxBodySize}, httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body)))
+	if resp.Data.BodyEncoding != "base64" || resp.Data.Body != "AP/+YQ==" || resp.Data.BodySize != 4 {
+		t.Errorf("binary body = %+v, want base64 AP/+YQ==", resp.Data)
+	}
+}
+
+// Test /echo reports multipart fields and the size and digest of files
+func TestEchoMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", "gopher")
+	fw, _ := mw.CreateFormFile("upload", "data.bin")
+	content := bytes.Repeat([]byte{0x01, 0x02}, 1000)
+	fw.Write(content)
+	mw.Close()
+	size := int64(buf.Len())
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", &buf)
+	req.Header.Set(headerContentType, mw.FormDataContentType())
+	_, resp := serveEcho(t, echoConfig{MaxBody: maxBodySize}, req)
+
+	if resp.Data.Body != "" || resp.Data.BodySize != size {
+		t.Errorf("body = %q, size = %d; want no body and size %d", resp.Data.Body, resp.Data.BodySize, size)
+	}
+	if got := resp.Data.Form["name"]; len(got) != 1 || got[0] != "gopher" {
+		t.Errorf("form = %v, want name=gopher", resp.Data.Form)
+	}
+	sum := sha256.Sum256(content)
+	want := echoFile{Field: "upload", Filename: "data.bin", ContentType: "application/octet-stream", Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:])}
+	if len(resp.Data.Files) != 1 || resp.Data.Files[0] != want {
+		t.Errorf("files = %+v, want %+v", resp.Data.Files, want)
+	}
+}
+
+// Test /echo rejects bodies over MaxBody and malformed multipart bodies
+func TestEchoErrors(t *testing.T) {
+	rec, _ := serveEcho(t, echoConfig{MaxBody: 4}, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("too long")))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body status = %d, want 413", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("--b\r\nbroken"))
+	req.Header.Set(headerContentType, "multipart/form-data; boundary=b")
+	if rec, _ := serveEcho(t, echoConfig{MaxBody: maxBodySize}, req); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed multipart status = %d, want 400", rec.Code)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("upload", "big.bin")
+	fw.Write(make([]byte, 1024))
+	mw.Close()
+	req = httptest.NewRequest(http.MethodPost, "/echo", &buf)
+	req.Header.Set(headerContentType, mw.FormDataContentType())
+	if rec, _ := serveEcho(t, echoConfig{MaxBody: 512}, req); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized multipart status = %d, want 413", rec.Code)
+	}
+}
+
+// Test parseEchoMock reads the control parameters and rejects invalid ones
+func TestParseEchoMock(t *testing.T) {
+	m, err := parseEchoMock(url.Values{
+		"status":         {"503"},
+		"delay":          {"1m"},
+		"header.X-Foo":   {"a", "b"},
+		"header.x-trace": {"1"},
+		"body":           {""},
+		"route":          {"ignored"},
+	})
+	if err != nil {
+		t.Fatalf("parseEchoMock() error = %v", err)
+	}
+	if len(m.status) != 1 || m.status[0].code != 503 {
+		t.Errorf("status = %+v, want 503", m.status)
+	}
+	if m.delay != maxDelay {
+		t.Errorf("delay = %s, want it capped at %s", m.delay, maxDelay)
+	}
+	if got := m.header.Values("X-Foo"); len(got) != 2 || m.header.Get("X-Trace") != "1" || len(m.header) != 2 {
+		t.Errorf("header = %v, want X-Foo: a, b and X-Trace: 1", m.header)
+	}
+	if !m.hasBody || m.body != "" {
+		t.Errorf("body = %q, %v; want an empty body", m.body, m.hasBody)
+	}
+
+	for _, query := range []url.Values{
+		{"status": {"99"}},
+		{"delay": {"2"}},
+		{"delay": {"-1s"}},
+		{"header.": {"x"}},
+		{"header.Bad Name": {"x"}},
+	} {
+		if _, err := parseEchoMock(query); err == nil {
+			t.Errorf("parseEchoMock(%v) error = nil, want an error", query)
+		}
+	}
+}
+
+// Test /echo/{path...} answers with the status, headers and body it is asked for
+func TestEchoMock(t *testing.T) {
+	c := echoConfig{MaxBody: maxBodySize}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo/{path...}", c.handleEchoMock)
+
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantHeader      string
+	}{
+		{"echo document", "/echo/api/users?x=1", http.StatusOK, contentTypeJSON, `"path":"/echo/api/users"`, ""},
+		{"status and header", "/echo/a?status=503&header.Retry-After=5", http.StatusServiceUnavailable, contentTypeJSON, `"path":"/echo/a"`, "5"},
+		{"body", "/echo/a?status=201&body=created", http.StatusCreated, "text/plain; charset=utf-8", "created", ""},
+		{"body with content type", "/echo/a?body=%7B%7D&header.Content-Type=application/problem%2Bjson", http.StatusOK, "application/problem+json", "{}", ""},
+		{"no content", "/echo/a?status=204&body=ignored", http.StatusNoContent, "", "", ""},
+		{"bad status", "/echo/a?status=abc", http.StatusBadRequest, contentTypeJSON, "invalid status code", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(headerContentType); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) || (tt.wantBody == "" && rec.Body.Len() != 0) {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if tt.wantHeader != "" && rec.Header().Get("Retry-After") != tt.wantHeader {
+				t.Errorf("Retry-After = %q, want %q", rec.Header().Get("Retry-After"), tt.wantHeader)
+			}
+		})
+	}
+}
+
+// Test /echo/{path...} waits for the requested delay
+func TestEchoMockDelay(t *testing.T) {
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	echoConfig{MaxBody: maxBodySize}.handleEchoMock(rec, httptest.NewRequest(http.MethodGet, "/echo/slow?delay=50ms", nil))
+	if elapsed := time.Since(start); rec.Code != http.StatusOK || elapsed < 50*time.Millisecond {
+		t.Errorf("got %d after %s, want 200 after at least 50ms", rec.Code, elapsed)
+	}
+}
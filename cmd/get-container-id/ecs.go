@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// ecsContainerFunc and ecsTaskFunc query the ECS task metadata endpoint;
+// tests replace them.
+var (
+	ecsContainerFunc = containerid.GetECSContainer
+	ecsTaskFunc      = containerid.GetECSTask
+)
+
+// ecsDocument is the ECS identity of the serving container.
+type ecsDocument struct {
+	ContainerID      string `json:"container_id"`
+	ContainerName    string `json:"container_name,omitempty"`
+	ContainerARN     string `json:"container_arn,omitempty"`
+	Image            string `json:"image,omitempty"`
+	TaskARN          string `json:"task_arn,omitempty"`
+	Cluster          string `json:"cluster,omitempty"`
+	Family           string `json:"family,omitempty"`
+	Revision         string `json:"revision,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	LaunchType       string `json:"launch_type,omitempty"`
+}
+
+// handleECS serves GET /ecs: the container and task metadata from the ECS
+// task metadata endpoint, or 404 outside of ECS.
+func handleECS(w http.ResponseWriter, r *http.Request) {
+	c, err := ecsContainerFunc(r.Context())
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, containerid.ErrNotECS) {
+			status = http.StatusNotFound
+		}
+		httpapi.WriteError(w, err.Error(), status)
+		return
+	}
+	task, err := ecsTaskFunc(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	httpapi.WriteSuccess(w, ecsDocument{
+		ContainerID:      c.DockerID,
+		ContainerName:    c.Name,
+		ContainerARN:     c.ContainerARN,
+		Image:            c.Image,
+		TaskARN:          task.TaskARN,
+		Cluster:          task.Cluster,
+		Family:           task.Family,
+		Revision:         task.Revision,
+		AvailabilityZone: task.AvailabilityZone,
+		LaunchType:       task.LaunchType,
+	})
+}
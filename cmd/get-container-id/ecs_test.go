@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+)
+
+// stubECS replaces the ECS metadata lookups for the duration of the test.
+func stubECS(t *testing.T, container containerid.ECSContainer, task containerid.ECSTask, err error) {
+	t.Helper()
+	origContainer, origTask := ecsContainerFunc, ecsTaskFunc
+	ecsContainerFunc = func(context.Context) (containerid.ECSContainer, error) { return container, err }
+	ecsTaskFunc = func(context.Context) (containerid.ECSTask, error) { return task, err }
+	t.Cleanup(func() { ecsContainerFunc, ecsTaskFunc = origContainer, origTask })
+}
+
+// Test /ecs combines the container and task metadata
+func TestHandleECS(t *testing.T) {
+	stubECS(t,
+		containerid.ECSContainer{DockerID: "cd189a933e5849daa93386466019ab50-2495160603", Name: "web"},
+		containerid.ECSTask{TaskARN: "arn:aws:ecs:us-west-2:111122223333:task/default/158d", Cluster: "default", LaunchType: "FARGATE"},
+		nil)
+
+	w := httptest.NewRecorder()
+	handleECS(w, httptest.NewRequest(http.MethodGet, "/ecs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data ecsDocument `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if resp.Data.ContainerID != "cd189a933e5849daa93386466019ab50-2495160603" || resp.Data.ContainerName != "web" || resp.Data.LaunchType != "FARGATE" || resp.Data.Cluster != "default" {
+		t.Errorf("ecs = %+v, want the stubbed container and task", resp.Data)
+	}
+}
+
+// Test /ecs reports 404 outside of ECS and 502 when the endpoint fails
+func TestHandleECS_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not ecs", containerid.ErrNotECS, http.StatusNotFound},
+		{"endpoint failure", errors.New("connection refused"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubECS(t, containerid.ECSContainer{}, containerid.ECSTask{}, tt.err)
+
+			w := httptest.NewRecorder()
+			handleECS(w, httptest.NewRequest(http.MethodGet, "/ecs", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// endpointConfig restricts which endpoints the HTTP server serves. Entries
+// are paths such as /env, which also cover the paths below them, e.g.
+// /counters covers /counters/{name}.
+type endpointConfig struct {
+	// Enable lists the only endpoints served; empty serves all of them.
+	Enable []string
+	// Disable lists endpoints that are never served, even if enabled.
+	Disable []string
+	// Status answers disabled endpoints: 404 hides them, 403 reports them
+	// as forbidden.
+	Status int
+}
+
+func (c endpointConfig) validate() error {
+	for _, entry := range append(c.Enable[:len(c.Enable):len(c.Enable)], c.Disable...) {
+		if !strings.HasPrefix(entry, "/") {
+			return fmt.Errorf("endpoint %q must be a path starting with /", entry)
+		}
+	}
+	if c.Status != http.StatusNotFound && c.Status != http.StatusForbidden {
+		return fmt.Errorf("disabled endpoint status must be 403 or 404, got %d", c.Status)
+	}
+	return nil
+}
+
+// enabled reports whether requests for path are served.
+func (c endpointConfig) enabled(path string) bool {
+	for _, entry := range c.Disable {
+		if endpointMatches(entry, path) {
+			return false
+		}
+	}
+	if len(c.Enable) == 0 {
+		return true
+	}
+	for _, entry := range c.Enable {
+		if endpointMatches(entry, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointMatches reports whether path is entry or lies below it. The root
+// entry / only matches the root itself.
+func endpointMatches(entry, path string) bool {
+	if entry == "/" {
+		return path == "/"
+	}
+	entry = strings.TrimSuffix(entry, "/")
+	return path == entry || strings.HasPrefix(path, entry+"/")
+}
+
+// middleware answers requests for disabled endpoints with c.Status.
+func (c endpointConfig) middleware(next http.Handler) http.Handler {
+	if len(c.Enable) == 0 && len(c.Disable) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.enabled(r.URL.Path) {
+			message := "endpoint " + r.URL.Path + " is disabled"
+			if c.Status == http.StatusNotFound {
+				message = "endpoint " + r.URL.Path + " not found"
+			}
+			httpapi.WriteError(w, message, c.Status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test endpointConfig applies the denylist before the allowlist
+func TestEndpointConfig_Enabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config endpointConfig
+		path   string
+		want   bool
+	}{
+		{"no lists", endpointConfig{}, "/env", true},
+		{"denied", endpointConfig{Disable: []string{"/env", "/echo"}}, "/echo", false},
+		{"not denied", endpointConfig{Disable: []string{"/env"}}, "/environment", true},
+		{"denied subpath", endpointConfig{Disable: []string{"/counters"}}, "/counters/hits", false},
+		{"allowed", endpointConfig{Enable: []string{"/container_id", "/livez"}}, "/livez", true},
+		{"not allowed", endpointConfig{Enable: []string{"/container_id", "/livez"}}, "/env", false},
+		{"allowed subpath", endpointConfig{Enable: []string{"/fault/"}}, "/fault/errors", true},
+		{"denied over allowed", endpointConfig{Enable: []string{"/fault"}, Disable: []string{"/fault/errors"}}, "/fault/errors", false},
+		{"root only", endpointConfig{Enable: []string{"/"}}, "/env", false},
+		{"root", endpointConfig{Enable: []string{"/"}}, "/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.enabled(tt.path); got != tt.want {
+				t.Errorf("enabled(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// Test endpointConfig.validate rejects relative paths and other statuses
+func TestEndpointConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config  endpointConfig
+		wantErr bool
+	}{
+		{endpointConfig{Disable: []string{"/env"}, Status: http.StatusNotFound}, false},
+		{endpointConfig{Enable: []string{"/livez"}, Status: http.StatusForbidden}, false},
+		{endpointConfig{Disable: []string{"env"}, Status: http.StatusNotFound}, true},
+		{endpointConfig{Status: http.StatusInternalServerError}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.config.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("validate(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+// Test the middleware answers disabled endpoints with the configured status
+func TestEndpointConfig_Middleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden} {
+		h := endpointConfig{Disable: []string{"/env"}, Status: status}.middleware(next)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/env", nil))
+		if w.Code != status {
+			t.Errorf("GET /env status = %d, want %d", w.Code, status)
+		}
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/container_id", nil))
+		if w.Code != http.StatusTeapot {
+			t.Errorf("GET /container_id status = %d, want it passed through", w.Code)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// defaultEnvRedact matches the names of environment variables whose values
+// /env masks unless -envRedact is set.
+const defaultEnvRedact = `.*TOKEN.*|.*SECRET.*|.*PASSWORD.*|.*KEY.*|.*CREDENTIAL.*`
+
+// envReporter serves GET /env.
+type envReporter struct {
+	redact  *regexp.Regexp
+	environ func() []string
+}
+
+// newEnvReporter returns a reporter that masks variables whose whole name
+// matches pattern, ignoring case.
+func newEnvReporter(pattern string) (*envReporter, error) {
+	redact, err := regexp.Compile(`(?i)^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -envRedact pattern: %w", err)
+	}
+	return &envReporter{redact: redact, environ: os.Environ}, nil
+}
+
+// collect returns the process environment. Values of variables matching the
+// redaction pattern are masked, as are passwords in URL values.
+func (e *envReporter) collect() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range e.environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		switch {
+		case name == "":
+			continue
+		case e.redact.MatchString(name) && value != "":
+			value = redacted
+		default:
+			value = redact(name, value)
+		}
+		env[name] = value
+	}
+	return env
+}
+
+// handleEnv serves GET /env.
+func (e *envReporter) handleEnv(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, e.collect())
+}
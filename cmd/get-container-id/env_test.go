@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test collect masks variables matching the redaction pattern and URL passwords
+func TestEnvReporter_Collect(t *testing.T) {
+	e, err := newEnvReporter(defaultEnvRedact)
+	if err != nil {
+		t.Fatalf("newEnvReporter() error = %v", err)
+	}
+	e.environ = func() []string {
+		return []string{
+			"PORT=8080",
+			"ADMIN_TOKEN=s3cret",
+			"db_password=hunter2",
+			"AWS_SECRET_ACCESS_KEY=abc",
+			"EMPTY_SECRET=",
+			"REDIS_URL=redis://:pass@redis:6379",
+			"EXTRA=a=b",
+			"=C:=C:\\",
+		}
+	}
+
+	got := e.collect()
+	want := map[string]string{
+		"PORT":                  "8080",
+		"ADMIN_TOKEN":           redacted,
+		"db_password":           redacted,
+		"AWS_SECRET_ACCESS_KEY": redacted,
+		"EMPTY_SECRET":          "",
+		"REDIS_URL":             "redis://:xxxxx@redis:6379",
+		"EXTRA":                 "a=b",
+	}
+	if len(got) != len(want) {
+		t.Errorf("collect() = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("collect()[%q] = %q, want %q", name, got[name], value)
+		}
+	}
+}
+
+// Test a custom pattern replaces the default list and must match the whole name
+func TestEnvReporter_CustomPattern(t *testing.T) {
+	e, err := newEnvReporter(`PORT`)
+	if err != nil {
+		t.Fatalf("newEnvReporter() error = %v", err)
+	}
+	e.environ = func() []string { return []string{"PORT=8080", "HTTP_PORT=9090", "ADMIN_TOKEN=s3cret"} }
+
+	w := httptest.NewRecorder()
+	e.handleEnv(w, httptest.NewRequest(http.MethodGet, "/env", nil))
+
+	var resp struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if resp.Data["PORT"] != redacted || resp.Data["HTTP_PORT"] != "9090" || resp.Data["ADMIN_TOKEN"] != "s3cret" {
+		t.Errorf("/env = %v, want only PORT redacted", resp.Data)
+	}
+}
+
+// Test an invalid pattern is rejected
+func TestNewEnvReporter_Invalid(t *testing.T) {
+	if _, err := newEnvReporter(`(`); err == nil {
+		t.Error("newEnvReporter(\"(\") error = nil, want error")
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	faultPathPrefix     = "/fault/"
+	headerFaultInjected = "X-Fault-Injected"
+)
+
+// faultErrorConfig controls probabilistic error injection.
+type faultErrorConfig struct {
+	// Probability is the fraction of requests (0..1) answered with Status.
+	Probability float64 `json:"p"`
+	Status      int     `json:"status"`
+
+	// PathPrefix limits injection to matching request paths; empty matches all.
+	PathPrefix string `json:"path_prefix,omitempty"`
+}
+
+func (c faultErrorConfig) validate() error {
+	if c.Probability < 0 || c.Probability > 1 {
+		return fmt.Errorf("p must be between 0 and 1, got %v", c.Probability)
+	}
+	if c.Status < 400 || c.Status > 599 {
+		return fmt.Errorf("status must be between 400 and 599, got %d", c.Status)
+	}
+	return nil
+}
+
+// faultLatencyConfig controls probabilistic latency injection.
+type faultLatencyConfig struct {
+	// DelayMS is added to a Probability share of requests, plus a uniformly
+	// random JitterMS on top.
+	DelayMS     int64   `json:"delay_ms"`
+	JitterMS    int64   `json:"jitter_ms"`
+	Probability float64 `json:"p"`
+
+	// PathPrefix limits injection to matching request paths; empty matches all.
+	PathPrefix string `json:"path_prefix,omitempty"`
+}
+
+func (c faultLatencyConfig) validate() error {
+	if c.DelayMS < 0 || c.JitterMS < 0 {
+		return fmt.Errorf("delay_ms and jitter_ms must not be negative, got %d and %d", c.DelayMS, c.JitterMS)
+	}
+	if c.Probability < 0 || c.Probability > 1 {
+		return fmt.Errorf("p must be between 0 and 1, got %v", c.Probability)
+	}
+	return nil
+}
+
+// delay returns the latency to inject for a random draw in [0, 1).
+func (c faultLatencyConfig) delay(jitter float64) time.Duration {
+	ms := float64(c.DelayMS) + jitter*float64(c.JitterMS)
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// faultThrottleConfig paces every response to a target bandwidth.
+type faultThrottleConfig struct {
+	// BPS is the bandwidth in bytes per second; zero disables throttling.
+	BPS int64 `json:"bps"`
+}
+
+func (c faultThrottleConfig) validate() error {
+	if c.BPS < 0 {
+		return fmt.Errorf("bps must not be negative, got %d", c.BPS)
+	}
+	return nil
+}
+
+// faultInjector holds the runtime-adjustable fault configuration.
+type faultInjector struct {
+	mu       sync.RWMutex
+	errors   faultErrorConfig
+	latency  faultLatencyConfig
+	throttle faultThrottleConfig
+
+	random func() float64
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func newFaultInjector(errors faultErrorConfig, latency faultLatencyConfig, throttle faultThrottleConfig) *faultInjector {
+	return &faultInjector{errors: errors, latency: latency, throttle: throttle, random: rand.Float64, sleep: sleepContext}
+}
+
+func (f *faultInjector) errorConfig() faultErrorConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.errors
+}
+
+func (f *faultInjector) setErrorConfig(c faultErrorConfig) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.errors = c
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *faultInjector) latencyConfig() faultLatencyConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.latency
+}
+
+func (f *faultInjector) setLatencyConfig(c faultLatencyConfig) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.latency = c
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *faultInjector) throttleConfig() faultThrottleConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.throttle
+}
+
+func (f *faultInjector) setThrottleConfig(c faultThrottleConfig) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.throttle = c
+	f.mu.Unlock()
+	return nil
+}
+
+// middleware delays a random share of requests, answers a random share with
+// the configured error status instead of passing them to next, and paces the
+// remaining responses when a global throttle is set. The /fault/ control endpoints are never
+// affected, so injection can always be switched off again.
+func (f *faultInjector) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, faultPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if l := f.latencyConfig(); l.Probability > 0 &&
+			(l.DelayMS > 0 || l.JitterMS > 0) &&
+			strings.HasPrefix(r.URL.Path, l.PathPrefix) &&
+			f.random() < l.Probability {
+			w.Header().Set(headerFaultInjected, "latency")
+			if err := f.sleep(r.Context(), l.delay(f.random())); err != nil {
+				return
+			}
+		}
+
+		c := f.errorConfig()
+		if c.Probability > 0 &&
+			strings.HasPrefix(r.URL.Path, c.PathPrefix) &&
+			f.random() < c.Probability {
+			w.Header().Set(headerFaultInjected, "error")
+			httpapi.WriteError(w, "injected fault", c.Status)
+			return
+		}
+
+		if bps := f.throttleConfig().BPS; bps > 0 {
+			w = newThrottledWriter(r.Context(), w, bps)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleErrors serves GET (read) and PUT (replace) for the error injection config.
+func (f *faultInjector) handleErrors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		httpapi.WriteSuccess(w, f.errorConfig())
+	case http.MethodPut:
+		c := f.errorConfig()
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&c); err != nil {
+			httpapi.WriteError(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := f.setErrorConfig(c); err != nil {
+			httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httpapi.WriteSuccess(w, c)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		httpapi.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLatency serves GET (read) and PUT (replace) for the latency injection config.
+func (f *faultInjector) handleLatency(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		httpapi.WriteSuccess(w, f.latencyConfig())
+	case http.MethodPut:
+		c := f.latencyConfig()
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&c); err != nil {
+			httpapi.WriteError(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := f.setLatencyConfig(c); err != nil {
+			httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httpapi.WriteSuccess(w, c)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		httpapi.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleThrottle serves GET (read) and PUT (replace) for the global throttle config.
+func (f *faultInjector) handleThrottle(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		httpapi.WriteSuccess(w, f.throttleConfig())
+	case http.MethodPut:
+		c := f.throttleConfig()
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&c); err != nil {
+			httpapi.WriteError(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := f.setThrottleConfig(c); err != nil {
+			httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		httpapi.WriteSuccess(w, c)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		httpapi.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.WriteSuccess(w, "ok")
+	})
+}
+
+// Test faultInjector middleware injects errors based on probability and path prefix
+func TestFaultInjectorMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     faultErrorConfig
+		random     float64
+		path       string
+		wantStatus int
+	}{
+		{name: "disabled", config: faultErrorConfig{Probability: 0, Status: 503}, random: 0, path: "/hello", wantStatus: http.StatusOK},
+		{name: "hit", config: faultErrorConfig{Probability: 0.5, Status: 503}, random: 0.2, path: "/hello", wantStatus: http.StatusServiceUnavailable},
+		{name: "miss", config: faultErrorConfig{Probability: 0.5, Status: 503}, random: 0.7, path: "/hello", wantStatus: http.StatusOK},
+		{name: "custom status", config: faultErrorConfig{Probability: 1, Status: 429}, random: 0.9, path: "/hello", wantStatus: http.StatusTooManyRequests},
+		{name: "prefix match", config: faultErrorConfig{Probability: 1, Status: 500, PathPrefix: "/api"}, random: 0, path: "/api/v1", wantStatus: http.StatusInternalServerError},
+		{name: "prefix mismatch", config: faultErrorConfig{Probability: 1, Status: 500, PathPrefix: "/api"}, random: 0, path: "/hello", wantStatus: http.StatusOK},
+		{name: "control endpoint exempt", config: faultErrorConfig{Probability: 1, Status: 500}, random: 0, path: "/fault/errors", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFaultInjector(tt.config, faultLatencyConfig{}, faultThrottleConfig{})
+			f.random = func() float64 { return tt.random }
+
+			w := httptest.NewRecorder()
+			f.middleware(okHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("middleware status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			injected := w.Header().Get(headerFaultInjected) != ""
+			if injected != (tt.wantStatus != http.StatusOK) {
+				t.Errorf("middleware %s header present = %v", headerFaultInjected, injected)
+			}
+		})
+	}
+}
+
+// Test handleErrors reads and updates the configuration at runtime
+func TestFaultInjectorHandleErrors(t *testing.T) {
+	f := newFaultInjector(faultErrorConfig{Probability: 0, Status: 503}, faultLatencyConfig{}, faultThrottleConfig{})
+
+	w := httptest.NewRecorder()
+	f.handleErrors(w, httptest.NewRequest(http.MethodPut, "/fault/errors", strings.NewReader(`{"p":0.25,"path_prefix":"/api"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d, body %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	want := faultErrorConfig{Probability: 0.25, Status: 503, PathPrefix: "/api"}
+	if got := f.errorConfig(); got != want {
+		t.Errorf("errorConfig() = %+v, want %+v", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	f.handleErrors(w, httptest.NewRequest(http.MethodGet, "/fault/errors", nil))
+
+	var resp struct {
+		Data faultErrorConfig `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("GET body unmarshal error: %v", err)
+	}
+	if resp.Data != want {
+		t.Errorf("GET data = %+v, want %+v", resp.Data, want)
+	}
+}
+
+// Test handleErrors rejects invalid configurations
+func TestFaultInjectorHandleErrors_Invalid(t *testing.T) {
+	bodies := []string{`{"p":1.5}`, `{"status":200}`, `not json`}
+
+	for _, body := range bodies {
+		f := newFaultInjector(faultErrorConfig{Status: 503}, faultLatencyConfig{}, faultThrottleConfig{})
+
+		w := httptest.NewRecorder()
+		f.handleErrors(w, httptest.NewRequest(http.MethodPut, "/fault/errors", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+		if got := f.errorConfig(); got != (faultErrorConfig{Status: 503}) {
+			t.Errorf("PUT %s changed config to %+v", body, got)
+		}
+	}
+}
+
+// Test the fault handlers only allow GET and PUT
+func TestFaultInjectorHandlers_MethodNotAllowed(t *testing.T) {
+	f := newFaultInjector(faultErrorConfig{Status: 503}, faultLatencyConfig{}, faultThrottleConfig{})
+	handlers := map[string]http.HandlerFunc{
+		"/fault/errors":   f.handleErrors,
+		"/fault/latency":  f.handleLatency,
+		"/fault/throttle": f.handleThrottle,
+	}
+
+	for path, h := range handlers {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"p":1}`)))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("POST %s status = %d, want %d", path, w.Code, http.StatusMethodNotAllowed)
+		}
+		if got := w.Header().Get("Allow"); got != "GET, PUT" {
+			t.Errorf("POST %s Allow = %q, want %q", path, got, "GET, PUT")
+		}
+	}
+	if got := f.errorConfig(); got != (faultErrorConfig{Status: 503}) {
+		t.Errorf("POST changed config to %+v", got)
+	}
+}
+
+// Test the middleware delays matching requests by the configured latency
+func TestFaultInjectorMiddleware_Latency(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    faultLatencyConfig
+		path      string
+		wantDelay time.Duration
+	}{
+		{name: "disabled", config: faultLatencyConfig{Probability: 1}, path: "/hello"},
+		{name: "fixed", config: faultLatencyConfig{DelayMS: 200, Probability: 1}, path: "/hello", wantDelay: 200 * time.Millisecond},
+		{name: "jitter", config: faultLatencyConfig{DelayMS: 200, JitterMS: 100, Probability: 1}, path: "/hello", wantDelay: 250 * time.Millisecond},
+		{name: "miss", config: faultLatencyConfig{DelayMS: 200, Probability: 0.25}, path: "/hello"},
+		{name: "prefix mismatch", config: faultLatencyConfig{DelayMS: 200, Probability: 1, PathPrefix: "/api"}, path: "/hello"},
+		{name: "control endpoint exempt", config: faultLatencyConfig{DelayMS: 200, Probability: 1}, path: "/fault/latency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFaultInjector(faultErrorConfig{Status: 503}, tt.config, faultThrottleConfig{})
+			f.random = func() float64 { return 0.5 }
+			var slept time.Duration
+			f.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = d
+				return nil
+			}
+
+			w := httptest.NewRecorder()
+			f.middleware(okHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("middleware status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if slept != tt.wantDelay {
+				t.Errorf("middleware delay = %v, want %v", slept, tt.wantDelay)
+			}
+			if got := w.Header().Get(headerFaultInjected); (got == "latency") != (tt.wantDelay > 0) {
+				t.Errorf("middleware %s = %q with delay %v", headerFaultInjected, got, tt.wantDelay)
+			}
+		})
+	}
+}
+
+// Test handleLatency updates the configuration and rejects invalid ones
+func TestFaultInjectorHandleLatency(t *testing.T) {
+	f := newFaultInjector(faultErrorConfig{Status: 503}, faultLatencyConfig{Probability: 1}, faultThrottleConfig{})
+
+	w := httptest.NewRecorder()
+	f.handleLatency(w, httptest.NewRequest(http.MethodPut, "/fault/latency", strings.NewReader(`{"delay_ms":150,"jitter_ms":50}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d, body %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := faultLatencyConfig{DelayMS: 150, JitterMS: 50, Probability: 1}
+	if got := f.latencyConfig(); got != want {
+		t.Errorf("latencyConfig() = %+v, want %+v", got, want)
+	}
+
+	for _, body := range []string{`{"delay_ms":-1}`, `{"p":2}`, `not json`} {
+		w := httptest.NewRecorder()
+		f.handleLatency(w, httptest.NewRequest(http.MethodPut, "/fault/latency", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+		if got := f.latencyConfig(); got != want {
+			t.Errorf("PUT %s changed config to %+v", body, got)
+		}
+	}
+}
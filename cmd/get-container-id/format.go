@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+	"github.com/ming-go/lab/get-container-id/internal/msgpack"
+	"github.com/ming-go/lab/get-container-id/internal/yaml"
+)
+
+// The response formats of the identity endpoints, as named by ?format=.
+const (
+	formatJSON    = "json"
+	formatYAML    = "yaml"
+	formatText    = "text"
+	formatMsgpack = "msgpack"
+)
+
+// responseFormats lists the formats in the order they are documented.
+var responseFormats = []string{formatJSON, formatYAML, formatText, formatMsgpack}
+
+// formatContentTypes is the Content-Type of each format.
+var formatContentTypes = map[string]string{
+	formatJSON:    contentTypeJSON,
+	formatYAML:    "application/yaml",
+	formatText:    "text/plain; charset=utf-8",
+	formatMsgpack: "application/msgpack",
+}
+
+// formatMediaTypes maps the media types of an Accept header to formats.
+// */* asks for the default, JSON.
+var formatMediaTypes = map[string]string{
+	"*/*":                     formatJSON,
+	"application/*":           formatJSON,
+	"application/json":        formatJSON,
+	"application/yaml":        formatYAML,
+	"application/x-yaml":      formatYAML,
+	"text/yaml":               formatYAML,
+	"text/plain":              formatText,
+	"application/msgpack":     formatMsgpack,
+	"application/x-msgpack":   formatMsgpack,
+	"application/vnd.msgpack": formatMsgpack,
+}
+
+// responseFormat returns the format r asks for. ?format= takes precedence
+// over Accept, whose media types are weighed by their q value, ties going
+// to the first listed. Without a supported media type the answer is JSON.
+func responseFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		format = strings.ToLower(format)
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", fmt.Errorf("unsupported format %q: use %s", format, strings.Join(responseFormats, ", "))
+		}
+		return format, nil
+	}
+
+	format, best := formatJSON, 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, entry := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+			if err != nil {
+				continue
+			}
+			f, ok := formatMediaTypes[mediaType]
+			if !ok {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(s, 64); err != nil {
+					continue
+				}
+			}
+			if q > best {
+				format, best = f, q
+			}
+		}
+	}
+	return format, nil
+}
+
+// bufferedResponse holds back the body and status of a response so it can
+// be re-encoded.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// negotiateFormat re-encodes the JSON responses of next as YAML, plain text
+// or MessagePack when the request asks for it with ?format= or Accept.
+// YAML and MessagePack keep the envelope; text is the bare value, for
+// shell scripts. Responses that are not JSON are passed on unchanged.
+func negotiateFormat(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		format, err := responseFormat(r)
+		if err != nil {
+			httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format == formatJSON {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{ResponseWriter: w}
+		next.ServeHTTP(buf, r)
+		body := buf.body.Bytes()
+		if buf.status == 0 {
+			buf.status = http.StatusOK
+		}
+		if mediaType, _, _ := mime.ParseMediaType(w.Header().Get(headerContentType)); mediaType == contentTypeJSON {
+			if v, err := jsonvalue.Parse(body); err == nil {
+				body = encodeFormat(format, v)
+				w.Header().Set(headerContentType, formatContentTypes[format])
+				w.Header().Del("Content-Length")
+			}
+		}
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	})
+}
+
+// encodeFormat encodes a response envelope in format.
+func encodeFormat(format string, v jsonvalue.Value) []byte {
+	switch format {
+	case formatYAML:
+		return yaml.Marshal(v)
+	case formatMsgpack:
+		return msgpack.Marshal(v)
+	case formatText:
+		if data, ok := v.Get("data"); ok {
+			return appendText(nil, "", data)
+		}
+		if errs, ok := v.Get("errors"); ok {
+			if message, ok := errs.Get("message"); ok {
+				return appendText(nil, "", message)
+			}
+		}
+	}
+	return appendText(nil, "", v)
+}
+
+// appendText writes v as plain text: a scalar as its bare value on a line
+// of its own, an array of scalars one per line, and anything else as one
+// name=value line per scalar, named by its path, e.g. build.version=v1.0.0
+// or addresses.0=10.0.0.1.
+func appendText(b []byte, prefix string, v jsonvalue.Value) []byte {
+	switch v.Kind {
+	case jsonvalue.Object:
+		for _, m := range v.Members {
+			b = appendText(b, textKey(prefix, m.Name), m.Value)
+		}
+		return b
+	case jsonvalue.Array:
+		scalars := prefix == ""
+		for _, item := range v.Items {
+			scalars = scalars && item.Kind != jsonvalue.Object && item.Kind != jsonvalue.Array
+		}
+		for i, item := range v.Items {
+			if scalars {
+				b = appendText(b, "", item)
+				continue
+			}
+			b = appendText(b, textKey(prefix, strconv.Itoa(i)), item)
+		}
+		return b
+	}
+
+	if prefix != "" {
+		b = append(append(b, prefix...), '=')
+	}
+	switch v.Kind {
+	case jsonvalue.Bool:
+		b = strconv.AppendBool(b, v.Bool)
+	case jsonvalue.Number:
+		b = append(b, v.Number...)
+	case jsonvalue.String:
+		b = append(b, v.String...)
+	}
+	return append(b, '\n')
+}
+
+func textKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+)
+
+// Test responseFormat prefers ?format= and weighs Accept by quality
+func TestResponseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: formatJSON},
+		{name: "curl", accept: "*/*", want: formatJSON},
+		{name: "query", query: "?format=TEXT", want: formatText},
+		{name: "query wins", query: "?format=yaml", accept: "application/msgpack", want: formatYAML},
+		{name: "unknown query", query: "?format=xml", wantErr: true},
+		{name: "accept text", accept: "text/plain", want: formatText},
+		{name: "accept msgpack alias", accept: "application/x-msgpack", want: formatMsgpack},
+		{name: "quality", accept: "application/json;q=0.5, application/yaml", want: formatYAML},
+		{name: "tie goes to first", accept: "application/yaml, text/plain", want: formatYAML},
+		{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: formatJSON},
+		{name: "unsupported only", accept: "application/xml", want: formatJSON},
+		{name: "refused", accept: "text/plain;q=0", want: formatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/container_id"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			got, err := responseFormat(req)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("responseFormat() = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test negotiateFormat re-encodes JSON responses in the requested format
+func TestNegotiateFormat(t *testing.T) {
+	value := httpapi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) (any, error) {
+		return "4b8e0f1c", nil
+	})
+	document := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.WriteSuccess(w, map[string]any{"instance_id": "019a", "build": map[string]any{"version": "v1.0.0"}})
+	})
+	failure := httpapi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) (any, error) {
+		return nil, httpapi.NewError(http.StatusNotFound, errors.New("container ID not found"))
+	})
+	plain := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, "text/plain")
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name            string
+		handler         http.Handler
+		query           string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"json unchanged", value, "", http.StatusOK, contentTypeJSON, `{"data":"4b8e0f1c"}`},
+		{"text value", value, "?format=text", http.StatusOK, "text/plain; charset=utf-8", "4b8e0f1c\n"},
+		{"text document", document, "?format=text", http.StatusOK, "text/plain; charset=utf-8", "build.version=v1.0.0\ninstance_id=019a\n"},
+		{"text error", failure, "?format=text", http.StatusNotFound, "text/plain; charset=utf-8", "container ID not found\n"},
+		{"yaml", value, "?format=yaml", http.StatusOK, "application/yaml", "data: \"4b8e0f1c\"\n"},
+		{"yaml error", failure, "?format=yaml", http.StatusNotFound, "application/yaml", "errors:\n  message: container ID not found\n  code: not_found\n"},
+		{"msgpack", value, "?format=msgpack", http.StatusOK, "application/msgpack", "\x81\xa4data\xa84b8e0f1c"},
+		{"not json", plain, "?format=yaml", http.StatusOK, "text/plain", "ok"},
+		{"unknown format", value, "?format=xml", http.StatusBadRequest, contentTypeJSON, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			negotiateFormat(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(headerContentType); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if rec.Header().Get("Vary") != "Accept" {
+				t.Errorf("Vary = %q, want Accept", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+// Test appendText writes arrays of scalars one per line and flattens the rest
+func TestAppendText(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`null`, "\n"},
+		{`42`, "42\n"},
+		{`["10.0.0.1","10.0.0.2"]`, "10.0.0.1\n10.0.0.2\n"},
+		{`{"families":["ipv4","ipv6"],"tls":false}`, "families.0=ipv4\nfamilies.1=ipv6\ntls=false\n"},
+		{`[{"a":1},{"a":2}]`, "0.a=1\n1.a=2\n"},
+	}
+	for _, tt := range tests {
+		v, err := jsonvalue.Parse([]byte(tt.json))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.json, err)
+		}
+		if got := appendText(nil, "", v); !bytes.Equal(got, []byte(tt.want)) {
+			t.Errorf("appendText(%s) = %q, want %q", tt.json, got, tt.want)
+		}
+	}
+}
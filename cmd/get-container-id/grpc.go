@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/internal/protowire"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	contentTypeGRPC = "application/grpc"
+
+	// maxGRPCMessageSize bounds received messages, as maxBodySize does for
+	// HTTP request bodies.
+	maxGRPCMessageSize = maxBodySize
+)
+
+// grpcCode is a gRPC status code.
+type grpcCode int
+
+// The gRPC status codes this server returns.
+const (
+	grpcOK                grpcCode = 0
+	grpcInvalidArgument   grpcCode = 3
+	grpcNotFound          grpcCode = 5
+	grpcResourceExhausted grpcCode = 8
+	grpcUnimplemented     grpcCode = 12
+	grpcInternal          grpcCode = 13
+	grpcUnavailable       grpcCode = 14
+)
+
+// grpcStatus is an error that is reported to the client as a gRPC status.
+// Other errors are reported as INTERNAL.
+type grpcStatus struct {
+	code    grpcCode
+	message string
+}
+
+func (s *grpcStatus) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", s.code, s.message)
+}
+
+func grpcErrorf(code grpcCode, format string, args ...any) error {
+	return &grpcStatus{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// grpcUnary handles one unary RPC: it decodes the request message and
+// returns the encoded response message.
+type grpcUnary func(r *http.Request, req []byte) ([]byte, error)
+
+// grpcServer implements the IdentityService of proto/identity/v1 and server
+// reflection over the net/http HTTP/2 server, using the gRPC wire protocol
+// directly.
+type grpcServer struct {
+	unary   map[string]grpcUnary
+	streams map[string]http.HandlerFunc
+}
+
+func newGRPCServer(meta *metadata) *grpcServer {
+	s := &grpcServer{
+		unary: map[string]grpcUnary{
+			"/" + identityServiceName + "/GetContainerID": grpcGetContainerID,
+			"/" + identityServiceName + "/GetPodID":       grpcGetPodID,
+			"/" + identityServiceName + "/GetMetadata":    meta.grpcGetMetadata,
+			"/" + identityServiceName + "/Echo":           grpcEcho,
+		},
+		streams: map[string]http.HandlerFunc{},
+	}
+	for _, svc := range reflectionServiceNames {
+		s.streams["/"+svc+"/ServerReflectionInfo"] = handleServerReflection
+	}
+	return s
+}
+
+// ServeHTTP dispatches a gRPC call by its path, /<service>/<method>.
+func (s *grpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	ct := r.Header.Get(headerContentType)
+	if ct != contentTypeGRPC && ct != contentTypeGRPC+"+proto" {
+		http.Error(w, "unsupported content type "+strconv.Quote(ct), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	w.Header().Set(headerContentType, contentTypeGRPC)
+	if stream, ok := s.streams[r.URL.Path]; ok {
+		stream(w, r)
+		return
+	}
+
+	unary, ok := s.unary[r.URL.Path]
+	if !ok {
+		writeGRPCStatus(w, grpcErrorf(grpcUnimplemented, "unknown method %s", r.URL.Path))
+		return
+	}
+	req, err := readGRPCMessage(r.Body)
+	if errors.Is(err, io.EOF) {
+		err = grpcErrorf(grpcInvalidArgument, "missing request message")
+	}
+	if err != nil {
+		writeGRPCStatus(w, err)
+		return
+	}
+	resp, err := unary(r, req)
+	if err != nil {
+		writeGRPCStatus(w, err)
+		return
+	}
+	if err := writeGRPCMessage(w, resp); err != nil {
+		return
+	}
+	writeGRPCStatus(w, nil)
+}
+
+// readGRPCMessage reads one length-prefixed message. It returns io.EOF when
+// the client has closed its side of the stream between messages.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, grpcErrorf(grpcInvalidArgument, "read message: %v", err)
+	}
+	if prefix[0] != 0 {
+		return nil, grpcErrorf(grpcUnimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxGRPCMessageSize {
+		return nil, grpcErrorf(grpcResourceExhausted, "message of %d bytes exceeds the limit of %d", size, maxGRPCMessageSize)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, grpcErrorf(grpcInvalidArgument, "read message: %v", err)
+	}
+	return msg, nil
+}
+
+// writeGRPCMessage writes one uncompressed length-prefixed message and
+// flushes it to the client.
+func writeGRPCMessage(w http.ResponseWriter, msg []byte) error {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	if _, err := w.Write(append(frame, msg...)); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// writeGRPCStatus ends the call with the status of err in the trailers.
+func writeGRPCStatus(w http.ResponseWriter, err error) {
+	code, message := grpcOK, ""
+	var st *grpcStatus
+	switch {
+	case errors.As(err, &st):
+		code, message = st.code, st.message
+	case err != nil:
+		code, message = grpcInternal, err.Error()
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(code)))
+	if message != "" {
+		// grpc-message is percent-encoded; url.PathEscape covers the
+		// characters the spec requires to be escaped.
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", url.PathEscape(message))
+	}
+}
+
+func grpcGetContainerID(r *http.Request, _ []byte) ([]byte, error) {
+	id, err := getContainerID(r.Context())
+	if errors.Is(err, containerid.ErrIDUnavailable) {
+		return nil, grpcErrorf(grpcUnavailable, "%v", err)
+	}
+	if errors.Is(err, ErrContainerIDNotFound) {
+		return nil, grpcErrorf(grpcNotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return protowire.AppendString(nil, 1, id), nil
+}
+
+func grpcGetPodID(r *http.Request, _ []byte) ([]byte, error) {
+	id, err := podid.GetContext(r.Context())
+	if errors.Is(err, podid.ErrPodIDNotFound) {
+		return nil, grpcErrorf(grpcNotFound, "%v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return protowire.AppendString(nil, 1, id), nil
+}
+
+// grpcGetMetadata encodes the /metadata document as a Metadata message.
+func (m *metadata) grpcGetMetadata(r *http.Request, _ []byte) ([]byte, error) {
+	return marshalMetadata(m.collect(r.Context())), nil
+}
+
+func marshalMetadata(doc metadataDocument) []byte {
+	var b []byte
+	b = protowire.AppendString(b, 1, doc.ContainerID)
+	b = protowire.AppendString(b, 2, doc.PodID)
+	b = protowire.AppendString(b, 3, doc.Hostname)
+	b = protowire.AppendString(b, 4, doc.InstanceID)
+	b = protowire.AppendString(b, 5, doc.Runtime)
+	b = protowire.AppendUint(b, 6, uint64(doc.CgroupVersion))
+	b = protowire.AppendString(b, 7, doc.Namespace)
+	b = protowire.AppendString(b, 8, doc.NodeName)
+	b = protowire.AppendString(b, 9, doc.StartedAt.Format(time.RFC3339Nano))
+	b = protowire.AppendDouble(b, 10, doc.UptimeSeconds)
+	return b
+}
+
+// grpcEcho returns the request message together with the call metadata.
+func grpcEcho(r *http.Request, req []byte) ([]byte, error) {
+	fields, err := protowire.Fields(req)
+	if err != nil {
+		return nil, grpcErrorf(grpcInvalidArgument, "decode EchoRequest: %v", err)
+	}
+	var message string
+	for _, f := range fields {
+		if f.Num == 1 && f.Type == protowire.BytesType {
+			message = string(f.Bytes)
+		}
+	}
+
+	var b []byte
+	b = protowire.AppendString(b, 1, message)
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var entry []byte
+		entry = protowire.AppendString(entry, 1, strings.ToLower(name))
+		entry = protowire.AppendString(entry, 2, strings.Join(r.Header[name], ", "))
+		b = protowire.AppendMessage(b, 2, entry)
+	}
+	b = protowire.AppendString(b, 3, r.RemoteAddr)
+	b = protowire.AppendString(b, 4, instanceID)
+	b = protowire.AppendString(b, 5, requestID(r.Context()))
+	return b, nil
+}
+
+// grpcProtocols returns the protocols of the gRPC listener: HTTP/2 over TLS
+// when it is configured and h2c otherwise. HTTP/1.1 stays enabled so plain
+// HTTP clients get a readable error instead of a failed handshake.
+func grpcProtocols(useTLS bool) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(useTLS)
+	p.SetUnencryptedHTTP2(!useTLS)
+	return p
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/ming-go/lab/get-container-id/internal/protowire"
+)
+
+const (
+	identityProtoFile    = "identity/v1/identity.proto"
+	identityProtoPackage = "getcontainerid.identity.v1"
+	identityServiceName  = identityProtoPackage + ".IdentityService"
+)
+
+// reflectionServiceNames are the server reflection services; grpcurl and
+// other tools try v1 first and fall back to v1alpha.
+var reflectionServiceNames = []string{
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+// FieldDescriptorProto types and labels used by identity.proto.
+const (
+	protoTypeDouble  = 1
+	protoTypeInt32   = 5
+	protoTypeString  = 9
+	protoTypeMessage = 11
+
+	protoLabelOptional = 1
+	protoLabelRepeated = 3
+)
+
+// protoField and protoMessage describe identity.proto for the descriptor
+// served by reflection.
+type protoField struct {
+	name     string
+	num      int
+	typ      int
+	typeName string
+	repeated bool
+}
+
+type protoMessage struct {
+	name     string
+	fields   []protoField
+	nested   []protoMessage
+	mapEntry bool
+}
+
+type protoMethod struct {
+	name, input, output string
+}
+
+// identityMessages mirrors the messages of proto/identity/v1/identity.proto.
+var identityMessages = []protoMessage{
+	{name: "GetContainerIDRequest"},
+	{name: "GetContainerIDResponse", fields: []protoField{{name: "container_id", num: 1, typ: protoTypeString}}},
+	{name: "GetPodIDRequest"},
+	{name: "GetPodIDResponse", fields: []protoField{{name: "pod_id", num: 1, typ: protoTypeString}}},
+	{name: "GetMetadataRequest"},
+	{name: "Metadata", fields: []protoField{
+		{name: "container_id", num: 1, typ: protoTypeString},
+		{name: "pod_id", num: 2, typ: protoTypeString},
+		{name: "hostname", num: 3, typ: protoTypeString},
+		{name: "instance_id", num: 4, typ: protoTypeString},
+		{name: "runtime", num: 5, typ: protoTypeString},
+		{name: "cgroup_version", num: 6, typ: protoTypeInt32},
+		{name: "namespace", num: 7, typ: protoTypeString},
+		{name: "node_name", num: 8, typ: protoTypeString},
+		{name: "started_at", num: 9, typ: protoTypeString},
+		{name: "uptime_seconds", num: 10, typ: protoTypeDouble},
+	}},
+	{name: "EchoRequest", fields: []protoField{{name: "message", num: 1, typ: protoTypeString}}},
+	{name: "EchoResponse",
+		fields: []protoField{
+			{name: "message", num: 1, typ: protoTypeString},
+			{name: "metadata", num: 2, typ: protoTypeMessage, typeName: "." + identityProtoPackage + ".EchoResponse.MetadataEntry", repeated: true},
+			{name: "peer", num: 3, typ: protoTypeString},
+			{name: "instance_id", num: 4, typ: protoTypeString},
+			{name: "request_id", num: 5, typ: protoTypeString},
+		},
+		nested: []protoMessage{{name: "MetadataEntry", mapEntry: true, fields: []protoField{
+			{name: "key", num: 1, typ: protoTypeString},
+			{name: "value", num: 2, typ: protoTypeString},
+		}}},
+	},
+}
+
+// identityMethods mirrors the IdentityService of identity.proto.
+var identityMethods = []protoMethod{
+	{"GetContainerID", "GetContainerIDRequest", "GetContainerIDResponse"},
+	{"GetPodID", "GetPodIDRequest", "GetPodIDResponse"},
+	{"GetMetadata", "GetMetadataRequest", "Metadata"},
+	{"Echo", "EchoRequest", "EchoResponse"},
+}
+
+// identityFileDescriptor is the serialized FileDescriptorProto of
+// identity.proto.
+var identityFileDescriptor = marshalFileDescriptor()
+
+func marshalFileDescriptor() []byte {
+	var b []byte
+	b = protowire.AppendString(b, 1, identityProtoFile)
+	b = protowire.AppendString(b, 2, identityProtoPackage)
+	for _, m := range identityMessages {
+		b = protowire.AppendMessage(b, 4, marshalMessageDescriptor(m))
+	}
+
+	var svc []byte
+	svc = protowire.AppendString(svc, 1, "IdentityService")
+	for _, m := range identityMethods {
+		var method []byte
+		method = protowire.AppendString(method, 1, m.name)
+		method = protowire.AppendString(method, 2, "."+identityProtoPackage+"."+m.input)
+		method = protowire.AppendString(method, 3, "."+identityProtoPackage+"."+m.output)
+		svc = protowire.AppendMessage(svc, 2, method)
+	}
+	b = protowire.AppendMessage(b, 6, svc)
+
+	// FileOptions.go_package
+	b = protowire.AppendMessage(b, 8, protowire.AppendString(nil, 11, "github.com/ming-go/lab/get-container-id/proto/identity/v1;identityv1"))
+	return protowire.AppendString(b, 12, "proto3")
+}
+
+func marshalMessageDescriptor(m protoMessage) []byte {
+	var b []byte
+	b = protowire.AppendString(b, 1, m.name)
+	for _, f := range m.fields {
+		label := protoLabelOptional
+		if f.repeated {
+			label = protoLabelRepeated
+		}
+		var field []byte
+		field = protowire.AppendString(field, 1, f.name)
+		field = protowire.AppendUint(field, 3, uint64(f.num))
+		field = protowire.AppendUint(field, 4, uint64(label))
+		field = protowire.AppendUint(field, 5, uint64(f.typ))
+		field = protowire.AppendString(field, 6, f.typeName)
+		field = protowire.AppendString(field, 10, jsonName(f.name))
+		b = protowire.AppendMessage(b, 2, field)
+	}
+	for _, nested := range m.nested {
+		b = protowire.AppendMessage(b, 3, marshalMessageDescriptor(nested))
+	}
+	if m.mapEntry {
+		// MessageOptions.map_entry
+		b = protowire.AppendMessage(b, 7, protowire.AppendBool(nil, 7, true))
+	}
+	return b
+}
+
+// jsonName converts a field name to lowerCamelCase as protoc does.
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			r := []rune(parts[i])
+			r[0] = unicode.ToUpper(r[0])
+			parts[i] = string(r)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// identitySymbols are the fully-qualified names defined in identity.proto.
+func identitySymbols() map[string]bool {
+	symbols := map[string]bool{identityServiceName: true}
+	for _, m := range identityMethods {
+		symbols[identityServiceName+"."+m.name] = true
+	}
+	var add func(prefix string, msgs []protoMessage)
+	add = func(prefix string, msgs []protoMessage) {
+		for _, m := range msgs {
+			symbols[prefix+"."+m.name] = true
+			add(prefix+"."+m.name, m.nested)
+		}
+	}
+	add(identityProtoPackage, identityMessages)
+	return symbols
+}
+
+// handleServerReflection serves the ServerReflectionInfo stream. It answers
+// each request as it arrives until the client closes its side.
+func handleServerReflection(w http.ResponseWriter, r *http.Request) {
+	symbols := identitySymbols()
+	for {
+		req, err := readGRPCMessage(r.Body)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			writeGRPCStatus(w, err)
+			return
+		}
+		resp, err := answerReflection(req, symbols)
+		if err == nil {
+			err = writeGRPCMessage(w, resp)
+		}
+		if err != nil {
+			writeGRPCStatus(w, err)
+			return
+		}
+	}
+}
+
+// answerReflection answers one ServerReflectionRequest.
+func answerReflection(req []byte, symbols map[string]bool) ([]byte, error) {
+	fields, err := protowire.Fields(req)
+	if err != nil {
+		return nil, grpcErrorf(grpcInvalidArgument, "decode ServerReflectionRequest: %v", err)
+	}
+
+	var b []byte
+	for _, f := range fields {
+		if f.Num == 1 {
+			b = protowire.AppendString(b, 1, string(f.Bytes))
+		}
+	}
+	b = protowire.AppendMessage(b, 2, req)
+
+	notFound := func(format string, args ...any) []byte {
+		var e []byte
+		e = protowire.AppendUint(e, 1, uint64(grpcNotFound))
+		e = protowire.AppendString(e, 2, fmt.Sprintf(format, args...))
+		return protowire.AppendMessage(b, 7, e)
+	}
+	fileResponse := func() []byte {
+		return protowire.AppendMessage(b, 4, protowire.AppendMessage(nil, 1, identityFileDescriptor))
+	}
+
+	for _, f := range fields {
+		arg := string(f.Bytes)
+		switch f.Num {
+		case 3: // file_by_filename
+			if arg != identityProtoFile {
+				return notFound("file %q not found", arg), nil
+			}
+			return fileResponse(), nil
+		case 4: // file_containing_symbol
+			if !symbols[arg] {
+				return notFound("symbol %q not found", arg), nil
+			}
+			return fileResponse(), nil
+		case 5: // file_containing_extension
+			return notFound("identity.proto defines no extensions"), nil
+		case 6: // all_extension_numbers_of_type
+			if !symbols[arg] {
+				return notFound("type %q not found", arg), nil
+			}
+			return protowire.AppendMessage(b, 5, protowire.AppendString(nil, 1, arg)), nil
+		case 7: // list_services
+			list := protowire.AppendMessage(nil, 1, protowire.AppendString(nil, 1, identityServiceName))
+			return protowire.AppendMessage(b, 6, list), nil
+		}
+	}
+	return nil, grpcErrorf(grpcInvalidArgument, "ServerReflectionRequest has no message_request")
+}
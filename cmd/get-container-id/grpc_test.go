@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/identity"
+	"github.com/ming-go/lab/get-container-id/internal/protowire"
+)
+
+// grpcTestServer serves newGRPCServer over h2c and returns a call function
+// that sends one request message and returns the response messages and the
+// grpc-status and grpc-message trailers.
+func grpcTestServer(t *testing.T) func(path string, msgs ...[]byte) ([][]byte, string, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Protocols: grpcProtocols(false), Handler: newGRPCServer(&metadata{started: time.Now()})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	return func(path string, msgs ...[]byte) ([][]byte, string, string) {
+		t.Helper()
+		var body bytes.Buffer
+		for _, msg := range msgs {
+			var prefix [5]byte
+			binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+			body.Write(prefix[:])
+			body.Write(msg)
+		}
+		req, _ := http.NewRequest(http.MethodPost, "http://"+ln.Addr().String()+path, &body)
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("X-Test", "yes")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s error = %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		var out [][]byte
+		for {
+			msg, err := readGRPCMessage(resp.Body)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read response of %s: %v", path, err)
+			}
+			out = append(out, msg)
+		}
+		message, _ := url.PathUnescape(resp.Trailer.Get("Grpc-Message"))
+		return out, resp.Trailer.Get("Grpc-Status"), message
+	}
+}
+
+// fieldsByNum decodes msg into its last value of each field number.
+func fieldsByNum(t *testing.T, msg []byte) map[int]protowire.Field {
+	t.Helper()
+	fields, err := protowire.Fields(msg)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	m := make(map[int]protowire.Field)
+	for _, f := range fields {
+		m[f.Num] = f
+	}
+	return m
+}
+
+// Test Echo returns the message with the call metadata
+func TestGRPC_Echo(t *testing.T) {
+	origInstance := instanceID
+	defer func() { instanceID = origInstance }()
+	instanceID = "test-instance"
+
+	call := grpcTestServer(t)
+	msgs, status, _ := call("/"+identityServiceName+"/Echo", protowire.AppendString(nil, 1, "ping"))
+	if status != "0" || len(msgs) != 1 {
+		t.Fatalf("Echo status = %q with %d messages, want 0 with 1", status, len(msgs))
+	}
+
+	fields, err := protowire.Fields(msgs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]string{}
+	var message, peer, instance string
+	for _, f := range fields {
+		switch f.Num {
+		case 1:
+			message = string(f.Bytes)
+		case 2:
+			entry := fieldsByNum(t, f.Bytes)
+			metadata[string(entry[1].Bytes)] = string(entry[2].Bytes)
+		case 3:
+			peer = string(f.Bytes)
+		case 4:
+			instance = string(f.Bytes)
+		}
+	}
+	if message != "ping" || instance != "test-instance" || peer == "" {
+		t.Errorf("Echo = message %q, instance %q, peer %q; want ping, test-instance and a peer", message, instance, peer)
+	}
+	if metadata["x-test"] != "yes" || metadata["content-type"] != "application/grpc" {
+		t.Errorf("Echo metadata = %v, want x-test and content-type", metadata)
+	}
+}
+
+// Test GetMetadata encodes the /metadata document
+func TestGRPC_GetMetadata(t *testing.T) {
+	origIdentity, origInstance := identityFunc, instanceID
+	defer func() { identityFunc, instanceID = origIdentity, origInstance }()
+	identityFunc = func(ctx context.Context, opts ...identity.Option) (identity.Identity, error) {
+		return identity.Identity{Node: identity.Field{Value: "node-1"}}, nil
+	}
+	instanceID = "test-instance"
+
+	call := grpcTestServer(t)
+	msgs, status, _ := call("/"+identityServiceName+"/GetMetadata", nil)
+	if status != "0" || len(msgs) != 1 {
+		t.Fatalf("GetMetadata status = %q with %d messages, want 0 with 1", status, len(msgs))
+	}
+	fields := fieldsByNum(t, msgs[0])
+	if got := string(fields[4].Bytes); got != "test-instance" {
+		t.Errorf("instance_id = %q, want %q", got, "test-instance")
+	}
+	if got := string(fields[8].Bytes); got != "node-1" {
+		t.Errorf("node_name = %q, want %q", got, "node-1")
+	}
+	if _, err := time.Parse(time.RFC3339Nano, string(fields[9].Bytes)); err != nil {
+		t.Errorf("started_at = %q, want RFC 3339: %v", fields[9].Bytes, err)
+	}
+}
+
+// Test calls that cannot be served end with a non-OK status
+func TestGRPC_Errors(t *testing.T) {
+	call := grpcTestServer(t)
+
+	if _, status, _ := call("/" + identityServiceName + "/Nope"); status != "12" {
+		t.Errorf("unknown method status = %q, want 12 (UNIMPLEMENTED)", status)
+	}
+	if _, status, _ := call("/" + identityServiceName + "/Echo"); status != "3" {
+		t.Errorf("missing request status = %q, want 3 (INVALID_ARGUMENT)", status)
+	}
+	if _, status, message := call("/"+identityServiceName+"/Echo", []byte{0x0a, 0x05}); status != "3" || message == "" {
+		t.Errorf("malformed request status = %q (%q), want 3 (INVALID_ARGUMENT) with a message", status, message)
+	}
+}
+
+// Test server reflection lists the service and serves its descriptor
+func TestGRPC_Reflection(t *testing.T) {
+	call := grpcTestServer(t)
+
+	listReq := protowire.AppendString(nil, 7, "*")
+	symbolReq := protowire.AppendString(nil, 4, identityServiceName+".Echo")
+	missingReq := protowire.AppendString(nil, 4, "nope.Service")
+
+	for _, svc := range reflectionServiceNames {
+		t.Run(svc, func(t *testing.T) {
+			msgs, status, _ := call("/"+svc+"/ServerReflectionInfo", listReq, symbolReq, missingReq)
+			if status != "0" || len(msgs) != 3 {
+				t.Fatalf("ServerReflectionInfo status = %q with %d messages, want 0 with 3", status, len(msgs))
+			}
+
+			list := fieldsByNum(t, fieldsByNum(t, msgs[0])[6].Bytes)
+			if got := string(fieldsByNum(t, list[1].Bytes)[1].Bytes); got != identityServiceName {
+				t.Errorf("list_services = %q, want %q", got, identityServiceName)
+			}
+
+			files := fieldsByNum(t, fieldsByNum(t, msgs[1])[4].Bytes)
+			file := fieldsByNum(t, files[1].Bytes)
+			if string(file[1].Bytes) != identityProtoFile || string(file[2].Bytes) != identityProtoPackage || string(file[12].Bytes) != "proto3" {
+				t.Errorf("file descriptor = name %q, package %q, syntax %q", file[1].Bytes, file[2].Bytes, file[12].Bytes)
+			}
+
+			errResp := fieldsByNum(t, fieldsByNum(t, msgs[2])[7].Bytes)
+			if errResp[1].Varint != uint64(grpcNotFound) {
+				t.Errorf("missing symbol error_code = %d, want %d", errResp[1].Varint, grpcNotFound)
+			}
+		})
+	}
+}
+
+// Test non-gRPC requests are rejected before dispatch
+func TestGRPC_RejectsPlainHTTP(t *testing.T) {
+	s := newGRPCServer(newMetadata(""))
+	req := httptest.NewRequest(http.MethodPost, "/"+identityServiceName+"/Echo", nil)
+	req.ProtoMajor = 2
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+// Test the descriptor's JSON names follow protoc
+func TestJSONName(t *testing.T) {
+	for name, want := range map[string]string{"container_id": "containerId", "pod_id": "podId", "message": "message", "uptime_seconds": "uptimeSeconds"} {
+		if got := jsonName(name); got != want {
+			t.Errorf("jsonName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
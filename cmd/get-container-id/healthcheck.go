@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// healthcheckCommand is the subcommand name that probes a running server.
+const healthcheckCommand = "healthcheck"
+
+// runHealthcheck probes url and returns the process exit code: 0 when the
+// server answers with a 2xx status, 1 otherwise and 2 for usage errors. It
+// lets images without curl or wget define a HEALTHCHECK with this binary.
+func runHealthcheck(args []string, stderr io.Writer) int {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	fs := flag.NewFlagSet(healthcheckCommand, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	url := fs.String("url", "http://127.0.0.1:"+port+"/livez", "URL to probe")
+	timeout := fs.Duration("timeout", 2*time.Second, "Give up after this long")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if err := probe(*url, *timeout); err != nil {
+		fmt.Fprintf(stderr, "healthcheck: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// probe performs a GET on url and fails unless it returns a 2xx status.
+func probe(url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
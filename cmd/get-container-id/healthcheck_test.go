@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test healthcheck exits 0 for a healthy endpoint and 1 otherwise
+func TestRunHealthcheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/livez" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"healthy", []string{"--url", srv.URL + "/livez"}, 0},
+		{"unhealthy", []string{"-url", srv.URL + "/readyz"}, 1},
+		{"unreachable", []string{"--url", "http://127.0.0.1:1/livez", "--timeout", "500ms"}, 1},
+		{"bad flag", []string{"--nope"}, 2},
+		{"help", []string{"-h"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if got := runHealthcheck(tt.args, &stderr); got != tt.want {
+				t.Errorf("runHealthcheck(%v) = %d, want %d (stderr: %s)", tt.args, got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+// Test healthcheck reports the failing status
+func TestRunHealthcheck_Message(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var stderr bytes.Buffer
+	runHealthcheck([]string{"--url", srv.URL}, &stderr)
+	if !strings.Contains(stderr.String(), "503") {
+		t.Errorf("stderr = %q, want it to mention 503", stderr.String())
+	}
+}
+
+// Test healthcheck gives up after the timeout
+func TestRunHealthcheck_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	var stderr bytes.Buffer
+	if got := runHealthcheck([]string{"--url", srv.URL, "--timeout", "50ms"}, &stderr); got != 1 {
+		t.Errorf("runHealthcheck() = %d, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runHealthcheck() took %v, want about 50ms", elapsed)
+	}
+}
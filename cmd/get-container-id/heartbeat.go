@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// heartbeatStats are the runtime statistics logged with every heartbeat.
+type heartbeatStats struct {
+	Seq            uint64  `json:"seq"`
+	UptimeSeconds  float64 `json:"uptime_seconds"`
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	NumGC          uint32  `json:"num_gc"`
+	InFlight       int64   `json:"in_flight"`
+	Draining       bool    `json:"draining"`
+}
+
+// heartbeat periodically logs the identity document and runtime statistics,
+// so log pipelines can reconstruct replica lifetimes without HTTP traffic.
+type heartbeat struct {
+	interval time.Duration
+	logger   *slog.Logger
+	doc      identityDocument
+	shutdown *shutdownState
+	started  time.Time
+
+	seq uint64
+}
+
+func newHeartbeat(interval time.Duration, logger *slog.Logger, doc identityDocument, shutdown *shutdownState) *heartbeat {
+	return &heartbeat{
+		interval: interval,
+		logger:   logger,
+		doc:      doc,
+		shutdown: shutdown,
+		started:  time.Now(),
+	}
+}
+
+// run logs a heartbeat immediately and then every interval until ctx is done.
+func (h *heartbeat) run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.log()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *heartbeat) log() {
+	h.seq++
+	h.logger.Info("heartbeat", slog.Any("identity", h.doc), slog.Any("stats", h.stats()))
+}
+
+func (h *heartbeat) stats() heartbeatStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return heartbeatStats{
+		Seq:            h.seq,
+		UptimeSeconds:  time.Since(h.started).Seconds(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		NumGC:          mem.NumGC,
+		InFlight:       h.shutdown.inFlight.Load(),
+		Draining:       h.shutdown.draining(),
+	}
+}
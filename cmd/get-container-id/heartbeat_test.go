@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// Test heartbeat logs the identity and stats immediately and on every tick
+func TestHeartbeatRun(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	doc := identityDocument{InstanceID: "instance-1", PodID: "pod-1"}
+	hb := newHeartbeat(10*time.Millisecond, logger, doc, newShutdownState())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hb.run(ctx)
+	}()
+	time.Sleep(55 * time.Millisecond)
+	cancel()
+	<-done
+
+	type record struct {
+		Msg      string           `json:"msg"`
+		Identity identityDocument `json:"identity"`
+		Stats    heartbeatStats   `json:"stats"`
+	}
+	var records []record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+
+	if len(records) < 2 {
+		t.Fatalf("got %d heartbeat records, want at least 2", len(records))
+	}
+	for i, rec := range records {
+		if rec.Msg != "heartbeat" || rec.Identity.InstanceID != "instance-1" || rec.Identity.PodID != "pod-1" {
+			t.Errorf("record %d = %+v, want heartbeat for instance-1", i, rec)
+		}
+		if rec.Stats.Seq != uint64(i+1) {
+			t.Errorf("record %d seq = %d, want %d", i, rec.Stats.Seq, i+1)
+		}
+		if rec.Stats.Goroutines == 0 || rec.Stats.HeapAllocBytes == 0 {
+			t.Errorf("record %d stats = %+v, want runtime stats", i, rec.Stats)
+		}
+	}
+	if last := records[len(records)-1]; last.Stats.UptimeSeconds <= 0 {
+		t.Errorf("last uptime_seconds = %v, want > 0", last.Stats.UptimeSeconds)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+var hookBucketRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// hookConfig limits the memory /hooks uses.
+type hookConfig struct {
+	// MaxBuckets is how many buckets are kept; a new bucket beyond it
+	// evicts the one written to least recently.
+	MaxBuckets int
+	// BucketSize is how many payloads each bucket keeps, the oldest being
+	// dropped first.
+	BucketSize int
+	// MaxBody is the largest payload accepted, in bytes.
+	MaxBody int64
+}
+
+func (c hookConfig) validate() error {
+	var errs []error
+	if c.MaxBuckets <= 0 {
+		errs = append(errs, fmt.Errorf("-hookBuckets must be positive, got %d", c.MaxBuckets))
+	}
+	if c.BucketSize <= 0 {
+		errs = append(errs, fmt.Errorf("-hookBucketSize must be positive, got %d", c.BucketSize))
+	}
+	if c.MaxBody <= 0 {
+		errs = append(errs, fmt.Errorf("-hookMaxBody must be positive, got %d", c.MaxBody))
+	}
+	return errors.Join(errs...)
+}
+
+// hook is a payload received by a bucket.
+type hook struct {
+	ID          uint64      `json:"id"`
+	Time        time.Time   `json:"time"`
+	RequestID   string      `json:"request_id,omitempty"`
+	Query       string      `json:"query,omitempty"`
+	Header      http.Header `json:"header"`
+	RemoteAddr  string      `json:"remote_addr"`
+	ContentType string      `json:"content_type,omitempty"`
+	Body        string      `json:"body"`
+	// BodyEncoding is base64 for payloads that are not valid UTF-8.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	BodySize     int    `json:"body_size"`
+}
+
+// hookBucket holds the latest payloads POSTed to one bucket.
+type hookBucket struct {
+	hooks   []hook
+	lastID  uint64
+	updated time.Time
+}
+
+// hookStore keeps the payloads of every bucket in memory; it is safe for
+// concurrent use.
+type hookStore struct {
+	config hookConfig
+
+	mu      sync.Mutex
+	buckets map[string]*hookBucket
+}
+
+func newHookStore(config hookConfig) *hookStore {
+	return &hookStore{config: config, buckets: make(map[string]*hookBucket)}
+}
+
+// add stores h in the named bucket and returns its ID.
+func (s *hookStore) add(name string, h hook) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[name]
+	if !ok {
+		if len(s.buckets) >= s.config.MaxBuckets {
+			s.evict()
+		}
+		b = &hookBucket{}
+		s.buckets[name] = b
+	}
+	b.lastID++
+	h.ID = b.lastID
+	b.hooks = append(b.hooks, h)
+	if len(b.hooks) > s.config.BucketSize {
+		b.hooks = slices.Delete(b.hooks, 0, len(b.hooks)-s.config.BucketSize)
+	}
+	b.updated = h.Time
+	return h.ID
+}
+
+// evict drops the bucket written to least recently.
+func (s *hookStore) evict() {
+	var oldest string
+	for name, b := range s.buckets {
+		if oldest == "" || b.updated.Before(s.buckets[oldest].updated) {
+			oldest = name
+		}
+	}
+	delete(s.buckets, oldest)
+}
+
+// get returns the payloads of the named bucket with an ID above after,
+// oldest first.
+func (s *hookStore) get(name string, after uint64) []hook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hooks := []hook{}
+	if b, ok := s.buckets[name]; ok {
+		for _, h := range b.hooks {
+			if h.ID > after {
+				hooks = append(hooks, h)
+			}
+		}
+	}
+	return hooks
+}
+
+// remove drops the named bucket and reports whether it existed.
+func (s *hookStore) remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.buckets[name]
+	delete(s.buckets, name)
+	return ok
+}
+
+type hookBucketInfo struct {
+	Name    string    `json:"name"`
+	Count   int       `json:"count"`
+	LastID  uint64    `json:"last_id"`
+	Updated time.Time `json:"updated"`
+}
+
+// list describes every bucket, by name.
+func (s *hookStore) list() []hookBucketInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []hookBucketInfo{}
+	for name, b := range s.buckets {
+		list = append(list, hookBucketInfo{Name: name, Count: len(b.hooks), LastID: b.lastID, Updated: b.updated})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// pathHookBucket validates the {bucket} path value, writing a 400 on
+// failure.
+func pathHookBucket(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("bucket")
+	if !hookBucketRegex.MatchString(name) {
+		httpapi.WriteError(w, "bucket name must match "+hookBucketRegex.String(), http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+// handleReceive serves POST /hooks/{bucket}: it stores the payload with the
+// headers it arrived with. Payloads larger than MaxBody get 413.
+func (s *hookStore) handleReceive(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathHookBucket(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.MaxBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		httpapi.WriteError(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		httpapi.WriteError(w, "failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h := hook{
+		Time:        time.Now().UTC(),
+		RequestID:   requestID(r.Context()),
+		Query:       r.URL.RawQuery,
+		Header:      r.Header,
+		RemoteAddr:  r.RemoteAddr,
+		ContentType: r.Header.Get(headerContentType),
+		Body:        string(body),
+		BodySize:    len(body),
+	}
+	if !utf8.Valid(body) {
+		h.Body, h.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	httpapi.WriteSuccess(w, map[string]any{"bucket": name, "id": s.add(name, h)})
+}
+
+// handleGet serves GET /hooks/{bucket}: the payloads kept, oldest first.
+// ?after= returns only those with a higher ID, for polling. Buckets that
+// received nothing yet are empty rather than missing.
+func (s *hookStore) handleGet(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathHookBucket(w, r)
+	if !ok {
+		return
+	}
+	var after uint64
+	if v := r.URL.Query().Get("after"); v != "" {
+		var err error
+		if after, err = strconv.ParseUint(v, 10, 64); err != nil {
+			httpapi.WriteError(w, "after must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+	httpapi.WriteSuccess(w, map[string]any{"bucket": name, "hooks": s.get(name, after)})
+}
+
+// handleList serves GET /hooks: the buckets and how many payloads each
+// holds.
+func (s *hookStore) handleList(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, s.list())
+}
+
+// handleDelete serves DELETE /hooks/{bucket}: it drops the bucket.
+func (s *hookStore) handleDelete(w http.ResponseWriter, r *http.Request) {
+	name, ok := pathHookBucket(w, r)
+	if !ok {
+		return
+	}
+	if !s.remove(name) {
+		httpapi.WriteError(w, "bucket not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
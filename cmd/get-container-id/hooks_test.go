@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newHooksMux(s *hookStore) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /hooks", s.handleList)
+	mux.HandleFunc("POST /hooks/{bucket}", s.handleReceive)
+	mux.HandleFunc("GET /hooks/{bucket}", s.handleGet)
+	mux.HandleFunc("DELETE /hooks/{bucket}", s.handleDelete)
+	return mux
+}
+
+func postHook(mux http.Handler, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(headerContentType, contentTypeJSON)
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func getHooks(t *testing.T, mux http.Handler, target string) []hook {
+	t.Helper()
+	w := serve(mux, http.MethodGet, target)
+	var resp struct {
+		Data struct {
+			Hooks []hook `json:"hooks"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("GET %s body unmarshal error: %v", target, err)
+	}
+	return resp.Data.Hooks
+}
+
+// Test webhook payloads are stored per bucket and read back oldest first
+func TestHookHandlers(t *testing.T) {
+	mux := newHooksMux(newHookStore(hookConfig{MaxBuckets: 10, BucketSize: 2, MaxBody: 16}))
+
+	if hooks := getHooks(t, mux, "/hooks/ci"); len(hooks) != 0 {
+		t.Errorf("GET empty bucket = %+v, want none", hooks)
+	}
+
+	for _, body := range []string{`{"n":1}`, `{"n":2}`, `{"n":3}`} {
+		if w := postHook(mux, "/hooks/ci?source=test", body); w.Code != http.StatusOK {
+			t.Fatalf("POST status = %d, want 200", w.Code)
+		}
+	}
+	postHook(mux, "/hooks/other", "\xff")
+
+	hooks := getHooks(t, mux, "/hooks/ci")
+	if len(hooks) != 2 || hooks[0].ID != 2 || hooks[0].Body != `{"n":2}` || hooks[1].ID != 3 {
+		t.Fatalf("GET bucket = %+v, want payloads 2 and 3", hooks)
+	}
+	if h := hooks[0]; h.ContentType != contentTypeJSON || h.Query != "source=test" || h.BodySize != 7 {
+		t.Errorf("payload = %+v", h)
+	}
+	if hooks := getHooks(t, mux, "/hooks/ci?after=2"); len(hooks) != 1 || hooks[0].ID != 3 {
+		t.Errorf("GET ?after=2 = %+v, want payload 3", hooks)
+	}
+	if hooks := getHooks(t, mux, "/hooks/other"); len(hooks) != 1 || hooks[0].BodyEncoding != "base64" || hooks[0].Body != "/w==" {
+		t.Errorf("binary payload = %+v, want base64", hooks)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"too large", http.MethodPost, "/hooks/ci", strings.Repeat("x", 17), http.StatusRequestEntityTooLarge},
+		{"bad bucket", http.MethodPost, "/hooks/a%20b", "{}", http.StatusBadRequest},
+		{"bad after", http.MethodGet, "/hooks/ci?after=-1", "", http.StatusBadRequest},
+		{"delete", http.MethodDelete, "/hooks/ci", "", http.StatusNoContent},
+		{"delete again", http.MethodDelete, "/hooks/ci", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// Test the bucket written to least recently is evicted beyond MaxBuckets
+func TestHookStoreEviction(t *testing.T) {
+	s := newHookStore(hookConfig{MaxBuckets: 2, BucketSize: 5, MaxBody: 16})
+	start := time.Now()
+	s.add("a", hook{Time: start})
+	s.add("b", hook{Time: start.Add(time.Second)})
+	s.add("a", hook{Time: start.Add(2 * time.Second)})
+	s.add("c", hook{Time: start.Add(3 * time.Second)})
+
+	var names []string
+	for _, b := range s.list() {
+		names = append(names, b.Name)
+	}
+	if strings.Join(names, ",") != "a,c" {
+		t.Errorf("buckets = %v, want a,c", names)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// maxDelay bounds /delay/{seconds}, as httpbin does.
+const maxDelay = 10 * time.Second
+
+// handleDelay serves /delay/{seconds}: it waits for the given, possibly
+// fractional, number of seconds before answering. The write deadline is
+// extended so delays up to maxDelay are not cut off by the server's
+// WriteTimeout.
+func handleDelay(w http.ResponseWriter, r *http.Request) {
+	seconds, err := strconv.ParseFloat(r.PathValue("seconds"), 64)
+	if err != nil || seconds < 0 {
+		httpapi.WriteError(w, fmt.Sprintf("invalid delay %q: must be a non-negative number of seconds", r.PathValue("seconds")), http.StatusBadRequest)
+		return
+	}
+	delay := min(time.Duration(seconds*float64(time.Second)), maxDelay)
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(delay + 5*time.Second))
+
+	start := time.Now()
+	if err := sleepContext(r.Context(), delay); err != nil {
+		return
+	}
+
+	httpapi.WriteSuccess(w, map[string]any{
+		"delay_seconds":   delay.Seconds(),
+		"elapsed_seconds": time.Since(start).Seconds(),
+		"instance_id":     instanceID,
+	})
+}
+
+// statusChoice is one status code of /status/{codes} with its weight.
+type statusChoice struct {
+	code   int
+	weight float64
+}
+
+// parseStatusChoices parses a comma-separated list of status codes, each
+// optionally weighted as code:weight. Unweighted codes have weight 1.
+func parseStatusChoices(s string) ([]statusChoice, error) {
+	var choices []statusChoice
+	for _, item := range strings.Split(s, ",") {
+		codeStr, weightStr, weighted := strings.Cut(strings.TrimSpace(item), ":")
+		code, err := strconv.Atoi(codeStr)
+		// 1xx codes are informational and cannot end a response.
+		if err != nil || code < 200 || code > 599 {
+			return nil, fmt.Errorf("invalid status code %q: must be between 200 and 599", codeStr)
+		}
+		weight := 1.0
+		if weighted {
+			if weight, err = strconv.ParseFloat(weightStr, 64); err != nil || weight < 0 {
+				return nil, fmt.Errorf("invalid weight %q for status %d: must be a non-negative number", weightStr, code)
+			}
+		}
+		choices = append(choices, statusChoice{code: code, weight: weight})
+	}
+
+	var total float64
+	for _, c := range choices {
+		total += c.weight
+	}
+	if total == 0 {
+		return nil, errors.New("status weights must not all be zero")
+	}
+	return choices, nil
+}
+
+// pickStatus returns a status code from choices, chosen with probability
+// proportional to its weight for a random draw in [0, 1).
+func pickStatus(choices []statusChoice, random float64) int {
+	var total float64
+	for _, c := range choices {
+		total += c.weight
+	}
+	target := random * total
+	for _, c := range choices {
+		if target < c.weight {
+			return c.code
+		}
+		target -= c.weight
+	}
+	return choices[len(choices)-1].code
+}
+
+// statusRandom draws the /status choice; tests replace it.
+var statusRandom = rand.Float64
+
+// handleStatus serves /status/{codes}: it answers with the given status code,
+// or one picked at random from a weighted list such as 200:0.9,503:0.1, so
+// clients can test their retry and circuit breaking behaviour.
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	choices, err := parseStatusChoices(r.PathValue("codes"))
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code := pickStatus(choices, statusRandom())
+	switch {
+	case code == http.StatusNoContent, code == http.StatusNotModified:
+		// These responses must not have a body.
+		w.WriteHeader(code)
+	case code >= 300 && code < 400:
+		w.Header().Set("Location", "/")
+		httpapi.WriteJSON(w, httpapi.Response{Data: map[string]any{"status": code}}, code)
+	case code >= 400:
+		httpapi.WriteError(w, http.StatusText(code), code)
+	default:
+		httpapi.WriteJSON(w, httpapi.Response{Data: map[string]any{"status": code}}, code)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test /delay/{seconds} waits before answering and rejects invalid delays
+func TestHandleDelay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/delay/{seconds}", handleDelay)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/delay/0.05", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/delay/0.05 status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("/delay/0.05 answered after %v, want at least 50ms", elapsed)
+	}
+
+	for _, path := range []string{"/delay/abc", "/delay/-1"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %d, want %d", path, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// Test /delay/{seconds} stops waiting when the client goes away
+func TestHandleDelay_Canceled(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/delay/{seconds}", handleDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/delay/10", nil).WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("/delay/10 returned after %v, want it to stop with the request context", elapsed)
+	}
+}
+
+// Test /status/{codes} answers with the requested or a weighted random status
+func TestHandleStatus(t *testing.T) {
+	orig := statusRandom
+	defer func() { statusRandom = orig }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status/{codes}", handleStatus)
+
+	tests := []struct {
+		path       string
+		random     float64
+		wantStatus int
+	}{
+		{"/status/418", 0, http.StatusTeapot},
+		{"/status/204", 0, http.StatusNoContent},
+		{"/status/302", 0, http.StatusFound},
+		{"/status/200,503", 0.4, http.StatusOK},
+		{"/status/200,503", 0.6, http.StatusServiceUnavailable},
+		{"/status/200:0.9,503:0.1", 0.85, http.StatusOK},
+		{"/status/200:0.9,503:0.1", 0.95, http.StatusServiceUnavailable},
+		{"/status/200:0,503", 0, http.StatusServiceUnavailable},
+		{"/status/abc", 0, http.StatusBadRequest},
+		{"/status/100", 0, http.StatusBadRequest},
+		{"/status/200:0", 0, http.StatusBadRequest},
+		{"/status/200:-1", 0, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		statusRandom = func() float64 { return tt.random }
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s (random %v) status = %d, want %d", tt.path, tt.random, w.Code, tt.wantStatus)
+		}
+		if w.Code == http.StatusNoContent && w.Body.Len() != 0 {
+			t.Errorf("%s wrote a body to a 204 response", tt.path)
+		}
+		if w.Code == http.StatusFound && w.Header().Get("Location") == "" {
+			t.Errorf("%s redirect has no Location", tt.path)
+		}
+	}
+}
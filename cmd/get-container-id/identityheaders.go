@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// The identity headers stamped on every response.
+const (
+	headerContainerID = "X-Container-ID"
+	headerPodID       = "X-Pod-ID"
+	headerInstanceID  = "X-Instance-ID"
+)
+
+// defaultIdentityHeaders is the default -identityHeaders.
+const defaultIdentityHeaders = headerContainerID + "," + headerPodID + "," + headerInstanceID
+
+var (
+	// stampContainerID and stampPodID look up the stamped IDs; tests replace
+	// them.
+	stampContainerID = getContainerID
+	stampPodID       = func(ctx context.Context) (string, error) { return podid.GetContext(ctx) }
+)
+
+// identityHeaders selects the identity headers stamped on responses, so a
+// client behind a load balancer can tell which replica served it without
+// parsing the body.
+type identityHeaders struct {
+	ContainerID bool
+	PodID       bool
+	InstanceID  bool
+}
+
+// parseIdentityHeaders parses a comma-separated list of X-Container-ID,
+// X-Pod-ID and X-Instance-ID, in any case. An empty list stamps none.
+func parseIdentityHeaders(s string) (identityHeaders, error) {
+	var h identityHeaders
+	for _, name := range splitList(s) {
+		switch http.CanonicalHeaderKey(name) {
+		case http.CanonicalHeaderKey(headerContainerID):
+			h.ContainerID = true
+		case http.CanonicalHeaderKey(headerPodID):
+			h.PodID = true
+		case http.CanonicalHeaderKey(headerInstanceID):
+			h.InstanceID = true
+		default:
+			return identityHeaders{}, fmt.Errorf("unknown identity header %q: must be one of %s", name, strings.ReplaceAll(defaultIdentityHeaders, ",", ", "))
+		}
+	}
+	return h, nil
+}
+
+// middleware sets the selected headers before the handler runs, so they
+// are present on every response, errors included. IDs that cannot be
+// detected are left out.
+func (h identityHeaders) middleware(next http.Handler) http.Handler {
+	if h == (identityHeaders{}) {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		if h.ContainerID {
+			if id, err := stampContainerID(r.Context()); err == nil && id != "" {
+				header.Set(headerContainerID, id)
+			}
+		}
+		if h.PodID {
+			if id, err := stampPodID(r.Context()); err == nil && id != "" {
+				header.Set(headerPodID, id)
+			}
+		}
+		if h.InstanceID && instanceID != "" {
+			header.Set(headerInstanceID, instanceID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
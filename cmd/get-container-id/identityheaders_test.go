@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test parseIdentityHeaders accepts the known headers in any case
+func TestParseIdentityHeaders(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    identityHeaders
+		wantErr bool
+	}{
+		{in: defaultIdentityHeaders, want: identityHeaders{ContainerID: true, PodID: true, InstanceID: true}},
+		{in: "x-instance-id", want: identityHeaders{InstanceID: true}},
+		{in: " X-Pod-ID , ", want: identityHeaders{PodID: true}},
+		{in: "", want: identityHeaders{}},
+		{in: "X-Node-Name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseIdentityHeaders(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseIdentityHeaders(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test the middleware stamps the selected identity headers on every response
+func TestIdentityHeadersMiddleware(t *testing.T) {
+	origContainer, origPod, origInstance := stampContainerID, stampPodID, instanceID
+	defer func() { stampContainerID, stampPodID, instanceID = origContainer, origPod, origInstance }()
+	stampContainerID = func(context.Context) (string, error) { return "abc123", nil }
+	stampPodID = func(context.Context) (string, error) { return "", errors.New("not in a pod") }
+	instanceID = "test-instance"
+
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+
+	tests := []struct {
+		name string
+		h    identityHeaders
+		want map[string]string
+	}{
+		{
+			name: "all",
+			h:    identityHeaders{ContainerID: true, PodID: true, InstanceID: true},
+			want: map[string]string{headerContainerID: "abc123", headerPodID: "", headerInstanceID: "test-instance"},
+		},
+		{
+			name: "instance only",
+			h:    identityHeaders{InstanceID: true},
+			want: map[string]string{headerContainerID: "", headerPodID: "", headerInstanceID: "test-instance"},
+		},
+		{
+			name: "none",
+			want: map[string]string{headerContainerID: "", headerPodID: "", headerInstanceID: ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.h.middleware(notFound).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			for name, want := range tt.want {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
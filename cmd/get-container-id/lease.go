@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// leaseRenewFraction is how many renewals happen per lease TTL, so a replica
+// survives a couple of failed renewals before its entry expires.
+const leaseRenewFraction = 3
+
+// ErrLeaseTTLTooShort is returned for a -leaseTTL under one second.
+var ErrLeaseTTLTooShort = errors.New("lease TTL must be at least 1s")
+
+// leaseHealth is the health reported with every renewal.
+type leaseHealth struct {
+	Ready bool   `json:"ready"`
+	State string `json:"state"`
+}
+
+// leaseDocument is the body PUT to the registry on every renewal.
+type leaseDocument struct {
+	Key        string           `json:"key"`
+	Identity   identityDocument `json:"identity"`
+	Health     leaseHealth      `json:"health"`
+	TTLSeconds int              `json:"ttl_seconds"`
+	RenewedAt  string           `json:"renewed_at"`
+	ExpiresAt  string           `json:"expires_at"`
+}
+
+// leaseKey identifies a replica in the registry: its container ID, else its
+// pod ID, else its instance ID.
+func leaseKey(doc identityDocument) string {
+	switch {
+	case doc.ContainerID != "":
+		return doc.ContainerID
+	case doc.PodID != "":
+		return doc.PodID
+	default:
+		return doc.InstanceID
+	}
+}
+
+// leaser keeps this replica's entry in a registry alive by PUTting it to
+// <registryURL>/<key> before its TTL runs out, and DELETEs it on shutdown.
+// Entries of replicas that die without deregistering expire on their own.
+type leaser struct {
+	url     string
+	ttl     time.Duration
+	client  *http.Client
+	logger  *slog.Logger
+	doc     identityDocument
+	startup *startupGate
+
+	// backoff is the delay before the first retry; it doubles on each attempt.
+	backoff time.Duration
+}
+
+func newLeaser(registryURL string, ttl time.Duration, logger *slog.Logger, doc identityDocument, startup *startupGate) *leaser {
+	return &leaser{
+		url:     strings.TrimSuffix(registryURL, "/") + "/" + url.PathEscape(leaseKey(doc)),
+		ttl:     ttl,
+		client:  &http.Client{Timeout: registerRequestTimeout},
+		logger:  logger,
+		doc:     doc,
+		startup: startup,
+		backoff: registerInitialBackoff,
+	}
+}
+
+// run renews the lease immediately and then every ttl/leaseRenewFraction
+// until ctx is done.
+func (l *leaser) run(ctx context.Context) {
+	ticker := time.NewTicker(l.ttl / leaseRenewFraction)
+	defer ticker.Stop()
+
+	for {
+		if err := l.renew(ctx); err != nil && ctx.Err() == nil {
+			l.logger.Error("lease renewal failed", slog.String("url", l.url), slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renew PUTs the current lease document.
+func (l *leaser) renew(ctx context.Context) error {
+	now := time.Now()
+	health := leaseHealth{Ready: l.startup.remaining() == 0, State: "ready"}
+	if !health.Ready {
+		health.State = "starting"
+	}
+
+	body, err := json.Marshal(leaseDocument{
+		Key:        leaseKey(l.doc),
+		Identity:   l.doc,
+		Health:     health,
+		TTLSeconds: int(l.ttl.Seconds()),
+		RenewedAt:  now.Format(time.RFC3339Nano),
+		ExpiresAt:  now.Add(l.ttl).Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = sendWithRetry(ctx, l.client, http.MethodPut, l.url, body, l.backoff, func(attempt int, err error) {
+		l.logger.Warn("lease renewal attempt failed", slog.String("url", l.url), slog.Int("attempt", attempt), slog.Any("error", err))
+	})
+	return err
+}
+
+// release DELETEs the lease so the replica leaves the inventory immediately.
+func (l *leaser) release(ctx context.Context) error {
+	attempt, err := sendWithRetry(ctx, l.client, http.MethodDelete, l.url, nil, l.backoff, func(attempt int, err error) {
+		l.logger.Warn("lease release attempt failed", slog.String("url", l.url), slog.Int("attempt", attempt), slog.Any("error", err))
+	})
+	if err != nil {
+		return err
+	}
+	l.logger.Info("lease released", slog.String("url", l.url), slog.Int("attempt", attempt))
+	return nil
+}
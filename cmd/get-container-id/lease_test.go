@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test leaseKey prefers the container ID, then the pod ID
+func TestLeaseKey(t *testing.T) {
+	tests := []struct {
+		doc  identityDocument
+		want string
+	}{
+		{identityDocument{InstanceID: "i", PodID: "p", ContainerID: "c"}, "c"},
+		{identityDocument{InstanceID: "i", PodID: "p"}, "p"},
+		{identityDocument{InstanceID: "i"}, "i"},
+	}
+	for _, tt := range tests {
+		if got := leaseKey(tt.doc); got != tt.want {
+			t.Errorf("leaseKey(%+v) = %q, want %q", tt.doc, got, tt.want)
+		}
+	}
+}
+
+// Test leaser renews with PUT and releases with DELETE on the keyed URL
+func TestLeaserRunAndRelease(t *testing.T) {
+	type call struct {
+		method, path string
+		doc          leaseDocument
+	}
+	var mu sync.Mutex
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := call{method: r.Method, path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&c.doc)
+		mu.Lock()
+		calls = append(calls, c)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	doc := identityDocument{InstanceID: "instance-1", PodID: "pod-1"}
+	l := newLeaser(srv.URL+"/replicas/", 30*time.Millisecond, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)), doc, newStartupGate(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.run(ctx)
+	}()
+	time.Sleep(45 * time.Millisecond)
+	cancel()
+	<-done
+
+	if err := l.release(context.Background()); err != nil {
+		t.Fatalf("release() returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) < 3 {
+		t.Fatalf("registry received %d calls, want at least 2 renewals and a release", len(calls))
+	}
+	for _, c := range calls[:len(calls)-1] {
+		if c.method != http.MethodPut || c.path != "/replicas/pod-1" {
+			t.Errorf("renewal = %s %s, want PUT /replicas/pod-1", c.method, c.path)
+		}
+		if c.doc.Key != "pod-1" || c.doc.Identity.InstanceID != "instance-1" || c.doc.TTLSeconds != 0 {
+			t.Errorf("renewal document = %+v", c.doc)
+		}
+		if c.doc.Health.Ready || c.doc.Health.State != "starting" {
+			t.Errorf("health = %+v, want starting during the startup delay", c.doc.Health)
+		}
+		renewed, _ := time.Parse(time.RFC3339Nano, c.doc.RenewedAt)
+		expires, _ := time.Parse(time.RFC3339Nano, c.doc.ExpiresAt)
+		if expires.Sub(renewed) != 30*time.Millisecond {
+			t.Errorf("expires_at %s is not one TTL after renewed_at %s", c.doc.ExpiresAt, c.doc.RenewedAt)
+		}
+	}
+	if last := calls[len(calls)-1]; last.method != http.MethodDelete || last.path != "/replicas/pod-1" {
+		t.Errorf("release = %s %s, want DELETE /replicas/pod-1", last.method, last.path)
+	}
+}
+
+// Test leaser reports a ready replica once the startup delay has passed
+func TestLeaserRenew_Ready(t *testing.T) {
+	var got leaseDocument
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	l := newLeaser(srv.URL, time.Minute, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)), identityDocument{InstanceID: "i"}, newStartupGate(0))
+	if err := l.renew(context.Background()); err != nil {
+		t.Fatalf("renew() returned error: %v", err)
+	}
+	if !got.Health.Ready || got.Health.State != "ready" || got.TTLSeconds != 60 {
+		t.Errorf("lease document = %+v, want ready with a 60s TTL", got)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// limitsDocument is the effective resource limits of the container. Limits
+// that are not set are omitted.
+type limitsDocument struct {
+	CgroupVersion    int   `json:"cgroup_version"`
+	MemoryLimitBytes int64 `json:"memory_limit_bytes,omitempty"`
+	CPUQuotaMicros   int64 `json:"cpu_quota_us,omitempty"`
+	CPUPeriodMicros  int64 `json:"cpu_period_us,omitempty"`
+	// CPULimit is the quota divided by the period.
+	CPULimit float64 `json:"cpu_limit,omitempty"`
+	// EffectiveCPUs is CPULimit capped at NumCPU, or NumCPU without a limit.
+	EffectiveCPUs float64 `json:"effective_cpus"`
+	NumCPU        int     `json:"num_cpu"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+}
+
+// readLimits reads the limits from the process's own cgroup directories.
+// Without readable cgroups no limits are reported.
+func readLimits() limitsDocument {
+	info, _ := inspectCgroups()
+	doc := limitsDocument{
+		CgroupVersion:    info.Version,
+		MemoryLimitBytes: info.Limits.MemoryBytes,
+		CPUQuotaMicros:   info.Limits.CPUQuotaMicros,
+		CPUPeriodMicros:  info.Limits.CPUPeriodMicros,
+		CPULimit:         info.Limits.CPUs(),
+		EffectiveCPUs:    float64(runtime.NumCPU()),
+		NumCPU:           runtime.NumCPU(),
+		GOMAXPROCS:       runtime.GOMAXPROCS(0),
+	}
+	if doc.CPULimit > 0 {
+		doc.EffectiveCPUs = min(doc.CPULimit, doc.EffectiveCPUs)
+	}
+	return doc
+}
+
+// handleLimits serves GET /limits.
+func handleLimits(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, readLimits())
+}
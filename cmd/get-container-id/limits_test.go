@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+)
+
+// Test /limits reports the cgroup limits alongside GOMAXPROCS
+func TestHandleLimits(t *testing.T) {
+	stubCgroups(t)
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) {
+		return cgroup.Info{Version: 2, Limits: cgroup.Limits{MemoryBytes: 268435456, CPUQuotaMicros: 50000, CPUPeriodMicros: 100000}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	handleLimits(w, httptest.NewRequest(http.MethodGet, "/limits", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleLimits() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data limitsDocument `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := limitsDocument{
+		CgroupVersion:    2,
+		MemoryLimitBytes: 268435456,
+		CPUQuotaMicros:   50000,
+		CPUPeriodMicros:  100000,
+		CPULimit:         0.5,
+		EffectiveCPUs:    min(0.5, float64(runtime.NumCPU())),
+		NumCPU:           runtime.NumCPU(),
+		GOMAXPROCS:       runtime.GOMAXPROCS(0),
+	}
+	if resp.Data != want {
+		t.Errorf("limits = %+v, want %+v", resp.Data, want)
+	}
+}
+
+// Test readLimits reports no limits when the cgroups are unreadable
+func TestReadLimits_Unreadable(t *testing.T) {
+	stubCgroups(t)
+
+	doc := readLimits()
+	if doc.CgroupVersion != 0 || doc.MemoryLimitBytes != 0 || doc.CPULimit != 0 {
+		t.Errorf("readLimits() = %+v, want no version or limits", doc)
+	}
+	if doc.EffectiveCPUs != float64(runtime.NumCPU()) {
+		t.Errorf("readLimits() effective CPUs = %v, want NumCPU %d", doc.EffectiveCPUs, runtime.NumCPU())
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	ipFamilyDual = "dual"
+	ipFamilyIPv4 = "ipv4"
+	ipFamilyIPv6 = "ipv6"
+)
+
+// listenInfo describes the address the HTTP server is bound to.
+type listenInfo struct {
+	Network  string   `json:"network"`
+	Address  string   `json:"address"`
+	Families []string `json:"families"`
+
+	ProxyProtocol bool `json:"proxy_protocol"`
+	TLS           bool `json:"tls"`
+	MutualTLS     bool `json:"mtls"`
+
+	Protocols []string `json:"protocols"`
+
+	GRPCAddress string `json:"grpc_address,omitempty"`
+}
+
+// listenNetwork maps an -ipFamily value to the network name passed to net.Listen.
+func listenNetwork(family string) (string, error) {
+	switch strings.ToLower(family) {
+	case "", ipFamilyDual:
+		return "tcp", nil
+	case ipFamilyIPv4, "v4", "4":
+		return "tcp4", nil
+	case ipFamilyIPv6, "v6", "6":
+		return "tcp6", nil
+	default:
+		return "", fmt.Errorf("invalid ip family %q (want %s, %s or %s)", family, ipFamilyDual, ipFamilyIPv4, ipFamilyIPv6)
+	}
+}
+
+// listenAddress joins a bind address and port. The bind address may be
+// empty, a bare IP, a bracketed IPv6 address such as "[::]", or a hostname.
+func listenAddress(bindAddr, port string) string {
+	host := strings.TrimSuffix(strings.TrimPrefix(bindAddr, "["), "]")
+	return net.JoinHostPort(host, port)
+}
+
+// listenFamilies reports which IP families a listener created on network
+// accepts connections from.
+func listenFamilies(network string, addr net.Addr) []string {
+	switch network {
+	case "tcp4":
+		return []string{ipFamilyIPv4}
+	case "tcp6":
+		return []string{ipFamilyIPv6}
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || tcpAddr.IP == nil {
+		return []string{ipFamilyIPv4, ipFamilyIPv6}
+	}
+
+	if tcpAddr.IP.To4() != nil {
+		return []string{ipFamilyIPv4}
+	}
+
+	// Wildcard binds on "tcp" use a dual-stack socket, which reports as "[::]".
+	if tcpAddr.IP.IsUnspecified() {
+		return []string{ipFamilyIPv4, ipFamilyIPv6}
+	}
+	return []string{ipFamilyIPv6}
+}
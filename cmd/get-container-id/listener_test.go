@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// Test listenNetwork maps ip families to net.Listen networks
+func TestListenNetwork(t *testing.T) {
+	tests := []struct {
+		family  string
+		want    string
+		wantErr bool
+	}{
+		{family: "", want: "tcp"},
+		{family: "dual", want: "tcp"},
+		{family: "ipv4", want: "tcp4"},
+		{family: "IPv6", want: "tcp6"},
+		{family: "v6", want: "tcp6"},
+		{family: "ipx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := listenNetwork(tt.family)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("listenNetwork(%q) expected error, got nil", tt.family)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("listenNetwork(%q) returned error: %v", tt.family, err)
+		}
+		if got != tt.want {
+			t.Errorf("listenNetwork(%q) = %q, want %q", tt.family, got, tt.want)
+		}
+	}
+}
+
+// Test listenAddress handles bracketed and bare addresses
+func TestListenAddress(t *testing.T) {
+	tests := []struct {
+		bindAddr string
+		want     string
+	}{
+		{bindAddr: "", want: ":8080"},
+		{bindAddr: "0.0.0.0", want: "0.0.0.0:8080"},
+		{bindAddr: "[::]", want: "[::]:8080"},
+		{bindAddr: "::1", want: "[::1]:8080"},
+		{bindAddr: "fd00::10", want: "[fd00::10]:8080"},
+	}
+
+	for _, tt := range tests {
+		if got := listenAddress(tt.bindAddr, "8080"); got != tt.want {
+			t.Errorf("listenAddress(%q) = %q, want %q", tt.bindAddr, got, tt.want)
+		}
+	}
+}
+
+// Test listenFamilies reports the families accepted by a listener
+func TestListenFamilies(t *testing.T) {
+	dual := []string{"ipv4", "ipv6"}
+
+	tests := []struct {
+		name    string
+		network string
+		addr    net.Addr
+		want    []string
+	}{
+		{name: "tcp4", network: "tcp4", addr: &net.TCPAddr{IP: net.IPv4zero}, want: []string{"ipv4"}},
+		{name: "tcp6", network: "tcp6", addr: &net.TCPAddr{IP: net.IPv6unspecified}, want: []string{"ipv6"}},
+		{name: "dual-stack wildcard", network: "tcp", addr: &net.TCPAddr{IP: net.IPv6unspecified}, want: dual},
+		{name: "ipv4 wildcard", network: "tcp", addr: &net.TCPAddr{IP: net.IPv4zero}, want: []string{"ipv4"}},
+		{name: "ipv4 address", network: "tcp", addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1")}, want: []string{"ipv4"}},
+		{name: "ipv6 address", network: "tcp", addr: &net.TCPAddr{IP: net.ParseIP("fd00::1")}, want: []string{"ipv6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listenFamilies(tt.network, tt.addr)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listenFamilies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
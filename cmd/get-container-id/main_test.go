@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// Test initInstanceID with environment variable
+func TestInitInstanceID_WithEnvVar(t *testing.T) {
+	// Save original value and restore after test
+	originalID := instanceID
+	originalEnv := os.Getenv("INSTANCE_ID")
+	defer func() {
+		instanceID = originalID
+		if originalEnv != "" {
+			os.Setenv("INSTANCE_ID", originalEnv)
+		} else {
+			os.Unsetenv("INSTANCE_ID")
+		}
+	}()
+
+	// Set environment variable
+	testID := "test-instance-12345"
+	os.Setenv("INSTANCE_ID", testID)
+	instanceID = "" // Reset
+
+	err := initInstanceID()
+	if err != nil {
+		t.Fatalf("initInstanceID() returned error: %v", err)
+	}
+
+	if instanceID != testID {
+		t.Errorf("initInstanceID() with env var: got %q, want %q", instanceID, testID)
+	}
+}
+
+// Test initInstanceID without environment variable (auto-generate)
+func TestInitInstanceID_WithoutEnvVar(t *testing.T) {
+	// Save original value and restore after test
+	originalID := instanceID
+	originalEnv := os.Getenv("INSTANCE_ID")
+	defer func() {
+		instanceID = originalID
+		if originalEnv != "" {
+			os.Setenv("INSTANCE_ID", originalEnv)
+		} else {
+			os.Unsetenv("INSTANCE_ID")
+		}
+	}()
+
+	// Unset environment variable
+	os.Unsetenv("INSTANCE_ID")
+	instanceID = "" // Reset
+
+	err := initInstanceID()
+	if err != nil {
+		t.Fatalf("initInstanceID() returned error: %v", err)
+	}
+
+	if instanceID == "" {
+		t.Error("initInstanceID() without env var: instanceID should not be empty")
+	}
+
+	// Should be a valid UUIDv7
+	uuidv7Regex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuidv7Regex.MatchString(instanceID) {
+		t.Errorf("initInstanceID() without env var: got %q, not a valid UUIDv7", instanceID)
+	}
+}
+
+// Test getRequestURL
+func TestGetRequestURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		uri      string
+		hasTLS   bool
+		expected string
+	}{
+		{
+			name:     "http request",
+			host:     "localhost:8080",
+			uri:      "/test",
+			hasTLS:   false,
+			expected: "http://localhost:8080/test",
+		},
+		{
+			name:     "https request",
+			host:     "example.com",
+			uri:      "/api/v1",
+			hasTLS:   true,
+			expected: "https://example.com/api/v1",
+		},
+		{
+			name:     "with query string",
+			host:     "api.example.com",
+			uri:      "/search?q=test",
+			hasTLS:   true,
+			expected: "https://api.example.com/search?q=test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.uri, nil)
+			req.Host = tt.host
+			if tt.hasTLS {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			got := getRequestURL(req)
+			if got != tt.expected {
+				t.Errorf("getRequestURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
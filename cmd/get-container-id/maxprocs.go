@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"runtime"
+)
+
+// The sources of the GOMAXPROCS value in effect.
+const (
+	maxProcsSourceDefault = "default"
+	maxProcsSourceEnv     = "env"
+	maxProcsSourceCgroup  = "cgroup"
+)
+
+// maxProcsAdjustment records how GOMAXPROCS was set at startup.
+type maxProcsAdjustment struct {
+	// Source is "cgroup" when GOMAXPROCS was capped to the CPU quota, "env"
+	// when the GOMAXPROCS environment variable set it, and "default"
+	// otherwise.
+	Source   string `json:"source"`
+	Previous int    `json:"previous"`
+	Current  int    `json:"current"`
+	// CPULimit is the cgroup CPU quota divided by the period, if one is set.
+	CPULimit float64 `json:"cpu_limit,omitempty"`
+}
+
+var (
+	// maxProcs is the startup adjustment reported by /runtime_info.
+	maxProcs = maxProcsAdjustment{Source: maxProcsSourceDefault}
+
+	// setGOMAXPROCS applies the adjustment; tests replace it.
+	setGOMAXPROCS = runtime.GOMAXPROCS
+)
+
+// maxProcsForQuota returns the GOMAXPROCS value for a CPU limit of cpus on a
+// machine with numCPU CPUs: the limit rounded down, at least 1 and at most
+// numCPU. A limit of 0 means none is set and yields numCPU.
+func maxProcsForQuota(cpus float64, numCPU int) int {
+	if cpus <= 0 {
+		return numCPU
+	}
+	return max(1, min(numCPU, int(math.Floor(cpus))))
+}
+
+// adjustMaxProcs caps GOMAXPROCS to the cgroup CPU quota, so a container
+// limited to two CPUs on a 64-core node does not run 64 Ps and get
+// throttled by the CFS scheduler. An explicit GOMAXPROCS environment
+// variable is left alone.
+func adjustMaxProcs(getenv func(string) string) maxProcsAdjustment {
+	current := setGOMAXPROCS(0)
+	adj := maxProcsAdjustment{Source: maxProcsSourceDefault, Previous: current, Current: current}
+	if getenv("GOMAXPROCS") != "" {
+		adj.Source = maxProcsSourceEnv
+		return adj
+	}
+
+	info, _ := inspectCgroups()
+	if info.Limits.CPUs() == 0 {
+		return adj
+	}
+
+	adj.CPULimit = info.Limits.CPUs()
+	if procs := maxProcsForQuota(adj.CPULimit, runtime.NumCPU()); procs != current {
+		setGOMAXPROCS(procs)
+		adj.Source = maxProcsSourceCgroup
+		adj.Current = procs
+	}
+	return adj
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+)
+
+// Test maxProcsForQuota rounds the CPU limit down within [1, NumCPU]
+func TestMaxProcsForQuota(t *testing.T) {
+	tests := []struct {
+		name   string
+		cpus   float64
+		numCPU int
+		want   int
+	}{
+		{"no limit", 0, 8, 8},
+		{"whole CPUs", 2, 8, 2},
+		{"fractional limit rounds down", 2.5, 8, 2},
+		{"below one CPU", 0.5, 8, 1},
+		{"above NumCPU", 16, 8, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxProcsForQuota(tt.cpus, tt.numCPU); got != tt.want {
+				t.Errorf("maxProcsForQuota(%v, %d) = %d, want %d", tt.cpus, tt.numCPU, got, tt.want)
+			}
+		})
+	}
+}
+
+// stubGOMAXPROCS records GOMAXPROCS changes instead of applying them.
+func stubGOMAXPROCS(t *testing.T, current int) *int {
+	t.Helper()
+	orig := setGOMAXPROCS
+	t.Cleanup(func() { setGOMAXPROCS = orig })
+	set := new(int)
+	setGOMAXPROCS = func(n int) int {
+		if n > 0 {
+			*set = n
+		}
+		return current
+	}
+	return set
+}
+
+// Test adjustMaxProcs caps GOMAXPROCS to the cgroup CPU quota
+func TestAdjustMaxProcs(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	t.Run("cgroup quota", func(t *testing.T) {
+		stubCgroups(t)
+		inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) {
+			return cgroup.Info{Version: 2, Limits: cgroup.Limits{CPUQuotaMicros: 100000, CPUPeriodMicros: 100000}}, nil
+		}
+		set := stubGOMAXPROCS(t, runtime.NumCPU()+1)
+
+		adj := adjustMaxProcs(noEnv)
+		want := maxProcsAdjustment{Source: maxProcsSourceCgroup, Previous: runtime.NumCPU() + 1, Current: 1, CPULimit: 1}
+		if adj != want || *set != 1 {
+			t.Errorf("adjustMaxProcs() = %+v and set %d, want %+v and set 1", adj, *set, want)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		stubCgroups(t)
+		set := stubGOMAXPROCS(t, 4)
+
+		adj := adjustMaxProcs(noEnv)
+		want := maxProcsAdjustment{Source: maxProcsSourceDefault, Previous: 4, Current: 4}
+		if adj != want || *set != 0 {
+			t.Errorf("adjustMaxProcs() = %+v and set %d, want %+v and no change", adj, *set, want)
+		}
+	})
+
+	t.Run("GOMAXPROCS env", func(t *testing.T) {
+		stubCgroups(t)
+		inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) {
+			return cgroup.Info{Version: 2, Limits: cgroup.Limits{CPUQuotaMicros: 100000, CPUPeriodMicros: 100000}}, nil
+		}
+		set := stubGOMAXPROCS(t, 4)
+
+		adj := adjustMaxProcs(func(key string) string {
+			if key == "GOMAXPROCS" {
+				return "4"
+			}
+			return ""
+		})
+		want := maxProcsAdjustment{Source: maxProcsSourceEnv, Previous: 4, Current: 4}
+		if adj != want || *set != 0 {
+			t.Errorf("adjustMaxProcs() = %+v and set %d, want %+v and no change", adj, *set, want)
+		}
+	})
+}
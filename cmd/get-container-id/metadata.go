@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/identity"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// identityFunc detects the runtime, namespace and node; tests replace it.
+var identityFunc = identity.Get
+
+// metadataDocument is the full identity of the serving replica in one
+// response. Fields that could not be detected are omitted.
+type metadataDocument struct {
+	ContainerID   string    `json:"container_id,omitempty"`
+	PodID         string    `json:"pod_id,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	InstanceID    string    `json:"instance_id"`
+	Runtime       string    `json:"runtime,omitempty"`
+	CgroupVersion int       `json:"cgroup_version"`
+	Namespace     string    `json:"namespace,omitempty"`
+	NodeName      string    `json:"node_name,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	// Color tells replicas apart in demos, see -color.
+	Color string `json:"color"`
+}
+
+// colorRegex matches the colors -color accepts: #rgb, #rrggbb or a CSS
+// color name.
+var colorRegex = regexp.MustCompile(`^(#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|[A-Za-z]{3,20})$`)
+
+func validateColor(color string) error {
+	if color != "" && !colorRegex.MatchString(color) {
+		return fmt.Errorf("invalid color %q: use #rgb, #rrggbb or a CSS color name", color)
+	}
+	return nil
+}
+
+// instanceColor derives a color from an instance ID, so that replicas get
+// distinct but stable colors without configuration. The hue comes from a
+// hash of the ID; saturation and lightness are fixed so white text stays
+// readable on it.
+func instanceColor(id string) string {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return hslHex(float64(h.Sum32()%360), 0.65, 0.42)
+}
+
+// hslHex converts a hue in degrees and a saturation and lightness in 0..1 to
+// a #rrggbb color.
+func hslHex(hue, s, l float64) string {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = c, x
+	case hue < 120:
+		r, g = x, c
+	case hue < 180:
+		g, b = c, x
+	case hue < 240:
+		g, b = x, c
+	case hue < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	channel := func(v float64) int { return int(math.Round((v + m) * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+}
+
+// metadata serves GET /metadata.
+type metadata struct {
+	started time.Time
+	// color is the -color of the replica; empty derives one from the
+	// instance ID.
+	color string
+}
+
+func newMetadata(color string) *metadata {
+	return &metadata{started: time.Now(), color: color}
+}
+
+// collect gathers the metadata document. Detection errors only leave the
+// corresponding fields empty.
+func (m *metadata) collect(ctx context.Context) metadataDocument {
+	cgroups, _ := inspectCgroups()
+	doc := metadataDocument{
+		InstanceID:    instanceID,
+		CgroupVersion: cgroups.Version,
+		StartedAt:     m.started.UTC(),
+		UptimeSeconds: time.Since(m.started).Seconds(),
+		Color:         m.color,
+	}
+	if doc.Color == "" {
+		doc.Color = instanceColor(instanceID)
+	}
+	doc.ContainerID, _ = getContainerID(ctx)
+	doc.PodID, _ = podid.GetContext(ctx)
+	doc.Hostname, _ = os.Hostname()
+
+	// identity.Get returns a partial result alongside its error.
+	id, _ := identityFunc(ctx)
+	doc.Runtime = id.Runtime.Value
+	doc.Namespace = id.Namespace.Value
+	doc.NodeName = id.Node.Value
+	return doc
+}
+
+// handleMetadata serves GET /metadata.
+func (m *metadata) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, m.collect(r.Context()))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+	"github.com/ming-go/lab/get-container-id/identity"
+)
+
+// Test /metadata combines the detected identity with instance and uptime
+func TestMetadata_Handle(t *testing.T) {
+	origIdentity, origInstance := identityFunc, instanceID
+	defer func() { identityFunc, instanceID = origIdentity, origInstance }()
+	stubCgroups(t)
+
+	identityFunc = func(ctx context.Context, opts ...identity.Option) (identity.Identity, error) {
+		return identity.Identity{
+			Runtime:   identity.Field{Value: "containerd", Source: "file:/proc/self/cgroup"},
+			Namespace: identity.Field{Value: "default", Source: "env:POD_NAMESPACE"},
+			Node:      identity.Field{Value: "node-1", Source: "env:NODE_NAME"},
+		}, nil
+	}
+	instanceID = "test-instance"
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) { return cgroup.Info{Version: 2}, nil }
+
+	m := &metadata{started: time.Now().Add(-time.Minute)}
+	rec := httptest.NewRecorder()
+	m.handleMetadata(rec, httptest.NewRequest(http.MethodGet, "/metadata", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp struct {
+		Data metadataDocument `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	doc := resp.Data
+	if doc.InstanceID != "test-instance" || doc.Runtime != "containerd" || doc.Namespace != "default" || doc.NodeName != "node-1" {
+		t.Errorf("metadata = %+v, want instance, runtime, namespace and node from the stubs", doc)
+	}
+	if doc.CgroupVersion != 2 {
+		t.Errorf("cgroup_version = %d, want 2", doc.CgroupVersion)
+	}
+	if doc.UptimeSeconds < 60 || !doc.StartedAt.Equal(m.started) {
+		t.Errorf("started_at = %v, uptime_seconds = %v, want %v and >= 60", doc.StartedAt, doc.UptimeSeconds, m.started)
+	}
+}
+
+// Test /metadata reports -color, or a color derived from the instance ID
+func TestMetadata_Color(t *testing.T) {
+	origIdentity, origInstance := identityFunc, instanceID
+	defer func() { identityFunc, instanceID = origIdentity, origInstance }()
+	identityFunc = func(ctx context.Context, opts ...identity.Option) (identity.Identity, error) {
+		return identity.Identity{}, nil
+	}
+
+	instanceID = "replica-a"
+	if got := newMetadata("tomato").collect(context.Background()).Color; got != "tomato" {
+		t.Errorf("color = %q, want tomato", got)
+	}
+	derived := newMetadata("").collect(context.Background()).Color
+	if derived != instanceColor("replica-a") || !colorRegex.MatchString(derived) || len(derived) != 7 {
+		t.Errorf("derived color = %q, want a stable #rrggbb", derived)
+	}
+	instanceID = "replica-b"
+	if other := newMetadata("").collect(context.Background()).Color; other == derived {
+		t.Errorf("replicas a and b share color %q", other)
+	}
+}
+
+// Test hslHex converts hue, saturation and lightness to hex colors
+func TestHSLHex(t *testing.T) {
+	tests := []struct {
+		hue, s, l float64
+		want      string
+	}{
+		{0, 1, 0.5, "#ff0000"},
+		{120, 1, 0.5, "#00ff00"},
+		{240, 1, 0.5, "#0000ff"},
+		{60, 1, 0.5, "#ffff00"},
+		{300, 1, 0.25, "#800080"},
+		{200, 0, 1, "#ffffff"},
+	}
+	for _, tt := range tests {
+		if got := hslHex(tt.hue, tt.s, tt.l); got != tt.want {
+			t.Errorf("hslHex(%v, %v, %v) = %s, want %s", tt.hue, tt.s, tt.l, got, tt.want)
+		}
+	}
+}
+
+// Test validateColor accepts hex colors and names only
+func TestValidateColor(t *testing.T) {
+	tests := []struct {
+		color   string
+		wantErr bool
+	}{
+		{"", false},
+		{"#fff", false},
+		{"#1E90FF", false},
+		{"rebeccapurple", false},
+		{"#ffff", true},
+		{"red;background:url(x)", true},
+		{"rgb(1,2,3)", true},
+	}
+	for _, tt := range tests {
+		if err := validateColor(tt.color); (err != nil) != tt.wantErr {
+			t.Errorf("validateColor(%q) error = %v, wantErr %v", tt.color, err, tt.wantErr)
+		}
+	}
+}
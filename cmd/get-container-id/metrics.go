@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/detectmetrics"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// unmatchedRoute labels requests that matched no registered pattern, so
+// arbitrary paths cannot blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// requestDurationBuckets are the upper bounds, in seconds, of the request
+// latency histogram; the Prometheus client defaults.
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// routeStats are the request counts by status code and the latency
+// histogram of one route.
+type routeStats struct {
+	codes   map[int]uint64
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// requestStats counts served requests and 5xx responses, and per route the
+// status codes and latency. The zero value is ready to use and records every
+// request under the unmatched route.
+type requestStats struct {
+	requests     atomic.Uint64
+	serverErrors atomic.Uint64
+
+	// route returns the pattern that serves r, or "" if none does.
+	route func(r *http.Request) string
+
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+// newRequestStats labels requests with the mux pattern that serves them.
+func newRequestStats(mux *http.ServeMux) *requestStats {
+	return &requestStats{
+		route: func(r *http.Request) string {
+			_, pattern := mux.Handler(r)
+			return pattern
+		},
+	}
+}
+
+// middleware counts every request, its response status and latency.
+func (s *requestStats) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		s.requests.Add(1)
+		if sw.status >= http.StatusInternalServerError {
+			s.serverErrors.Add(1)
+		}
+
+		route := unmatchedRoute
+		if s.route != nil {
+			if pattern := s.route(r); pattern != "" {
+				route = pattern
+			}
+		}
+		s.observe(route, sw.status, time.Since(start))
+	})
+}
+
+func (s *requestStats) observe(route string, code int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.routes == nil {
+		s.routes = make(map[string]*routeStats)
+	}
+	rs := s.routes[route]
+	if rs == nil {
+		rs = &routeStats{codes: make(map[int]uint64), buckets: make([]uint64, len(requestDurationBuckets))}
+		s.routes[route] = rs
+	}
+	rs.codes[code]++
+	rs.count++
+	secs := d.Seconds()
+	rs.sum += secs
+	for i, le := range requestDurationBuckets {
+		if secs <= le {
+			rs.buckets[i]++
+		}
+	}
+}
+
+// writePrometheus writes the request counters and latency histograms,
+// sorted by route and status code.
+func (s *requestStats) writePrometheus(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	routes := make([]string, 0, len(s.routes))
+	for route := range s.routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	fmt.Fprintf(w, "# HELP gcid_http_requests_total HTTP requests served, by route and status code.\n")
+	fmt.Fprintf(w, "# TYPE gcid_http_requests_total counter\n")
+	for _, route := range routes {
+		rs := s.routes[route]
+		codes := make([]int, 0, len(rs.codes))
+		for code := range rs.codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "gcid_http_requests_total{path=%q,code=\"%d\"} %d\n", route, code, rs.codes[code])
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP gcid_http_request_duration_seconds HTTP request latency, by route.\n")
+	fmt.Fprintf(w, "# TYPE gcid_http_request_duration_seconds histogram\n")
+	for _, route := range routes {
+		rs := s.routes[route]
+		for i, le := range requestDurationBuckets {
+			fmt.Fprintf(w, "gcid_http_request_duration_seconds_bucket{path=%q,le=\"%g\"} %d\n", route, le, rs.buckets[i])
+		}
+		fmt.Fprintf(w, "gcid_http_request_duration_seconds_bucket{path=%q,le=\"+Inf\"} %d\n", route, rs.count)
+		fmt.Fprintf(w, "gcid_http_request_duration_seconds_sum{path=%q} %g\n", route, rs.sum)
+		fmt.Fprintf(w, "gcid_http_request_duration_seconds_count{path=%q} %d\n", route, rs.count)
+	}
+}
+
+// statusWriter records the response status code and body size.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	bytes       int64
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusWriter) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// hitCounter backs GET /counter. total only grows and is exported to
+// Prometheus; the endpoint and the once-a-second log report the hits since
+// the last log line.
+type hitCounter struct {
+	total  atomic.Uint64
+	logged atomic.Uint64
+}
+
+// inc records a hit and returns the hits since the last log line.
+func (c *hitCounter) inc() uint64 {
+	return c.total.Add(1) - c.logged.Load()
+}
+
+// log writes the hits since the previous call, if any.
+func (c *hitCounter) log(logger *slog.Logger) {
+	total := c.total.Load()
+	if n := total - c.logged.Swap(total); n != 0 {
+		logger.Info("CounterLogger", slog.Uint64("counter", n))
+	}
+}
+
+// run logs the hits once a second until ctx is done.
+func (c *hitCounter) run(ctx context.Context, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.log(logger)
+		}
+	}
+}
+
+func (c *hitCounter) handleCounter(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, strconv.FormatUint(c.inc(), 10))
+}
+
+// metrics serves GET /metrics in the Prometheus text exposition format.
+type metrics struct {
+	requests *requestStats
+	counter  *hitCounter
+	counters *counterRegistry
+	shutdown *shutdownState
+	version  string
+}
+
+func (m *metrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	containerID, _ := getContainerID(r.Context())
+	podID, _ := podid.GetContext(r.Context())
+
+	w.Header().Set(headerContentType, "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintf(w, "# HELP gcid_info Identity of this replica; always 1.\n")
+	fmt.Fprintf(w, "# TYPE gcid_info gauge\n")
+	fmt.Fprintf(w, "gcid_info{container_id=%q,pod_id=%q,instance_id=%q,version=%q} 1\n", containerID, podID, instanceID, m.version)
+
+	fmt.Fprintf(w, "# HELP gcid_http_requests_in_flight HTTP requests currently being served.\n")
+	fmt.Fprintf(w, "# TYPE gcid_http_requests_in_flight gauge\n")
+	fmt.Fprintf(w, "gcid_http_requests_in_flight %d\n", m.shutdown.inFlight.Load())
+
+	m.requests.writePrometheus(w)
+
+	fmt.Fprintf(w, "# HELP gcid_counter_hits_total Requests to /counter.\n")
+	fmt.Fprintf(w, "# TYPE gcid_counter_hits_total counter\n")
+	fmt.Fprintf(w, "gcid_counter_hits_total %d\n", m.counter.total.Load())
+
+	values := m.counters.snapshot()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "# HELP gcid_counter_value Named counters from /counters.\n")
+	fmt.Fprintf(w, "# TYPE gcid_counter_value gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "gcid_counter_value{name=%q} %d\n", name, values[name])
+	}
+
+	detectmetrics.WritePrometheus(w, containerid.Metrics(), podid.Metrics())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test requestStats labels requests with the mux pattern that served them
+func TestRequestStats_Routes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /counters/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	stats := newRequestStats(mux)
+	handler := stats.middleware(mux)
+
+	for _, path := range []string{"/counters/a", "/counters/b", "/fail", "/no/such/path"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var buf bytes.Buffer
+	stats.writePrometheus(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		`gcid_http_requests_total{path="GET /counters/{name}",code="200"} 2`,
+		`gcid_http_requests_total{path="/fail",code="500"} 1`,
+		`gcid_http_requests_total{path="unmatched",code="404"} 1`,
+		`gcid_http_request_duration_seconds_bucket{path="GET /counters/{name}",le="+Inf"} 2`,
+		`gcid_http_request_duration_seconds_count{path="/fail"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if got := stats.requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+	if got := stats.serverErrors.Load(); got != 1 {
+		t.Errorf("serverErrors = %d, want 1", got)
+	}
+}
+
+// Test /counter reports the hits since the last log line while the exported
+// total keeps growing
+func TestHitCounter(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	c := &hitCounter{}
+
+	for want := uint64(1); want <= 3; want++ {
+		if got := c.inc(); got != want {
+			t.Fatalf("inc() = %d, want %d", got, want)
+		}
+	}
+	c.log(logger)
+	if !strings.Contains(logs.String(), `"counter":3`) {
+		t.Errorf("log = %q, want counter 3", logs.String())
+	}
+
+	rec := httptest.NewRecorder()
+	c.handleCounter(rec, httptest.NewRequest(http.MethodGet, "/counter", nil))
+	var resp struct {
+		Data string `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Data != "1" {
+		t.Errorf("/counter after log = %q, %v, want 1", resp.Data, err)
+	}
+	if got := c.total.Load(); got != 4 {
+		t.Errorf("total = %d, want 4", got)
+	}
+
+	logs.Reset()
+	c.log(logger)
+	c.log(logger)
+	if n := strings.Count(logs.String(), "CounterLogger"); n != 1 {
+		t.Errorf("logged %d lines for one hit, want 1", n)
+	}
+}
+
+// Test /metrics serves identity, request and detection metrics
+func TestMetrics_Handle(t *testing.T) {
+	origInstance := instanceID
+	defer func() { instanceID = origInstance }()
+	instanceID = "test-instance"
+
+	counter := &hitCounter{}
+	counter.inc()
+	shutdown := newShutdownState()
+	shutdown.inFlight.Add(2)
+	counters := newCounterRegistry()
+	counters.add("jobs.done", 3)
+	m := &metrics{requests: &requestStats{}, counter: counter, counters: counters, shutdown: shutdown, version: "v1.2.3"}
+
+	rec := httptest.NewRecorder()
+	m.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get(headerContentType); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		`instance_id="test-instance",version="v1.2.3"} 1`,
+		"gcid_http_requests_in_flight 2\n",
+		"gcid_counter_hits_total 1\n",
+		`gcid_counter_value{name="jobs.done"} 3` + "\n",
+		"# TYPE gcid_http_request_duration_seconds histogram\n",
+		`gcid_detection_attempts_total{detector="containerid"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// recoverMiddleware converts handler panics into HTTP 500 JSON responses and
+// logs the panic value together with the goroutine stack, with the request ID
+// if requestIDMiddleware assigned one.
+func recoverMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// ErrAbortHandler is the sanctioned way to abort a response; let net/http handle it.
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestLogger(r.Context(), logger).Error(
+				"PanicRecovered",
+				slog.String("panic", fmt.Sprint(rec)),
+				slog.String("request_method", r.Method),
+				slog.String("request_url_path", r.URL.Path),
+				slog.String("stack", string(debug.Stack())),
+			)
+
+			httpapi.WriteError(w, "internal server error", http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// Test recoverMiddleware turns a panic into a 500 JSON response and logs the stack
+func TestRecoverMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	h := recoverMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("recoverMiddleware() status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	var resp httpapi.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("recoverMiddleware() body unmarshal error: %v", err)
+	}
+	if resp.Errors.Message == "" {
+		t.Error("recoverMiddleware() error message should not be empty")
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("recoverMiddleware() log unmarshal error: %v", err)
+	}
+	if record["panic"] != "boom" {
+		t.Errorf("recoverMiddleware() logged panic = %v, want %q", record["panic"], "boom")
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("recoverMiddleware() logged stack = %q, want a goroutine trace", stack)
+	}
+}
+
+// Test recoverMiddleware re-panics with http.ErrAbortHandler
+func TestRecoverMiddleware_AbortHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	h := recoverMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recoverMiddleware() recovered %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+// mountInfoPath is read for /mounts and to find the filesystem type backing
+// a volume path.
+var mountInfoPath = mountinfo.Path
+
+// handleMounts serves GET /mounts?fstype=..., the mount table of the
+// container's mount namespace.
+func handleMounts(w http.ResponseWriter, r *http.Request) {
+	f, err := os.Open(mountInfoPath)
+	if err != nil {
+		httpapi.WriteError(w, "failed to read mountinfo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	fsType := r.URL.Query().Get("fstype")
+	mounts := []mountinfo.Mount{}
+	err = mountinfo.Scan(f, func(m mountinfo.Mount) bool {
+		if fsType == "" || m.FSType == fsType {
+			mounts = append(mounts, m)
+		}
+		return false
+	})
+	if err != nil {
+		httpapi.WriteError(w, "failed to read mountinfo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	httpapi.WriteSuccess(w, mounts)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+// Test /mounts lists the parsed mount table, optionally filtered by type
+func TestHandleMounts(t *testing.T) {
+	orig := mountInfoPath
+	defer func() { mountInfoPath = orig }()
+	mountInfoPath = writeTestFile(t, `36 25 0:32 / / rw - overlay overlay rw,lowerdir=/l
+40 36 259:1 /vol /data\040dir rw,relatime shared:1 - ext4 /dev/nvme1n1 rw
+not a mount line
+41 36 0:50 / /proc rw,nosuid - proc proc rw
+`)
+
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{target: "/mounts", want: []string{"/", "/data dir", "/proc"}},
+		{target: "/mounts?fstype=ext4", want: []string{"/data dir"}},
+		{target: "/mounts?fstype=nfs4", want: []string{}},
+	}
+	for _, tt := range tests {
+		rec := serve(http.HandlerFunc(handleMounts), http.MethodGet, tt.target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", tt.target, rec.Code)
+		}
+		var resp struct {
+			Data []mountinfo.Mount `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range resp.Data {
+			got = append(got, m.MountPoint)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("GET %s mount points = %v, want %v", tt.target, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("GET %s mount points = %v, want %v", tt.target, got, tt.want)
+				break
+			}
+		}
+	}
+
+	mountInfoPath = filepath.Join(t.TempDir(), "missing")
+	if rec := serve(http.HandlerFunc(handleMounts), http.MethodGet, "/mounts"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("missing mountinfo status = %d, want 500", rec.Code)
+	}
+}
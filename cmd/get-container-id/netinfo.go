@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+var (
+	procNetRoutePath     = "/proc/net/route"
+	procNetIPv6RoutePath = "/proc/net/ipv6_route"
+	procSysDir           = "/proc/sys"
+
+	// nativeBigEndian tells the byte order of /proc/net/route.
+	nativeBigEndian = binary.NativeEndian.Uint16([]byte{0, 1}) == 1
+)
+
+// netSysctls are the sysctls /net reports, as paths below procSysDir.
+var netSysctls = []string{
+	"net/ipv4/ip_forward",
+	"net/ipv4/conf/all/rp_filter",
+	"net/ipv4/ip_local_port_range",
+	"net/ipv4/ip_unprivileged_port_start",
+	"net/ipv4/ping_group_range",
+	"net/ipv4/tcp_keepalive_time",
+	"net/ipv6/conf/all/forwarding",
+	"net/ipv6/conf/all/disable_ipv6",
+	"net/core/somaxconn",
+}
+
+// Route flags of the kernel's route tables, see route(8).
+const (
+	rtfUp      = 0x0001
+	rtfGateway = 0x0002
+	rtfReject  = 0x0200
+	rtfLocal   = 0x80000000
+)
+
+// netDocument describes the pod's network as its CNI plugin set it up.
+type netDocument struct {
+	Interfaces []netInterface `json:"interfaces"`
+	Routes     []netRoute     `json:"routes"`
+	// Sysctls maps dotted sysctl names to their values; unreadable ones
+	// are omitted.
+	Sysctls map[string]string `json:"sysctls"`
+}
+
+type netInterface struct {
+	Name         string   `json:"name"`
+	Index        int      `json:"index"`
+	MTU          int      `json:"mtu"`
+	HardwareAddr string   `json:"hardware_addr,omitempty"`
+	Flags        []string `json:"flags"`
+	Addrs        []string `json:"addrs"`
+}
+
+// netRoute is a route of the main routing table.
+type netRoute struct {
+	Family      string `json:"family"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Interface   string `json:"interface"`
+	Metric      uint32 `json:"metric"`
+	Default     bool   `json:"default"`
+}
+
+// readInterfaces lists the network interfaces and their addresses.
+func readInterfaces() ([]netInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]netInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		ni := netInterface{
+			Name:         iface.Name,
+			Index:        iface.Index,
+			MTU:          iface.MTU,
+			HardwareAddr: iface.HardwareAddr.String(),
+			Flags:        strings.Split(iface.Flags.String(), "|"),
+			Addrs:        []string{},
+		}
+		if iface.Flags == 0 {
+			ni.Flags = []string{}
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ni.Addrs = append(ni.Addrs, addr.String())
+		}
+		list = append(list, ni)
+	}
+	return list, nil
+}
+
+// parseIPv4Routes parses /proc/net/route, whose addresses are hex in host
+// byte order. Routes that are not up are skipped.
+func parseIPv4Routes(r io.Reader, bigEndian bool) []netRoute {
+	routes := []netRoute{}
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		dest, okDest := parseHexIPv4(fields[1], bigEndian)
+		gateway, okGateway := parseHexIPv4(fields[2], bigEndian)
+		mask, okMask := parseHexIPv4(fields[7], bigEndian)
+		flags, errFlags := strconv.ParseUint(fields[3], 16, 32)
+		metric, errMetric := strconv.ParseUint(fields[6], 10, 32)
+		if !okDest || !okGateway || !okMask || errFlags != nil || errMetric != nil || flags&rtfUp == 0 {
+			continue
+		}
+		ones, _ := net.IPMask(mask.To4()).Size()
+		route := netRoute{
+			Family:      "ipv4",
+			Destination: (&net.IPNet{IP: dest, Mask: net.CIDRMask(ones, 32)}).String(),
+			Interface:   fields[0],
+			Metric:      uint32(metric),
+			Default:     ones == 0,
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = gateway.String()
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func parseHexIPv4(s string, bigEndian bool) (net.IP, bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 4 {
+		return nil, false
+	}
+	if !bigEndian {
+		b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	}
+	return net.IP(b), true
+}
+
+// parseIPv6Routes parses /proc/net/ipv6_route, whose addresses are hex in
+// network byte order. Routes that are not up, reject routes and the local
+// table's routes to the pod's own addresses are skipped.
+func parseIPv6Routes(r io.Reader) []netRoute {
+	routes := []netRoute{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		dest, errDest := hex.DecodeString(fields[0])
+		prefix, errPrefix := strconv.ParseUint(fields[1], 16, 8)
+		gateway, errGateway := hex.DecodeString(fields[4])
+		metric, errMetric := strconv.ParseUint(fields[5], 16, 32)
+		flags, errFlags := strconv.ParseUint(fields[8], 16, 32)
+		if errDest != nil || errPrefix != nil || errGateway != nil || errMetric != nil || errFlags != nil ||
+			len(dest) != net.IPv6len || len(gateway) != net.IPv6len || prefix > 128 {
+			continue
+		}
+		if flags&rtfUp == 0 || flags&(rtfReject|rtfLocal) != 0 {
+			continue
+		}
+		route := netRoute{
+			Family:      "ipv6",
+			Destination: (&net.IPNet{IP: net.IP(dest), Mask: net.CIDRMask(int(prefix), 128)}).String(),
+			Interface:   fields[9],
+			Metric:      uint32(metric),
+			Default:     prefix == 0,
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = net.IP(gateway).String()
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// readNetSysctls reads netSysctls below procSysDir.
+func readNetSysctls() map[string]string {
+	values := map[string]string{}
+	for _, path := range netSysctls {
+		b, err := os.ReadFile(filepath.Join(procSysDir, path))
+		if err != nil {
+			continue
+		}
+		values[strings.ReplaceAll(path, "/", ".")] = strings.Join(strings.Fields(string(b)), " ")
+	}
+	return values
+}
+
+// readNet gathers the network document. Route tables that cannot be read,
+// e.g. outside Linux, are left empty.
+func readNet() (netDocument, error) {
+	ifaces, err := readInterfaces()
+	if err != nil {
+		return netDocument{}, err
+	}
+	doc := netDocument{Interfaces: ifaces, Routes: []netRoute{}, Sysctls: readNetSysctls()}
+	if f, err := os.Open(procNetRoutePath); err == nil {
+		doc.Routes = append(doc.Routes, parseIPv4Routes(f, nativeBigEndian)...)
+		f.Close()
+	}
+	if f, err := os.Open(procNetIPv6RoutePath); err == nil {
+		doc.Routes = append(doc.Routes, parseIPv6Routes(f)...)
+		f.Close()
+	}
+	return doc, nil
+}
+
+// handleNet serves GET /net.
+func handleNet(w http.ResponseWriter, r *http.Request) {
+	doc, err := readNet()
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	httpapi.WriteSuccess(w, doc)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test IPv4 routes are decoded from host byte order, skipping routes that
+// are down
+func TestParseIPv4Routes(t *testing.T) {
+	input := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101F40A	0003	0	0	100	00000000	0	0	0
+eth0	0001F40A	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth1	0000A8C0	00000000	0000	0	0	0	0000FFFF	0	0	0
+`
+	want := []netRoute{
+		{Family: "ipv4", Destination: "0.0.0.0/0", Gateway: "10.244.1.1", Interface: "eth0", Metric: 100, Default: true},
+		{Family: "ipv4", Destination: "10.244.1.0/24", Interface: "eth0"},
+	}
+	if got := parseIPv4Routes(strings.NewReader(input), false); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPv4Routes = %+v, want %+v", got, want)
+	}
+
+	bigEndian := "Iface\tDestination\tGateway\tFlags\tRefCnt\tUse\tMetric\tMask\nenc0\t0AF40100\t00000000\t0001\t0\t0\t0\tFFFFFF00\n"
+	want = []netRoute{{Family: "ipv4", Destination: "10.244.1.0/24", Interface: "enc0"}}
+	if got := parseIPv4Routes(strings.NewReader(bigEndian), true); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPv4Routes(big endian) = %+v, want %+v", got, want)
+	}
+}
+
+// Test IPv6 routes skip local and reject routes
+func TestParseIPv6Routes(t *testing.T) {
+	input := `fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 fd000000000000000000000000000001 00000400 00000001 00000000 00000003     eth0
+fd000000000000000000000000000002 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001       lo
+00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200       lo
+`
+	want := []netRoute{
+		{Family: "ipv6", Destination: "fd00::/64", Interface: "eth0", Metric: 256},
+		{Family: "ipv6", Destination: "::/0", Gateway: "fd00::1", Interface: "eth0", Metric: 1024, Default: true},
+	}
+	if got := parseIPv6Routes(strings.NewReader(input)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPv6Routes = %+v, want %+v", got, want)
+	}
+}
+
+// Test /net reads the sysctls it knows and omits the missing ones
+func TestReadNetSysctls(t *testing.T) {
+	orig := procSysDir
+	defer func() { procSysDir = orig }()
+	procSysDir = t.TempDir()
+	for path, value := range map[string]string{
+		"net/ipv4/ip_forward":          "1\n",
+		"net/ipv4/ip_local_port_range": "32768\t60999\n",
+	} {
+		full := filepath.Join(procSysDir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(value), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"net.ipv4.ip_forward":          "1",
+		"net.ipv4.ip_local_port_range": "32768 60999",
+	}
+	if got := readNetSysctls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("readNetSysctls = %v, want %v", got, want)
+	}
+}
+
+// Test /net lists the loopback interface with its address
+func TestReadNet(t *testing.T) {
+	doc, err := readNet()
+	if err != nil {
+		t.Fatalf("readNet: %v", err)
+	}
+	for _, iface := range doc.Interfaces {
+		for _, flag := range iface.Flags {
+			if flag == "loopback" && len(iface.Addrs) > 0 && iface.MTU > 0 {
+				return
+			}
+		}
+	}
+	t.Errorf("interfaces = %+v, want a loopback interface with an address", doc.Interfaces)
+}
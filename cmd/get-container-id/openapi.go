@@ -0,0 +1,291 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const openAPIVersion = "3.0.3"
+
+// openAPITags describes the route tags, in the order /docs lists them.
+var openAPITags = []openAPITag{
+	{tagIdentity, "Container, pod, node and instance identity"},
+	{tagContainer, "Cgroups, limits and the container runtime"},
+	{tagKubernetes, "Kubernetes API objects and service account tokens"},
+	{tagNetwork, "DNS, connectivity and client addresses"},
+	{tagDiagnostics, "Configuration, environment and detection internals"},
+	{tagTesting, "Echo, delays, status codes and payloads for testing clients and proxies"},
+	{tagFault, "Runtime fault injection"},
+	{tagChaos, "Destructive endpoints; require -chaos"},
+	{tagLifecycle, "Probes, metrics and shutdown"},
+	{tagAPI, "This API description"},
+}
+
+// docsPage renders /openapi.json with Swagger UI, loaded from a CDN so the
+// binary stays small.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>get-container-id API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Tags       []openAPITag                           `json:"tags"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Required    bool          `json:"required,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Schema      openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the subset of JSON schema the document needs.
+type openAPISchema struct {
+	Ref        string                   `json:"$ref,omitempty"`
+	Type       string                   `json:"type,omitempty"`
+	Format     string                   `json:"format,omitempty"`
+	Enum       []string                 `json:"enum,omitempty"`
+	Properties map[string]openAPISchema `json:"properties,omitempty"`
+	Required   []string                 `json:"required,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// envelopeSchemas are the schemas of the httpapi envelopes.
+func envelopeSchemas() map[string]openAPISchema {
+	codes := []string{}
+	for status := 400; status < 600; status++ {
+		if code := string(httpapi.CodeForStatus(status)); !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return map[string]openAPISchema{
+		"Response": {
+			Type:       "object",
+			Properties: map[string]openAPISchema{"data": {}},
+			Required:   []string{"data"},
+		},
+		"ErrorResponse": {
+			Type: "object",
+			Properties: map[string]openAPISchema{"errors": {
+				Type: "object",
+				Properties: map[string]openAPISchema{
+					"message": {Type: "string"},
+					"code":    {Type: "string", Enum: codes},
+				},
+				Required: []string{"message"},
+			}},
+			Required: []string{"errors"},
+		},
+	}
+}
+
+// operationID derives an operation ID from a method and path, e.g.
+// getCountersName for GET /counters/{name}.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		words = []string{"root"}
+	}
+	for _, word := range words {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// pathParams returns the wildcards of a ServeMux path, and the path in
+// OpenAPI form, with {name...} written as {name}.
+func pathParams(path string) (params []string, openAPIPath string) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			name = strings.TrimSuffix(strings.TrimSuffix(name, "}"), "...")
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return params, strings.Join(segments, "/")
+}
+
+// newOpenAPIDocument describes routes as an OpenAPI 3 document. Routes of
+// disabled endpoints are left out, and endpoints protected by auth carry
+// its security schemes.
+func newOpenAPIDocument(routes []route, version string, endpoints endpointConfig, auth authConfig) openAPIDocument {
+	doc := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       "get-container-id",
+			Description: "Reports the container, pod and node identity of the replica serving the request, with diagnostics and testing endpoints. Successful JSON responses are wrapped as {\"data\": ...} and errors as {\"errors\": {\"message\": ..., \"code\": ...}}.",
+			Version:     version,
+		},
+		Tags:       openAPITags,
+		Paths:      map[string]map[string]openAPIOperation{},
+		Components: openAPIComponents{Schemas: envelopeSchemas()},
+	}
+
+	var security []map[string][]string
+	if auth.Token != "" {
+		security = append(security, map[string][]string{"bearerAuth": {}})
+		doc.Components.SecuritySchemes = map[string]openAPISecurityScheme{"bearerAuth": {Type: "http", Scheme: "bearer"}}
+	}
+	if auth.BasicUser != "" {
+		security = append(security, map[string][]string{"basicAuth": {}})
+		if doc.Components.SecuritySchemes == nil {
+			doc.Components.SecuritySchemes = map[string]openAPISecurityScheme{}
+		}
+		doc.Components.SecuritySchemes["basicAuth"] = openAPISecurityScheme{Type: "http", Scheme: "basic"}
+	}
+
+	errorResponse := openAPIResponse{
+		Description: "Error",
+		Content:     map[string]openAPIMediaType{contentTypeJSON: {Schema: openAPISchema{Ref: "#/components/schemas/ErrorResponse"}}},
+	}
+	for _, rt := range routes {
+		methods, path := rt.routeMethods()
+		if !endpoints.enabled(path) {
+			continue
+		}
+		params, openAPIPath := pathParams(path)
+
+		op := openAPIOperation{
+			Summary: rt.summary,
+			Responses: map[string]openAPIResponse{
+				"200": {
+					Description: "OK",
+					Content:     map[string]openAPIMediaType{contentTypeJSON: {Schema: openAPISchema{Ref: "#/components/schemas/Response"}}},
+				},
+				"default": errorResponse,
+			},
+		}
+		if rt.contentType != "" {
+			op.Responses["200"] = openAPIResponse{
+				Description: "OK",
+				Content:     map[string]openAPIMediaType{rt.contentType: {Schema: openAPISchema{Type: "string"}}},
+			}
+		}
+		if rt.formats {
+			for _, format := range responseFormats[1:] {
+				mediaType, _, _ := mime.ParseMediaType(formatContentTypes[format])
+				op.Responses["200"].Content[mediaType] = openAPIMediaType{Schema: openAPISchema{Type: "string"}}
+			}
+		}
+		if rt.tag != "" {
+			op.Tags = []string{rt.tag}
+		}
+		for _, name := range params {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: name, In: "path", Required: true, Schema: openAPISchema{Type: "string"}})
+		}
+		for _, q := range rt.query {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: q.name, In: "query", Description: q.description, Schema: openAPISchema{Type: q.typ}})
+		}
+		if rt.formats {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: "format", In: "query", Description: "Response format; overrides Accept", Schema: openAPISchema{Type: "string", Enum: responseFormats}})
+		}
+		if rt.body != "" {
+			schema := openAPISchema{Type: "string"}
+			if rt.body == contentTypeJSON {
+				schema = openAPISchema{Type: "object"}
+			} else if rt.body == "application/octet-stream" {
+				schema.Format = "binary"
+			}
+			op.RequestBody = &openAPIRequestBody{Content: map[string]openAPIMediaType{rt.body: {Schema: schema}}}
+		}
+		if auth.enabled() && auth.protected(path) {
+			op.Security = security
+		}
+
+		if doc.Paths[openAPIPath] == nil {
+			doc.Paths[openAPIPath] = map[string]openAPIOperation{}
+		}
+		for _, method := range methods {
+			op.OperationID = operationID(method, path)
+			doc.Paths[openAPIPath][strings.ToLower(method)] = op
+		}
+	}
+	return doc
+}
+
+// openAPIRoutes returns the /openapi.json route describing routes and
+// itself, and the /docs route rendering it.
+func openAPIRoutes(routes []route, version string, endpoints endpointConfig, auth authConfig) []route {
+	api := []route{
+		{pattern: "GET /openapi.json", tag: tagAPI, summary: "OpenAPI 3 description of this API", contentType: contentTypeJSON},
+		{pattern: "GET /docs", tag: tagAPI, summary: "Swagger UI for /openapi.json", contentType: "text/html"},
+	}
+	doc := newOpenAPIDocument(append(routes[:len(routes):len(routes)], api...), version, endpoints, auth)
+
+	api[0].handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpapi.WriteJSON(w, doc, http.StatusOK)
+	})
+	api[1].handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, "text/html; charset=utf-8")
+		w.Write([]byte(docsPage))
+	})
+	return api
+}
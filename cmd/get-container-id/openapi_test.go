@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Test operationID builds a camel-case ID from the method and path
+func TestOperationID(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/", "getRoot"},
+		{"GET", "/timestamp_nano", "getTimestampNano"},
+		{"POST", "/counters/{name}", "postCountersName"},
+		{"GET", "/pids/{pid}/identity", "getPidsPidIdentity"},
+		{"GET", "/openapi.json", "getOpenapiJson"},
+	}
+	for _, tt := range tests {
+		if got := operationID(tt.method, tt.path); got != tt.want {
+			t.Errorf("operationID(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// Test pathParams finds the wildcards of a pattern
+func TestPathParams(t *testing.T) {
+	params, path := pathParams("/files/{dir}/{rest...}")
+	if !slices.Equal(params, []string{"dir", "rest"}) || path != "/files/{dir}/{rest}" {
+		t.Errorf("pathParams() = %v, %q; want [dir rest], /files/{dir}/{rest}", params, path)
+	}
+	if params, path := pathParams("/hostname"); params != nil || path != "/hostname" {
+		t.Errorf("pathParams(/hostname) = %v, %q; want no params", params, path)
+	}
+}
+
+// Test newOpenAPIDocument describes the enabled routes with their parameters
+func TestNewOpenAPIDocument(t *testing.T) {
+	routes := []route{
+		{pattern: "/container_id", tag: tagIdentity, summary: "Container ID", formats: true, query: []queryParam{{"short", "boolean", "Short ID"}}},
+		{pattern: "POST /counters/{name}", tag: tagTesting},
+		{pattern: "/fault/errors", methods: []string{"GET", "PUT"}, body: contentTypeJSON},
+		{pattern: "GET /metrics", contentType: "text/plain"},
+		{pattern: "GET /env"},
+	}
+	endpoints := endpointConfig{Disable: []string{"/metrics"}}
+	auth := authConfig{Token: "t0ken", Protect: []string{"/env"}}
+	doc := newOpenAPIDocument(routes, "v1.2.3", endpoints, auth)
+
+	if doc.OpenAPI != openAPIVersion || doc.Info.Version != "v1.2.3" {
+		t.Errorf("openapi = %q, version = %q", doc.OpenAPI, doc.Info.Version)
+	}
+	if _, ok := doc.Paths["/metrics"]; ok {
+		t.Error("disabled /metrics is documented")
+	}
+
+	op := doc.Paths["/container_id"]["get"]
+	if op.OperationID != "getContainerId" || op.Summary != "Container ID" || !slices.Equal(op.Tags, []string{tagIdentity}) {
+		t.Errorf("GET /container_id = %+v", op)
+	}
+	if len(op.Parameters) != 2 || op.Parameters[0].Schema.Type != "boolean" || op.Parameters[1].Name != "format" {
+		t.Errorf("GET /container_id parameters = %+v, want the short and format query parameters", op.Parameters)
+	}
+	for _, mediaType := range []string{contentTypeJSON, "application/yaml", "text/plain", "application/msgpack"} {
+		if _, ok := op.Responses["200"].Content[mediaType]; !ok {
+			t.Errorf("GET /container_id has no %s response", mediaType)
+		}
+	}
+	if op.Security != nil {
+		t.Errorf("GET /container_id security = %v, want none", op.Security)
+	}
+
+	op = doc.Paths["/counters/{name}"]["post"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "name" || op.Parameters[0].In != "path" || !op.Parameters[0].Required {
+		t.Errorf("POST /counters/{name} parameters = %+v, want the required name path parameter", op.Parameters)
+	}
+
+	for _, method := range []string{"get", "put"} {
+		if _, ok := doc.Paths["/fault/errors"][method]; !ok {
+			t.Errorf("/fault/errors has no %s operation", method)
+		}
+	}
+	if body := doc.Paths["/fault/errors"]["put"].RequestBody; body == nil || body.Content[contentTypeJSON].Schema.Type != "object" {
+		t.Errorf("PUT /fault/errors request body = %+v, want a JSON object", body)
+	}
+
+	if got := doc.Paths["/env"]["get"].Security; len(got) != 1 || got[0]["bearerAuth"] == nil {
+		t.Errorf("GET /env security = %v, want bearerAuth", got)
+	}
+	if doc.Components.SecuritySchemes["bearerAuth"].Scheme != "bearer" {
+		t.Errorf("security schemes = %v, want bearerAuth", doc.Components.SecuritySchemes)
+	}
+}
+
+// Test openAPIRoutes serves the document, including itself, and the docs page
+func TestOpenAPIRoutes(t *testing.T) {
+	api := openAPIRoutes([]route{{pattern: "/hostname"}}, "v1.2.3", endpointConfig{}, authConfig{})
+	mux := http.NewServeMux()
+	registerRoutes(mux, api)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get(headerContentType) != contentTypeJSON {
+		t.Fatalf("GET /openapi.json = %d %q", rec.Code, rec.Header().Get(headerContentType))
+	}
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, path := range []string{"/hostname", "/openapi.json", "/docs"} {
+		if _, ok := doc.Paths[path]["get"]; !ok {
+			t.Errorf("GET %s is not documented", path)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(headerContentType), "text/html") || !strings.Contains(rec.Body.String(), `url: "openapi.json"`) {
+		t.Errorf("GET /docs = %d %q", rec.Code, rec.Header().Get(headerContentType))
+	}
+}
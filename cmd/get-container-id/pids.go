@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// identityCommand is the subcommand that resolves the identity of a PID.
+const identityCommand = "identity"
+
+var (
+	ErrInvalidPID  = errors.New("pid must be a positive integer or \"self\"")
+	ErrPIDNotFound = errors.New("no such process")
+)
+
+// pidIdentity is the container and pod identity of one process.
+type pidIdentity struct {
+	PID         string              `json:"pid"`
+	Comm        string              `json:"comm,omitempty"`
+	ContainerID string              `json:"container_id,omitempty"`
+	PodID       string              `json:"pod_id,omitempty"`
+	Cgroups     []cgroup.Membership `json:"cgroups,omitempty"`
+}
+
+// pidResolver resolves process identities from a proc filesystem, usually
+// the host's /proc mounted into a DaemonSet pod.
+type pidResolver struct {
+	procRoot string
+	fsys     fs.FS
+}
+
+// newPIDResolver returns a resolver reading procRoot. An empty procRoot
+// disables resolution.
+func newPIDResolver(procRoot string) *pidResolver {
+	p := &pidResolver{procRoot: procRoot}
+	if procRoot != "" {
+		p.fsys = os.DirFS(procRoot)
+	}
+	return p
+}
+
+// resolve returns the identity of pid. Fields that cannot be detected, for
+// example the pod ID of a host process, are left empty.
+func (p *pidResolver) resolve(pid string) (pidIdentity, error) {
+	if pid != "self" {
+		if n, err := strconv.Atoi(pid); err != nil || n <= 0 {
+			return pidIdentity{}, ErrInvalidPID
+		}
+	}
+
+	if _, err := fs.Stat(p.fsys, pid); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return pidIdentity{}, fmt.Errorf("%w: %s", ErrPIDNotFound, pid)
+		}
+		return pidIdentity{}, err
+	}
+
+	id := pidIdentity{PID: pid}
+	if b, err := fs.ReadFile(p.fsys, pid+"/comm"); err == nil {
+		id.Comm = strings.TrimSpace(string(b))
+	}
+	id.ContainerID, _ = containerid.GetFromFS(p.fsys, pid+"/mountinfo")
+	if id.ContainerID == "" {
+		id.ContainerID, _ = containerid.GetFromCgroupFS(p.fsys, pid+"/cgroup")
+	}
+	id.PodID, _ = podid.GetFromFS(p.fsys, pid+"/mountinfo")
+
+	if info, err := cgroup.Inspect(cgroup.WithFS(p.fsys), cgroup.WithProcDir(pid)); err == nil {
+		id.Cgroups = info.Cgroups
+	}
+	return id, nil
+}
+
+// handleIdentity serves GET /pids/{pid}/identity.
+func (p *pidResolver) handleIdentity(w http.ResponseWriter, r *http.Request) {
+	if p.fsys == nil {
+		httpapi.WriteError(w, "host-agent mode is disabled (start with -procRoot)", http.StatusForbidden)
+		return
+	}
+
+	id, err := p.resolve(r.PathValue("pid"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidPID):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrPIDNotFound):
+			status = http.StatusNotFound
+		}
+		httpapi.WriteError(w, err.Error(), status)
+		return
+	}
+	httpapi.WriteSuccess(w, id)
+}
+
+// runIdentity resolves one PID from the command line and prints it as JSON.
+func runIdentity(args []string, stdout, stderr io.Writer) int {
+	procRoot := os.Getenv("PROC_ROOT")
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+
+	flags := flag.NewFlagSet(identityCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&procRoot, "proc-root", procRoot, "Proc filesystem to read, e.g. /host/proc (also configurable via PROC_ROOT env variable)")
+	pid := flags.String("pid", "self", "Process ID to resolve")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	id, err := newPIDResolver(procRoot).resolve(*pid)
+	if err != nil {
+		fmt.Fprintf(stderr, "identity: %v\n", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(id); err != nil {
+		fmt.Fprintf(stderr, "identity: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testPIDContainerID = "5400841d228a911190db096a8333820b24e64d25bd15a6345a1451549b2a9229"
+	testPIDPodID       = "036da4f7-d553-4eb6-9802-90f81041a412"
+)
+
+// writeProcRoot creates a fake proc filesystem with one containerized
+// process (4242) and one host process (1).
+func writeProcRoot(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"4242/comm": "nginx\n",
+		"4242/mountinfo": "1245 1234 259:2 /var/lib/docker/containers/" + testPIDContainerID + "/hostname /etc/hostname rw,relatime - ext4 /dev/nvme0n1p2 rw\n" +
+			"29 37 0:25 / /var/lib/kubelet/pods/" + testPIDPodID + "/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n",
+		"4242/cgroup": "0::/kubepods/besteffort/pod" + testPIDPodID + "/" + testPIDContainerID + "\n",
+		"1/comm":      "systemd\n",
+		"1/mountinfo": "22 1 259:2 / / rw,relatime shared:1 - ext4 /dev/nvme0n1p2 rw\n",
+		"1/cgroup":    "0::/init.scope\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	return root
+}
+
+// Test resolve reads the identity of container and host processes
+func TestPIDResolver_Resolve(t *testing.T) {
+	p := newPIDResolver(writeProcRoot(t))
+
+	id, err := p.resolve("4242")
+	if err != nil {
+		t.Fatalf("resolve(4242) error = %v", err)
+	}
+	if id.Comm != "nginx" || id.ContainerID != testPIDContainerID || id.PodID != testPIDPodID {
+		t.Errorf("resolve(4242) = %+v, want nginx in container %s, pod %s", id, testPIDContainerID, testPIDPodID)
+	}
+	if len(id.Cgroups) != 1 || id.Cgroups[0].HierarchyID != 0 {
+		t.Errorf("resolve(4242).Cgroups = %+v, want one v2 entry", id.Cgroups)
+	}
+
+	id, err = p.resolve("1")
+	if err != nil {
+		t.Fatalf("resolve(1) error = %v", err)
+	}
+	if id.Comm != "systemd" || id.ContainerID != "" || id.PodID != "" {
+		t.Errorf("resolve(1) = %+v, want host process without container or pod", id)
+	}
+
+	for _, pid := range []string{"0", "-1", "abc", "../1"} {
+		if _, err := p.resolve(pid); !errors.Is(err, ErrInvalidPID) {
+			t.Errorf("resolve(%q) error = %v, want ErrInvalidPID", pid, err)
+		}
+	}
+	if _, err := p.resolve("999"); !errors.Is(err, ErrPIDNotFound) {
+		t.Errorf("resolve(999) error = %v, want ErrPIDNotFound", err)
+	}
+}
+
+// Test /pids/{pid}/identity status codes and response
+func TestPIDResolver_HandleIdentity(t *testing.T) {
+	root := writeProcRoot(t)
+
+	tests := []struct {
+		name     string
+		procRoot string
+		path     string
+		want     int
+	}{
+		{"disabled", "", "/pids/4242/identity", http.StatusForbidden},
+		{"found", root, "/pids/4242/identity", http.StatusOK},
+		{"invalid", root, "/pids/abc/identity", http.StatusBadRequest},
+		{"not found", root, "/pids/999/identity", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /pids/{pid}/identity", newPIDResolver(tt.procRoot).handleIdentity)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("GET %s status = %d, want %d (body: %s)", tt.path, w.Code, tt.want, w.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data pidIdentity `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if resp.Data.PID != "4242" || resp.Data.ContainerID != testPIDContainerID {
+				t.Errorf("data = %+v, want pid 4242 in container %s", resp.Data, testPIDContainerID)
+			}
+		})
+	}
+}
+
+// Test the identity subcommand prints JSON and reports missing processes
+func TestRunIdentity(t *testing.T) {
+	root := writeProcRoot(t)
+
+	var stdout, stderr bytes.Buffer
+	if got := runIdentity([]string{"--proc-root", root, "--pid", "4242"}, &stdout, &stderr); got != 0 {
+		t.Fatalf("runIdentity() = %d, want 0 (stderr: %s)", got, stderr.String())
+	}
+	var id pidIdentity
+	if err := json.Unmarshal(stdout.Bytes(), &id); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if id.PodID != testPIDPodID {
+		t.Errorf("pod_id = %q, want %q", id.PodID, testPIDPodID)
+	}
+
+	t.Setenv("PROC_ROOT", root)
+	stdout.Reset()
+	if got := runIdentity([]string{"-pid", "999"}, &stdout, &stderr); got != 1 {
+		t.Errorf("runIdentity(999) = %d, want 1", got)
+	}
+	if got := runIdentity([]string{"--nope"}, &stdout, &stderr); got != 2 {
+		t.Errorf("runIdentity(--nope) = %d, want 2", got)
+	}
+}
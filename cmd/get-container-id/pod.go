@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/k8sclient"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// ErrPodUIDMismatch is returned when the pod read from the API server is not
+// the pod the process runs in, e.g. because the hostname differs from the
+// pod name.
+var ErrPodUIDMismatch = errors.New("pod UID does not match the detected pod ID")
+
+// podDocument is the current pod as reported by the Kubernetes API.
+type podDocument struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	UID               string            `json:"uid"`
+	NodeName          string            `json:"node_name,omitempty"`
+	Phase             string            `json:"phase"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	OwnerReferences   []podOwner        `json:"owner_references,omitempty"`
+	ContainerStatuses []podContainer    `json:"container_statuses,omitempty"`
+}
+
+type podOwner struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller bool   `json:"controller,omitempty"`
+}
+
+type podContainer struct {
+	Name         string `json:"name"`
+	ContainerID  string `json:"container_id,omitempty"`
+	Image        string `json:"image"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restart_count"`
+	State        string `json:"state"`
+}
+
+// podAPI serves GET /pod from the Kubernetes API. A nil client means
+// -enableK8sAPI is off.
+type podAPI struct {
+	client *k8sclient.Client
+
+	// podName, namespace and podID locate the current pod; tests replace them.
+	podName   func() (string, error)
+	namespace func() (string, error)
+	podID     func(ctx context.Context) (string, error)
+}
+
+func newPodAPI(client *k8sclient.Client) *podAPI {
+	return &podAPI{
+		client: client,
+		podName: func() (string, error) {
+			// The hostname is the pod name unless spec.hostname overrides it.
+			if name, err := podid.GetPodName(); err == nil {
+				return name, nil
+			}
+			return os.Hostname()
+		},
+		namespace: func() (string, error) { return podid.GetNamespace() },
+		podID: func(ctx context.Context) (string, error) {
+			return podid.GetContext(ctx)
+		},
+	}
+}
+
+// lookup reads the current pod and checks its UID against the pod ID
+// detected from the mounts, when there is one.
+func (p *podAPI) lookup(ctx context.Context) (k8sclient.Pod, error) {
+	name, err := p.podName()
+	if err != nil {
+		return k8sclient.Pod{}, err
+	}
+	namespace, err := p.namespace()
+	if err != nil {
+		return k8sclient.Pod{}, err
+	}
+
+	pod, err := p.client.GetPod(ctx, namespace, name)
+	if err != nil {
+		return k8sclient.Pod{}, err
+	}
+	if uid, err := p.podID(ctx); err == nil && uid != pod.Metadata.UID {
+		return k8sclient.Pod{}, fmt.Errorf("%w: pod %s/%s has UID %s, detected %s", ErrPodUIDMismatch, namespace, name, pod.Metadata.UID, uid)
+	}
+	return pod, nil
+}
+
+// handlePod serves GET /pod.
+func (p *podAPI) handlePod(w http.ResponseWriter, r *http.Request) {
+	if p.client == nil {
+		httpapi.WriteError(w, "Kubernetes API access is disabled (start with -enableK8sAPI)", http.StatusForbidden)
+		return
+	}
+
+	pod, err := p.lookup(r.Context())
+	if err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, podid.ErrPodNameNotFound), errors.Is(err, podid.ErrNamespaceNotFound), errors.Is(err, k8sclient.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrPodUIDMismatch):
+			status = http.StatusConflict
+		}
+		httpapi.WriteError(w, err.Error(), status)
+		return
+	}
+
+	httpapi.WriteSuccess(w, newPodDocument(pod))
+}
+
+func newPodDocument(pod k8sclient.Pod) podDocument {
+	doc := podDocument{
+		Name:        pod.Metadata.Name,
+		Namespace:   pod.Metadata.Namespace,
+		UID:         pod.Metadata.UID,
+		NodeName:    pod.Spec.NodeName,
+		Phase:       pod.Status.Phase,
+		Labels:      pod.Metadata.Labels,
+		Annotations: pod.Metadata.Annotations,
+	}
+	for _, o := range pod.Metadata.OwnerReferences {
+		doc.OwnerReferences = append(doc.OwnerReferences, podOwner{Kind: o.Kind, Name: o.Name, UID: o.UID, Controller: o.Controller})
+	}
+	for _, c := range pod.Status.ContainerStatuses {
+		doc.ContainerStatuses = append(doc.ContainerStatuses, podContainer{
+			Name:         c.Name,
+			ContainerID:  c.ContainerID,
+			Image:        c.Image,
+			Ready:        c.Ready,
+			RestartCount: c.RestartCount,
+			State:        c.State.String(),
+		})
+	}
+	return doc
+}
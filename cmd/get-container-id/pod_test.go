@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/k8sclient"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const testPodUID = "9f1c2d3a-5b6e-4f70-8a9b-0c1d2e3f4a5b"
+
+// newTestPodAPI serves the pod web-0 in namespace default from a fake API
+// server and locates the current pod as web-0 with the given detected UID.
+func newTestPodAPI(t *testing.T, detectedUID string) *podAPI {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/pods/web-0" {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"metadata":{"name":"web-0","namespace":"default","uid":"` + testPodUID + `","labels":{"app":"web"},` +
+			`"ownerReferences":[{"apiVersion":"apps/v1","kind":"StatefulSet","name":"web","uid":"1a2b","controller":true}]},` +
+			`"spec":{"nodeName":"node-1"},` +
+			`"status":{"phase":"Running","containerStatuses":[{"name":"web","containerID":"containerd://4b8e","image":"web:1","ready":true,"restartCount":1,"state":{"running":{}}}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := newPodAPI(k8sclient.New(srv.URL, "", srv.Client()))
+	p.podName = func() (string, error) { return "web-0", nil }
+	p.namespace = func() (string, error) { return "default", nil }
+	p.podID = func(context.Context) (string, error) {
+		if detectedUID == "" {
+			return "", podid.ErrPodIDNotFound
+		}
+		return detectedUID, nil
+	}
+	return p
+}
+
+// Test /pod reports labels, owners and container statuses
+func TestPodAPI_HandlePod(t *testing.T) {
+	p := newTestPodAPI(t, testPodUID)
+
+	w := httptest.NewRecorder()
+	p.handlePod(w, httptest.NewRequest(http.MethodGet, "/pod", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var resp struct {
+		Data podDocument `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	doc := resp.Data
+	if doc.UID != testPodUID || doc.NodeName != "node-1" || doc.Labels["app"] != "web" {
+		t.Errorf("pod = %+v, want web-0 on node-1", doc)
+	}
+	if len(doc.OwnerReferences) != 1 || doc.OwnerReferences[0].Kind != "StatefulSet" || !doc.OwnerReferences[0].Controller {
+		t.Errorf("owner_references = %+v, want the StatefulSet", doc.OwnerReferences)
+	}
+	if len(doc.ContainerStatuses) != 1 || doc.ContainerStatuses[0].State != "running" || doc.ContainerStatuses[0].ContainerID != "containerd://4b8e" {
+		t.Errorf("container_statuses = %+v, want one running container", doc.ContainerStatuses)
+	}
+}
+
+// Test /pod status codes when disabled, missing or not the current pod
+func TestPodAPI_HandlePod_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(t *testing.T) *podAPI
+		want int
+	}{
+		{"disabled", func(*testing.T) *podAPI { return newPodAPI(nil) }, http.StatusForbidden},
+		{"uid mismatch", func(t *testing.T) *podAPI { return newTestPodAPI(t, "other-uid") }, http.StatusConflict},
+		{"pod id unknown", func(t *testing.T) *podAPI { return newTestPodAPI(t, "") }, http.StatusOK},
+		{"not found", func(t *testing.T) *podAPI {
+			p := newTestPodAPI(t, "")
+			p.podName = func() (string, error) { return "web-1", nil }
+			return p
+		}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.api(t).handlePod(w, httptest.NewRequest(http.MethodGet, "/pod", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
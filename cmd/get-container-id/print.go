@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// printFlag switches the binary from serving HTTP to printing its identity.
+const printFlag = "print"
+
+const (
+	printContainer = "container"
+	printPod       = "pod"
+	printAll       = "all"
+
+	printFormatText = "text"
+	printFormatJSON = "json"
+)
+
+// isPrintMode reports whether args ask for -print, in any position.
+func isPrintMode(args []string) bool {
+	for _, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if name == printFlag || strings.HasPrefix(name, printFlag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// idPrinter detects identities for -print; tests replace the lookups.
+type idPrinter struct {
+	containerID func(ctx context.Context) (string, error)
+	podID       func(ctx context.Context) (string, error)
+}
+
+func newIDPrinter() *idPrinter {
+	return &idPrinter{
+		containerID: getContainerID,
+		podID: func(ctx context.Context) (string, error) {
+			return podid.GetContext(ctx)
+		},
+	}
+}
+
+// runPrint detects the requested identities, prints them and returns the
+// process exit code: 0 when every one was found, 1 otherwise and 2 for usage
+// errors. It lets init containers and entrypoint scripts use the binary.
+func runPrint(args []string, stdout, stderr io.Writer) int {
+	return newIDPrinter().run(args, stdout, stderr)
+}
+
+func (p *idPrinter) run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(printFlag, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	what := fs.String(printFlag, printAll, "Identity to print: container, pod or all")
+	format := fs.String("format", printFormatText, "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	type lookup struct {
+		key    string
+		detect func(ctx context.Context) (string, error)
+	}
+	var lookups []lookup
+	switch *what {
+	case printContainer:
+		lookups = []lookup{{"container_id", p.containerID}}
+	case printPod:
+		lookups = []lookup{{"pod_id", p.podID}}
+	case printAll:
+		lookups = []lookup{{"container_id", p.containerID}, {"pod_id", p.podID}}
+	default:
+		fmt.Fprintf(stderr, "print: unknown identity %q, want container, pod or all\n", *what)
+		return 2
+	}
+	if *format != printFormatText && *format != printFormatJSON {
+		fmt.Fprintf(stderr, "print: unknown format %q, want text or json\n", *format)
+		return 2
+	}
+
+	ctx := context.Background()
+	found := make(map[string]string, len(lookups))
+	var keys []string
+	code := 0
+	for _, l := range lookups {
+		id, err := l.detect(ctx)
+		if err != nil {
+			fmt.Fprintf(stderr, "print: %s: %v\n", l.key, err)
+			code = 1
+			continue
+		}
+		found[l.key] = id
+		keys = append(keys, l.key)
+	}
+
+	if *format == printFormatJSON {
+		if err := json.NewEncoder(stdout).Encode(found); err != nil {
+			fmt.Fprintf(stderr, "print: %v\n", err)
+			return 1
+		}
+		return code
+	}
+
+	// A single identity is printed bare so scripts can capture it with
+	// $(...); "all" prints key=value lines.
+	for _, key := range keys {
+		if len(lookups) == 1 {
+			fmt.Fprintln(stdout, found[key])
+			continue
+		}
+		fmt.Fprintf(stdout, "%s=%s\n", key, found[key])
+	}
+	return code
+}
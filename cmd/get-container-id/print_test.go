@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func stubPrinter(containerErr, podErr error) *idPrinter {
+	return &idPrinter{
+		containerID: func(context.Context) (string, error) {
+			if containerErr != nil {
+				return "", containerErr
+			}
+			return "abc123", nil
+		},
+		podID: func(context.Context) (string, error) {
+			if podErr != nil {
+				return "", podErr
+			}
+			return "pod-uid", nil
+		},
+	}
+}
+
+// Test isPrintMode finds -print in any position and form
+func TestIsPrintMode(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-httpPort", "9090"}, false},
+		{[]string{"--print", "pod"}, true},
+		{[]string{"-print=container"}, true},
+		{[]string{"--format", "json", "-print", "all"}, true},
+		{[]string{"print"}, false},
+		{[]string{"-printer"}, false},
+	}
+	for _, tt := range tests {
+		if got := isPrintMode(tt.args); got != tt.want {
+			t.Errorf("isPrintMode(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+// Test print writes the requested identities and exits non-zero on failure
+func TestIDPrinter_Run(t *testing.T) {
+	errMissing := errors.New("not found")
+	tests := []struct {
+		name         string
+		args         []string
+		containerErr error
+		podErr       error
+		want         int
+		wantOut      string
+	}{
+		{"container text", []string{"--print", "container"}, nil, nil, 0, "abc123\n"},
+		{"pod text", []string{"--print", "pod"}, nil, nil, 0, "pod-uid\n"},
+		{"all text", []string{"--print", "all"}, nil, nil, 0, "container_id=abc123\npod_id=pod-uid\n"},
+		{"all json", []string{"--print", "all", "--format", "json"}, nil, nil, 0, `{"container_id":"abc123","pod_id":"pod-uid"}` + "\n"},
+		{"container json", []string{"-print=container", "-format=json"}, nil, nil, 0, `{"container_id":"abc123"}` + "\n"},
+		{"container missing", []string{"--print", "container"}, errMissing, nil, 1, ""},
+		{"all partial", []string{"--print", "all"}, nil, errMissing, 1, "container_id=abc123\n"},
+		{"unknown identity", []string{"--print", "node"}, nil, nil, 2, ""},
+		{"unknown format", []string{"--print", "pod", "--format", "yaml"}, nil, nil, 2, ""},
+		{"bad flag", []string{"--print", "pod", "--nope"}, nil, nil, 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := stubPrinter(tt.containerErr, tt.podErr).run(tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("run(%q) = %d, want %d (stderr: %s)", tt.args, got, tt.want, stderr.String())
+			}
+			if stdout.String() != tt.wantOut {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	// defaultProbeTimeout and maxProbeTimeout bound ?timeout= of /probe.
+	defaultProbeTimeout = 2 * time.Second
+	maxProbeTimeout     = 30 * time.Second
+
+	// maxProbeBody is how much of an HTTP probe's response body is read, so
+	// the timing covers the transfer start without downloading large bodies.
+	maxProbeBody = 64 << 10
+)
+
+// probeTLS describes the TLS connection of a probe.
+type probeTLS struct {
+	Version     string    `json:"version"`
+	CipherSuite string    `json:"cipher_suite"`
+	ServerName  string    `json:"server_name,omitempty"`
+	ALPN        string    `json:"alpn,omitempty"`
+	Subject     string    `json:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	NotAfter    time.Time `json:"not_after,omitzero"`
+	// Verified is false when ?insecure=true skipped verification.
+	Verified bool `json:"verified"`
+}
+
+// probeTimings are the phases of a probe in seconds; phases that did not
+// happen are omitted.
+type probeTimings struct {
+	DNSSeconds       float64 `json:"dns_seconds,omitempty"`
+	ConnectSeconds   float64 `json:"connect_seconds,omitempty"`
+	TLSSeconds       float64 `json:"tls_seconds,omitempty"`
+	FirstByteSeconds float64 `json:"first_byte_seconds,omitempty"`
+	TotalSeconds     float64 `json:"total_seconds"`
+}
+
+// probeResult is the outcome of a /probe. A failed probe is still a result:
+// its error and timing are what the caller is debugging.
+type probeResult struct {
+	URL        string       `json:"url"`
+	Protocol   string       `json:"protocol"`
+	Address    string       `json:"address"`
+	ResolvedIP string       `json:"resolved_ip,omitempty"`
+	Reachable  bool         `json:"reachable"`
+	Status     int          `json:"status,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	Timings    probeTimings `json:"timings"`
+	TLS        *probeTLS    `json:"tls,omitempty"`
+}
+
+// probeTarget is a parsed ?url= of /probe: an http or https URL, or a
+// tcp://host:port address.
+type probeTarget struct {
+	url      *url.URL
+	protocol string
+	address  string
+}
+
+// parseProbeTarget parses raw, which must name a host and, for tcp, a port.
+func parseProbeTarget(raw string) (probeTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return probeTarget{}, fmt.Errorf("invalid url %q: %w", raw, err)
+	}
+	if u.Hostname() == "" {
+		return probeTarget{}, fmt.Errorf("invalid url %q: want http://, https:// or tcp:// with a host", raw)
+	}
+
+	t := probeTarget{url: u, protocol: u.Scheme, address: u.Host}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+			t.address = net.JoinHostPort(u.Hostname(), port)
+		}
+	case "tcp":
+		if _, err := strconv.ParseUint(u.Port(), 10, 16); err != nil {
+			return probeTarget{}, fmt.Errorf("invalid url %q: tcp:// needs a port", raw)
+		}
+	default:
+		return probeTarget{}, fmt.Errorf("invalid url %q: want http://, https:// or tcp://", raw)
+	}
+	return t, nil
+}
+
+// run dials the target, and for http and https sends a GET request,
+// recording the timing of each phase.
+func (t probeTarget) run(ctx context.Context, insecure bool) probeResult {
+	result := probeResult{URL: t.url.String(), Protocol: t.protocol, Address: t.address}
+	start := time.Now()
+	since := func(from time.Time) float64 {
+		if from.IsZero() {
+			return 0
+		}
+		return time.Since(from).Seconds()
+	}
+
+	// The transport dials in its own goroutine, which may still report to
+	// the trace after a timed out request returns.
+	var mu sync.Mutex
+	var dnsStart, connectStart, tlsStart time.Time
+	record := func(fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn()
+	}
+	finish := func(err error) probeResult {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Timings.TotalSeconds = since(start)
+		return result
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { record(func() { dnsStart = time.Now() }) },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() { result.Timings.DNSSeconds = since(dnsStart) })
+		},
+		ConnectStart: func(string, string) { record(func() { connectStart = time.Now() }) },
+		ConnectDone: func(_, addr string, err error) {
+			record(func() {
+				result.Timings.ConnectSeconds = since(connectStart)
+				if err == nil {
+					result.ResolvedIP, _, _ = net.SplitHostPort(addr)
+				}
+			})
+		},
+		TLSHandshakeStart: func() { record(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			record(func() {
+				result.Timings.TLSSeconds = since(tlsStart)
+				if err == nil {
+					result.TLS = newProbeTLS(state, !insecure)
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			record(func() { result.Timings.FirstByteSeconds = since(start) })
+		},
+	}
+	ctx = httptrace.WithClientTrace(ctx, trace)
+
+	if t.protocol == "tcp" {
+		// The dialer reports its DNS and connect phases to the trace too.
+		conn, err := new(net.Dialer).DialContext(ctx, "tcp", t.address)
+		if err != nil {
+			return finish(err)
+		}
+		conn.Close()
+		record(func() { result.Reachable = true })
+		return finish(nil)
+	}
+
+	client := &http.Client{
+		// No proxy: the probe tests the pod's own egress path.
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: insecure},
+		},
+		// The probe reports the first response, not where it redirects to.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url.String(), nil)
+	if err != nil {
+		return finish(err)
+	}
+	req.Header.Set("User-Agent", "get-container-id-probe")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return finish(err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxProbeBody))
+	resp.Body.Close()
+
+	record(func() {
+		result.Reachable = true
+		result.Status = resp.StatusCode
+	})
+	return finish(nil)
+}
+
+func newProbeTLS(state tls.ConnectionState, verified bool) *probeTLS {
+	t := &probeTLS{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		ALPN:        state.NegotiatedProtocol,
+		Verified:    verified,
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		t.Subject = leaf.Subject.String()
+		t.Issuer = leaf.Issuer.String()
+		t.DNSNames = leaf.DNSNames
+		t.NotAfter = leaf.NotAfter.UTC()
+	}
+	return t
+}
+
+// handleProbe serves GET /probe?url=&timeout=: it connects to url from
+// inside the pod, so egress policies and service-to-service reachability
+// can be tested from this replica. http and https URLs are fetched with a
+// GET request; tcp://host:port only opens a connection. timeout is a
+// duration such as 2s, at most 30s; insecure=true skips TLS verification.
+func handleProbe(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	target, err := parseProbeTarget(strings.TrimSpace(query.Get("url")))
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	timeout := defaultProbeTimeout
+	if s := query.Get("timeout"); s != "" {
+		if timeout, err = time.ParseDuration(s); err != nil || timeout <= 0 || timeout > maxProbeTimeout {
+			httpapi.WriteError(w, "timeout must be a positive duration of at most "+maxProbeTimeout.String(), http.StatusBadRequest)
+			return
+		}
+	}
+	insecure, _ := strconv.ParseBool(query.Get("insecure"))
+
+	// A probe may outlast the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	httpapi.WriteSuccess(w, target.run(ctx, insecure))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// getProbe serves /probe?query and decodes the result.
+func getProbe(t *testing.T, query url.Values) probeResult {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleProbe(w, httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("/probe?%s status = %d, want %d: %s", query.Encode(), w.Code, http.StatusOK, w.Body)
+	}
+	var resp struct {
+		Data probeResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return resp.Data
+}
+
+// Test /probe fetches http URLs and reports the status and resolved IP
+func TestHandleProbe_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	got := getProbe(t, url.Values{"url": {srv.URL + "/path"}})
+	if !got.Reachable || got.Status != http.StatusFound || got.Error != "" {
+		t.Errorf("probe = %+v, want a reachable 302 without following the redirect", got)
+	}
+	if got.Protocol != "http" || got.Address != srv.Listener.Addr().String() || got.ResolvedIP != "127.0.0.1" {
+		t.Errorf("probe = %+v, want http to %s via 127.0.0.1", got, srv.Listener.Addr())
+	}
+	if got.Timings.TotalSeconds <= 0 || got.Timings.FirstByteSeconds <= 0 || got.TLS != nil {
+		t.Errorf("probe timings = %+v, tls %+v; want total and first byte timings without TLS", got.Timings, got.TLS)
+	}
+}
+
+// Test /probe reports TLS details and verification failures
+func TestHandleProbe_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	got := getProbe(t, url.Values{"url": {srv.URL}})
+	if got.Reachable || !strings.Contains(got.Error, "certificate") {
+		t.Errorf("probe = %+v, want an unreachable result with a certificate error", got)
+	}
+
+	got = getProbe(t, url.Values{"url": {srv.URL}, "insecure": {"true"}})
+	if !got.Reachable || got.Status != http.StatusOK || got.TLS == nil {
+		t.Fatalf("insecure probe = %+v, want a reachable 200 with TLS details", got)
+	}
+	if got.TLS.Verified || got.TLS.Version == "" || got.TLS.CipherSuite == "" || got.TLS.NotAfter.IsZero() || got.Timings.TLSSeconds <= 0 {
+		t.Errorf("probe tls = %+v, timings %+v; want unverified TLS details and timing", got.TLS, got.Timings)
+	}
+}
+
+// Test /probe opens TCP connections to tcp:// addresses
+func TestHandleProbe_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := ln.Addr().String()
+
+	got := getProbe(t, url.Values{"url": {"tcp://" + open}})
+	if !got.Reachable || got.ResolvedIP != "127.0.0.1" || got.Status != 0 || got.Timings.ConnectSeconds <= 0 {
+		t.Errorf("probe = %+v, want a reachable TCP address with connect timing", got)
+	}
+
+	ln.Close()
+	got = getProbe(t, url.Values{"url": {"tcp://" + open}, "timeout": {"1s"}})
+	if got.Reachable || got.Error == "" {
+		t.Errorf("probe of a closed port = %+v, want an unreachable result with an error", got)
+	}
+}
+
+// Test /probe rejects invalid URLs and timeouts
+func TestHandleProbe_Invalid(t *testing.T) {
+	for _, query := range []url.Values{
+		{},
+		{"url": {"ftp://example.com"}},
+		{"url": {"tcp://example.com"}},
+		{"url": {"http://"}},
+		{"url": {"http://example.com"}, "timeout": {"1m"}},
+		{"url": {"http://example.com"}, "timeout": {"soon"}},
+	} {
+		w := httptest.NewRecorder()
+		handleProbe(w, httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("/probe?%s status = %d, want %d", query.Encode(), w.Code, http.StatusBadRequest)
+		}
+	}
+}
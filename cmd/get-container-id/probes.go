@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// startupGate reports the server as still starting until a fixed delay has
+// elapsed, so probe configuration can be tested deterministically.
+type startupGate struct {
+	readyAt time.Time
+
+	// now is replaceable for tests.
+	now func() time.Time
+}
+
+func newStartupGate(delay time.Duration) *startupGate {
+	return &startupGate{readyAt: time.Now().Add(delay), now: time.Now}
+}
+
+// remaining returns how long the server will keep reporting itself as starting.
+func (g *startupGate) remaining() time.Duration {
+	if d := g.readyAt.Sub(g.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// check writes a 503 countdown and returns false while the server is starting.
+func (g *startupGate) check(w http.ResponseWriter) bool {
+	remaining := g.remaining()
+	if remaining == 0 {
+		return true
+	}
+
+	seconds := int(math.Ceil(remaining.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	fmt.Fprintf(w, "starting: %ds remaining", seconds)
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test startupGate reports 503 with a countdown until the delay elapses
+func TestStartupGate(t *testing.T) {
+	now := time.Now()
+	g := &startupGate{readyAt: now.Add(30 * time.Second), now: func() time.Time { return now }}
+
+	w := httptest.NewRecorder()
+	if g.check(w) {
+		t.Fatal("check() = true during startup delay, want false")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("check() status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Body.String(); got != "starting: 30s remaining" {
+		t.Errorf("check() body = %q, want %q", got, "starting: 30s remaining")
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("check() Retry-After = %q, want %q", got, "30")
+	}
+
+	now = now.Add(31 * time.Second)
+	w = httptest.NewRecorder()
+	if !g.check(w) {
+		t.Error("check() = false after startup delay, want true")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("check() after delay wrote %q, want nothing", w.Body.String())
+	}
+}
+
+// Test newStartupGate without delay is immediately ready
+func TestStartupGate_NoDelay(t *testing.T) {
+	if !newStartupGate(0).check(httptest.NewRecorder()) {
+		t.Error("check() with zero delay = false, want true")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// connSeq numbers accepted connections.
+var connSeq atomic.Uint64
+
+// connStats counts the requests served on one connection. Over HTTP/2 each
+// request is a stream, so the counts show how a client multiplexes them.
+type connStats struct {
+	id     uint64
+	total  atomic.Int64
+	active atomic.Int64
+}
+
+type connStatsKey struct{}
+type streamKey struct{}
+
+// withConnStats is the http.Server ConnContext hook that attaches fresh
+// connection statistics to every accepted connection.
+func withConnStats(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connStatsKey{}, &connStats{id: connSeq.Add(1)})
+}
+
+// connStatsMiddleware counts each request against its connection and records
+// its ordinal on the connection in the request context.
+func connStatsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats, ok := r.Context().Value(connStatsKey{}).(*connStats)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		stream := stats.total.Add(1)
+		stats.active.Add(1)
+		defer stats.active.Add(-1)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), streamKey{}, stream)))
+	})
+}
+
+// protocolInfo describes the protocol a request arrived over for /echo.
+type protocolInfo struct {
+	Proto string `json:"proto"`
+	// ALPN is the protocol negotiated in the TLS handshake.
+	ALPN string `json:"alpn,omitempty"`
+	// H2C is set for HTTP/2 over cleartext TCP.
+	H2C bool `json:"h2c"`
+
+	Connection    uint64 `json:"connection,omitempty"`
+	Stream        int64  `json:"stream,omitempty"`
+	ActiveStreams int64  `json:"active_streams,omitempty"`
+}
+
+func newProtocolInfo(r *http.Request) protocolInfo {
+	info := protocolInfo{
+		Proto: r.Proto,
+		H2C:   r.ProtoMajor == 2 && r.TLS == nil,
+	}
+	if r.TLS != nil {
+		info.ALPN = r.TLS.NegotiatedProtocol
+	}
+	if stats, ok := r.Context().Value(connStatsKey{}).(*connStats); ok {
+		info.Connection = stats.id
+		info.ActiveStreams = stats.active.Load()
+	}
+	info.Stream, _ = r.Context().Value(streamKey{}).(int64)
+	return info
+}
+
+// serverProtocols returns the protocols the server accepts: HTTP/1.1 always,
+// HTTP/2 over TLS unless disabled, and HTTP/2 over cleartext with h2c.
+func serverProtocols(useTLS, http2, h2c bool) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(useTLS && http2)
+	p.SetUnencryptedHTTP2(!useTLS && h2c)
+	return p
+}
+
+// alpnProtocols returns the ALPN protocol IDs to offer for p, most preferred
+// first.
+func alpnProtocols(p *http.Protocols) []string {
+	if p.HTTP2() {
+		return []string{"h2", "http/1.1"}
+	}
+	return []string{"http/1.1"}
+}
+
+// protocolNames lists the protocols in p for /info.
+func protocolNames(p *http.Protocols) []string {
+	names := []string{}
+	if p.HTTP1() {
+		names = append(names, "http/1.1")
+	}
+	if p.HTTP2() {
+		names = append(names, "h2")
+	}
+	if p.UnencryptedHTTP2() {
+		names = append(names, "h2c")
+	}
+	return names
+}
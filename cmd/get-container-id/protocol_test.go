@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// Test h2c requests report the protocol and their stream on the connection
+func TestProtocolInfo_H2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Protocols:   serverProtocols(false, true, true),
+		ConnContext: withConnStats,
+		Handler: connStatsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(newProtocolInfo(r))
+		})),
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	get := func() protocolInfo {
+		t.Helper()
+		resp, err := client.Get("http://" + ln.Addr().String())
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		defer resp.Body.Close()
+		var info protocolInfo
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			t.Fatalf("decode error = %v", err)
+		}
+		return info
+	}
+
+	first, second := get(), get()
+	if first.Proto != "HTTP/2.0" || !first.H2C {
+		t.Errorf("protocol = %s (h2c %v), want HTTP/2.0 over h2c", first.Proto, first.H2C)
+	}
+	if first.Connection == 0 || second.Connection != first.Connection {
+		t.Errorf("connections = %d, %d, want the same non-zero connection", first.Connection, second.Connection)
+	}
+	if first.Stream != 1 || second.Stream != 2 {
+		t.Errorf("streams = %d, %d, want 1, 2", first.Stream, second.Stream)
+	}
+	if first.ActiveStreams != 1 {
+		t.Errorf("active streams = %d, want 1", first.ActiveStreams)
+	}
+}
+
+// Test serverProtocols only offers HTTP/2 where it can be negotiated
+func TestServerProtocols(t *testing.T) {
+	tests := []struct {
+		name                string
+		useTLS, h2, h2c     bool
+		wantNames, wantALPN []string
+	}{
+		{"plain", false, true, false, []string{"http/1.1"}, []string{"http/1.1"}},
+		{"plain h2c", false, true, true, []string{"http/1.1", "h2c"}, []string{"http/1.1"}},
+		{"tls", true, true, false, []string{"http/1.1", "h2"}, []string{"h2", "http/1.1"}},
+		{"tls without http2", true, false, false, []string{"http/1.1"}, []string{"http/1.1"}},
+		{"tls ignores h2c", true, true, true, []string{"http/1.1", "h2"}, []string{"h2", "http/1.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := serverProtocols(tt.useTLS, tt.h2, tt.h2c)
+			if got := protocolNames(p); !reflect.DeepEqual(got, tt.wantNames) {
+				t.Errorf("protocolNames() = %v, want %v", got, tt.wantNames)
+			}
+			if got := alpnProtocols(p); !reflect.DeepEqual(got, tt.wantALPN) {
+				t.Errorf("alpnProtocols() = %v, want %v", got, tt.wantALPN)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// proxyHeaderTimeout bounds how long a client may take to send the PROXY header.
+	proxyHeaderTimeout = 5 * time.Second
+
+	// proxyV1MaxLength is the longest valid PROXY protocol v1 header, including CRLF.
+	proxyV1MaxLength = 107
+)
+
+var (
+	ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+	proxyV1Signature = []byte("PROXY")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// proxyProtoListener wraps a net.Listener and strips the HAProxy PROXY protocol
+// (v1 or v2) header from every accepted connection, so RemoteAddr reports the
+// original client address.
+type proxyProtoListener struct {
+	net.Listener
+}
+
+func (l *proxyProtoListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyProtoConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyProtoConn parses the PROXY header lazily on first use, so a slow client
+// never blocks the accept loop.
+type proxyProtoConn struct {
+	net.Conn
+
+	reader     *bufio.Reader
+	once       sync.Once
+	remoteAddr net.Addr
+	localAddr  net.Addr
+	err        error
+}
+
+func (c *proxyProtoConn) init() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+		c.remoteAddr, c.localAddr, c.err = readProxyHeader(c.reader)
+		c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *proxyProtoConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *proxyProtoConn) RemoteAddr() net.Addr {
+	c.init()
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyProtoConn) LocalAddr() net.Addr {
+	c.init()
+	if c.localAddr != nil {
+		return c.localAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+// readProxyHeader consumes a PROXY protocol v1 or v2 header from r and returns
+// the source and destination addresses it carries. Both addresses are nil for
+// LOCAL (v2) and UNKNOWN (v1) connections, which should keep the socket addresses.
+func readProxyHeader(r *bufio.Reader) (src, dst net.Addr, err error) {
+	// Peek no further than the shortest header needs, so a client that
+	// sends only the header and waits is not blocked on.
+	peek, err := r.Peek(len(proxyV1Signature))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	if bytes.Equal(peek, proxyV1Signature) {
+		return readProxyHeaderV1(r)
+	}
+	if bytes.HasPrefix(proxyV2Signature, peek) {
+		peek, err = r.Peek(len(proxyV2Signature))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+		}
+		if bytes.Equal(peek, proxyV2Signature) {
+			return readProxyHeaderV2(r)
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: missing signature", ErrInvalidProxyHeader)
+}
+
+// readProxyHeaderV1 parses the human-readable header, e.g.
+// "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readProxyHeaderV1(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, fmt.Errorf("%w: v1 header not terminated by CRLF", ErrInvalidProxyHeader)
+	}
+	if !bytes.HasPrefix(line, []byte("PROXY ")) {
+		return nil, nil, fmt.Errorf("%w: malformed v1 header %q", ErrInvalidProxyHeader, line)
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, nil, fmt.Errorf("%w: malformed v1 header %q", ErrInvalidProxyHeader, line)
+	}
+
+	src, err := parseProxyV1Addr(fields[2], fields[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	dst, err := parseProxyV1Addr(fields[3], fields[5])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return src, dst, nil
+}
+
+func parseProxyV1Addr(host, port string) (*net.TCPAddr, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidProxyHeader, host)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid port %q", ErrInvalidProxyHeader, port)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(p)}, nil
+}
+
+// readProxyHeaderV2 parses the binary header. TLVs following the address block
+// are skipped.
+func readProxyHeaderV2(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	if header[12]>>4 != 2 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidProxyHeader, header[12]>>4)
+	}
+	command := header[12] & 0x0f
+	family := header[13]
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	switch command {
+	case 0x0: // LOCAL: health checks from the proxy itself
+		return nil, nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported command %d", ErrInvalidProxyHeader, command)
+	}
+
+	var ipLen int
+	switch family >> 4 {
+	case 0x1:
+		ipLen = net.IPv4len
+	case 0x2:
+		ipLen = net.IPv6len
+	default:
+		// AF_UNSPEC and AF_UNIX carry no usable IP addresses.
+		return nil, nil, nil
+	}
+
+	if len(payload) < 2*ipLen+4 {
+		return nil, nil, fmt.Errorf("%w: address block too short", ErrInvalidProxyHeader)
+	}
+
+	srcIP := net.IP(payload[:ipLen])
+	dstIP := net.IP(payload[ipLen : 2*ipLen])
+	srcPort := binary.BigEndian.Uint16(payload[2*ipLen:])
+	dstPort := binary.BigEndian.Uint16(payload[2*ipLen+2:])
+
+	return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, &net.TCPAddr{IP: dstIP, Port: int(dstPort)}, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func proxyV2Header(command, family byte, addrs []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(proxyV2Signature)
+	buf.WriteByte(0x20 | command)
+	buf.WriteByte(family)
+	binary.Write(&buf, binary.BigEndian, uint16(len(addrs)))
+	buf.Write(addrs)
+	return buf.Bytes()
+}
+
+// Test readProxyHeader with v1 and v2 headers
+func TestReadProxyHeader(t *testing.T) {
+	v4Addrs := []byte{192, 0, 2, 1, 192, 0, 2, 2, 0xdc, 0x04, 0x01, 0xbb}
+	v6Addrs := append(append(net.ParseIP("2001:db8::1").To16(), net.ParseIP("2001:db8::2").To16()...), 0x1f, 0x90, 0x00, 0x50)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantSrc string
+		wantDst string
+	}{
+		{
+			name:    "v1 tcp4",
+			input:   []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\nGET / HTTP/1.1\r\n"),
+			wantSrc: "192.0.2.1:56324",
+			wantDst: "192.0.2.2:443",
+		},
+		{
+			name:    "v1 tcp6",
+			input:   []byte("PROXY TCP6 2001:db8::1 2001:db8::2 8080 80\r\nGET / HTTP/1.1\r\n"),
+			wantSrc: "[2001:db8::1]:8080",
+			wantDst: "[2001:db8::2]:80",
+		},
+		{
+			name:  "v1 unknown",
+			input: []byte("PROXY UNKNOWN\r\nGET / HTTP/1.1\r\n"),
+		},
+		{
+			name:    "v2 tcp4",
+			input:   append(proxyV2Header(0x1, 0x11, v4Addrs), "GET / HTTP/1.1\r\n"...),
+			wantSrc: "192.0.2.1:56324",
+			wantDst: "192.0.2.2:443",
+		},
+		{
+			name:    "v2 tcp6",
+			input:   append(proxyV2Header(0x1, 0x21, v6Addrs), "GET / HTTP/1.1\r\n"...),
+			wantSrc: "[2001:db8::1]:8080",
+			wantDst: "[2001:db8::2]:80",
+		},
+		{
+			name:  "v2 local",
+			input: append(proxyV2Header(0x0, 0x00, nil), "GET / HTTP/1.1\r\n"...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(tt.input))
+			src, dst, err := readProxyHeader(r)
+			if err != nil {
+				t.Fatalf("readProxyHeader() returned error: %v", err)
+			}
+
+			if got := addrString(src); got != tt.wantSrc {
+				t.Errorf("readProxyHeader() src = %q, want %q", got, tt.wantSrc)
+			}
+			if got := addrString(dst); got != tt.wantDst {
+				t.Errorf("readProxyHeader() dst = %q, want %q", got, tt.wantDst)
+			}
+
+			rest, _ := io.ReadAll(r)
+			if string(rest) != "GET / HTTP/1.1\r\n" {
+				t.Errorf("readProxyHeader() left %q unread, want request line", rest)
+			}
+		})
+	}
+}
+
+// Test readProxyHeader rejects connections without a valid header
+func TestReadProxyHeader_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "plain http", input: "GET / HTTP/1.1\r\nHost: x\r\n\r\n"},
+		{name: "v1 bad address", input: "PROXY TCP4 nope 192.0.2.2 1 2\r\n"},
+		{name: "v1 bad port", input: "PROXY TCP4 192.0.2.1 192.0.2.2 99999 2\r\n"},
+		{name: "v1 missing crlf", input: "PROXY TCP4 192.0.2.1 192.0.2.2 1 2" + strings.Repeat(" ", 100)},
+		{name: "truncated", input: "PROXY"},
+		{name: "v1 no space", input: "PROXYTCP4 192.0.2.1 192.0.2.2 1 2\r\n"},
+		{name: "v2 truncated signature", input: "\r\n\r\n\x00\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readProxyHeader(bufio.NewReader(strings.NewReader(tt.input)))
+			if !errors.Is(err, ErrInvalidProxyHeader) {
+				t.Errorf("readProxyHeader() error = %v, want ErrInvalidProxyHeader", err)
+			}
+		})
+	}
+}
+
+// Test a header sent on its own is read without waiting for more data
+func TestReadProxyHeader_HeaderOnly(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go client.Write([]byte("PROXY UNKNOWN\r\n"))
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := readProxyHeader(bufio.NewReader(server))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("readProxyHeader() returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("readProxyHeader() blocked waiting for data after the header")
+	}
+}
+
+// Test proxyProtoListener exposes the client address from the header
+func TestProxyProtoListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error: %v", err)
+	}
+	pl := &proxyProtoListener{Listener: ln}
+	defer pl.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("PROXY TCP4 203.0.113.7 192.0.2.2 40000 80\r\nhello"))
+	}()
+
+	conn, err := pl.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error: %v", err)
+	}
+	defer conn.Close()
+
+	if got := conn.RemoteAddr().String(); got != "203.0.113.7:40000" {
+		t.Errorf("RemoteAddr() = %q, want %q", got, "203.0.113.7:40000")
+	}
+
+	body, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("Read() = %q, want %q", body, "hello")
+	}
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNATSSubject  = "get-container-id.lifecycle"
+	defaultRedisChannel = "get-container-id:lifecycle"
+)
+
+var ErrPublishRejected = errors.New("publish rejected")
+
+// publisher announces a lifecycle event on a message bus. Events are rare,
+// so every publish uses its own short-lived connection.
+type publisher interface {
+	publish(ctx context.Context, payload []byte) error
+	String() string
+}
+
+// newPublisher returns a NATS or Redis publisher for rawURL, depending on its
+// scheme. TLS is not supported.
+func newPublisher(rawURL, topic string) (publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "nats":
+		p := &natsPublisher{addr: hostPort(u, "4222"), subject: topic}
+		if u.User != nil {
+			p.user = u.User.Username()
+			p.pass, _ = u.User.Password()
+		}
+		return p, nil
+	case "redis":
+		p := &redisPublisher{addr: hostPort(u, "6379"), channel: topic}
+		if u.User != nil {
+			p.user = u.User.Username()
+			p.pass, _ = u.User.Password()
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unsupported publish URL scheme %q (want nats or redis)", u.Scheme)
+	}
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// dialPublish opens a connection whose deadline follows ctx, bounded by
+// registerRequestTimeout.
+func dialPublish(ctx context.Context, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, registerRequestTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// natsPublisher publishes with the NATS client protocol: CONNECT, PUB, and a
+// PING whose PONG confirms the server processed the PUB.
+type natsPublisher struct {
+	addr       string
+	subject    string
+	user, pass string
+}
+
+// natsConnect is the CONNECT options document.
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+func (p *natsPublisher) String() string {
+	return "nats://" + p.addr + "/" + p.subject
+}
+
+func (p *natsPublisher) publish(ctx context.Context, payload []byte) error {
+	conn, err := dialPublish(ctx, p.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("%w: unexpected NATS greeting %q", ErrPublishRejected, strings.TrimSpace(line))
+	}
+
+	connect, err := json.Marshal(natsConnect{Name: "get-container-id", User: p.user, Pass: p.pass})
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CONNECT %s\r\n", connect)
+	fmt.Fprintf(&b, "PUB %s %d\r\n%s\r\n", p.subject, len(payload), payload)
+	b.WriteString("PING\r\n")
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return err
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("%w: %s", ErrPublishRejected, line)
+		}
+		// Skip +OK, PING and further INFO lines.
+	}
+}
+
+// redisPublisher publishes with the Redis PUBLISH command, authenticating
+// first if the URL has credentials.
+type redisPublisher struct {
+	addr       string
+	channel    string
+	user, pass string
+}
+
+func (p *redisPublisher) String() string {
+	return "redis://" + p.addr + "/" + p.channel
+}
+
+func (p *redisPublisher) publish(ctx context.Context, payload []byte) error {
+	conn, err := dialPublish(ctx, p.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	if p.pass != "" {
+		args := []string{"AUTH", p.pass}
+		if p.user != "" {
+			args = []string{"AUTH", p.user, p.pass}
+		}
+		if _, err := redisCommand(conn, r, args...); err != nil {
+			return err
+		}
+	}
+
+	_, err = redisCommand(conn, r, "PUBLISH", p.channel, string(payload))
+	return err
+}
+
+// redisCommand sends args as a RESP array and returns the reply line.
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "-") {
+		return "", fmt.Errorf("%w: %s", ErrPublishRejected, line[1:])
+	}
+	return line, nil
+}
+
+// lifecycleAnnouncer publishes start and stop events carrying the identity
+// document to every configured publisher.
+type lifecycleAnnouncer struct {
+	publishers []publisher
+	logger     *slog.Logger
+}
+
+// announce publishes event to every publisher concurrently and waits for
+// all of them. Failures are logged.
+func (a *lifecycleAnnouncer) announce(ctx context.Context, event string, doc identityDocument) {
+	payload, err := json.Marshal(registrationEvent{
+		Event:    event,
+		Time:     time.Now().Format(time.RFC3339Nano),
+		Identity: doc,
+	})
+	if err != nil {
+		a.logger.Error("lifecycle event encoding failed", slog.String("event", event), slog.Any("error", err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range a.publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.publish(ctx, payload); err != nil {
+				a.logger.Error("lifecycle event publish failed", slog.String("event", event), slog.String("target", p.String()), slog.Any("error", err))
+				return
+			}
+			a.logger.Info("lifecycle event published", slog.String("event", event), slog.String("target", p.String()))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// pushMaxPendingBatches bounds how many undelivered batches are kept while
+// the collector is unreachable; the oldest samples are dropped first.
+const pushMaxPendingBatches = 10
+
+// pushSample is one interval of counter and request metrics. Requests and
+// ServerErrors count only the interval; Counters are the absolute values of
+// the named counters.
+type pushSample struct {
+	Time         string            `json:"time"`
+	Requests     uint64            `json:"requests"`
+	ServerErrors uint64            `json:"server_errors"`
+	InFlight     int64             `json:"in_flight"`
+	Counters     map[string]uint64 `json:"counters"`
+}
+
+// pushBatch is the body POSTed to the push URL.
+type pushBatch struct {
+	Identity identityDocument `json:"identity"`
+	Samples  []pushSample     `json:"samples"`
+}
+
+// pusher samples metrics every interval and POSTs them to a collector in
+// batches, for test farms that aggregate results centrally.
+type pusher struct {
+	url       string
+	client    *http.Client
+	logger    *slog.Logger
+	interval  time.Duration
+	batchSize int
+
+	doc      identityDocument
+	counters *counterRegistry
+	requests *requestStats
+	shutdown *shutdownState
+
+	// backoff is the delay before the first retry; it doubles on each attempt.
+	backoff time.Duration
+
+	pending                  []pushSample
+	lastRequests, lastErrors uint64
+}
+
+func newPusher(url string, interval time.Duration, batchSize int, logger *slog.Logger, doc identityDocument, counters *counterRegistry, requests *requestStats, shutdown *shutdownState) *pusher {
+	return &pusher{
+		url:       url,
+		client:    &http.Client{Timeout: registerRequestTimeout},
+		logger:    logger,
+		interval:  interval,
+		batchSize: max(batchSize, 1),
+		doc:       doc,
+		counters:  counters,
+		requests:  requests,
+		shutdown:  shutdown,
+		backoff:   registerInitialBackoff,
+	}
+}
+
+// run samples every interval, pushing a batch once batchSize samples are
+// pending. When ctx is done it takes a final sample and pushes whatever is
+// left, bounded by shutdownTimeout.
+func (p *pusher) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.sample()
+			flushCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			p.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			p.sample()
+			if len(p.pending) >= p.batchSize {
+				p.flush(ctx)
+			}
+		}
+	}
+}
+
+// sample appends the metrics of the interval since the previous sample.
+func (p *pusher) sample() {
+	requests, serverErrors := p.requests.requests.Load(), p.requests.serverErrors.Load()
+	p.pending = append(p.pending, pushSample{
+		Time:         time.Now().Format(time.RFC3339Nano),
+		Requests:     requests - p.lastRequests,
+		ServerErrors: serverErrors - p.lastErrors,
+		InFlight:     p.shutdown.inFlight.Load(),
+		Counters:     p.counters.snapshot(),
+	})
+	p.lastRequests, p.lastErrors = requests, serverErrors
+
+	if limit := pushMaxPendingBatches * p.batchSize; len(p.pending) > limit {
+		dropped := len(p.pending) - limit
+		p.pending = p.pending[dropped:]
+		p.logger.Warn("push samples dropped", slog.String("url", p.url), slog.Int("dropped", dropped))
+	}
+}
+
+// flush POSTs every pending sample. On failure the samples stay pending and
+// are retried with the next batch.
+func (p *pusher) flush(ctx context.Context) {
+	if len(p.pending) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(pushBatch{Identity: p.doc, Samples: p.pending})
+	if err != nil {
+		p.logger.Error("push failed", slog.String("url", p.url), slog.Any("error", err))
+		return
+	}
+
+	attempt, err := sendWithRetry(ctx, p.client, http.MethodPost, p.url, body, p.backoff, func(attempt int, err error) {
+		p.logger.Warn("push attempt failed", slog.String("url", p.url), slog.Int("attempt", attempt), slog.Any("error", err))
+	})
+	if err != nil {
+		p.logger.Error("push failed", slog.String("url", p.url), slog.Int("attempt", attempt), slog.Int("pending", len(p.pending)), slog.Any("error", err))
+		return
+	}
+	p.pending = p.pending[:0]
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPusher(url string, batchSize int) (*pusher, *counterRegistry, *requestStats) {
+	counters := newCounterRegistry()
+	requests := &requestStats{}
+	p := newPusher(url, time.Hour, batchSize, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)),
+		identityDocument{InstanceID: "instance-1"}, counters, requests, newShutdownState())
+	p.backoff = time.Millisecond
+	return p, counters, requests
+}
+
+// Test requestStats counts requests and 5xx responses
+func TestRequestStatsMiddleware(t *testing.T) {
+	stats := &requestStats{}
+	handler := stats.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, path := range []string{"/", "/fail", "/", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if got := stats.requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+	if got := stats.serverErrors.Load(); got != 2 {
+		t.Errorf("serverErrors = %d, want 2", got)
+	}
+}
+
+// Test pusher sends interval deltas and counter values in one batch
+func TestPusherFlush(t *testing.T) {
+	var received pushBatch
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p, counters, requests := newTestPusher(srv.URL, 2)
+	requests.requests.Add(5)
+	requests.serverErrors.Add(1)
+	counters.add("orders", 3)
+	p.sample()
+	requests.requests.Add(2)
+	p.sample()
+	p.flush(context.Background())
+
+	if received.Identity.InstanceID != "instance-1" || len(received.Samples) != 2 {
+		t.Fatalf("collector received %+v, want 2 samples for instance-1", received)
+	}
+	first, second := received.Samples[0], received.Samples[1]
+	if first.Requests != 5 || first.ServerErrors != 1 || first.Counters["orders"] != 3 {
+		t.Errorf("first sample = %+v, want 5 requests, 1 error, orders=3", first)
+	}
+	if second.Requests != 2 || second.ServerErrors != 0 {
+		t.Errorf("second sample = %+v, want the interval delta of 2 requests", second)
+	}
+	if len(p.pending) != 0 {
+		t.Errorf("pending = %d after successful flush, want 0", len(p.pending))
+	}
+}
+
+// Test pusher keeps samples after a failed push and bounds them
+func TestPusherFlush_KeepsPending(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p, _, _ := newTestPusher(srv.URL, 1)
+	p.sample()
+	p.flush(context.Background())
+
+	if got := calls.Load(); got != registerAttempts {
+		t.Errorf("flush() made %d attempts, want %d", got, registerAttempts)
+	}
+	if len(p.pending) != 1 {
+		t.Errorf("pending = %d after failed flush, want 1", len(p.pending))
+	}
+
+	for range pushMaxPendingBatches * 2 {
+		p.sample()
+	}
+	if len(p.pending) != pushMaxPendingBatches {
+		t.Errorf("pending = %d, want bounded at %d", len(p.pending), pushMaxPendingBatches)
+	}
+}
+
+// Test pusher pushes the remaining samples when stopped
+func TestPusherRun_FlushesOnStop(t *testing.T) {
+	var mu sync.Mutex
+	var batches []pushBatch
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch pushBatch
+		json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	p, _, _ := newTestPusher(srv.URL, 100)
+	p.interval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.run(ctx)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || len(batches[0].Samples) < 2 {
+		t.Errorf("collector received %d batches (%+v), want one final batch with several samples", len(batches), batches)
+	}
+}
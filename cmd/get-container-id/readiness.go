@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	checkStatusOK   = "ok"
+	checkStatusFail = "fail"
+)
+
+// readinessCheck is the result of one /readyz check.
+type readinessCheck struct {
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Value    string `json:"value,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// readinessReport is the /readyz response document. Ready is false if any
+// required check failed.
+type readinessReport struct {
+	Ready  bool                      `json:"ready"`
+	Checks map[string]readinessCheck `json:"checks"`
+}
+
+// readiness runs the identity checks behind /readyz. Every check runs and is
+// reported; only the required ones can fail the probe.
+type readiness struct {
+	checks []identityCheck
+}
+
+// identityCheck detects one identity value. Detection is cached after the
+// first success, so repeated probes are cheap.
+type identityCheck struct {
+	name     string
+	required bool
+	detect   func(ctx context.Context) (string, error)
+}
+
+func newReadiness(requireContainerID, requirePodID bool) *readiness {
+	return &readiness{checks: []identityCheck{
+		{name: "container_id", required: requireContainerID, detect: getContainerID},
+		{name: "pod_id", required: requirePodID, detect: func(ctx context.Context) (string, error) {
+			return podid.GetContext(ctx)
+		}},
+	}}
+}
+
+func (rd *readiness) report(ctx context.Context) readinessReport {
+	report := readinessReport{Ready: true, Checks: make(map[string]readinessCheck, len(rd.checks))}
+	for _, c := range rd.checks {
+		result := readinessCheck{Status: checkStatusOK, Required: c.required}
+		value, err := c.detect(ctx)
+		if err != nil {
+			result.Status = checkStatusFail
+			result.Error = err.Error()
+			if c.required {
+				report.Ready = false
+			}
+		}
+		result.Value = value
+		report.Checks[c.name] = result
+	}
+	return report
+}
+
+// handleReadyz writes the check results, with 503 if a required check failed.
+func (rd *readiness) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := rd.report(r.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	httpapi.WriteJSON(w, httpapi.Response{Data: report}, status)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func stubReadiness(requireContainerID, requirePodID bool, containerErr, podErr error) *readiness {
+	rd := newReadiness(requireContainerID, requirePodID)
+	rd.checks[0].detect = func(context.Context) (string, error) {
+		if containerErr != nil {
+			return "", containerErr
+		}
+		return "abc123", nil
+	}
+	rd.checks[1].detect = func(context.Context) (string, error) {
+		if podErr != nil {
+			return "", podErr
+		}
+		return "pod-uid", nil
+	}
+	return rd
+}
+
+// Test /readyz reports every check and fails only on required ones
+func TestReadiness_HandleReadyz(t *testing.T) {
+	errMissing := errors.New("not found")
+	tests := []struct {
+		name                   string
+		requireContainer       bool
+		requirePod             bool
+		containerErr, podErr   error
+		wantStatus             int
+		wantContainer, wantPod string
+	}{
+		{"all ok", true, true, nil, nil, http.StatusOK, checkStatusOK, checkStatusOK},
+		{"optional failure", false, false, errMissing, errMissing, http.StatusOK, checkStatusFail, checkStatusFail},
+		{"required container failure", true, false, errMissing, nil, http.StatusServiceUnavailable, checkStatusFail, checkStatusOK},
+		{"required pod failure", false, true, nil, errMissing, http.StatusServiceUnavailable, checkStatusOK, checkStatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := stubReadiness(tt.requireContainer, tt.requirePod, tt.containerErr, tt.podErr)
+
+			rec := httptest.NewRecorder()
+			rd.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var resp struct {
+				Data readinessReport `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Data.Ready != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("ready = %v, want %v", resp.Data.Ready, tt.wantStatus == http.StatusOK)
+			}
+			container, pod := resp.Data.Checks["container_id"], resp.Data.Checks["pod_id"]
+			if container.Status != tt.wantContainer || container.Required != tt.requireContainer {
+				t.Errorf("container_id = %+v, want status %q required %v", container, tt.wantContainer, tt.requireContainer)
+			}
+			if pod.Status != tt.wantPod || pod.Required != tt.requirePod {
+				t.Errorf("pod_id = %+v, want status %q required %v", pod, tt.wantPod, tt.requirePod)
+			}
+			if container.Status == checkStatusOK && container.Value != "abc123" {
+				t.Errorf("container_id value = %q, want %q", container.Value, "abc123")
+			}
+			if pod.Status == checkStatusFail && pod.Error != errMissing.Error() {
+				t.Errorf("pod_id error = %q, want %q", pod.Error, errMissing.Error())
+			}
+		})
+	}
+}
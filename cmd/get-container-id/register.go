@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	registerAttempts       = 5
+	registerInitialBackoff = 500 * time.Millisecond
+	registerRequestTimeout = 5 * time.Second
+)
+
+// identityDocument describes this replica to an external collector.
+type identityDocument struct {
+	InstanceID  string   `json:"instance_id"`
+	ContainerID string   `json:"container_id,omitempty"`
+	PodID       string   `json:"pod_id,omitempty"`
+	Hostname    string   `json:"hostname,omitempty"`
+	NodeName    string   `json:"node_name,omitempty"`
+	Addresses   []string `json:"addresses,omitempty"`
+	Listen      string   `json:"listen,omitempty"`
+}
+
+// newIdentityDocument gathers the identity of this replica. Detection
+// failures leave the corresponding fields empty.
+func newIdentityDocument(listen listenInfo) identityDocument {
+	doc := identityDocument{
+		InstanceID: instanceID,
+		NodeName:   os.Getenv("NODE_NAME"),
+		Addresses:  interfaceAddresses(),
+		Listen:     listen.Address,
+	}
+	doc.ContainerID, _ = getContainerID(context.Background())
+	doc.PodID, _ = podid.Get()
+	doc.Hostname, _ = os.Hostname()
+	return doc
+}
+
+// interfaceAddresses returns the non-loopback IP addresses of this host.
+func interfaceAddresses() []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var ips []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		ips = append(ips, ipNet.IP.String())
+	}
+	return ips
+}
+
+// registrationEvent is the body POSTed to the register URL.
+type registrationEvent struct {
+	Event    string           `json:"event"`
+	Time     string           `json:"time"`
+	Identity identityDocument `json:"identity"`
+}
+
+// registrar announces this replica to a collector on startup and shutdown.
+type registrar struct {
+	url    string
+	client *http.Client
+	logger *slog.Logger
+
+	// backoff is the delay before the first retry; it doubles on each attempt.
+	backoff time.Duration
+}
+
+func newRegistrar(url string, logger *slog.Logger) *registrar {
+	return &registrar{
+		url:     url,
+		client:  &http.Client{Timeout: registerRequestTimeout},
+		logger:  logger,
+		backoff: registerInitialBackoff,
+	}
+}
+
+// send POSTs event with the identity document, retrying with exponential
+// backoff on transport errors and non-2xx responses.
+func (r *registrar) send(ctx context.Context, event string, doc identityDocument) error {
+	body, err := json.Marshal(registrationEvent{
+		Event:    event,
+		Time:     time.Now().Format(time.RFC3339Nano),
+		Identity: doc,
+	})
+	if err != nil {
+		return err
+	}
+
+	attempt, err := sendWithRetry(ctx, r.client, http.MethodPost, r.url, body, r.backoff, func(attempt int, err error) {
+		r.logger.Warn("registration attempt failed", slog.String("event", event), slog.Int("attempt", attempt), slog.Any("error", err))
+	})
+	if err != nil {
+		return fmt.Errorf("%s failed after %d attempts: %w", event, attempt, err)
+	}
+	r.logger.Info("registration sent", slog.String("event", event), slog.String("url", r.url), slog.Int("attempt", attempt))
+	return nil
+}
+
+// sendWithRetry sends body to url up to registerAttempts times, doubling
+// backoff between attempts. onRetry is called before each wait. It returns
+// the number of attempts made.
+func sendWithRetry(ctx context.Context, client *http.Client, method, url string, body []byte, backoff time.Duration, onRetry func(attempt int, err error)) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := send(ctx, client, method, url, body)
+		if err == nil {
+			return attempt, nil
+		}
+		if attempt == registerAttempts {
+			return attempt, err
+		}
+
+		onRetry(attempt, err)
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send performs one request and fails unless it returns a 2xx status. A nil
+// body is sent without a Content-Type.
+func send(ctx context.Context, client *http.Client, method, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set(headerContentType, contentTypeJSON)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test registrar retries until the collector accepts the event
+func TestRegistrarSend(t *testing.T) {
+	var calls atomic.Int32
+	var received registrationEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	reg := newRegistrar(srv.URL, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	reg.backoff = time.Millisecond
+
+	doc := identityDocument{InstanceID: "instance-1", PodID: "pod-1"}
+	if err := reg.send(context.Background(), "register", doc); err != nil {
+		t.Fatalf("send() returned error: %v", err)
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("send() made %d attempts, want 3", got)
+	}
+	if received.Event != "register" || received.Identity.InstanceID != "instance-1" || received.Identity.PodID != "pod-1" {
+		t.Errorf("collector received %+v", received)
+	}
+}
+
+// Test registrar gives up after registerAttempts failures
+func TestRegistrarSend_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	reg := newRegistrar(srv.URL, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	reg.backoff = time.Millisecond
+
+	if err := reg.send(context.Background(), "deregister", identityDocument{}); err == nil {
+		t.Fatal("send() expected error, got nil")
+	}
+	if got := calls.Load(); got != registerAttempts {
+		t.Errorf("send() made %d attempts, want %d", got, registerAttempts)
+	}
+}
+
+// Test newIdentityDocument fills in the instance ID and node name
+func TestNewIdentityDocument(t *testing.T) {
+	t.Setenv("NODE_NAME", "node-a")
+
+	doc := newIdentityDocument(listenInfo{Address: "[::]:8080"})
+	if doc.InstanceID != instanceID || doc.NodeName != "node-a" || doc.Listen != "[::]:8080" {
+		t.Errorf("newIdentityDocument() = %+v", doc)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/ming-go/lab/get-container-id/idgen"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+
+	// maxRequestIDLength bounds request IDs accepted from clients.
+	maxRequestIDLength = 128
+)
+
+// newRequestID generates request IDs; tests replace it.
+var newRequestID = idgen.NewV7
+
+type requestIDKey struct{}
+type requestLoggerKey struct{}
+
+// requestIDMiddleware keeps the X-Request-ID of the request, or generates a
+// UUIDv7 when it is missing or not a printable ASCII string of at most 128
+// bytes. The ID is set on the request and the response headers, and handlers
+// get it and a logger carrying it as request_id from the request context.
+func requestIDMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerRequestID)
+		if !validRequestID(id) {
+			var err error
+			if id, err = newRequestID(); err != nil {
+				logger.Error("failed to generate request ID", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Header.Set(headerRequestID, id)
+		}
+		w.Header().Set(headerRequestID, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = context.WithValue(ctx, requestLoggerKey{}, logger.With(slog.String("request_id", id)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID can be echoed
+// into headers and logs as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the request ID of ctx, or "" outside of
+// requestIDMiddleware.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns the logger carrying the request ID of ctx, or
+// fallback outside of requestIDMiddleware.
+func requestLogger(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(requestLoggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
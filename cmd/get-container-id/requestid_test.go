@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test requestIDMiddleware keeps valid client IDs and generates the others
+func TestRequestIDMiddleware(t *testing.T) {
+	orig := newRequestID
+	defer func() { newRequestID = orig }()
+	newRequestID = func() (string, error) { return "generated", nil }
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"missing", "", "generated"},
+		{"client", "abc-123", "abc-123"},
+		{"control characters", "abc\x01", "generated"},
+		{"spaces", "abc 123", "generated"},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeader, gotContext string
+			h := requestIDMiddleware(slog.Default(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get(headerRequestID)
+				gotContext = requestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/echo", nil)
+			if tt.header != "" {
+				req.Header.Set(headerRequestID, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if got := w.Header().Get(headerRequestID); got != tt.want {
+				t.Errorf("response %s = %q, want %q", headerRequestID, got, tt.want)
+			}
+			if gotHeader != tt.want || gotContext != tt.want {
+				t.Errorf("handler saw header %q and context %q, want %q", gotHeader, gotContext, tt.want)
+			}
+		})
+	}
+}
+
+// Test handlers log with the request ID through requestLogger
+func TestRequestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	h := requestIDMiddleware(logger, recoverMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(headerRequestID, "req-42")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("log unmarshal error: %v", err)
+	}
+	if record["request_id"] != "req-42" {
+		t.Errorf("panic log request_id = %v, want req-42", record["request_id"])
+	}
+
+	if got := requestLogger(req.Context(), logger); got != logger {
+		t.Error("requestLogger() outside the middleware should return the fallback")
+	}
+}
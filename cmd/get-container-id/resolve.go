@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// resolveTimeout bounds a /resolve lookup.
+const resolveTimeout = 5 * time.Second
+
+// resolveTypes are the record types /resolve looks up.
+var resolveTypes = []string{"A", "AAAA", "CNAME", "SRV", "TXT"}
+
+// dnsResolver is the subset of *net.Resolver /resolve uses.
+type dnsResolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+var (
+	// resolver performs /resolve lookups with the system's resolver
+	// configuration; tests replace it.
+	resolver dnsResolver = net.DefaultResolver
+
+	resolvConfPath = "/etc/resolv.conf"
+)
+
+// resolvConf is the parsed resolver configuration of the pod.
+type resolvConf struct {
+	Nameservers []string `json:"nameservers,omitempty"`
+	Search      []string `json:"search,omitempty"`
+	Options     []string `json:"options,omitempty"`
+	// Ndots is the ndots option: names with fewer dots are tried with each
+	// search domain first. It defaults to 1; Kubernetes sets 5.
+	Ndots int `json:"ndots"`
+	// Raw is the file as read.
+	Raw string `json:"raw"`
+}
+
+// readResolvConf reads the resolver configuration file.
+func readResolvConf() (resolvConf, error) {
+	b, err := os.ReadFile(resolvConfPath)
+	if err != nil {
+		return resolvConf{}, err
+	}
+	conf := parseResolvConf(bytes.NewReader(b))
+	conf.Raw = string(b)
+	return conf, nil
+}
+
+// parseResolvConf parses the nameserver, search, domain and options lines
+// of a resolv.conf file. A later search or domain line replaces an earlier
+// one, as in the C library.
+func parseResolvConf(r io.Reader) resolvConf {
+	conf := resolvConf{Ndots: 1}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if len(fields) > 1 {
+				conf.Nameservers = append(conf.Nameservers, fields[1])
+			}
+		case "search", "domain":
+			conf.Search = slices.Clone(fields[1:])
+		case "options":
+			for _, opt := range fields[1:] {
+				conf.Options = append(conf.Options, opt)
+				if v, ok := strings.CutPrefix(opt, "ndots:"); ok {
+					if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+						conf.Ndots = min(n, 15)
+					}
+				}
+			}
+		}
+	}
+	return conf
+}
+
+// resolveAnswer is one record of a /resolve answer. Only the fields of the
+// record type are set.
+type resolveAnswer struct {
+	IP       string `json:"ip,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+	Priority uint16 `json:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// resolveResult is the outcome of a /resolve lookup. A failed lookup is
+// still a result: its error and timing are what the caller is debugging.
+type resolveResult struct {
+	Name           string          `json:"name"`
+	Type           string          `json:"type"`
+	Answers        []resolveAnswer `json:"answers"`
+	Error          string          `json:"error,omitempty"`
+	NotFound       bool            `json:"not_found,omitempty"`
+	Timeout        bool            `json:"timeout,omitempty"`
+	ElapsedSeconds float64         `json:"elapsed_seconds"`
+	Resolver       *resolvConf     `json:"resolver,omitempty"`
+}
+
+// resolveRecords resolves name as a record of type typ, which must be one of
+// resolveTypes.
+func resolveRecords(ctx context.Context, name, typ string) ([]resolveAnswer, error) {
+	answers := []resolveAnswer{}
+	switch typ {
+	case "A", "AAAA":
+		network := "ip4"
+		if typ == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		for _, ip := range ips {
+			answers = append(answers, resolveAnswer{IP: ip.String()})
+		}
+		return answers, err
+	case "CNAME":
+		target, err := resolver.LookupCNAME(ctx, name)
+		if target != "" {
+			answers = append(answers, resolveAnswer{Target: target})
+		}
+		return answers, err
+	case "SRV":
+		_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+		for _, srv := range srvs {
+			answers = append(answers, resolveAnswer{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight})
+		}
+		return answers, err
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		for _, txt := range txts {
+			answers = append(answers, resolveAnswer{Text: txt})
+		}
+		return answers, err
+	}
+	return nil, fmt.Errorf("unsupported record type %q", typ)
+}
+
+// handleResolve serves GET /resolve?name=&type=: it resolves name from
+// inside the pod with the system resolver, so search domains and ndots
+// apply as they do for the application, and reports the answers, the
+// timing and the resolv.conf in effect. type is A (default), AAAA, CNAME,
+// SRV or TXT; SRV names are given in full, e.g. _http._tcp.web.default.svc.
+func handleResolve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		httpapi.WriteError(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	typ := strings.ToUpper(r.URL.Query().Get("type"))
+	if typ == "" {
+		typ = "A"
+	}
+	if !slices.Contains(resolveTypes, typ) {
+		httpapi.WriteError(w, "type must be one of "+strings.Join(resolveTypes, ", "), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resolveTimeout)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := resolveRecords(ctx, name, typ)
+	result := resolveResult{
+		Name:           name,
+		Type:           typ,
+		Answers:        answers,
+		ElapsedSeconds: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			result.NotFound = dnsErr.IsNotFound
+			result.Timeout = dnsErr.IsTimeout
+		}
+		result.Timeout = result.Timeout || errors.Is(err, context.DeadlineExceeded)
+	}
+	if conf, err := readResolvConf(); err == nil {
+		result.Resolver = &conf
+	}
+
+	httpapi.WriteSuccess(w, result)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers /resolve lookups from fixed records.
+type fakeResolver struct {
+	ips   map[string][]net.IP
+	srvs  []*net.SRV
+	txts  []string
+	cname string
+}
+
+func (f fakeResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ips, ok := f.ips[network+" "+host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (f fakeResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	return f.cname, nil
+}
+
+func (f fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return name, f.srvs, nil
+}
+
+func (f fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if f.txts == nil {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+	return f.txts, nil
+}
+
+// Test parseResolvConf reads nameservers, search domains and ndots
+func TestParseResolvConf(t *testing.T) {
+	conf := parseResolvConf(strings.NewReader(`# generated by kubelet
+nameserver 10.96.0.10
+domain example.com
+search default.svc.cluster.local svc.cluster.local cluster.local
+nameserver 10.96.0.11
+options ndots:5 timeout:2
+`))
+	want := resolvConf{
+		Nameservers: []string{"10.96.0.10", "10.96.0.11"},
+		Search:      []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"},
+		Options:     []string{"ndots:5", "timeout:2"},
+		Ndots:       5,
+	}
+	if !reflect.DeepEqual(conf, want) {
+		t.Errorf("parseResolvConf() = %+v, want %+v", conf, want)
+	}
+
+	if conf := parseResolvConf(strings.NewReader("nameserver 1.1.1.1\n")); conf.Ndots != 1 {
+		t.Errorf("default ndots = %d, want 1", conf.Ndots)
+	}
+}
+
+// Test /resolve reports the answers of each record type and lookup errors
+func TestHandleResolve(t *testing.T) {
+	origResolver, origPath := resolver, resolvConfPath
+	defer func() { resolver, resolvConfPath = origResolver, origPath }()
+	resolver = fakeResolver{
+		ips: map[string][]net.IP{
+			"ip4 web": {net.ParseIP("10.0.0.7")},
+			"ip6 web": {net.ParseIP("fd00::7")},
+		},
+		srvs:  []*net.SRV{{Target: "web-0.web.default.svc.cluster.local.", Port: 8080, Priority: 0, Weight: 100}},
+		cname: "web.default.svc.cluster.local.",
+	}
+	resolvConfPath = writeTestFile(t, "nameserver 10.96.0.10\noptions ndots:5\n")
+
+	tests := []struct {
+		query        string
+		wantType     string
+		wantAnswers  []resolveAnswer
+		wantNotFound bool
+		wantTimeout  bool
+	}{
+		{query: "name=web", wantType: "A", wantAnswers: []resolveAnswer{{IP: "10.0.0.7"}}},
+		{query: "name=web&type=aaaa", wantType: "AAAA", wantAnswers: []resolveAnswer{{IP: "fd00::7"}}},
+		{query: "name=web&type=CNAME", wantType: "CNAME", wantAnswers: []resolveAnswer{{Target: "web.default.svc.cluster.local."}}},
+		{query: "name=_http._tcp.web&type=SRV", wantType: "SRV", wantAnswers: []resolveAnswer{{Target: "web-0.web.default.svc.cluster.local.", Port: 8080, Weight: 100}}},
+		{query: "name=web&type=TXT", wantType: "TXT", wantAnswers: []resolveAnswer{}, wantTimeout: true},
+		{query: "name=missing", wantType: "A", wantAnswers: []resolveAnswer{}, wantNotFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handleResolve(w, httptest.NewRequest(http.MethodGet, "/resolve?"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			var resp struct {
+				Data resolveResult `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got := resp.Data
+			if got.Type != tt.wantType || !reflect.DeepEqual(got.Answers, tt.wantAnswers) {
+				t.Errorf("resolve = %s %+v, want %s %+v", got.Type, got.Answers, tt.wantType, tt.wantAnswers)
+			}
+			if got.NotFound != tt.wantNotFound || got.Timeout != tt.wantTimeout || (got.Error != "") != (tt.wantNotFound || tt.wantTimeout) {
+				t.Errorf("error = %q, not_found %v, timeout %v", got.Error, got.NotFound, got.Timeout)
+			}
+			if got.Resolver == nil || got.Resolver.Ndots != 5 || got.Resolver.Raw == "" {
+				t.Errorf("resolver = %+v, want the resolv.conf with ndots 5", got.Resolver)
+			}
+		})
+	}
+}
+
+// Test /resolve rejects a missing name and unsupported types
+func TestHandleResolve_Invalid(t *testing.T) {
+	for _, query := range []string{"", "name=web&type=MX"} {
+		w := httptest.NewRecorder()
+		handleResolve(w, httptest.NewRequest(http.MethodGet, "/resolve?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("/resolve?%s status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Route tags group the endpoints in /openapi.json and /docs.
+const (
+	tagIdentity    = "identity"
+	tagContainer   = "container"
+	tagKubernetes  = "kubernetes"
+	tagNetwork     = "network"
+	tagDiagnostics = "diagnostics"
+	tagTesting     = "testing"
+	tagFault       = "fault"
+	tagChaos       = "chaos"
+	tagLifecycle   = "lifecycle"
+	tagAPI         = "api"
+)
+
+// route is one entry of the HTTP API: the pattern and handler registered on
+// the mux, and the description /openapi.json publishes for it.
+type route struct {
+	// pattern is the ServeMux pattern, e.g. "GET /counters/{name}".
+	pattern string
+	handler http.Handler
+
+	// methods documents the methods of a pattern that does not name one;
+	// it defaults to GET.
+	methods []string
+	summary string
+	tag     string
+	query   []queryParam
+	// body is the media type of the request body, if the route reads one.
+	body string
+	// contentType is the media type of successful responses; empty means
+	// the JSON envelope of httpapi.
+	contentType string
+	// formats lets clients ask for YAML, text or MessagePack instead of
+	// JSON, see negotiateFormat.
+	formats bool
+}
+
+// queryParam documents a query parameter of a route.
+type queryParam struct {
+	name string
+	// typ is the JSON schema type: string, integer, number or boolean.
+	typ         string
+	description string
+}
+
+// routeMethods returns the methods and the path of a route's pattern.
+func (rt route) routeMethods() (methods []string, path string) {
+	method, path, ok := strings.Cut(rt.pattern, " ")
+	if ok {
+		return []string{method}, path
+	}
+	if len(rt.methods) == 0 {
+		return []string{http.MethodGet}, rt.pattern
+	}
+	return rt.methods, rt.pattern
+}
+
+// registerRoutes registers the handler of every route on mux.
+func registerRoutes(mux *http.ServeMux, routes []route) {
+	for _, rt := range routes {
+		handler := rt.handler
+		if rt.formats {
+			handler = negotiateFormat(handler)
+		}
+		mux.Handle(rt.pattern, handler)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// Test routeMethods splits the method off a pattern and defaults to GET
+func TestRouteMethods(t *testing.T) {
+	tests := []struct {
+		route       route
+		wantMethods []string
+		wantPath    string
+	}{
+		{route{pattern: "POST /counters/{name}"}, []string{"POST"}, "/counters/{name}"},
+		{route{pattern: "/hostname"}, []string{"GET"}, "/hostname"},
+		{route{pattern: "/fault/errors", methods: []string{"GET", "PUT"}}, []string{"GET", "PUT"}, "/fault/errors"},
+	}
+	for _, tt := range tests {
+		methods, path := tt.route.routeMethods()
+		if !slices.Equal(methods, tt.wantMethods) || path != tt.wantPath {
+			t.Errorf("routeMethods(%q) = %v, %q; want %v, %q", tt.route.pattern, methods, path, tt.wantMethods, tt.wantPath)
+		}
+	}
+}
+
+// Test registerRoutes serves every route under its pattern
+func TestRegisterRoutes(t *testing.T) {
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) })
+	}
+	mux := http.NewServeMux()
+	registerRoutes(mux, []route{
+		{pattern: "GET /a", handler: handler("a")},
+		{pattern: "/b/{name}", handler: handler("b")},
+	})
+
+	tests := []struct {
+		method, path string
+		wantStatus   int
+		wantBody     string
+	}{
+		{http.MethodGet, "/a", http.StatusOK, "a"},
+		{http.MethodPost, "/a", http.StatusMethodNotAllowed, ""},
+		{http.MethodPut, "/b/x", http.StatusOK, "b"},
+		{http.MethodGet, "/c", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.wantStatus || (tt.wantBody != "" && rec.Body.String() != tt.wantBody) {
+			t.Errorf("%s %s = %d %q, want %d %q", tt.method, tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+		}
+	}
+}
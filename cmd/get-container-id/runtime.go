@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// runtimeFunc identifies the container runtime; tests replace it.
+var runtimeFunc = func() (containerid.RuntimeKind, error) { return containerid.Runtime() }
+
+// handleRuntime serves GET /runtime: the container runtime this replica runs
+// under, or 404 when it cannot be identified.
+func handleRuntime(w http.ResponseWriter, r *http.Request) (any, error) {
+	kind, err := runtimeFunc()
+	if errors.Is(err, containerid.ErrRuntimeNotDetected) {
+		return nil, httpapi.NewError(http.StatusNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"runtime": kind}, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// Test /runtime reports the detected runtime by name
+func TestHandleRuntime(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       containerid.RuntimeKind
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"detected", containerid.RuntimeCRIO, nil, http.StatusOK, `{"data":{"runtime":"cri-o"}}`},
+		{"not detected", containerid.RuntimeUnknown, containerid.ErrRuntimeNotDetected, http.StatusNotFound, ""},
+		{"read error", containerid.RuntimeUnknown, errors.New("permission denied"), http.StatusInternalServerError, ""},
+	}
+	orig := runtimeFunc
+	defer func() { runtimeFunc = orig }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtimeFunc = func() (containerid.RuntimeKind, error) { return tt.kind, tt.err }
+
+			w := httptest.NewRecorder()
+			httpapi.HandlerFunc(handleRuntime).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/runtime", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); tt.wantBody != "" && got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
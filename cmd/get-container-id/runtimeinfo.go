@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"runtime"
+	runtimemetrics "runtime/metrics"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// runtimeInfoDocument is the Go runtime's view of the container's resources.
+type runtimeInfoDocument struct {
+	GOMAXPROCS   int                `json:"gomaxprocs"`
+	NumCPU       int                `json:"num_cpu"`
+	MaxProcs     maxProcsAdjustment `json:"maxprocs"`
+	NumGoroutine int                `json:"num_goroutine"`
+	// MemoryLimitBytes is the cgroup memory limit, omitted if none is set.
+	MemoryLimitBytes int64 `json:"memory_limit_bytes,omitempty"`
+	// GoMemoryLimitBytes is the runtime soft memory limit (GOMEMLIMIT),
+	// omitted if it is unlimited.
+	GoMemoryLimitBytes int64          `json:"go_memory_limit_bytes,omitempty"`
+	GC                 runtimeGCStats `json:"gc"`
+}
+
+// runtimeGCStats are the garbage collector and heap statistics.
+type runtimeGCStats struct {
+	NumGC           uint32     `json:"num_gc"`
+	NumForcedGC     uint32     `json:"num_forced_gc"`
+	PauseTotalNanos uint64     `json:"pause_total_ns"`
+	LastPauseNanos  uint64     `json:"last_pause_ns"`
+	LastGC          *time.Time `json:"last_gc,omitempty"`
+	CPUFraction     float64    `json:"cpu_fraction"`
+	HeapAllocBytes  uint64     `json:"heap_alloc_bytes"`
+	HeapSysBytes    uint64     `json:"heap_sys_bytes"`
+	NextGCBytes     uint64     `json:"next_gc_bytes"`
+	// GOGC is the GC percentage, or -1 when the collector is off.
+	GOGC int `json:"gogc"`
+}
+
+// newRuntimeInfoDocument reads the runtime and memory statistics. It stops
+// the world briefly to read them.
+func newRuntimeInfoDocument() runtimeInfoDocument {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	doc := runtimeInfoDocument{
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		MaxProcs:     maxProcs,
+		NumGoroutine: runtime.NumGoroutine(),
+		GC: runtimeGCStats{
+			NumGC:           m.NumGC,
+			NumForcedGC:     m.NumForcedGC,
+			PauseTotalNanos: m.PauseTotalNs,
+			LastPauseNanos:  m.PauseNs[(m.NumGC+255)%256],
+			CPUFraction:     m.GCCPUFraction,
+			HeapAllocBytes:  m.HeapAlloc,
+			HeapSysBytes:    m.HeapSys,
+			NextGCBytes:     m.NextGC,
+		},
+	}
+	if doc.MaxProcs.Current == 0 {
+		// -autoMaxProcs=false left GOMAXPROCS untouched.
+		doc.MaxProcs.Previous, doc.MaxProcs.Current = doc.GOMAXPROCS, doc.GOMAXPROCS
+	}
+	if m.NumGC > 0 {
+		last := time.Unix(0, int64(m.LastGC)).UTC()
+		doc.GC.LastGC = &last
+	}
+
+	samples := []runtimemetrics.Sample{{Name: "/gc/gogc:percent"}, {Name: "/gc/gomemlimit:bytes"}}
+	runtimemetrics.Read(samples)
+	if samples[0].Value.Kind() == runtimemetrics.KindUint64 {
+		// GOGC=off is reported as -1.
+		doc.GC.GOGC = int(int64(samples[0].Value.Uint64()))
+	}
+	if samples[1].Value.Kind() == runtimemetrics.KindUint64 {
+		if limit := samples[1].Value.Uint64(); limit < math.MaxInt64 {
+			doc.GoMemoryLimitBytes = int64(limit)
+		}
+	}
+
+	info, _ := inspectCgroups()
+	doc.MemoryLimitBytes = info.Limits.MemoryBytes
+	return doc
+}
+
+// handleRuntimeInfo serves GET /runtime_info.
+func handleRuntimeInfo(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, newRuntimeInfoDocument())
+}
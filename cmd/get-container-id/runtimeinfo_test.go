@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/cgroup"
+)
+
+// Test /runtime_info reports GOMAXPROCS, the memory limit and GC stats
+func TestHandleRuntimeInfo(t *testing.T) {
+	stubCgroups(t)
+	inspectCgroups = func(...cgroup.Option) (cgroup.Info, error) {
+		return cgroup.Info{Version: 2, Limits: cgroup.Limits{MemoryBytes: 268435456}}, nil
+	}
+	origMaxProcs := maxProcs
+	defer func() { maxProcs = origMaxProcs }()
+	maxProcs = maxProcsAdjustment{Source: maxProcsSourceCgroup, Previous: 8, Current: 2, CPULimit: 2}
+
+	runtime.GC()
+	w := httptest.NewRecorder()
+	handleRuntimeInfo(w, httptest.NewRequest(http.MethodGet, "/runtime_info", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleRuntimeInfo() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Data runtimeInfoDocument `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	doc := resp.Data
+	if doc.GOMAXPROCS != runtime.GOMAXPROCS(0) || doc.NumCPU != runtime.NumCPU() || doc.NumGoroutine == 0 {
+		t.Errorf("runtime_info = %+v, want the runtime's GOMAXPROCS, NumCPU and goroutines", doc)
+	}
+	if doc.MaxProcs != maxProcs {
+		t.Errorf("maxprocs = %+v, want %+v", doc.MaxProcs, maxProcs)
+	}
+	if doc.MemoryLimitBytes != 268435456 {
+		t.Errorf("memory_limit_bytes = %d, want 268435456", doc.MemoryLimitBytes)
+	}
+	if doc.GC.NumGC == 0 || doc.GC.NumForcedGC == 0 || doc.GC.LastGC == nil || doc.GC.HeapSysBytes == 0 {
+		t.Errorf("gc = %+v, want the forced collection to be counted", doc.GC)
+	}
+	if os.Getenv("GOGC") == "" && doc.GC.GOGC != 100 {
+		t.Errorf("gogc = %d, want the default 100", doc.GC.GOGC)
+	}
+}
+
+// Test /runtime_info reports the current GOMAXPROCS when it was not adjusted
+func TestHandleRuntimeInfo_NotAdjusted(t *testing.T) {
+	stubCgroups(t)
+	origMaxProcs := maxProcs
+	defer func() { maxProcs = origMaxProcs }()
+	maxProcs = maxProcsAdjustment{Source: maxProcsSourceDefault}
+
+	doc := newRuntimeInfoDocument()
+	if doc.MaxProcs.Previous != doc.GOMAXPROCS || doc.MaxProcs.Current != doc.GOMAXPROCS {
+		t.Errorf("maxprocs = %+v, want previous and current %d", doc.MaxProcs, doc.GOMAXPROCS)
+	}
+	if doc.MemoryLimitBytes != 0 {
+		t.Errorf("memory_limit_bytes = %d, want 0 without a limit", doc.MemoryLimitBytes)
+	}
+}
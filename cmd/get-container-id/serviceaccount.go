@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/k8sclient"
+)
+
+var (
+	// readServiceAccountFunc decodes the mounted service account token;
+	// tests replace it.
+	readServiceAccountFunc = func() (k8sclient.ServiceAccount, error) {
+		return k8sclient.ReadServiceAccount(k8sclient.ServiceAccountDir)
+	}
+
+	// serviceAccountNow is the clock token expiry is measured against.
+	serviceAccountNow = time.Now
+)
+
+// serviceAccountDocument is the answer of /serviceaccount.
+type serviceAccountDocument struct {
+	k8sclient.ServiceAccount
+	// Verified is always false: the token's signature is not checked.
+	Verified         bool     `json:"verified"`
+	ExpiresInSeconds *float64 `json:"expires_in_seconds,omitempty"`
+	Expired          bool     `json:"expired"`
+}
+
+// handleServiceAccount serves GET /serviceaccount: the service account
+// name, namespace, audiences and expiry claimed by the mounted token, or
+// 404 when no token is mounted. The token itself is never returned.
+func handleServiceAccount(w http.ResponseWriter, r *http.Request) (any, error) {
+	sa, err := readServiceAccountFunc()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, httpapi.NewError(http.StatusNotFound, errors.New("no service account token is mounted"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc := serviceAccountDocument{ServiceAccount: sa}
+	if sa.Expiry != nil {
+		remaining := sa.Expiry.Sub(serviceAccountNow()).Seconds()
+		doc.ExpiresInSeconds = &remaining
+		doc.Expired = remaining <= 0
+	}
+	return doc, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/k8sclient"
+)
+
+// Test /serviceaccount reports the token's claims and time to expiry
+func TestHandleServiceAccount(t *testing.T) {
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	soon, past := now.Add(10*time.Minute), now.Add(-time.Minute)
+	inTenMinutes, minuteAgo := 600.0, -60.0
+
+	tests := []struct {
+		name        string
+		sa          k8sclient.ServiceAccount
+		err         error
+		wantStatus  int
+		wantExpires *float64
+		wantExpired bool
+	}{
+		{name: "projected", sa: k8sclient.ServiceAccount{Name: "web", Namespace: "default", Expiry: &soon, Projected: true}, wantStatus: http.StatusOK, wantExpires: &inTenMinutes},
+		{name: "expired", sa: k8sclient.ServiceAccount{Name: "web", Namespace: "default", Expiry: &past, Projected: true}, wantStatus: http.StatusOK, wantExpires: &minuteAgo, wantExpired: true},
+		{name: "legacy", sa: k8sclient.ServiceAccount{Name: "builder", Namespace: "tools"}, wantStatus: http.StatusOK},
+		{name: "not mounted", err: fmt.Errorf("failed to read service account token: %w", fs.ErrNotExist), wantStatus: http.StatusNotFound},
+		{name: "malformed", err: errors.New("failed to decode service account token"), wantStatus: http.StatusInternalServerError},
+	}
+	origRead, origNow := readServiceAccountFunc, serviceAccountNow
+	defer func() { readServiceAccountFunc, serviceAccountNow = origRead, origNow }()
+	serviceAccountNow = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readServiceAccountFunc = func() (k8sclient.ServiceAccount, error) { return tt.sa, tt.err }
+
+			w := httptest.NewRecorder()
+			httpapi.HandlerFunc(handleServiceAccount).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/serviceaccount", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data serviceAccountDocument `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got := resp.Data
+			if got.Name != tt.sa.Name || got.Namespace != tt.sa.Namespace || got.Verified {
+				t.Errorf("service account = %+v, want %s/%s unverified", got, tt.sa.Namespace, tt.sa.Name)
+			}
+			if (got.ExpiresInSeconds == nil) != (tt.wantExpires == nil) || (got.ExpiresInSeconds != nil && *got.ExpiresInSeconds != *tt.wantExpires) || got.Expired != tt.wantExpired {
+				t.Errorf("expires_in_seconds = %v, expired = %v; want %v, %v", got.ExpiresInSeconds, got.Expired, tt.wantExpires, tt.wantExpired)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const sessionCookieName = "gcid_session"
+
+// sessionIssuer issues and verifies cookies binding a client to this instance.
+type sessionIssuer struct {
+	secret []byte
+}
+
+// newSessionIssuer creates an issuer signing with secret. An empty secret
+// generates a random per-process key, in which case cookies issued by other
+// replicas are reported as having an invalid signature.
+func newSessionIssuer(secret string) (*sessionIssuer, error) {
+	if secret != "" {
+		return &sessionIssuer{secret: []byte(secret)}, nil
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate session secret: %w", err)
+	}
+	return &sessionIssuer{secret: key}, nil
+}
+
+func (s *sessionIssuer) sign(id string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookieValue returns "<instance ID>.<signature>".
+func (s *sessionIssuer) cookieValue(id string) string {
+	return id + "." + s.sign(id)
+}
+
+// verify splits a cookie value and reports the embedded instance ID and
+// whether its signature is valid.
+func (s *sessionIssuer) verify(value string) (string, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return "", false
+	}
+
+	id, sig := value[:i], value[i+1:]
+	return id, hmac.Equal([]byte(sig), []byte(s.sign(id)))
+}
+
+// sessionStatus is the /session response document.
+type sessionStatus struct {
+	InstanceID       string `json:"instance_id"`
+	CookiePresent    bool   `json:"cookie_present"`
+	CookieInstanceID string `json:"cookie_instance_id,omitempty"`
+	SignatureValid   bool   `json:"signature_valid"`
+	Matches          bool   `json:"matches"`
+	Issued           bool   `json:"issued"`
+}
+
+// handleSession reports whether the request's session cookie was issued by
+// this instance. A cookie is issued when none is present or ?reset=true is set;
+// a mismatched cookie is left in place so broken affinity stays visible.
+func (s *sessionIssuer) handleSession(w http.ResponseWriter, r *http.Request) {
+	st := sessionStatus{InstanceID: instanceID}
+
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		st.CookiePresent = true
+		st.CookieInstanceID, st.SignatureValid = s.verify(c.Value)
+		st.Matches = st.SignatureValid && st.CookieInstanceID == instanceID
+	}
+
+	if !st.CookiePresent || r.URL.Query().Get("reset") == "true" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookieName,
+			Value:    s.cookieValue(instanceID),
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		st.Issued = true
+	}
+
+	httpapi.WriteSuccess(w, st)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func sessionRequest(t *testing.T, s *sessionIssuer, cookie *http.Cookie, target string) (sessionStatus, *http.Cookie) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	s.handleSession(w, r)
+
+	var resp struct {
+		Data sessionStatus `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("handleSession() body unmarshal error: %v", err)
+	}
+
+	var issued *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			issued = c
+		}
+	}
+	return resp.Data, issued
+}
+
+// Test handleSession issues a cookie and recognizes it on later requests
+func TestSessionIssuer(t *testing.T) {
+	s, err := newSessionIssuer("shared-secret")
+	if err != nil {
+		t.Fatalf("newSessionIssuer() error: %v", err)
+	}
+
+	st, cookie := sessionRequest(t, s, nil, "/session")
+	if st.CookiePresent || !st.Issued || cookie == nil {
+		t.Fatalf("first request status = %+v, cookie = %v; want a new cookie", st, cookie)
+	}
+
+	st, again := sessionRequest(t, s, cookie, "/session")
+	if !st.CookiePresent || !st.SignatureValid || !st.Matches || st.Issued || again != nil {
+		t.Errorf("second request status = %+v, want a matching cookie and no reissue", st)
+	}
+
+	// A replica with the same secret but a different instance ID
+	other := &http.Cookie{Name: sessionCookieName, Value: s.cookieValue("other-instance")}
+	st, _ = sessionRequest(t, s, other, "/session")
+	if !st.SignatureValid || st.Matches || st.CookieInstanceID != "other-instance" || st.Issued {
+		t.Errorf("other instance status = %+v, want valid signature and no match", st)
+	}
+
+	st, _ = sessionRequest(t, s, other, "/session?reset=true")
+	if !st.Issued {
+		t.Errorf("reset status = %+v, want issued", st)
+	}
+}
+
+// Test verify rejects tampered or malformed cookies
+func TestSessionIssuer_Verify(t *testing.T) {
+	s, _ := newSessionIssuer("shared-secret")
+	other, _ := newSessionIssuer("")
+
+	value := s.cookieValue("instance-1")
+	if id, ok := s.verify(value); !ok || id != "instance-1" {
+		t.Errorf("verify(valid) = %q, %v", id, ok)
+	}
+	if _, ok := other.verify(value); ok {
+		t.Error("verify() with a different secret should fail")
+	}
+	if _, ok := s.verify("instance-2" + value[len("instance-1"):]); ok {
+		t.Error("verify() of a tampered instance ID should fail")
+	}
+	if _, ok := s.verify("no-signature"); ok {
+		t.Error("verify() of a malformed cookie should fail")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// shutdownState tracks termination signals and in-flight requests so the
+// drain phase of a graceful shutdown can be observed over HTTP.
+type shutdownState struct {
+	mu         sync.RWMutex
+	signal     os.Signal
+	signaledAt time.Time
+
+	inFlight atomic.Int64
+
+	// now is replaceable for tests.
+	now func() time.Time
+}
+
+func newShutdownState() *shutdownState {
+	return &shutdownState{now: time.Now}
+}
+
+// begin records the first termination signal and reports whether sig was
+// it; later signals are ignored.
+func (s *shutdownState) begin(sig os.Signal) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signal != nil {
+		return false
+	}
+	s.signal = sig
+	s.signaledAt = s.now()
+	return true
+}
+
+// draining reports whether a termination signal has been received.
+func (s *shutdownState) draining() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signal != nil
+}
+
+// check writes a 503 and returns false once a termination signal has been
+// received, so load balancers stop routing new requests during the drain.
+func (s *shutdownState) check(w http.ResponseWriter) bool {
+	if !s.draining() {
+		return true
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte("shutting down"))
+	return false
+}
+
+// shutdownStatus is the /shutdown_state response document.
+type shutdownStatus struct {
+	SignalReceived  bool    `json:"signal_received"`
+	Signal          string  `json:"signal,omitempty"`
+	SignaledAt      string  `json:"signaled_at,omitempty"`
+	DrainingSeconds float64 `json:"draining_seconds"`
+	InFlight        int64   `json:"in_flight"`
+}
+
+func (s *shutdownState) status() shutdownStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := shutdownStatus{InFlight: s.inFlight.Load()}
+	if s.signal != nil {
+		st.SignalReceived = true
+		st.Signal = s.signal.String()
+		st.SignaledAt = s.signaledAt.Format(time.RFC3339Nano)
+		st.DrainingSeconds = s.now().Sub(s.signaledAt).Seconds()
+	}
+	return st
+}
+
+// middleware counts requests currently being served.
+func (s *shutdownState) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *shutdownState) handleState(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, s.status())
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Test check lets readiness probes pass until a termination signal arrives
+func TestShutdownState_Check(t *testing.T) {
+	s := newShutdownState()
+
+	rec := httptest.NewRecorder()
+	if !s.check(rec) || rec.Code != http.StatusOK {
+		t.Errorf("check() before signal = false, %d, want true, 200", rec.Code)
+	}
+
+	s.begin(syscall.SIGTERM)
+	rec = httptest.NewRecorder()
+	if s.check(rec) || rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("check() after signal = true, %d, want false, 503", rec.Code)
+	}
+}
+
+// Test shutdownState reports the signal and drain duration
+func TestShutdownState(t *testing.T) {
+	now := time.Now()
+	s := newShutdownState()
+	s.now = func() time.Time { return now }
+
+	if st := s.status(); st.SignalReceived || s.draining() {
+		t.Errorf("status() before signal = %+v, want no signal", st)
+	}
+
+	if !s.begin(syscall.SIGTERM) {
+		t.Error("begin(SIGTERM) = false, want true for the first signal")
+	}
+	now = now.Add(5 * time.Second)
+	if s.begin(syscall.SIGINT) {
+		t.Error("begin(SIGINT) = true, want later signals ignored")
+	}
+
+	st := s.status()
+	if !st.SignalReceived || !s.draining() {
+		t.Errorf("status() after signal = %+v, want signal received", st)
+	}
+	if st.Signal != syscall.SIGTERM.String() {
+		t.Errorf("status() signal = %q, want %q", st.Signal, syscall.SIGTERM.String())
+	}
+	if st.DrainingSeconds != 5 {
+		t.Errorf("status() draining_seconds = %v, want 5", st.DrainingSeconds)
+	}
+}
+
+// Test shutdownState middleware counts in-flight requests
+func TestShutdownState_InFlight(t *testing.T) {
+	s := newShutdownState()
+
+	var seen int64
+	h := s.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = s.status().InFlight
+		s.handleState(w, r)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shutdown_state", nil))
+
+	if seen != 1 {
+		t.Errorf("in-flight during request = %d, want 1", seen)
+	}
+	if got := s.status().InFlight; got != 0 {
+		t.Errorf("in-flight after request = %d, want 0", got)
+	}
+
+	var resp struct {
+		Data shutdownStatus `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("handleState() body unmarshal error: %v", err)
+	}
+	if resp.Data.InFlight != 1 {
+		t.Errorf("handleState() in_flight = %d, want 1", resp.Data.InFlight)
+	}
+}
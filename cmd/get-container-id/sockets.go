@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+// procNetDir holds the socket tables of the network namespace.
+var procNetDir = "/proc/net"
+
+// socketProtocols are the socket tables /sockets reads, by file name.
+var socketProtocols = []string{"tcp", "tcp6", "udp", "udp6"}
+
+// maxEstablished bounds the connections /sockets lists.
+const maxEstablished = 1000
+
+// tcpStates names the states of the socket tables, see
+// include/net/tcp_states.h. UDP sockets use ESTABLISHED once connected
+// and CLOSE otherwise.
+var tcpStates = map[uint64]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+	0x0C: "NEW_SYN_RECV",
+}
+
+// socket is an entry of a socket table.
+type socket struct {
+	Protocol   string `json:"protocol"`
+	LocalAddr  string `json:"local_addr"`
+	LocalPort  int    `json:"local_port"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	RemotePort int    `json:"remote_port,omitempty"`
+	State      string `json:"state"`
+	UID        int    `json:"uid"`
+	Inode      uint64 `json:"inode"`
+}
+
+// socketsDocument summarizes the sockets of the pod's network namespace.
+type socketsDocument struct {
+	// Listening holds the listening TCP sockets and the bound, unconnected
+	// UDP sockets.
+	Listening []socket `json:"listening"`
+	// Established holds up to maxEstablished connected sockets.
+	Established []socket `json:"established"`
+	Truncated   bool     `json:"truncated"`
+	// States counts the sockets of each protocol by state.
+	States map[string]map[string]int `json:"states"`
+}
+
+// parseSockets parses a /proc/net socket table of protocol; the *6 tables
+// hold IPv6 addresses. Addresses are hex in host byte order, word by word.
+func parseSockets(r io.Reader, protocol string, bigEndian bool) []socket {
+	sockets := []socket{}
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localAddr, localPort, okLocal := parseSocketAddr(fields[1], bigEndian)
+		remoteAddr, remotePort, okRemote := parseSocketAddr(fields[2], bigEndian)
+		state, errState := strconv.ParseUint(fields[3], 16, 8)
+		uid, errUID := strconv.Atoi(fields[7])
+		inode, errInode := strconv.ParseUint(fields[9], 10, 64)
+		if !okLocal || !okRemote || errState != nil || errUID != nil || errInode != nil {
+			continue
+		}
+		s := socket{
+			Protocol:  protocol,
+			LocalAddr: localAddr.String(),
+			LocalPort: localPort,
+			State:     tcpStates[state],
+			UID:       uid,
+			Inode:     inode,
+		}
+		if s.State == "" {
+			s.State = strconv.FormatUint(state, 10)
+		}
+		if remotePort != 0 || !remoteAddr.IsUnspecified() {
+			s.RemoteAddr, s.RemotePort = remoteAddr.String(), remotePort
+		}
+		sockets = append(sockets, s)
+	}
+	return sockets
+}
+
+// parseSocketAddr parses an ADDR:PORT field of a socket table.
+func parseSocketAddr(s string, bigEndian bool) (net.IP, int, bool) {
+	addr, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, false
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return nil, 0, false
+	}
+	b, err := hex.DecodeString(addr)
+	if err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return nil, 0, false
+	}
+	if !bigEndian {
+		for i := 0; i < len(b); i += 4 {
+			b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+		}
+	}
+	return net.IP(b), int(p), true
+}
+
+// listening reports whether s accepts traffic: a listening TCP socket or
+// an unconnected UDP one.
+func (s socket) listening() bool {
+	if strings.HasPrefix(s.Protocol, "udp") {
+		return s.State == "CLOSE" && s.RemoteAddr == ""
+	}
+	return s.State == "LISTEN"
+}
+
+// summarizeSockets sorts sockets into a socketsDocument.
+func summarizeSockets(sockets []socket) socketsDocument {
+	doc := socketsDocument{
+		Listening:   []socket{},
+		Established: []socket{},
+		States:      map[string]map[string]int{},
+	}
+	for _, s := range sockets {
+		if doc.States[s.Protocol] == nil {
+			doc.States[s.Protocol] = map[string]int{}
+		}
+		doc.States[s.Protocol][s.State]++
+
+		switch {
+		case s.listening():
+			doc.Listening = append(doc.Listening, s)
+		case s.State == "ESTABLISHED":
+			if len(doc.Established) == maxEstablished {
+				doc.Truncated = true
+				continue
+			}
+			doc.Established = append(doc.Established, s)
+		}
+	}
+	sort.SliceStable(doc.Listening, func(i, j int) bool {
+		return doc.Listening[i].LocalPort < doc.Listening[j].LocalPort
+	})
+	return doc
+}
+
+// readSockets reads the socket tables of procNetDir. Tables that cannot be
+// read, e.g. tcp6 with IPv6 disabled, are skipped.
+func readSockets() socketsDocument {
+	var sockets []socket
+	for _, protocol := range socketProtocols {
+		f, err := os.Open(filepath.Join(procNetDir, protocol))
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, parseSockets(f, protocol, nativeBigEndian)...)
+		f.Close()
+	}
+	return summarizeSockets(sockets)
+}
+
+// handleSockets serves GET /sockets.
+func handleSockets(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, readSockets())
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test socket tables are decoded from host byte order
+func TestParseSockets(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 1701F40A:1F90 0201F40A:D431 01 00000000:00000000 00:00000000 00000000  1000        0 12346 1 0000000000000000 20 4 30 10 -1
+   2: 1701F40A:1F90 zz:D431 01 00000000:00000000 00:00000000 00000000  1000        0 12347
+`
+	want := []socket{
+		{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 8080, State: "LISTEN", UID: 1000, Inode: 12345},
+		{Protocol: "tcp", LocalAddr: "10.244.1.23", LocalPort: 8080, RemoteAddr: "10.244.1.2", RemotePort: 54321, State: "ESTABLISHED", UID: 1000, Inode: 12346},
+	}
+	if got := parseSockets(strings.NewReader(tcp), "tcp", false); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSockets(tcp) = %+v, want %+v", got, want)
+	}
+
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F91 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 222 1 0000000000000000 100 0 0 10 0
+`
+	got := parseSockets(strings.NewReader(tcp6), "tcp6", false)
+	if len(got) != 1 || !net.ParseIP(got[0].LocalAddr).Equal(net.IPv6loopback) || got[0].LocalPort != 8081 {
+		t.Errorf("parseSockets(tcp6) = %+v, want [::1]:8081", got)
+	}
+
+	bigEndian := "header\n0: 7F000001:0035 00000000:0000 07 0:0 0:0 0 0 0 333\n"
+	got = parseSockets(strings.NewReader(bigEndian), "udp", true)
+	if len(got) != 1 || got[0].LocalAddr != "127.0.0.1" || got[0].LocalPort != 53 || got[0].State != "CLOSE" {
+		t.Errorf("parseSockets(big endian udp) = %+v, want 127.0.0.1:53", got)
+	}
+}
+
+// Test sockets are sorted into listening and established, with counts by
+// state
+func TestSummarizeSockets(t *testing.T) {
+	sockets := []socket{
+		{Protocol: "tcp", LocalPort: 9090, State: "LISTEN"},
+		{Protocol: "tcp", LocalPort: 8080, State: "LISTEN"},
+		{Protocol: "tcp", LocalPort: 8080, RemoteAddr: "10.0.0.2", RemotePort: 1234, State: "ESTABLISHED"},
+		{Protocol: "tcp", LocalPort: 8080, RemoteAddr: "10.0.0.3", RemotePort: 1235, State: "TIME_WAIT"},
+		{Protocol: "udp", LocalPort: 53, State: "CLOSE"},
+		{Protocol: "udp", LocalPort: 40000, RemoteAddr: "10.0.0.10", RemotePort: 53, State: "ESTABLISHED"},
+	}
+	doc := summarizeSockets(sockets)
+
+	var ports []int
+	for _, s := range doc.Listening {
+		ports = append(ports, s.LocalPort)
+	}
+	if !reflect.DeepEqual(ports, []int{53, 8080, 9090}) {
+		t.Errorf("listening ports = %v, want [53 8080 9090]", ports)
+	}
+	if len(doc.Established) != 2 || doc.Truncated {
+		t.Errorf("established = %+v, truncated = %v", doc.Established, doc.Truncated)
+	}
+	want := map[string]map[string]int{
+		"tcp": {"LISTEN": 2, "ESTABLISHED": 1, "TIME_WAIT": 1},
+		"udp": {"CLOSE": 1, "ESTABLISHED": 1},
+	}
+	if !reflect.DeepEqual(doc.States, want) {
+		t.Errorf("states = %v, want %v", doc.States, want)
+	}
+
+	many := make([]socket, maxEstablished+1)
+	for i := range many {
+		many[i] = socket{Protocol: "tcp", State: "ESTABLISHED", RemoteAddr: "10.0.0.2", RemotePort: i + 1}
+	}
+	if doc := summarizeSockets(many); len(doc.Established) != maxEstablished || !doc.Truncated {
+		t.Errorf("established = %d, truncated = %v; want %d, true", len(doc.Established), doc.Truncated, maxEstablished)
+	}
+}
+
+// Test /sockets skips missing tables
+func TestReadSockets(t *testing.T) {
+	orig := procNetDir
+	defer func() { procNetDir = orig }()
+	procNetDir = t.TempDir()
+	table := "header\n0: 00000000:1F90 00000000:0000 0A 0:0 0:0 0 0 0 1\n"
+	if err := os.WriteFile(filepath.Join(procNetDir, "tcp"), []byte(table), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := readSockets()
+	if len(doc.Listening) != 1 || doc.Listening[0].LocalPort != 8080 || len(doc.States) != 1 {
+		t.Errorf("readSockets = %+v, want one listener on 8080", doc)
+	}
+}
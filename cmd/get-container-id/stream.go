@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	defaultRandomBytes = 1024
+	maxRandomBytes     = 1 << 30 // 1GB
+
+	defaultStreamCount    = 10
+	defaultStreamInterval = time.Second
+)
+
+// queryInt returns the integer query parameter name, or def when absent.
+// It reports false when the value is present but not an integer within [lo, hi].
+func queryInt(r *http.Request, name string, def, lo, hi int64) (int64, bool) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, true
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < lo || v > hi {
+		return 0, false
+	}
+	return v, true
+}
+
+// handleRandom writes ?bytes= random bytes, optionally paced with ?bps=.
+func handleRandom(w http.ResponseWriter, r *http.Request) {
+	n, ok := queryInt(r, "bytes", defaultRandomBytes, 0, maxRandomBytes)
+	if !ok {
+		httpapi.WriteError(w, "bytes must be an integer between 0 and "+strconv.Itoa(maxRandomBytes), http.StatusBadRequest)
+		return
+	}
+
+	out, err := throttleResponse(w, r)
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(headerContentType, "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(n, 10))
+	w.WriteHeader(http.StatusOK)
+	io.CopyN(out, rand.Reader, n)
+}
+
+// handleStream writes ?count= newline-delimited JSON records, one every
+// ?intervalMs= milliseconds, flushing after each, optionally paced with ?bps=.
+func handleStream(w http.ResponseWriter, r *http.Request) {
+	count, ok := queryInt(r, "count", defaultStreamCount, 1, 1<<20)
+	if !ok {
+		httpapi.WriteError(w, "count must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	intervalMs, ok := queryInt(r, "intervalMs", defaultStreamInterval.Milliseconds(), 0, int64(time.Hour/time.Millisecond))
+	if !ok {
+		httpapi.WriteError(w, "intervalMs must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	out, err := throttleResponse(w, r)
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set(headerContentType, "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(out)
+	enc := json.NewEncoder(out)
+	interval := time.Duration(intervalMs) * time.Millisecond
+
+	for seq := int64(1); seq <= count; seq++ {
+		if err := enc.Encode(map[string]any{
+			"seq":         seq,
+			"time":        time.Now().Format(time.RFC3339Nano),
+			"instance_id": instanceID,
+		}); err != nil {
+			return
+		}
+		rc.Flush()
+
+		if seq == count {
+			break
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// heartbeatRecord is one line of the /heartbeat stream.
+type heartbeatRecord struct {
+	Seq         uint64 `json:"seq"`
+	Time        string `json:"time"`
+	InstanceID  string `json:"instance_id"`
+	ContainerID string `json:"container_id,omitempty"`
+	PodID       string `json:"pod_id,omitempty"`
+}
+
+// handleHeartbeat streams a newline-delimited JSON record identifying this
+// backend every ?intervalMs= milliseconds until the client disconnects.
+func handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	intervalMs, ok := queryInt(r, "intervalMs", defaultStreamInterval.Milliseconds(), 1, int64(time.Hour/time.Millisecond))
+	if !ok {
+		httpapi.WriteError(w, "intervalMs must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Long-lived stream: lift the server-wide write timeout for this response.
+	rc.SetWriteDeadline(time.Time{})
+
+	containerID, _ := getContainerID(r.Context())
+	podID, _ := podid.GetContext(r.Context())
+
+	w.Header().Set(headerContentType, "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	ticker := time.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for seq := uint64(1); ; seq++ {
+		if err := enc.Encode(heartbeatRecord{
+			Seq:         seq,
+			Time:        time.Now().Format(time.RFC3339Nano),
+			InstanceID:  instanceID,
+			ContainerID: containerID,
+			PodID:       podID,
+		}); err != nil {
+			return
+		}
+		rc.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
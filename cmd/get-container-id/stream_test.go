@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test handleRandom returns the requested number of bytes
+func TestHandleRandom(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleRandom(w, httptest.NewRequest(http.MethodGet, "/random?bytes=4096", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleRandom() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w.Body.Len() != 4096 {
+		t.Errorf("handleRandom() body length = %d, want 4096", w.Body.Len())
+	}
+
+	w = httptest.NewRecorder()
+	handleRandom(w, httptest.NewRequest(http.MethodGet, "/random?bps=slow", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("handleRandom() with invalid bps status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// Test handleStream writes one JSON line per record
+func TestHandleStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleStream(w, httptest.NewRequest(http.MethodGet, "/stream?count=3&intervalMs=0", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleStream() status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(w.Body.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Errorf("handleStream() wrote %d lines, want 3", len(lines))
+	}
+}
+
+// Test handleHeartbeat streams records until the client disconnects
+func TestHandleHeartbeat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	handleHeartbeat(w, httptest.NewRequest(http.MethodGet, "/heartbeat?intervalMs=20", nil).WithContext(ctx))
+
+	lines := bytes.Split(bytes.TrimSpace(w.Body.Bytes()), []byte("\n"))
+	if len(lines) < 3 {
+		t.Fatalf("handleHeartbeat() wrote %d lines, want at least 3", len(lines))
+	}
+
+	var first heartbeatRecord
+	if err := json.Unmarshal(lines[0], &first); err != nil {
+		t.Fatalf("handleHeartbeat() line unmarshal error: %v", err)
+	}
+	if first.Seq != 1 || first.InstanceID != instanceID {
+		t.Errorf("handleHeartbeat() first record = %+v", first)
+	}
+}
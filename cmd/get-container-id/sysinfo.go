@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+var (
+	// procSysKernelDir holds the kernel's uname fields, one file each.
+	procSysKernelDir = "/proc/sys/kernel"
+	procStatPath     = "/proc/stat"
+	// osReleasePaths are tried in order, as os-release(5) prescribes.
+	osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+)
+
+// goarchMachines maps GOARCH to the uname machine name, for kernels without
+// /proc/sys/kernel/arch.
+var goarchMachines = map[string]string{
+	"amd64":   "x86_64",
+	"386":     "i686",
+	"arm64":   "aarch64",
+	"arm":     "armv7l",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// sysInfoDocument describes the kernel and OS the replica runs on. The
+// kernel, page size and boot time are the node's, while os_release is the
+// container image's. Fields that cannot be read are omitted.
+type sysInfoDocument struct {
+	Uname     sysUname          `json:"uname"`
+	OSRelease map[string]string `json:"os_release,omitempty"`
+	// Arch is the GOARCH the binary was built for.
+	Arch     string `json:"arch"`
+	PageSize int    `json:"page_size"`
+	// BootTime is when the node booted.
+	BootTime      *time.Time `json:"boot_time,omitempty"`
+	UptimeSeconds float64    `json:"uptime_seconds,omitempty"`
+}
+
+// sysUname holds the fields of uname(2).
+type sysUname struct {
+	Sysname    string `json:"sysname,omitempty"`
+	Nodename   string `json:"nodename,omitempty"`
+	Release    string `json:"release,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Machine    string `json:"machine,omitempty"`
+	Domainname string `json:"domainname,omitempty"`
+}
+
+// readSysInfo gathers the system information. Errors only leave the
+// corresponding fields empty.
+func readSysInfo(now time.Time) sysInfoDocument {
+	doc := sysInfoDocument{
+		Uname: sysUname{
+			Sysname:    readKernelField("ostype"),
+			Nodename:   readKernelField("hostname"),
+			Release:    readKernelField("osrelease"),
+			Version:    readKernelField("version"),
+			Machine:    readKernelField("arch"),
+			Domainname: readKernelField("domainname"),
+		},
+		Arch:     runtime.GOARCH,
+		PageSize: os.Getpagesize(),
+	}
+	if doc.Uname.Machine == "" {
+		doc.Uname.Machine = goarchMachines[runtime.GOARCH]
+	}
+	if doc.Uname.Domainname == "(none)" {
+		doc.Uname.Domainname = ""
+	}
+
+	for _, path := range osReleasePaths {
+		if f, err := os.Open(path); err == nil {
+			doc.OSRelease = parseOSRelease(f)
+			f.Close()
+			break
+		}
+	}
+
+	if f, err := os.Open(procStatPath); err == nil {
+		if boot, ok := parseBootTime(f); ok {
+			doc.BootTime = &boot
+			doc.UptimeSeconds = now.Sub(boot).Seconds()
+		}
+		f.Close()
+	}
+	return doc
+}
+
+// readKernelField reads a file of procSysKernelDir, or returns "".
+func readKernelField(name string) string {
+	b, err := os.ReadFile(filepath.Join(procSysKernelDir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// parseOSRelease parses the KEY=value lines of os-release(5), unquoting
+// values. Comments and malformed lines are skipped.
+func parseOSRelease(r io.Reader) map[string]string {
+	release := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			quote := value[0]
+			value = value[1 : len(value)-1]
+			if quote == '"' {
+				// Double-quoted values may escape $, ", \ and `.
+				var b strings.Builder
+				for i := 0; i < len(value); i++ {
+					if value[i] == '\\' && i+1 < len(value) && strings.IndexByte("$\"\\`", value[i+1]) >= 0 {
+						i++
+					}
+					b.WriteByte(value[i])
+				}
+				value = b.String()
+			}
+		}
+		release[key] = value
+	}
+	return release
+}
+
+// parseBootTime reads the btime line of /proc/stat: the boot time in
+// seconds since the epoch.
+func parseBootTime(r io.Reader) (time.Time, bool) {
+	scanner := bufio.NewScanner(r)
+	// The intr line before it lists every interrupt and outgrows the
+	// default buffer on large machines.
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(sec, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// handleSysInfo serves GET /sysinfo.
+func handleSysInfo(w http.ResponseWriter, r *http.Request) {
+	httpapi.WriteSuccess(w, readSysInfo(time.Now()))
+}
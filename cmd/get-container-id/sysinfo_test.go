@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test os-release lines are unquoted and comments skipped
+func TestParseOSRelease(t *testing.T) {
+	input := `# comment
+NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.20.3
+PRETTY_NAME='Alpine Linux v3.20'
+QUOTED="say \"hi\" for \$5"
+broken line
+
+`
+	got := parseOSRelease(strings.NewReader(input))
+	want := map[string]string{
+		"NAME":        "Alpine Linux",
+		"ID":          "alpine",
+		"VERSION_ID":  "3.20.3",
+		"PRETTY_NAME": "Alpine Linux v3.20",
+		"QUOTED":      `say "hi" for $5`,
+	}
+	if len(got) != len(want) {
+		t.Errorf("parseOSRelease = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// Test the boot time is read from the btime line past a long intr line
+func TestParseBootTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "btime",
+			input:  "cpu  1 2 3\nintr " + strings.Repeat("0 ", 100000) + "\nctxt 5\nbtime 1700000000\nprocesses 7\n",
+			want:   time.Unix(1700000000, 0).UTC(),
+			wantOK: true,
+		},
+		{name: "missing", input: "cpu  1 2 3\n"},
+		{name: "invalid", input: "btime soon\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseBootTime(strings.NewReader(tt.input))
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("parseBootTime = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Test /sysinfo combines the uname fields, os-release and boot time
+func TestReadSysInfo(t *testing.T) {
+	origKernel, origStat, origRelease := procSysKernelDir, procStatPath, osReleasePaths
+	defer func() { procSysKernelDir, procStatPath, osReleasePaths = origKernel, origStat, origRelease }()
+
+	procSysKernelDir = t.TempDir()
+	for name, value := range map[string]string{
+		"ostype":     "Linux",
+		"hostname":   "node-1",
+		"osrelease":  "6.1.0-18-amd64",
+		"version":    "#1 SMP PREEMPT_DYNAMIC Debian 6.1.76-1",
+		"domainname": "(none)",
+	} {
+		if err := os.WriteFile(filepath.Join(procSysKernelDir, name), []byte(value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	procStatPath = writeTestFile(t, "btime 1700000000\n")
+	osReleasePaths = []string{filepath.Join(t.TempDir(), "missing"), writeTestFile(t, "ID=debian\n")}
+
+	now := time.Unix(1700000060, 0)
+	doc := readSysInfo(now)
+	want := sysUname{
+		Sysname:  "Linux",
+		Nodename: "node-1",
+		Release:  "6.1.0-18-amd64",
+		Version:  "#1 SMP PREEMPT_DYNAMIC Debian 6.1.76-1",
+		Machine:  goarchMachines[runtime.GOARCH],
+	}
+	if doc.Uname != want {
+		t.Errorf("uname = %+v, want %+v", doc.Uname, want)
+	}
+	if doc.OSRelease["ID"] != "debian" {
+		t.Errorf("os_release = %v, want the fallback path read", doc.OSRelease)
+	}
+	if doc.BootTime == nil || doc.BootTime.Unix() != 1700000000 || doc.UptimeSeconds != 60 {
+		t.Errorf("boot_time = %v, uptime_seconds = %v; want 1700000000 and 60", doc.BootTime, doc.UptimeSeconds)
+	}
+	if doc.Arch != runtime.GOARCH || doc.PageSize <= 0 {
+		t.Errorf("arch = %q, page_size = %d", doc.Arch, doc.PageSize)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxThrottleChunk = 32 * 1024
+
+// throttledWriter paces writes to the underlying ResponseWriter so that the
+// average throughput stays at or below bps bytes per second.
+type throttledWriter struct {
+	http.ResponseWriter
+
+	ctx     context.Context
+	bps     int64
+	start   time.Time
+	written int64
+}
+
+func newThrottledWriter(ctx context.Context, w http.ResponseWriter, bps int64) *throttledWriter {
+	return &throttledWriter{ResponseWriter: w, ctx: ctx, bps: bps, start: time.Now()}
+}
+
+// chunkSize splits writes into roughly ten chunks per second.
+func (t *throttledWriter) chunkSize() int {
+	size := t.bps / 10
+	if size < 1 {
+		size = 1
+	}
+	if size > maxThrottleChunk {
+		size = maxThrottleChunk
+	}
+	return int(size)
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	total := 0
+	for len(p) > 0 {
+		chunk := min(len(p), t.chunkSize())
+
+		n, err := t.ResponseWriter.Write(p[:chunk])
+		total += n
+		t.written += int64(n)
+		if err != nil {
+			return total, err
+		}
+		p = p[chunk:]
+
+		if f, ok := t.ResponseWriter.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		due := time.Duration(float64(t.written) / float64(t.bps) * float64(time.Second))
+		if wait := due - time.Since(t.start); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-t.ctx.Done():
+				timer.Stop()
+				return total, t.ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return total, nil
+}
+
+func (t *throttledWriter) Flush() {
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (t *throttledWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// parseBPS parses a bytes-per-second value; zero disables throttling.
+func parseBPS(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	bps, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || bps < 0 {
+		return 0, fmt.Errorf("invalid bps %q: must be a non-negative integer", s)
+	}
+	return bps, nil
+}
+
+// throttleResponse wraps w according to the request's ?bps= parameter.
+func throttleResponse(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, error) {
+	bps, err := parseBPS(r.URL.Query().Get("bps"))
+	if err != nil {
+		return w, err
+	}
+	if bps == 0 {
+		return w, nil
+	}
+	return newThrottledWriter(r.Context(), w, bps), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Test throttledWriter paces writes to the configured bandwidth
+func TestThrottledWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	tw := newThrottledWriter(context.Background(), rec, 1000)
+
+	payload := bytes.Repeat([]byte("x"), 200)
+	start := time.Now()
+	n, err := tw.Write(payload)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if n != len(payload) {
+		t.Errorf("Write() = %d, want %d", n, len(payload))
+	}
+	if !bytes.Equal(rec.Body.Bytes(), payload) {
+		t.Errorf("Write() body mismatch, got %d bytes", rec.Body.Len())
+	}
+
+	// 200 bytes at 1000 B/s should take about 200ms
+	if elapsed < 150*time.Millisecond {
+		t.Errorf("Write() took %v, want at least 150ms", elapsed)
+	}
+}
+
+// Test throttledWriter stops when the request context is cancelled
+func TestThrottledWriter_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tw := newThrottledWriter(ctx, httptest.NewRecorder(), 10)
+	_, err := tw.Write(bytes.Repeat([]byte("x"), 100))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Write() error = %v, want context.Canceled", err)
+	}
+}
+
+// Test parseBPS
+func TestParseBPS(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "0", want: 0},
+		{input: "1024", want: 1024},
+		{input: "-1", wantErr: true},
+		{input: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBPS(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBPS(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseBPS(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ErrNoClientCAs is returned when the -tlsClientCA file holds no PEM
+// certificates.
+var ErrNoClientCAs = errors.New("no certificates found in client CA file")
+
+// tlsReloader holds the server certificate and client CA pool loaded from
+// -tlsCert, -tlsKey and -tlsClientCA, and reloads them on demand so that
+// rotated files take effect without a restart.
+type tlsReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	nextProtos   []string
+
+	mu     sync.RWMutex
+	config *tls.Config
+}
+
+// newTLSReloader loads the certificate, key and optional client CA bundle.
+// With a client CA bundle, clients must present a certificate it signed.
+// nextProtos are the ALPN protocols offered to clients.
+func newTLSReloader(certFile, keyFile, clientCAFile string, nextProtos []string) (*tlsReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("-tlsCert and -tlsKey must be set together")
+	}
+	r := &tlsReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile, nextProtos: nextProtos}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the files again. On error the previous configuration stays
+// in use.
+func (r *tlsReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   r.nextProtos,
+	}
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("load TLS client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load TLS client CA %s: %w", r.clientCAFile, ErrNoClientCAs)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.mu.Unlock()
+	return nil
+}
+
+// mutual reports whether clients must present a certificate.
+func (r *tlsReloader) mutual() bool {
+	return r.clientCAFile != ""
+}
+
+// serverConfig returns a tls.Config that hands each new connection the most
+// recently loaded configuration.
+func (r *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.config, nil
+		},
+	}
+}
+
+// tlsInfo describes the TLS state of a request for /echo.
+type tlsInfo struct {
+	Version     string   `json:"version"`
+	CipherSuite string   `json:"cipher_suite"`
+	ServerName  string   `json:"server_name,omitempty"`
+	Protocol    string   `json:"negotiated_protocol,omitempty"`
+	PeerSubject []string `json:"peer_certificates,omitempty"`
+}
+
+// newTLSInfo returns nil for plain HTTP requests.
+func newTLSInfo(state *tls.ConnectionState) *tlsInfo {
+	if state == nil {
+		return nil
+	}
+	info := &tlsInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+		Protocol:    state.NegotiatedProtocol,
+	}
+	for _, cert := range state.PeerCertificates {
+		info.PeerSubject = append(info.PeerSubject, cert.Subject.String())
+	}
+	return info
+}
+
+// reloadOnSIGHUP reloads the certificate files of every non-nil reloader
+// each time the process receives SIGHUP. It never returns.
+func reloadOnSIGHUP(logger *slog.Logger, reloaders ...*tlsReloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		for _, certs := range reloaders {
+			if certs == nil {
+				continue
+			}
+			if err := certs.reload(); err != nil {
+				logger.Error("TLS reload failed, keeping the previous certificates", slog.Any("error", err))
+				continue
+			}
+			logger.Info("TLS certificates reloaded", slog.String("cert", certs.certFile), slog.String("client_ca", certs.clientCAFile))
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var h2Protos = []string{"h2", "http/1.1"}
+
+// testCert is a certificate and key, signed by parent or self-signed.
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write stores the certificate and key as PEM files in dir.
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// serveTLS serves a handler that echoes the client certificate subject over
+// the reloader's configuration and returns its address.
+func serveTLS(t *testing.T, certs *tlsReloader) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info := newTLSInfo(r.TLS); info != nil && len(info.PeerSubject) > 0 {
+			w.Write([]byte(info.PeerSubject[0]))
+		}
+	})}
+	srv.ErrorLog = log.New(io.Discard, "", 0)
+	go srv.Serve(tls.NewListener(ln, certs.serverConfig()))
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String()
+}
+
+func tlsClient(roots *x509.CertPool, clientCert *testCert) *http.Client {
+	config := &tls.Config{RootCAs: roots}
+	if clientCert != nil {
+		config.Certificates = []tls.Certificate{clientCert.tlsCertificate()}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: config, ForceAttemptHTTP2: true}, Timeout: 5 * time.Second}
+}
+
+// Test the server requires a client certificate signed by -tlsClientCA
+func TestTLSReloader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil, true)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "server", ca, false).write(t, dir, "server")
+
+	certs, err := newTLSReloader(certFile, keyFile, caFile, h2Protos)
+	if err != nil {
+		t.Fatalf("newTLSReloader() error = %v", err)
+	}
+	if !certs.mutual() {
+		t.Error("mutual() = false, want true with a client CA")
+	}
+	addr := serveTLS(t, certs)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	if _, err := tlsClient(roots, nil).Get("https://" + addr); err == nil {
+		t.Error("request without a client certificate succeeded, want handshake error")
+	}
+
+	other := newTestCert(t, "other-ca", nil, true)
+	if _, err := tlsClient(roots, newTestCert(t, "intruder", other, false)).Get("https://" + addr); err == nil {
+		t.Error("request with a certificate from another CA succeeded, want handshake error")
+	}
+
+	resp, err := tlsClient(roots, newTestCert(t, "client", ca, false)).Get("https://" + addr)
+	if err != nil {
+		t.Fatalf("request with a valid client certificate error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("negotiated protocol = %s, want HTTP/2", resp.Proto)
+	}
+}
+
+// Test reload picks up a rotated certificate and keeps the old one on error
+func TestTLSReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil, true)
+	first := newTestCert(t, "first", ca, false)
+	certFile, keyFile := first.write(t, dir, "server")
+
+	certs, err := newTLSReloader(certFile, keyFile, "", h2Protos)
+	if err != nil {
+		t.Fatalf("newTLSReloader() error = %v", err)
+	}
+	addr := serveTLS(t, certs)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	leaf := func() string {
+		t.Helper()
+		resp, err := tlsClient(roots, nil).Get("https://" + addr)
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if got := leaf(); got != "first" {
+		t.Errorf("served certificate = %q, want %q", got, "first")
+	}
+
+	newTestCert(t, "second", ca, false).write(t, dir, "server")
+	if err := certs.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if got := leaf(); got != "second" {
+		t.Errorf("served certificate after reload = %q, want %q", got, "second")
+	}
+
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := certs.reload(); err == nil {
+		t.Error("reload() with a broken certificate succeeded, want error")
+	}
+	if got := leaf(); got != "second" {
+		t.Errorf("served certificate after failed reload = %q, want %q", got, "second")
+	}
+}
+
+// Test newTLSReloader rejects incomplete and invalid configurations
+func TestNewTLSReloader_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "server", nil, false).write(t, dir, "server")
+	emptyCA := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(emptyCA, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTLSReloader(certFile, "", "", h2Protos); err == nil {
+		t.Error("newTLSReloader() without a key succeeded, want error")
+	}
+	if _, err := newTLSReloader("", "", filepath.Join(dir, "ca.pem"), h2Protos); err == nil {
+		t.Error("newTLSReloader() with only a client CA succeeded, want error")
+	}
+	if _, err := newTLSReloader(certFile, keyFile, emptyCA, h2Protos); !errors.Is(err, ErrNoClientCAs) {
+		t.Errorf("newTLSReloader() with an empty client CA error = %v, want %v", err, ErrNoClientCAs)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+)
+
+const (
+	// defaultUIRefresh is how often /ui reloads itself, in seconds.
+	defaultUIRefresh = 2
+	// maxUIRefresh bounds ?refresh=, in seconds.
+	maxUIRefresh = 3600
+)
+
+// uiTemplate renders /ui. It is self-contained, with no scripts or external
+// assets, and reloads itself with a meta refresh so every reload is a new
+// request the load balancer may send to another replica.
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">
+{{end}}<title>{{or .Doc.Hostname .Doc.InstanceID}} - get-container-id</title>
+<style>
+body { margin: 0; font-family: system-ui, sans-serif; background: #f4f5f7; color: #1d2330; }
+main { max-width: 44rem; margin: 3rem auto; padding: 0 1rem; }
+h1 { font-size: 1.1rem; font-weight: 600; color: #5b6475; margin: 0 0 .5rem; }
+.banner { font-family: ui-monospace, monospace; font-size: 2rem; word-break: break-all; margin: 0 0 1.5rem; padding: 1rem 1.2rem; border-radius: 6px; color: #fff; text-shadow: 0 1px 2px rgba(0, 0, 0, .4); }
+table { width: 100%; border-collapse: collapse; background: #fff; border-radius: 6px; overflow: hidden; }
+th, td { text-align: left; padding: .6rem .8rem; border-bottom: 1px solid #e6e8ec; vertical-align: top; }
+th { width: 9rem; font-weight: 500; color: #5b6475; }
+td { font-family: ui-monospace, monospace; word-break: break-all; }
+.missing { color: #9aa1ad; font-family: system-ui, sans-serif; }
+footer { margin-top: 1rem; font-size: .85rem; color: #5b6475; }
+</style>
+</head>
+<body style="border-top: .5rem solid {{.Doc.Color}}">
+<main>
+<h1>Served by</h1>
+<p class="banner" style="background: {{.Doc.Color}}">{{or .Doc.Hostname .Doc.InstanceID}}</p>
+<table>
+<tr><th>Instance ID</th><td>{{.Doc.InstanceID}}</td></tr>
+<tr><th>Container ID</th><td>{{with .Doc.ContainerID}}{{.}}{{else}}<span class="missing">not detected</span>{{end}}</td></tr>
+<tr><th>Pod ID</th><td>{{with .Doc.PodID}}{{.}}{{else}}<span class="missing">not detected</span>{{end}}</td></tr>
+<tr><th>Hostname</th><td>{{with .Doc.Hostname}}{{.}}{{else}}<span class="missing">not detected</span>{{end}}</td></tr>
+<tr><th>Node</th><td>{{with .Doc.NodeName}}{{.}}{{else}}<span class="missing">not detected</span>{{end}}</td></tr>
+<tr><th>Namespace</th><td>{{with .Doc.Namespace}}{{.}}{{else}}<span class="missing">not detected</span>{{end}}</td></tr>
+<tr><th>Color</th><td>{{.Doc.Color}}</td></tr>
+</table>
+<footer>Rendered {{.Time.Format "15:04:05 MST"}}{{if .Refresh}}, reloading every {{.Refresh}}s{{end}}.</footer>
+</main>
+</body>
+</html>
+`))
+
+// uiPage is the data uiTemplate renders.
+type uiPage struct {
+	Doc     metadataDocument
+	Refresh int
+	Time    time.Time
+}
+
+// handleUI serves /ui: an HTML banner with the identity of the replica in
+// its color, for humans load balancing through a browser. ?refresh= sets
+// the reload interval in seconds; 0 turns reloading off.
+func (m *metadata) handleUI(w http.ResponseWriter, r *http.Request) {
+	refresh := defaultUIRefresh
+	if s := r.URL.Query().Get("refresh"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxUIRefresh {
+			httpapi.WriteError(w, "refresh must be an integer between 0 and "+strconv.Itoa(maxUIRefresh), http.StatusBadRequest)
+			return
+		}
+		refresh = n
+	}
+
+	var buf bytes.Buffer
+	page := uiPage{Doc: m.collect(r.Context()), Refresh: refresh, Time: time.Now()}
+	if err := uiTemplate.Execute(&buf, page); err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(headerContentType, "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/identity"
+)
+
+// Test /ui renders the identity as escaped, self-contained HTML with a
+// configurable refresh
+func TestUI(t *testing.T) {
+	origIdentity, origInstance := identityFunc, instanceID
+	defer func() { identityFunc, instanceID = origIdentity, origInstance }()
+
+	identityFunc = func(ctx context.Context, opts ...identity.Option) (identity.Identity, error) {
+		return identity.Identity{Node: identity.Field{Value: "<node-1>"}}, nil
+	}
+	instanceID = "test-instance"
+	m := newMetadata("#c0ffee")
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "default refresh",
+			target:   "/ui",
+			status:   http.StatusOK,
+			contains: []string{`<meta http-equiv="refresh" content="2">`, "test-instance", "&lt;node-1&gt;", `style="background: #c0ffee"`},
+			excludes: []string{"<node-1>", "<script", "http://", "https://"},
+		},
+		{
+			name:     "custom refresh",
+			target:   "/ui?refresh=10",
+			status:   http.StatusOK,
+			contains: []string{`content="10"`, "reloading every 10s"},
+		},
+		{
+			name:     "no refresh",
+			target:   "/ui?refresh=0",
+			status:   http.StatusOK,
+			excludes: []string{"http-equiv", "reloading"},
+		},
+		{name: "negative refresh", target: "/ui?refresh=-1", status: http.StatusBadRequest},
+		{name: "invalid refresh", target: "/ui?refresh=soon", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.handleUI(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get(headerContentType); ct != "text/html; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			body := rec.Body.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("body does not contain %q", s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %q", s)
+				}
+			}
+		})
+	}
+}
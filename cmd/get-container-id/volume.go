@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/httpapi"
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+const (
+	defaultVolumeBytes = 4096
+	maxVolumeBytes     = 64 << 20 // 64MB
+)
+
+var (
+	ErrPathNotAllowed = errors.New("path is not under an allowed volume path")
+	ErrPathExists     = errors.New("path already exists")
+)
+
+// volumeProber performs write/fsync/read/stat checks under allowlisted paths.
+type volumeProber struct {
+	allowed   []string
+	mountInfo string
+}
+
+// newVolumeProber parses a comma-separated list of allowed directories.
+func newVolumeProber(paths string) *volumeProber {
+	p := &volumeProber{mountInfo: mountInfoPath}
+	for _, path := range splitList(paths) {
+		p.allowed = append(p.allowed, filepath.Clean(path))
+	}
+	return p
+}
+
+// resolve cleans path and checks it lies strictly below an allowed directory,
+// following symlinks in the parent so they cannot escape the allowlist. The
+// final element must not exist, so the probe never overwrites data on the
+// volume or follows a symlink planted there.
+func (p *volumeProber) resolve(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be absolute: %q", path)
+	}
+	path = filepath.Clean(path)
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	resolved := filepath.Join(dir, filepath.Base(path))
+
+	for _, allowed := range p.allowed {
+		root, err := filepath.EvalSymlinks(allowed)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			if _, err := os.Lstat(resolved); err == nil {
+				return "", fmt.Errorf("%w: %s", ErrPathExists, resolved)
+			}
+			return resolved, nil
+		}
+	}
+	return "", ErrPathNotAllowed
+}
+
+// volumeResult is the /volume response document.
+type volumeResult struct {
+	Path     string             `json:"path"`
+	Bytes    int                `json:"bytes"`
+	FSType   string             `json:"fs_type,omitempty"`
+	Mount    string             `json:"mount_point,omitempty"`
+	Verified bool               `json:"verified"`
+	Kept     bool               `json:"kept"`
+	Mode     string             `json:"mode"`
+	ModTime  string             `json:"mod_time"`
+	Latency  map[string]float64 `json:"latency_ms"`
+}
+
+// probe creates path, writes size random bytes to it, fsyncs, reads them
+// back, and stats the file, timing each step. The file is removed unless
+// keep is set.
+func (p *volumeProber) probe(path string, size int, keep bool) (volumeResult, error) {
+	res := volumeResult{Path: path, Bytes: size, Kept: keep, Latency: map[string]float64{}}
+	timed := func(step string, fn func() error) error {
+		start := time.Now()
+		err := fn()
+		res.Latency[step] = float64(time.Since(start).Microseconds()) / 1000
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", step, err)
+		}
+		return nil
+	}
+
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return res, err
+	}
+
+	// O_EXCL fails on anything created at path after resolve, including a
+	// symlink, which it does not follow.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return res, fmt.Errorf("%w: %s", ErrPathExists, path)
+	}
+	if err != nil {
+		return res, fmt.Errorf("open failed: %w", err)
+	}
+	if !keep {
+		defer os.Remove(path)
+	}
+
+	err = timed("write", func() error { _, err := f.Write(data); return err })
+	if err == nil {
+		err = timed("fsync", f.Sync)
+	}
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close failed: %w", closeErr)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	var readBack []byte
+	if err := timed("read", func() error { readBack, err = os.ReadFile(path); return err }); err != nil {
+		return res, err
+	}
+	res.Verified = bytes.Equal(readBack, data)
+
+	var info os.FileInfo
+	if err := timed("stat", func() error { info, err = os.Stat(path); return err }); err != nil {
+		return res, err
+	}
+	res.Mode = info.Mode().String()
+	res.ModTime = info.ModTime().Format(time.RFC3339Nano)
+
+	res.Mount, res.FSType = mountFSType(p.mountInfo, path)
+	return res, nil
+}
+
+// mountFSType returns the mount point and filesystem type of the longest
+// mount in mountinfo that contains path.
+func mountFSType(mountInfo, path string) (string, string) {
+	f, err := os.Open(mountInfo)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	var mount, fsType string
+	mountinfo.Scan(f, func(m mountinfo.Mount) bool {
+		mp := m.MountPoint
+		if (path == mp || strings.HasPrefix(path, strings.TrimSuffix(mp, "/")+"/")) && len(mp) >= len(mount) {
+			mount, fsType = mp, m.FSType
+		}
+		return false
+	})
+	return mount, fsType
+}
+
+// handleVolume serves /volume?path=...&bytes=...&keep=true.
+func (p *volumeProber) handleVolume(w http.ResponseWriter, r *http.Request) {
+	if len(p.allowed) == 0 {
+		httpapi.WriteError(w, "volume probing is disabled (start with -volumePaths)", http.StatusForbidden)
+		return
+	}
+
+	size, ok := queryInt(r, "bytes", defaultVolumeBytes, 1, maxVolumeBytes)
+	if !ok {
+		httpapi.WriteError(w, fmt.Sprintf("bytes must be an integer between 1 and %d", maxVolumeBytes), http.StatusBadRequest)
+		return
+	}
+
+	path, err := p.resolve(r.URL.Query().Get("path"))
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrPathNotAllowed):
+			status = http.StatusForbidden
+		case errors.Is(err, ErrPathExists):
+			status = http.StatusConflict
+		}
+		httpapi.WriteError(w, err.Error(), status)
+		return
+	}
+
+	res, err := p.probe(path, int(size), r.URL.Query().Get("keep") == "true")
+	if errors.Is(err, ErrPathExists) {
+		httpapi.WriteError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		httpapi.WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	httpapi.WriteSuccess(w, res)
+}
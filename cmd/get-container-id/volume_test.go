@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test resolve only accepts paths below allowed directories
+func TestVolumeProberResolve(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "data")
+	outside := filepath.Join(root, "etc")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatalf("Mkdir: %v", err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	p := newVolumeProber(" " + allowed + " ,")
+
+	if _, err := p.resolve(filepath.Join(allowed, "probe")); err != nil {
+		t.Errorf("resolve(allowed) error: %v", err)
+	}
+
+	for _, path := range []string{
+		allowed,
+		filepath.Join(outside, "probe"),
+		filepath.Join(allowed, "..", "etc", "probe"),
+		filepath.Join(allowed, "escape", "probe"),
+	} {
+		if _, err := p.resolve(path); !errors.Is(err, ErrPathNotAllowed) {
+			t.Errorf("resolve(%q) error = %v, want ErrPathNotAllowed", path, err)
+		}
+	}
+
+	if _, err := p.resolve("relative/probe"); err == nil {
+		t.Error("resolve(relative) expected error, got nil")
+	}
+}
+
+// Test /volume refuses existing files and symlinks under an allowed directory
+func TestVolumeProberHandleVolume_Exists(t *testing.T) {
+	allowed, outside := t.TempDir(), t.TempDir()
+	target := filepath.Join(outside, "victim")
+	if err := os.WriteFile(target, []byte("keep me"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	link := filepath.Join(allowed, "probe")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	data := filepath.Join(allowed, "app.db")
+	if err := os.WriteFile(data, []byte("rows"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	p := newVolumeProber(allowed)
+	for _, path := range []string{link, data} {
+		w := httptest.NewRecorder()
+		p.handleVolume(w, httptest.NewRequest(http.MethodPost, "/volume?path="+url.QueryEscape(path), nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("handleVolume(%s) status = %d, want %d, body %s", path, w.Code, http.StatusConflict, w.Body.String())
+		}
+	}
+
+	if b, err := os.ReadFile(data); err != nil || string(b) != "rows" {
+		t.Errorf("existing file = %q, %v; want it untouched", b, err)
+	}
+	if b, err := os.ReadFile(target); err != nil || string(b) != "keep me" {
+		t.Errorf("symlink target = %q, %v; want it untouched", b, err)
+	}
+	if _, err := os.Lstat(link); err != nil {
+		t.Errorf("symlink should be left in place, Lstat error = %v", err)
+	}
+
+	// A symlink swapped in after resolve is not followed when the file is
+	// created.
+	if _, err := p.probe(link, 16, false); !errors.Is(err, ErrPathExists) {
+		t.Errorf("probe(symlink) error = %v, want ErrPathExists", err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "keep me" {
+		t.Errorf("symlink target = %q after probe, want it untouched", b)
+	}
+}
+
+// Test handleVolume writes, verifies and removes the probe file
+func TestVolumeProberHandleVolume(t *testing.T) {
+	dir := t.TempDir()
+	p := newVolumeProber(dir)
+	p.mountInfo = writeTestFile(t, "36 25 0:32 / / rw - overlay overlay rw\n")
+
+	path := filepath.Join(dir, "probe")
+	w := httptest.NewRecorder()
+	p.handleVolume(w, httptest.NewRequest(http.MethodPost, "/volume?bytes=1024&path="+url.QueryEscape(path), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleVolume() status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data volumeResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("handleVolume() body unmarshal error: %v", err)
+	}
+	if !resp.Data.Verified || resp.Data.Bytes != 1024 {
+		t.Errorf("handleVolume() result = %+v, want 1024 verified bytes", resp.Data)
+	}
+	if resp.Data.FSType != "overlay" || resp.Data.Mount != "/" {
+		t.Errorf("handleVolume() fs = %q on %q, want overlay on /", resp.Data.FSType, resp.Data.Mount)
+	}
+	for _, step := range []string{"write", "fsync", "read", "stat"} {
+		if _, ok := resp.Data.Latency[step]; !ok {
+			t.Errorf("handleVolume() missing latency for %s", step)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("probe file should be removed, stat error = %v", err)
+	}
+}
+
+// Test handleVolume is disabled without an allowlist
+func TestVolumeProberHandleVolume_Disabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	newVolumeProber("").handleVolume(w, httptest.NewRequest(http.MethodPost, "/volume?path=/tmp/probe", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("handleVolume() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+// Test mountFSType picks the longest matching mount point
+func TestMountFSType(t *testing.T) {
+	path := writeTestFile(t, `36 25 0:32 / / rw - overlay overlay rw
+40 36 259:1 / /data rw,relatime shared:1 - ext4 /dev/nvme1n1 rw
+41 36 0:50 / /data\040dir rw - nfs4 server:/export rw
+42 36 0:51 / /database rw - xfs /dev/sdb rw
+`)
+
+	tests := []struct {
+		path      string
+		wantMount string
+		wantType  string
+	}{
+		{path: "/data/probe", wantMount: "/data", wantType: "ext4"},
+		{path: "/data dir/probe", wantMount: "/data dir", wantType: "nfs4"},
+		{path: "/database/x", wantMount: "/database", wantType: "xfs"},
+		{path: "/tmp/x", wantMount: "/", wantType: "overlay"},
+	}
+
+	for _, tt := range tests {
+		mount, fsType := mountFSType(path, tt.path)
+		if mount != tt.wantMount || fsType != tt.wantType {
+			t.Errorf("mountFSType(%q) = %q, %q; want %q, %q", tt.path, mount, fsType, tt.wantMount, tt.wantType)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
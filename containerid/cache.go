@@ -0,0 +1,76 @@
+package containerid
+
+import (
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/internal/singleflight"
+)
+
+var (
+	// cachedAt is when cachedID was detected; cacheTTL bounds its age, and
+	// zero keeps it forever. Both are guarded by mu.
+	cachedAt time.Time
+	cacheTTL time.Duration
+
+	// generation is incremented by Reset, also under mu. It keys the shared
+	// lookup, so a lookup started before a Reset is neither joined nor
+	// cached afterwards.
+	generation uint64
+
+	// refreshGroup shares one Refresh among concurrent callers, so a burst
+	// of them detects the ID once instead of each resetting the lookup of
+	// the others.
+	refreshGroup singleflight.Group[string]
+
+	now = time.Now
+)
+
+// Reset discards the cached container ID, so the next Get detects it again.
+// Use it after a checkpoint/restore (CRIU) or live migration has moved the
+// process into a different container.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	cachedID = ""
+	hasID = false
+	generation++
+}
+
+// SetCacheTTL makes a cached container ID expire d after it was detected, after
+// which Get detects it again. The default, zero, caches it for the lifetime
+// of the process.
+func SetCacheTTL(d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	cacheTTL = d
+}
+
+// Refresh discards the cached container ID and detects it again. If detection
+// fails the cache stays empty, so a stale ID is never served afterwards.
+// Concurrent calls share a single detection, run with the options of the
+// first caller.
+func Refresh(opts ...Option) (string, error) {
+	id, err, _ := refreshGroup.Do("", func() (string, error) {
+		Reset()
+		return Get(opts...)
+	})
+	return id, err
+}
+
+// cacheValid reports whether cachedID may be served. The caller holds mu.
+func cacheValid() bool {
+	return hasID && (cacheTTL <= 0 || now().Sub(cachedAt) < cacheTTL)
+}
+
+// store caches id if no Reset happened since the lookup of generation gen
+// started.
+func store(gen uint64, id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if gen != generation {
+		return
+	}
+	cachedID = id
+	hasID = true
+	cachedAt = now()
+}
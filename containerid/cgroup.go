@@ -0,0 +1,127 @@
+package containerid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CgroupPath is the default path to the cgroup membership file.
+const CgroupPath = "/proc/self/cgroup"
+
+var (
+	// reCgroup matches a container ID as the last element of a cgroup path.
+	// Matches: /docker/<id>, /kubepods/besteffort/pod<uid>/<id>,
+	// /system.slice/docker-<id>.scope, .../cri-containerd-<id>.scope, etc.
+	// A scope may have a "container" child, as podman creates under
+	// user@<uid>.service when running rootless with cgroup v2.
+	reCgroup = regexp.MustCompile(`(?:^|[/-])([0-9a-f]{64})(?:\.scope(?:/container)?)?$`)
+
+	// reCrio matches a CRI-O container scope. With cgroup v2 and a nested
+	// cgroup manager the container's processes sit in a "container" child.
+	reCrio = regexp.MustCompile(`/crio-([0-9a-f]{64})\.scope(?:/container)?$`)
+
+	// reCrioConmon matches the scope of the conmon monitor CRI-O runs next to
+	// each container. It carries the container's ID but is not the container.
+	reCrioConmon = regexp.MustCompile(`/crio-conmon-[0-9a-f]{64}\.scope$`)
+
+	// cgroupName is CgroupPath as an fs.FS path.
+	cgroupName = strings.TrimPrefix(CgroupPath, "/")
+)
+
+// GetFromCgroupFile retrieves the container ID from a specific cgroup file
+// path, such as /proc/<pid>/cgroup.
+func GetFromCgroupFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	defer file.Close()
+
+	d, err := scanCgroup(file)
+	return d.ID, err
+}
+
+// GetFromCgroupFS retrieves the container ID from the cgroup file name in
+// fsys. It finds the ID on hosts where mountinfo does not expose the
+// hostname and hosts bind mounts, as long as the cgroup namespace does not
+// hide the container's cgroup path.
+func GetFromCgroupFS(fsys fs.FS, name string) (string, error) {
+	d, err := detectCgroupFS(fsys, name)
+	return d.ID, err
+}
+
+// detectCgroupFS is GetFromCgroupFS with the line and hierarchy version the
+// ID was found in.
+func detectCgroupFS(fsys fs.FS, name string) (Detection, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	defer file.Close()
+
+	return scanCgroup(file)
+}
+
+// ExtractContainerIDFromCgroup returns the container ID at the end of a
+// cgroup path, as written by Docker, containerd, CRI-O and podman under both
+// the cgroupfs and systemd drivers. It returns false if path does not end in
+// a container ID, and for CRI-O's crio-conmon-<id>.scope, which holds the
+// conmon monitor rather than the container. Digests such as sha256-<hex> are
+// skipped, as are, with SetStrict, cgroups that no known runtime creates.
+func ExtractContainerIDFromCgroup(path string) (string, bool) {
+	if reCrioConmon.MatchString(path) {
+		return "", false
+	}
+	if matches := reCrio.FindStringSubmatch(path); len(matches) > 1 {
+		return matches[1], true
+	}
+	if m := reCgroup.FindStringSubmatchIndex(path); m != nil && validContext(path[:m[2]]) {
+		return path[m[2]:m[3]], true
+	}
+	return "", false
+}
+
+// scanCgroup returns the first container ID found in a cgroup stream, and
+// the line it was found in. The unified (v2) hierarchy is checked first,
+// then the v1 hierarchies.
+func scanCgroup(r io.Reader) (Detection, error) {
+	var v1 []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		c, err := ParseCgroupLine(line)
+		if err != nil {
+			continue
+		}
+		if c.HierarchyID != 0 {
+			v1 = append(v1, line)
+			continue
+		}
+		if id, ok := ExtractContainerIDFromCgroup(c.Path); ok {
+			return Detection{ID: id, Line: line, CgroupVersion: 2}, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Detection{}, fmt.Errorf("error reading cgroup: %w", err)
+	}
+
+	for _, line := range v1 {
+		c, _ := ParseCgroupLine(line)
+		if id, ok := ExtractContainerIDFromCgroup(c.Path); ok {
+			return Detection{ID: id, Line: line, CgroupVersion: 1}, nil
+		}
+	}
+
+	return Detection{}, ErrContainerIDNotFound
+}
+
+// getCgroup reads the cgroup file from the default path.
+func getCgroup() (string, error) {
+	return GetFromCgroupFS(rootFS, cgroupName)
+}
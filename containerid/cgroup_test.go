@@ -0,0 +1,116 @@
+package containerid
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractContainerIDFromCgroup(t *testing.T) {
+	id := strings.Repeat("a", 64)
+	tests := []struct {
+		name   string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"docker systemd", "/system.slice/docker-" + id + ".scope", id, true},
+		{"docker cgroupfs", "/docker/" + id, id, true},
+		{"kubepods cgroupfs", "/kubepods/burstable/pod036da4f7-d553-4eb6-9802-90f81041a412/" + id, id, true},
+		{"kubepods systemd containerd", "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod036da4f7_d553.slice/cri-containerd-" + id + ".scope", id, true},
+		{"crio", "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod5b9c.slice/crio-" + id + ".scope", id, true},
+		{"crio nested container", "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod5b9c.slice/crio-" + id + ".scope/container", id, true},
+		{"crio conmon", "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod5b9c.slice/crio-conmon-" + id + ".scope", "", false},
+		{"podman", "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + id + ".scope", id, true},
+		{"podman rootless nested", "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + id + ".scope/container", id, true},
+		{"podman rootful", "/machine.slice/libpod-" + id + ".scope", id, true},
+		{"ecs", "/ecs/f1a9e839391d222b03c675b0a8cce7fa/" + id, id, true},
+		{"root", "/", "", false},
+		{"pod slice only", "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod5b9c.slice", "", false},
+		{"id not last", "/docker/" + id + "/child", "", false},
+		{"too short", "/docker/" + id[:63], "", false},
+		{"uppercase", "/docker/" + strings.ToUpper(id), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractContainerIDFromCgroup(tt.path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ExtractContainerIDFromCgroup(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetFromCgroupFS(t *testing.T) {
+	v2 := strings.Repeat("2", 64)
+	v1 := strings.Repeat("1", 64)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr error
+	}{
+		{"unified", "0::/system.slice/docker-" + v2 + ".scope\n", v2, nil},
+		{"v1", "12:pids:/docker/" + v1 + "\n11:memory:/docker/" + v1 + "\n0::/\n", v1, nil},
+		{"unified preferred", "4:cpuset:/docker/" + v1 + "\n0::/system.slice/docker-" + v2 + ".scope\n", v2, nil},
+		{"malformed lines skipped", "garbage\n0::/docker/" + v2 + "\n", v2, nil},
+		{"containerd systemd v1", "12:memory:/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod036da4f7_d553.slice/cri-containerd-" + v1 + ".scope\n1:name=systemd:/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod036da4f7_d553.slice/cri-containerd-" + v1 + ".scope\n", v1, nil},
+		{"containerd systemd v2", "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod5b9c.slice/cri-containerd-" + v2 + ".scope\n", v2, nil},
+		{"crio conmon", "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod5b9c.slice/crio-conmon-" + v2 + ".scope\n", "", ErrContainerIDNotFound},
+		{"namespaced", "0::/\n", "", ErrContainerIDNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"proc/self/cgroup": {Data: []byte(tt.content)}}
+			got, err := GetFromCgroupFS(fsys, "proc/self/cgroup")
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetFromCgroupFS() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := GetFromCgroupFS(fstest.MapFS{}, "proc/self/cgroup"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetFromCgroupFS on missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestGetFromCgroupFile(t *testing.T) {
+	want := strings.Repeat("c", 64)
+	path := filepath.Join(t.TempDir(), "cgroup")
+	if err := os.WriteFile(path, []byte("0::/system.slice/docker-"+want+".scope\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := GetFromCgroupFile(path)
+	if err != nil || got != want {
+		t.Fatalf("GetFromCgroupFile = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestGetFallsBackToCgroup(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("7", 64)
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("1235 1234 0:315 / /proc rw - proc proc rw\n")},
+		"proc/self/cgroup":    {Data: []byte("0::/system.slice/docker-" + want + ".scope\n")},
+	}
+	getCgroupFunc = getCgroup
+
+	got, err := Get()
+	if err != nil || got != want {
+		t.Fatalf("Get = %q, %v, want %q from cgroup", got, err, want)
+	}
+
+	got, err = Get(WithFS(rootFS))
+	if err != nil || got != want {
+		t.Fatalf("Get(WithFS) = %q, %v, want %q from cgroup", got, err, want)
+	}
+}
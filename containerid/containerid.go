@@ -1,56 +1,122 @@
 package containerid
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+
+	"github.com/ming-go/lab/get-container-id/internal/singleflight"
+	"github.com/ming-go/lab/get-container-id/mountinfo"
 )
 
+// ErrContainerIDNotFound is returned when no container ID could be found
+// in mountinfo or cgroup.
+var ErrContainerIDNotFound = errors.New("container ID not found")
+
 var (
 	// reGeneric matches container IDs in common mount paths
-	// Matches: /containers/<containerID>/hostname, /sandboxes/<containerID>/resolv.conf, etc.
+	// Matches: /containers/<containerID>/hostname, /containers/<containerID>/resolv.conf, etc.
 	reGeneric = regexp.MustCompile(`/([0-9a-f]{64})/(?:hostname|hosts|resolv\.conf)`)
 
 	// Cached container ID
 	cachedID string
 	hasID    bool
 	mu       sync.RWMutex
+	group    singleflight.Group[string]
+
+	getFunc             = get
+	getCgroupFunc       = getCgroup
+	getContainerEnvFunc = getContainerEnv
 
-	getFunc = get
+	// rootFS is the host filesystem that Get reads from by default.
+	rootFS fs.FS = os.DirFS("/")
+
+	// mountInfoName is MountInfoPath as an fs.FS path.
+	mountInfoName = strings.TrimPrefix(MountInfoPath, "/")
 )
 
 const (
 	// MountInfoPath is the default path to the mountinfo file
-	MountInfoPath = "/proc/self/mountinfo"
+	MountInfoPath = mountinfo.Path
 
 	// ShortIDLength is the standard length for short container IDs
 	ShortIDLength = 12
 )
 
-// Get retrieves the full container ID from /proc/self/mountinfo.
-// The result is cached after the first successful call.
-func Get() (string, error) {
+// Get retrieves the full container ID by running DefaultChain: first the
+// cgroup v1 cpuset path in /proc/self/cpuset, then /proc/self/mountinfo,
+// then the cgroup paths in /proc/self/cgroup, then
+// podman's /run/.containerenv, then the ECS task metadata, then the
+// hostname on Docker Desktop and WSL2, then the CRI runtime service if its
+// socket exists, then any registered providers.
+//
+// The result is cached after the first successful call, until Reset or the
+// SetCacheTTL expiry. Pass WithLogger to trace the lookup and WithChain to
+// change the providers. On failure the error is a *DetectionError listing
+// every source, which matches ErrIDUnavailable when the process evidently
+// runs in a container and ErrNotInContainer otherwise.
+func Get(opts ...Option) (string, error) {
+	return GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get but stops waiting and returns ctx.Err() once ctx is
+// done. ctx is passed to every provider, and no further providers run after
+// it is done. A cold-cache lookup shared with other callers keeps running
+// after ctx is done, so its result is still cached for them.
+func GetContext(ctx context.Context, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if o.fsys != nil || o.chain != nil || o.dockerSocket != "" || o.criSocket != "" {
+		// Lookups against a caller-supplied filesystem or chain bypass the cache.
+		return o.resolveChain().detect(ctx, o)
+	}
+
 	mu.RLock()
-	if hasID {
+	if cacheValid() {
 		id := cachedID
 		mu.RUnlock()
+		metrics.CacheHit()
+		o.debug("containerid: using cached container ID", slog.String("id", id))
 		return id, nil
 	}
+	gen := generation
 	mu.RUnlock()
 
-	id, err := getFunc()
-	if err != nil {
-		return "", err
-	}
+	metrics.CacheMiss()
 
-	mu.Lock()
-	cachedID = id
-	hasID = true
-	mu.Unlock()
+	// Concurrent callers on a cold cache share a single lookup, which is not
+	// tied to any one caller's cancellation.
+	lookupCtx := context.WithoutCancel(ctx)
+	ch := group.DoChan(strconv.FormatUint(gen, 10), func() (string, error) {
+		id, err := DefaultChain().detect(lookupCtx, o)
+		if err != nil {
+			return "", err
+		}
 
-	return id, nil
+		store(gen, id)
+
+		return id, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			o.debug("containerid: shared concurrent lookup")
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		o.debug("containerid: stopped waiting for lookup", slog.Any("error", ctx.Err()))
+		return "", ctx.Err()
+	}
 }
 
 // GetShort returns the short version (12 characters) of the container ID.
@@ -60,10 +126,34 @@ func GetShort() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if len(fullID) >= ShortIDLength {
-		return fullID[:ShortIDLength], nil
+	return Short(fullID), nil
+}
+
+// Short truncates id to ShortIDLength characters, the form docker ps and
+// the default container hostname use. Shorter IDs are returned unchanged.
+func Short(id string) string {
+	if len(id) > ShortIDLength {
+		return id[:ShortIDLength]
+	}
+	return id
+}
+
+// IsValidID reports whether id is a full container ID: 64 lowercase
+// hexadecimal characters.
+func IsValidID(id string) bool {
+	return reContainerID.MatchString(id)
+}
+
+// Normalize converts a container ID as other tools print it to the form Get
+// returns: surrounding whitespace and a Kubernetes runtime scheme such as
+// containerd:// or cri-o:// are removed and hex digits are lowercased. The
+// result still needs IsValidID to be trusted.
+func Normalize(id string) string {
+	id = strings.TrimSpace(id)
+	if _, rest, ok := strings.Cut(id, "://"); ok {
+		id = rest
 	}
-	return fullID, nil
+	return strings.ToLower(id)
 }
 
 // GetFromFile retrieves the container ID from a specific mountinfo file path.
@@ -75,26 +165,54 @@ func GetFromFile(path string) (string, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-	for scanner.Scan() {
-		line := scanner.Text()
+	d, err := scanMountInfo(file)
+	return d.ID, err
+}
 
-		if matches := reGeneric.FindStringSubmatch(line); len(matches) > 1 {
-			return matches[1], nil
-		}
-	}
+// GetFromFS retrieves the container ID from the mountinfo file name in fsys.
+// Together with os.DirFS it allows reading a /proc mounted elsewhere, and
+// with testing/fstest.MapFS it allows fully in-memory tests.
+func GetFromFS(fsys fs.FS, name string) (string, error) {
+	d, err := detectMountInfoFS(fsys, name)
+	return d.ID, err
+}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading mountinfo: %w", err)
+// detectMountInfoFS is GetFromFS with the mount the ID was found in.
+func detectMountInfoFS(fsys fs.FS, name string) (Detection, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to open mountinfo: %w", err)
 	}
+	defer file.Close()
+
+	return scanMountInfo(file)
+}
 
-	return "", fmt.Errorf("container ID not found in mountinfo")
+// scanMountInfo returns the first container ID found in the paths of the
+// mounts of a mountinfo stream, and the mount's line.
+func scanMountInfo(r io.Reader) (Detection, error) {
+	var d Detection
+	err := mountinfo.Scan(r, func(m mountinfo.Mount) bool {
+		for _, path := range m.Paths() {
+			if found, ok := ExtractContainerID(path); ok {
+				d = Detection{ID: found, Line: m.String()}
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return Detection{}, fmt.Errorf("error reading mountinfo: %w", err)
+	}
+	if d.ID == "" {
+		return Detection{}, ErrContainerIDNotFound
+	}
+	return d, nil
 }
 
 // get is the internal implementation that reads from the default path
 func get() (string, error) {
-	return GetFromFile(MountInfoPath)
+	return GetFromFS(rootFS, mountInfoName)
 }
 
 // IsInContainer checks if the current process is running inside a container.
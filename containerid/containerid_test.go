@@ -1,29 +1,52 @@
 package containerid
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func resetTestState() func() {
 	origFunc := getFunc
+	origCgroupFunc := getCgroupFunc
+	origContainerEnvFunc := getContainerEnvFunc
+	origCpusetFunc := getCpusetFunc
 	origCachedID := cachedID
 	origHasID := hasID
+	origTTL, origNow := cacheTTL, now
+	origHasCRISocket := hasCRISocket
 
 	cachedID = ""
 	hasID = false
+	cacheTTL, now = 0, time.Now
 	mu = sync.RWMutex{}
 	getFunc = get
+	// Keep the host's own cgroup out of tests that stub the mountinfo lookup.
+	getCgroupFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+	getContainerEnvFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+	getCpusetFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+	hasCRISocket = func() bool { return false }
 
 	return func() {
 		cachedID = origCachedID
 		hasID = origHasID
+		cacheTTL, now = origTTL, origNow
 		mu = sync.RWMutex{}
 		getFunc = origFunc
+		getCgroupFunc = origCgroupFunc
+		getContainerEnvFunc = origContainerEnvFunc
+		getCpusetFunc = origCpusetFunc
+		hasCRISocket = origHasCRISocket
 	}
 }
 
@@ -144,6 +167,265 @@ func TestGetDoesNotCacheFailure(t *testing.T) {
 	}
 }
 
+func TestGetDeduplicatesConcurrentCalls(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("e", 64)
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	getFunc = func() (string, error) {
+		if calls.Add(1) == 1 {
+			close(entered)
+		}
+		<-release
+		return want, nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := Get()
+			if err == nil && got != want {
+				err = fmt.Errorf("Get = %q, want %q", got, want)
+			}
+			errs <- err
+		}()
+	}
+
+	<-entered
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("Get called provider %d times, want 1", got)
+	}
+}
+
+func TestGetContextCanceled(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls atomic.Int32
+	getFunc = func() (string, error) {
+		calls.Add(1)
+		return strings.Repeat("a", 64), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GetContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetContext error = %v, want context.Canceled", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("lookup ran %d times with a canceled context, want 0", calls.Load())
+	}
+}
+
+func TestGetContextStopsWaiting(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("a", 64)
+	release := make(chan struct{})
+	getFunc = func() (string, error) {
+		<-release
+		return want, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := GetContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetContext error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The abandoned lookup still completes and fills the cache.
+	close(release)
+	if got, err := Get(); err != nil || got != want {
+		t.Fatalf("Get after timeout = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestGetWithLogger(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("f", 64)
+	getFunc = func() (string, error) { return want, nil }
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := Get(WithLogger(logger)); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if _, err := Get(WithLogger(logger)); err != nil {
+		t.Fatalf("Get second call returned error: %v", err)
+	}
+
+	out := buf.String()
+	for _, msg := range []string{
+		"containerid: running provider",
+		"containerid: provider found container ID",
+		"containerid: using cached container ID",
+	} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("log output missing %q:\n%s", msg, out)
+		}
+	}
+	if !strings.Contains(out, want) {
+		t.Errorf("log output missing id %q:\n%s", want, out)
+	}
+}
+
+func TestGetWithoutLoggerIsSilent(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	getFunc = func() (string, error) { return "", errors.New("not found") }
+
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(orig)
+
+	_, _ = Get()
+	if buf.Len() != 0 {
+		t.Errorf("Get without logger wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestGetFromFS(t *testing.T) {
+	want := strings.Repeat("9", 64)
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/123/containers/app/" + want + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")},
+	}
+
+	got, err := GetFromFS(fsys, "proc/self/mountinfo")
+	if err != nil {
+		t.Fatalf("GetFromFS returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("GetFromFS = %q, want %q", got, want)
+	}
+
+	if _, err := GetFromFS(fstest.MapFS{}, "proc/self/mountinfo"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("GetFromFS on missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestGetSkipsPodSandbox(t *testing.T) {
+	sandbox := strings.Repeat("5", 64)
+	want := strings.Repeat("6", 64)
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("3012 3001 259:1 /var/lib/containerd/io.containerd.grpc.v1.cri/sandboxes/" + sandbox + "/hostname /etc/hostname rw - ext4 /dev/root rw\n")},
+		"proc/self/cgroup":    {Data: []byte("1:name=systemd:/kubepods/besteffort/pod036da4f7-d553-4eb6-9802-90f81041a412/" + want + "\n")},
+	}
+
+	for _, strict := range []bool{false, true} {
+		SetStrict(strict)
+		got, err := Get(WithFS(fsys))
+		if err != nil || got != want {
+			t.Errorf("strict=%v: Get = %q, %v, want the cgroup ID %q", strict, got, err, want)
+		}
+	}
+	SetStrict(false)
+}
+
+func TestGetReadsRootFS(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("9", 64)
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/123/containers/app/" + want + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")},
+	}
+
+	got, err := Get()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("Get = %q, want %q", got, want)
+	}
+}
+
+func TestGetWithFSBypassesCache(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	cached := "cached"
+	getFunc = func() (string, error) { return cached, nil }
+	if _, err := Get(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	want := strings.Repeat("9", 64)
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/123/containers/app/" + want + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")},
+	}
+	got, err := Get(WithFS(fsys))
+	if err != nil {
+		t.Fatalf("Get(WithFS) returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("Get(WithFS) = %q, want %q", got, want)
+	}
+
+	if got, _ := Get(); got != cached {
+		t.Fatalf("Get after WithFS = %q, want cached %q", got, cached)
+	}
+}
+
+func TestGetReturnsDetectionError(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("1235 1234 0:315 / /proc rw - proc proc rw\n")},
+	}
+
+	_, err := Get()
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Get error = %v, want *DetectionError", err)
+	}
+	if len(detErr.Sources) != 6 || detErr.Sources[0].Source != CpusetPath || detErr.Sources[1].Source != MountInfoPath || detErr.Sources[2].Source != CgroupPath || detErr.Sources[3].Source != ContainerEnvPath || detErr.Sources[4].Source != "env:"+ECSMetadataEnv || detErr.Sources[5].Source != DockerEnvPath || detErr.Sources[1].Err == nil {
+		t.Fatalf("DetectionError.Sources = %+v, want failed %s, %s, %s, %s, ECS and %s sources", detErr.Sources, CpusetPath, MountInfoPath, CgroupPath, ContainerEnvPath, DockerEnvPath)
+	}
+	if !strings.Contains(err.Error(), MountInfoPath) {
+		t.Fatalf("Get error = %q, want it to mention %s", err, MountInfoPath)
+	}
+}
+
+func TestGetWithFSReturnsDetectionError(t *testing.T) {
+	_, err := Get(WithFS(fstest.MapFS{}))
+
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Get error = %v, want *DetectionError", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get error = %v, want it to wrap fs.ErrNotExist", err)
+	}
+}
+
 func TestGetFromFileHandlesLongLines(t *testing.T) {
 	id := strings.Repeat("d", 64)
 	padding := strings.Repeat("x", 70*1024)
@@ -158,3 +440,55 @@ func TestGetFromFileHandlesLongLines(t *testing.T) {
 		t.Fatalf("GetFromFile for long line = %q, want %q", got, id)
 	}
 }
+
+func TestShort(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	tests := map[string]string{
+		id:                 "abababababab",
+		"abc":              "abc",
+		"":                 "",
+		id[:ShortIDLength]: id[:ShortIDLength],
+	}
+	for in, want := range tests {
+		if got := Short(in); got != want {
+			t.Errorf("Short(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsValidID(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	tests := map[string]bool{
+		id:                      true,
+		strings.ToUpper(id):     false,
+		id[:ShortIDLength]:      false,
+		id + "a":                false,
+		"docker://" + id:        false,
+		strings.Repeat("g", 64): false,
+		"":                      false,
+	}
+	for in, want := range tests {
+		if got := IsValidID(in); got != want {
+			t.Errorf("IsValidID(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	tests := []struct {
+		in, want string
+	}{
+		{id, id},
+		{" " + id + "\n", id},
+		{"containerd://" + id, id},
+		{"cri-o://" + strings.ToUpper(id), id},
+		{"docker://" + id, id},
+		{"abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
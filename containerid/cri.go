@@ -0,0 +1,297 @@
+package containerid
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/internal/protowire"
+)
+
+// CRISocketPath is the default path to the containerd socket, which also
+// serves the Kubernetes Container Runtime Interface.
+const CRISocketPath = "/run/containerd/containerd.sock"
+
+const (
+	// criListContainers is the gRPC method that lists the containers of the
+	// runtime.
+	criListContainers = "/runtime.v1.RuntimeService/ListContainers"
+
+	// criContainerRunning is CONTAINER_RUNNING of the CRI ContainerState.
+	criContainerRunning = 1
+
+	// criRequestTimeout bounds each CRI request when the caller's context has
+	// no deadline.
+	criRequestTimeout = 5 * time.Second
+
+	// maxCRIMessageSize bounds the ListContainers response, which grows with
+	// the number of containers on the node.
+	maxCRIMessageSize = 16 << 20
+)
+
+// The labels the kubelet sets on every container it creates through CRI.
+const (
+	criLabelPodName       = "io.kubernetes.pod.name"
+	criLabelPodUID        = "io.kubernetes.pod.uid"
+	criLabelContainerName = "io.kubernetes.container.name"
+)
+
+var (
+	// reKubeletContainer matches the termination log the kubelet mounts into
+	// every container, /var/lib/kubelet/pods/<uid>/containers/<name>/<hash>,
+	// which names the pod UID and the container.
+	reKubeletContainer = regexp.MustCompile(`/pods/([0-9a-f-]{36})/containers/([^/ ]+)/`)
+
+	// criSocketName is CRISocketPath as an fs.FS path.
+	criSocketName = strings.TrimPrefix(CRISocketPath, "/")
+
+	// hasCRISocket reports whether DefaultChain includes the CRI provider.
+	hasCRISocket = func() bool {
+		info, err := fs.Stat(rootFS, criSocketName)
+		return err == nil && info.Mode().Type() == fs.ModeSocket
+	}
+)
+
+// criContainer is the part of a CRI Container message that is used.
+type criContainer struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+// criProvider asks the CRI runtime service on a unix socket which of its
+// running containers the process runs in.
+type criProvider struct {
+	path   string
+	fsys   fs.FS
+	client *http.Client
+}
+
+// CRIProvider detects the container ID by listing the running containers of
+// the CRI runtime service on the unix socket at path, usually CRISocketPath,
+// and picking the one the process runs in: the container whose ID appears in
+// the cgroup file or mountinfo, else the one named by the kubelet's
+// termination log mount, else the only container of the pod named after the
+// hostname. It finds the ID on Kubernetes nodes whose cgroup paths the other
+// providers do not recognize, but requires the socket to be mounted into the
+// container, which grants control over the runtime.
+func CRIProvider(path string) Provider {
+	dialer := &net.Dialer{}
+	transport := &http.Transport{
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	// gRPC needs HTTP/2, which the socket speaks without TLS.
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	return criProvider{path: path, client: &http.Client{Transport: transport}}
+}
+
+func (p criProvider) Name() string   { return "cri" }
+func (p criProvider) source() string { return p.path }
+
+func (p criProvider) withFS(fsys fs.FS) Provider {
+	p.fsys = fsys
+	return p
+}
+
+func (p criProvider) Detect(ctx context.Context) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, criRequestTimeout)
+		defer cancel()
+	}
+	containers, err := p.listContainers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	fsys := p.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	hostname, err := hostnameFunc()
+	if err != nil {
+		return "", fmt.Errorf("failed to read hostname: %w", err)
+	}
+	return matchCRIContainer(fsys, containers, hostname)
+}
+
+// listContainers calls ListContainers for the running containers.
+func (p criProvider) listContainers(ctx context.Context) ([]criContainer, error) {
+	state := protowire.AppendUint(nil, 1, criContainerRunning)
+	filter := protowire.AppendMessage(nil, 2, state)
+	msg := protowire.AppendMessage(nil, 1, filter)
+
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://cri"+criListContainers, bytes.NewReader(append(frame, msg...)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CRI: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CRI %s: unexpected status %s", criListContainers, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5+maxCRIMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRI response: %w", err)
+	}
+	// A call that fails before any message carries its status in the
+	// headers; otherwise it follows the message in the trailers.
+	status := resp.Header.Get("Grpc-Status")
+	if status == "" {
+		status = resp.Trailer.Get("Grpc-Status")
+	}
+	if status != "0" {
+		message := resp.Header.Get("Grpc-Message") + resp.Trailer.Get("Grpc-Message")
+		return nil, fmt.Errorf("CRI %s: status %s: %s", criListContainers, status, message)
+	}
+	if len(body) < 5 || body[0] != 0 {
+		return nil, fmt.Errorf("CRI %s: malformed response", criListContainers)
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if size > maxCRIMessageSize || uint32(len(body)-5) < size {
+		return nil, fmt.Errorf("CRI %s: response of %d bytes is truncated or too large", criListContainers, size)
+	}
+	return parseCRIContainers(body[5 : 5+size])
+}
+
+// parseCRIContainers decodes a ListContainersResponse.
+func parseCRIContainers(msg []byte) ([]criContainer, error) {
+	fields, err := protowire.Fields(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CRI containers: %w", err)
+	}
+	var containers []criContainer
+	for _, f := range fields {
+		if f.Num != 1 || f.Type != protowire.BytesType {
+			continue
+		}
+		c, err := parseCRIContainer(f.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// parseCRIContainer decodes the id, metadata and labels of a Container.
+func parseCRIContainer(msg []byte) (criContainer, error) {
+	fields, err := protowire.Fields(msg)
+	if err != nil {
+		return criContainer{}, fmt.Errorf("failed to decode CRI container: %w", err)
+	}
+	c := criContainer{Labels: map[string]string{}}
+	for _, f := range fields {
+		if f.Type != protowire.BytesType {
+			continue
+		}
+		switch f.Num {
+		case 1:
+			c.ID = string(f.Bytes)
+		case 3:
+			metadata, err := protowire.Fields(f.Bytes)
+			if err != nil {
+				return criContainer{}, fmt.Errorf("failed to decode CRI container metadata: %w", err)
+			}
+			for _, m := range metadata {
+				if m.Num == 1 && m.Type == protowire.BytesType {
+					c.Name = string(m.Bytes)
+				}
+			}
+		case 8:
+			entry, err := protowire.Fields(f.Bytes)
+			if err != nil {
+				return criContainer{}, fmt.Errorf("failed to decode CRI container labels: %w", err)
+			}
+			var key, value string
+			for _, e := range entry {
+				switch {
+				case e.Num == 1 && e.Type == protowire.BytesType:
+					key = string(e.Bytes)
+				case e.Num == 2 && e.Type == protowire.BytesType:
+					value = string(e.Bytes)
+				}
+			}
+			c.Labels[key] = value
+		}
+	}
+	return c, nil
+}
+
+// matchCRIContainer picks the container the process runs in, see
+// CRIProvider.
+func matchCRIContainer(fsys fs.FS, containers []criContainer, hostname string) (string, error) {
+	if len(containers) == 0 {
+		return "", fmt.Errorf("no running CRI containers: %w", ErrContainerIDNotFound)
+	}
+	byID := make(map[string]bool, len(containers))
+	for _, c := range containers {
+		byID[c.ID] = true
+	}
+
+	found := ""
+	var podUID, containerName string
+	find := func(line string) bool {
+		for _, id := range reHexID.FindAllString(line, -1) {
+			if byID[id] {
+				found = id
+				return true
+			}
+		}
+		if m := reKubeletContainer.FindStringSubmatch(line); m != nil && podUID == "" {
+			podUID, containerName = m[1], m[2]
+		}
+		return false
+	}
+	for _, name := range []string{cgroupName, mountInfoName} {
+		if err := scanLines(fsys, name, find); err != nil {
+			return "", err
+		}
+		if found != "" {
+			return found, nil
+		}
+	}
+
+	if podUID != "" {
+		for _, c := range containers {
+			if c.Labels[criLabelPodUID] == podUID && c.Labels[criLabelContainerName] == containerName {
+				return c.ID, nil
+			}
+		}
+	}
+
+	var inPod []string
+	for _, c := range containers {
+		if c.Labels[criLabelPodName] == hostname {
+			inPod = append(inPod, c.ID)
+		}
+	}
+	switch len(inPod) {
+	case 0:
+		return "", fmt.Errorf("no CRI container of pod %q: %w", hostname, ErrContainerIDNotFound)
+	case 1:
+		return inPod[0], nil
+	}
+	return "", fmt.Errorf("pod %q has %d running containers: %w", hostname, len(inPod), ErrContainerIDNotFound)
+}
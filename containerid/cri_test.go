@@ -0,0 +1,184 @@
+package containerid
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ming-go/lab/get-container-id/internal/protowire"
+)
+
+// serveCRI serves a fake CRI runtime service on a unix socket that answers
+// ListContainers with containers, or with status if it is not "0", and
+// returns the socket path.
+func serveCRI(t *testing.T, status string, containers ...criContainer) string {
+	t.Helper()
+
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir may
+	// exceed.
+	dir, err := os.MkdirTemp("", "cri")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "containerd.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	var resp []byte
+	for _, c := range containers {
+		var msg []byte
+		msg = protowire.AppendString(msg, 1, c.ID)
+		msg = protowire.AppendMessage(msg, 3, protowire.AppendString(nil, 1, c.Name))
+		for k, v := range c.Labels {
+			msg = protowire.AppendMessage(msg, 8, protowire.AppendString(protowire.AppendString(nil, 1, k), 2, v))
+		}
+		resp = protowire.AppendMessage(resp, 1, msg)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+criListContainers, func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		if len(req) < 5 || r.Header.Get("Content-Type") != "application/grpc" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		if status != "0" {
+			w.Header().Set("Grpc-Status", status)
+			w.Header().Set("Grpc-Message", "unknown service runtime.v1.RuntimeService")
+			return
+		}
+		w.Header().Set("Trailer", "Grpc-Status")
+		frame := make([]byte, 5, 5+len(resp))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(resp)))
+		w.Write(append(frame, resp...))
+		w.Header().Set("Grpc-Status", "0")
+	})
+
+	srv := &http.Server{Handler: mux, Protocols: new(http.Protocols)}
+	srv.Protocols.SetUnencryptedHTTP2(true)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return path
+}
+
+func TestCRIProvider(t *testing.T) {
+	app := strings.Repeat("a", 64)
+	sidecar := strings.Repeat("b", 64)
+	other := strings.Repeat("c", 64)
+	const uid = "036da4f7-d553-4eb6-9802-90f81041a412"
+	path := serveCRI(t, "0",
+		criContainer{ID: app, Name: "app", Labels: map[string]string{criLabelPodName: "web-0", criLabelPodUID: uid, criLabelContainerName: "app"}},
+		criContainer{ID: sidecar, Name: "proxy", Labels: map[string]string{criLabelPodName: "web-0", criLabelPodUID: uid, criLabelContainerName: "proxy"}},
+		criContainer{ID: other, Name: "db", Labels: map[string]string{criLabelPodName: "db-0", criLabelContainerName: "db"}},
+	)
+
+	origHostname := hostnameFunc
+	defer func() { hostnameFunc = origHostname }()
+
+	tests := []struct {
+		name     string
+		hostname string
+		files    fstest.MapFS
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "ID in cgroup",
+			hostname: "web-0",
+			files:    fstest.MapFS{"proc/self/cgroup": {Data: []byte("0::/kubepods.slice/kubepods-pod.slice/unknown-runtime-" + sidecar + ".scope\n")}},
+			want:     sidecar,
+		},
+		{
+			name:     "termination log mount",
+			hostname: "web-0",
+			files: fstest.MapFS{
+				"proc/self/cgroup":    {Data: []byte("0::/\n")},
+				"proc/self/mountinfo": {Data: []byte("1 0 8:1 /var/lib/kubelet/pods/" + uid + "/containers/app/5f4c6b0a /dev/termination-log rw - ext4 /dev/sda1 rw\n")},
+			},
+			want: app,
+		},
+		{name: "only container of the pod", hostname: "db-0", files: fstest.MapFS{}, want: other},
+		{name: "several containers in the pod", hostname: "web-0", files: fstest.MapFS{}, wantErr: ErrContainerIDNotFound},
+		{name: "unknown pod", hostname: "api-0", files: fstest.MapFS{}, wantErr: ErrContainerIDNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostnameFunc = func() (string, error) { return tt.hostname, nil }
+			p := CRIProvider(path).(rebinder).withFS(tt.files)
+			got, err := p.Detect(context.Background())
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Detect = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCRIProviderErrors(t *testing.T) {
+	failing := serveCRI(t, "12")
+	if _, err := CRIProvider(failing).Detect(context.Background()); err == nil || !strings.Contains(err.Error(), "status 12") {
+		t.Errorf("Detect error = %v, want gRPC status 12", err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "containerd.sock")
+	if _, err := CRIProvider(missing).Detect(context.Background()); err == nil {
+		t.Error("Detect on a missing socket expected error, got nil")
+	}
+}
+
+func TestGetWithCRISocket(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("d", 64)
+	path := serveCRI(t, "0", criContainer{ID: want, Labels: map[string]string{criLabelPodName: "api-0"}})
+
+	origHostname := hostnameFunc
+	defer func() { hostnameFunc = origHostname }()
+	hostnameFunc = func() (string, error) { return "api-0", nil }
+
+	got, err := Get(WithFS(fstest.MapFS{}), WithCRISocket(path))
+	if err != nil || got != want {
+		t.Fatalf("Get(WithCRISocket) = %q, %v, want %q", got, err, want)
+	}
+
+	// The socket is queried once even if DefaultChain already includes it.
+	hasCRISocket = func() bool { return true }
+	chain := newOptions([]Option{WithCRISocket(CRISocketPath)}).resolveChain()
+	n := 0
+	for _, p := range chain {
+		if p.Name() == "cri" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("resolveChain has %d CRI providers, want 1", n)
+	}
+}
+
+func TestDefaultChainCRI(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	for _, present := range []bool{false, true} {
+		hasCRISocket = func() bool { return present }
+		found := false
+		for _, p := range DefaultChain() {
+			found = found || sourceOf(p) == CRISocketPath
+		}
+		if found != present {
+			t.Errorf("DefaultChain with socket present=%v includes CRI = %v", present, found)
+		}
+	}
+}
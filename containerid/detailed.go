@@ -0,0 +1,64 @@
+package containerid
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Detection describes where a container ID was found, so a wrong or
+// missing ID can be reported with the line that produced it.
+type Detection struct {
+	ID string
+	// Provider is the name of the provider that found ID, e.g. "cgroup".
+	// It is empty if no provider finds the cached ID anymore.
+	Provider string
+	// Source is the file the provider read, or its name if it reads none.
+	Source string
+	// Line is the mountinfo or cgroup line ID was found in, empty for
+	// providers that do not read lines.
+	Line string
+	// CgroupVersion is 1 or 2 if ID came from a cgroup v1 hierarchy or the
+	// unified v2 one, and zero otherwise.
+	CgroupVersion int
+}
+
+// detailer is implemented by providers that can report the line they found
+// the container ID in.
+type detailer interface {
+	detectDetailed(ctx context.Context) (Detection, error)
+}
+
+// GetDetailed is like GetContext but also reports where the container ID
+// came from. The ID is the one Get returns, cached or not; the providers of
+// the chain then run again until one finds it, so Provider, Source and Line
+// describe the current contents of that source. It takes the same options
+// as Get.
+func GetDetailed(ctx context.Context, opts ...Option) (Detection, error) {
+	id, err := GetContext(ctx, opts...)
+	if err != nil {
+		return Detection{}, err
+	}
+
+	o := newOptions(opts)
+	for _, p := range o.resolveChain() {
+		if ctx.Err() != nil {
+			break
+		}
+		d, err := detectDetailed(ctx, p)
+		if err != nil || d.ID != id {
+			continue
+		}
+		d.Provider, d.Source = p.Name(), sourceOf(p)
+		o.debug("containerid: found container ID source", slog.String("provider", d.Provider), slog.String("line", d.Line))
+		return d, nil
+	}
+	return Detection{ID: id}, nil
+}
+
+func detectDetailed(ctx context.Context, p Provider) (Detection, error) {
+	if d, ok := p.(detailer); ok {
+		return d.detectDetailed(ctx)
+	}
+	id, err := p.Detect(ctx)
+	return Detection{ID: id}, err
+}
@@ -0,0 +1,70 @@
+package containerid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetDetailed(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	id := strings.Repeat("c", 64)
+	mountLine := "1246 1234 8:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw"
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  Detection
+	}{
+		{
+			name: "mountinfo",
+			files: fstest.MapFS{
+				"proc/self/mountinfo": {Data: []byte("1 0 8:1 / / rw - ext4 /dev/sda1 rw\n" + mountLine + "\n")},
+			},
+			want: Detection{ID: id, Provider: "mountinfo", Source: MountInfoPath, Line: mountLine},
+		},
+		{
+			name: "cgroup v2",
+			files: fstest.MapFS{
+				"proc/self/cgroup": {Data: []byte("0::/system.slice/docker-" + id + ".scope\n")},
+			},
+			want: Detection{ID: id, Provider: "cgroup", Source: CgroupPath, Line: "0::/system.slice/docker-" + id + ".scope", CgroupVersion: 2},
+		},
+		{
+			name: "cgroup v1",
+			files: fstest.MapFS{
+				"proc/self/cgroup": {Data: []byte("0::/\n4:cpu,cpuacct:/docker/" + id + "\n")},
+			},
+			want: Detection{ID: id, Provider: "cgroup", Source: CgroupPath, Line: "4:cpu,cpuacct:/docker/" + id, CgroupVersion: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetDetailed(context.Background(), WithFS(tt.files))
+			if err != nil {
+				t.Fatalf("GetDetailed returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetDetailed = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDetailedProviderWithoutLines(t *testing.T) {
+	t.Setenv("TEST_CONTAINER_ID", "from-env")
+
+	got, err := GetDetailed(context.Background(), WithChain(Chain{EnvProvider("TEST_CONTAINER_ID")}))
+	want := Detection{ID: "from-env", Provider: "env:TEST_CONTAINER_ID", Source: "env:TEST_CONTAINER_ID"}
+	if err != nil || got != want {
+		t.Errorf("GetDetailed = %+v, %v, want %+v", got, err, want)
+	}
+
+	_, err = GetDetailed(context.Background(), WithFS(fstest.MapFS{}))
+	if !errors.Is(err, ErrContainerIDNotFound) {
+		t.Errorf("GetDetailed on an empty filesystem error = %v, want ErrContainerIDNotFound", err)
+	}
+}
@@ -0,0 +1,39 @@
+package containerid
+
+import (
+	"context"
+	"time"
+)
+
+// ProviderResult is the outcome of one provider run by Diagnose.
+type ProviderResult struct {
+	// Provider is the provider name, e.g. "mountinfo".
+	Provider string
+	// Source is the file the provider read, or its name if it reads none.
+	Source string
+	// ID is the container ID found, empty if Err is set.
+	ID      string
+	Err     error
+	Elapsed time.Duration
+}
+
+// Diagnose runs every provider of the chain Get would run, including those
+// after the first one that finds an ID, and reports what each returned. It
+// bypasses the cache and does not record metrics, so it can be used to
+// investigate a wrong or missing container ID without disturbing Get. WithFS,
+// WithChain and WithDockerSocket apply as they do for Get.
+func Diagnose(ctx context.Context, opts ...Option) []ProviderResult {
+	o := newOptions(opts)
+	chain := o.resolveChain()
+	results := make([]ProviderResult, 0, len(chain))
+	for _, p := range chain {
+		r := ProviderResult{Provider: p.Name(), Source: sourceOf(p)}
+		if r.Err = ctx.Err(); r.Err == nil {
+			start := time.Now()
+			r.ID, r.Err = p.Detect(ctx)
+			r.Elapsed = time.Since(start)
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,51 @@
+package containerid
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiagnose(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	mountID := strings.Repeat("a", 64)
+	cgroupID := strings.Repeat("b", 64)
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("1246 1234 8:1 /var/lib/docker/containers/" + mountID + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")},
+		"proc/self/cgroup":    {Data: []byte("0::/system.slice/docker-" + cgroupID + ".scope\n")},
+	}
+	attempts := Metrics().Snapshot().Attempts
+	results := Diagnose(context.Background(), WithFS(fsys))
+
+	byProvider := make(map[string]ProviderResult)
+	for _, r := range results {
+		byProvider[r.Provider] = r
+	}
+	if r := byProvider["mountinfo"]; r.ID != mountID || r.Err != nil || r.Source != MountInfoPath {
+		t.Errorf("mountinfo result = %+v, want %s from %s", r, mountID, MountInfoPath)
+	}
+	if r := byProvider["cgroup"]; r.ID != cgroupID || r.Err != nil {
+		t.Errorf("cgroup result = %+v, want %s even though mountinfo already matched", r, cgroupID)
+	}
+	if r := byProvider["podman"]; !errors.Is(r.Err, fs.ErrNotExist) || r.Source != ContainerEnvPath {
+		t.Errorf("podman result = %+v, want a missing %s", r, ContainerEnvPath)
+	}
+	if hasID || Metrics().Snapshot().Attempts != attempts {
+		t.Error("Diagnose should neither cache nor record metrics")
+	}
+}
+
+func TestDiagnoseCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range Diagnose(ctx, WithChain(Chain{EnvProvider("CONTAINER_ID")})) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s result = %+v, want context.Canceled", r.Provider, r)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package containerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DockerSocketPath is the default path to the Docker Engine API socket.
+const DockerSocketPath = "/var/run/docker.sock"
+
+// dockerRequestTimeout bounds each Docker Engine API request when the
+// caller's context has no deadline.
+const dockerRequestTimeout = 5 * time.Second
+
+// hostnameFunc returns the hostname to match against containers.
+var hostnameFunc = os.Hostname
+
+// dockerContainer is the part of a container inspect response that is used.
+type dockerContainer struct {
+	ID     string `json:"Id"`
+	Config struct {
+		Hostname string `json:"Hostname"`
+	} `json:"Config"`
+}
+
+// dockerProvider asks the Docker Engine API which container has the current
+// hostname.
+type dockerProvider struct {
+	path   string
+	client *http.Client
+}
+
+// DockerSocketProvider detects the container ID by querying the Docker
+// Engine API on the unix socket at path for the container whose hostname is
+// the current hostname. It finds the ID when a cgroup namespace hides it in
+// /proc, but requires the socket to be mounted into the container, which
+// grants control over the Docker daemon. It does not help when the hostname
+// was overridden with the same value on several containers.
+func DockerSocketProvider(path string) Provider {
+	dialer := &net.Dialer{}
+	return dockerProvider{
+		path: path,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DisableKeepAlives: true,
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+func (p dockerProvider) Name() string   { return "docker" }
+func (p dockerProvider) source() string { return p.path }
+
+func (p dockerProvider) Detect(ctx context.Context) (string, error) {
+	hostname, err := hostnameFunc()
+	if err != nil {
+		return "", fmt.Errorf("failed to read hostname: %w", err)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dockerRequestTimeout)
+		defer cancel()
+	}
+
+	// Docker defaults the hostname to the short container ID, which the
+	// inspect endpoint accepts directly.
+	var c dockerContainer
+	err = p.get(ctx, "/containers/"+url.PathEscape(hostname)+"/json", &c)
+	if err == nil && c.Config.Hostname == hostname {
+		return c.ID, nil
+	}
+	if err != nil && !errors.Is(err, errDockerNotFound) {
+		return "", err
+	}
+
+	// Otherwise the hostname was set explicitly; inspect every running
+	// container until one matches.
+	var list []struct {
+		ID string `json:"Id"`
+	}
+	if err := p.get(ctx, "/containers/json", &list); err != nil {
+		return "", err
+	}
+	for _, item := range list {
+		var c dockerContainer
+		if err := p.get(ctx, "/containers/"+item.ID+"/json", &c); err != nil {
+			if errors.Is(err, errDockerNotFound) {
+				continue
+			}
+			return "", err
+		}
+		if c.Config.Hostname == hostname {
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no container with hostname %q: %w", hostname, ErrContainerIDNotFound)
+}
+
+// errDockerNotFound is returned by get for a 404 response.
+var errDockerNotFound = errors.New("docker: no such container")
+
+// get decodes the JSON response of a Docker Engine API GET request into v.
+func (p dockerProvider) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query docker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errDockerNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("docker %s: unexpected status %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode docker %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package containerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveDocker serves a fake Docker Engine API on a unix socket and returns
+// its path.
+func serveDocker(t *testing.T, containers map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		var list []map[string]string
+		for id := range containers {
+			list = append(list, map[string]string{"Id": id})
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("GET /containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		for id, hostname := range containers {
+			if strings.HasPrefix(id, r.PathValue("id")) {
+				json.NewEncoder(w).Encode(map[string]any{"Id": id, "Config": map[string]string{"Hostname": hostname}})
+				return
+			}
+		}
+		http.Error(w, `{"message":"No such container"}`, http.StatusNotFound)
+	})
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return path
+}
+
+func TestDockerSocketProvider(t *testing.T) {
+	shortID := strings.Repeat("a", 64)
+	customID := strings.Repeat("b", 64)
+	path := serveDocker(t, map[string]string{
+		shortID:  shortID[:ShortIDLength],
+		customID: "web-1",
+	})
+
+	origHostname := hostnameFunc
+	defer func() { hostnameFunc = origHostname }()
+
+	tests := []struct {
+		hostname string
+		want     string
+		wantErr  error
+	}{
+		{shortID[:ShortIDLength], shortID, nil},
+		{"web-1", customID, nil},
+		{"elsewhere", "", ErrContainerIDNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			hostnameFunc = func() (string, error) { return tt.hostname, nil }
+			got, err := DockerSocketProvider(path).Detect(context.Background())
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Detect = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetWithDockerSocket(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("c", 64)
+	path := serveDocker(t, map[string]string{want: "api-0"})
+
+	origHostname := hostnameFunc
+	defer func() { hostnameFunc = origHostname }()
+	hostnameFunc = func() (string, error) { return "api-0", nil }
+	getFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+
+	got, err := Get(WithDockerSocket(path))
+	if err != nil || got != want {
+		t.Fatalf("Get(WithDockerSocket) = %q, %v, want %q", got, err, want)
+	}
+
+	// Without the option the socket is not queried.
+	if _, err := Get(); !errors.Is(err, ErrContainerIDNotFound) {
+		t.Errorf("Get without option error = %v, want ErrContainerIDNotFound", err)
+	}
+}
+
+func TestGetWithDockerSocketMissing(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	getFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+	missing := filepath.Join(t.TempDir(), "docker.sock")
+
+	_, err := Get(WithDockerSocket(missing))
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Get error = %v, want *DetectionError", err)
+	}
+	if last := detErr.Sources[len(detErr.Sources)-1]; last.Source != missing {
+		t.Errorf("last source = %q, want %q", last.Source, missing)
+	}
+}
@@ -0,0 +1,141 @@
+package containerid
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+const (
+	// HostnamePath is the hostname file Docker bind-mounts into every
+	// container.
+	HostnamePath = "/etc/hostname"
+
+	// KernelReleasePath holds the release of the running kernel.
+	KernelReleasePath = "/proc/sys/kernel/osrelease"
+)
+
+// The VMs DesktopEnvironment recognizes.
+const (
+	EnvDockerDesktop = "docker-desktop"
+	EnvWSL2          = "wsl2"
+)
+
+// ErrNotInContainer is returned, wrapped together with
+// ErrContainerIDNotFound, when a provider can tell that the process does not
+// run in a container at all, rather than in one whose ID it cannot find. A
+// *DetectionError wraps it when there is no ContainerEvidence.
+var ErrNotInContainer = errors.New("not running in a container")
+
+var (
+	// reShortID matches a whole short container ID, the hostname Docker
+	// gives a container unless --hostname overrides it.
+	reShortID = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+	// reHexID matches a full-length container ID anywhere in a line.
+	reHexID = regexp.MustCompile(`[0-9a-f]{64}`)
+
+	hostnameName      = strings.TrimPrefix(HostnamePath, "/")
+	kernelReleaseName = strings.TrimPrefix(KernelReleasePath, "/")
+)
+
+// DesktopEnvironment reports whether the kernel in fsys is that of Docker
+// Desktop's LinuxKit VM on macOS and Windows, EnvDockerDesktop, or a WSL2
+// kernel, EnvWSL2, which Docker Desktop's WSL2 backend also runs on. It
+// returns "" for any other kernel. A nil fsys reads the host root.
+func DesktopEnvironment(fsys fs.FS) string {
+	if fsys == nil {
+		fsys = rootFS
+	}
+	b, err := fs.ReadFile(fsys, kernelReleaseName)
+	if err != nil {
+		return ""
+	}
+	release := strings.ToLower(string(b))
+	switch {
+	case strings.Contains(release, "linuxkit"):
+		return EnvDockerDesktop
+	case strings.Contains(release, "microsoft-standard-wsl2"):
+		return EnvWSL2
+	}
+	return ""
+}
+
+// DockerDesktopProvider detects the container ID on Docker Desktop and
+// WSL2, where the cpuset reads "/", the cgroup namespace hides the cgroup
+// path and mountinfo holds no kubelet or container paths. There Docker's
+// /.dockerenv marker and the hostname, which defaults to the short
+// container ID, still identify the container. The provider returns the full
+// ID if mountinfo or the cgroup file mention one starting with the
+// hostname, and otherwise the ShortIDLength short ID, which IsValidID
+// rejects. On these kernels a missing /.dockerenv without other
+// ContainerEvidence means the process runs in the VM or WSL2 distribution
+// itself, reported as ErrNotInContainer; on any other kernel the provider
+// finds nothing. A nil fsys reads the host root.
+func DockerDesktopProvider(fsys fs.FS) Provider {
+	return fileProvider{name: "docker-desktop", path: DockerEnvPath, fsys: fsys, scan: getFromDockerDesktopFS, detail: detectDockerDesktopFS}
+}
+
+func getFromDockerDesktopFS(fsys fs.FS, name string) (string, error) {
+	d, err := detectDockerDesktopFS(fsys, name)
+	return d.ID, err
+}
+
+// detectDockerDesktopFS checks the marker file name in fsys and resolves
+// the hostname; Line is the hostname.
+func detectDockerDesktopFS(fsys fs.FS, name string) (Detection, error) {
+	env := DesktopEnvironment(fsys)
+	if env == "" {
+		return Detection{}, fmt.Errorf("not a Docker Desktop or WSL2 kernel: %w", ErrContainerIDNotFound)
+	}
+	if _, err := fs.Stat(fsys, name); errors.Is(err, fs.ErrNotExist) {
+		if containerEvidence(fsys) != "" {
+			return Detection{}, fmt.Errorf("%s kernel without /%s: %w", env, name, ErrContainerIDNotFound)
+		}
+		return Detection{}, fmt.Errorf("%s kernel without /%s: %w: %w", env, name, ErrNotInContainer, ErrContainerIDNotFound)
+	} else if err != nil {
+		return Detection{}, fmt.Errorf("failed to stat /%s: %w", name, err)
+	}
+
+	b, err := fs.ReadFile(fsys, hostnameName)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to read hostname: %w", err)
+	}
+	hostname := strings.TrimSpace(string(b))
+	if !reShortID.MatchString(hostname) {
+		return Detection{}, fmt.Errorf("hostname %q is not a short container ID: %w", hostname, ErrContainerIDNotFound)
+	}
+
+	id, err := expandShortID(fsys, hostname)
+	if err != nil {
+		return Detection{}, err
+	}
+	return Detection{ID: id, Line: hostname}, nil
+}
+
+// expandShortID returns the first full container ID in mountinfo or the
+// cgroup file of fsys that starts with short, or short itself if there is
+// none.
+func expandShortID(fsys fs.FS, short string) (string, error) {
+	full := ""
+	find := func(line string) bool {
+		for _, m := range reHexID.FindAllStringIndex(line, -1) {
+			if id := line[m[0]:m[1]]; strings.HasPrefix(id, short) && validContext(line[:m[0]]) {
+				full = id
+				return true
+			}
+		}
+		return false
+	}
+	for _, name := range []string{mountInfoName, cgroupName} {
+		if err := scanLines(fsys, name, find); err != nil {
+			return "", err
+		}
+		if full != "" {
+			return full, nil
+		}
+	}
+	return short, nil
+}
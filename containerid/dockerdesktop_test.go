@@ -0,0 +1,96 @@
+package containerid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDesktopEnvironment(t *testing.T) {
+	tests := map[string]string{
+		"6.10.14-linuxkit\n":                   EnvDockerDesktop,
+		"5.15.167.4-microsoft-standard-WSL2\n": EnvWSL2,
+		"6.1.0-18-amd64\n":                     "",
+	}
+	for release, want := range tests {
+		fsys := fstest.MapFS{"proc/sys/kernel/osrelease": {Data: []byte(release)}}
+		if got := DesktopEnvironment(fsys); got != want {
+			t.Errorf("DesktopEnvironment(%q) = %q, want %q", release, got, want)
+		}
+	}
+	if got := DesktopEnvironment(fstest.MapFS{}); got != "" {
+		t.Errorf("DesktopEnvironment without osrelease = %q, want empty", got)
+	}
+}
+
+func TestDockerDesktopProvider(t *testing.T) {
+	id := "3f9c2b7d41e8" + strings.Repeat("a", 52)
+	linuxkit := &fstest.MapFile{Data: []byte("6.10.14-linuxkit\n")}
+	hostname := &fstest.MapFile{Data: []byte("3f9c2b7d41e8\n")}
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "short ID",
+			files: fstest.MapFS{"proc/sys/kernel/osrelease": linuxkit, ".dockerenv": {}, "etc/hostname": hostname},
+			want:  "3f9c2b7d41e8",
+		},
+		{
+			name: "full ID from mountinfo",
+			files: fstest.MapFS{
+				"proc/sys/kernel/osrelease": {Data: []byte("5.15.167.4-microsoft-standard-WSL2\n")},
+				".dockerenv":                {},
+				"etc/hostname":              hostname,
+				"proc/self/mountinfo":       {Data: []byte("820 812 8:48 /docker/containers/" + id + "/mounts/secrets /run/secrets ro - ext4 /dev/sdd rw\n")},
+			},
+			want: id,
+		},
+		{
+			name: "layer digest is not expanded",
+			files: fstest.MapFS{
+				"proc/sys/kernel/osrelease": linuxkit,
+				".dockerenv":                {},
+				"etc/hostname":              hostname,
+				"proc/self/mountinfo":       {Data: []byte("1 0 0:1 / / rw - overlay overlay rw,upperdir=/var/lib/docker/overlay2/" + id + "/diff\n")},
+			},
+			want: "3f9c2b7d41e8",
+		},
+		{
+			name:    "custom hostname",
+			files:   fstest.MapFS{"proc/sys/kernel/osrelease": linuxkit, ".dockerenv": {}, "etc/hostname": {Data: []byte("web\n")}},
+			wantErr: ErrContainerIDNotFound,
+		},
+		{
+			name:    "not in a container",
+			files:   fstest.MapFS{"proc/sys/kernel/osrelease": linuxkit, "etc/hostname": hostname},
+			wantErr: ErrNotInContainer,
+		},
+		{
+			name:    "other kernel",
+			files:   fstest.MapFS{"proc/sys/kernel/osrelease": {Data: []byte("6.1.0-18-amd64\n")}, ".dockerenv": {}, "etc/hostname": hostname},
+			wantErr: ErrContainerIDNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DockerDesktopProvider(tt.files).Detect(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrContainerIDNotFound) {
+					t.Fatalf("Detect error = %v, want %v wrapping ErrContainerIDNotFound", err, tt.wantErr)
+				}
+				if tt.wantErr != ErrNotInContainer && errors.Is(err, ErrNotInContainer) {
+					t.Fatalf("Detect error = %v, want it not to claim ErrNotInContainer", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("Detect = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package containerid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ECSMetadataEnv is the environment variable in which the ECS agent passes
+// the task metadata endpoint (version 4) to every container.
+const ECSMetadataEnv = "ECS_CONTAINER_METADATA_URI_V4"
+
+// ecsRequestTimeout bounds each metadata request when the caller's context
+// has no deadline.
+const ecsRequestTimeout = 2 * time.Second
+
+// ecsTaskARNLabel is the container label holding the task ARN.
+const ecsTaskARNLabel = "com.amazonaws.ecs.task-arn"
+
+// ErrNotECS is returned by the ECS lookups outside of ECS, where
+// ECS_CONTAINER_METADATA_URI_V4 is not set.
+var ErrNotECS = errors.New(ECSMetadataEnv + " is not set")
+
+// ecsClient queries the ECS task metadata endpoint.
+var ecsClient = &http.Client{}
+
+// ECSContainer is the container metadata returned by the ECS task metadata
+// endpoint. DockerID is a 64-character hex ID on EC2 and of the form
+// "<32 hex>-<number>" on Fargate.
+type ECSContainer struct {
+	DockerID     string            `json:"DockerId"`
+	Name         string            `json:"Name"`
+	DockerName   string            `json:"DockerName"`
+	Image        string            `json:"Image"`
+	ImageID      string            `json:"ImageID"`
+	ContainerARN string            `json:"ContainerARN"`
+	Labels       map[string]string `json:"Labels"`
+}
+
+// TaskARN returns the ARN of the task the container belongs to.
+func (c ECSContainer) TaskARN() string {
+	return c.Labels[ecsTaskARNLabel]
+}
+
+// ECSTask is the task metadata returned by the ECS task metadata endpoint.
+type ECSTask struct {
+	Cluster          string         `json:"Cluster"`
+	TaskARN          string         `json:"TaskARN"`
+	Family           string         `json:"Family"`
+	Revision         string         `json:"Revision"`
+	DesiredStatus    string         `json:"DesiredStatus"`
+	KnownStatus      string         `json:"KnownStatus"`
+	AvailabilityZone string         `json:"AvailabilityZone"`
+	LaunchType       string         `json:"LaunchType"`
+	Containers       []ECSContainer `json:"Containers"`
+}
+
+// GetECSContainer returns the metadata of the current container from the
+// ECS task metadata endpoint. It returns ErrNotECS outside of ECS.
+func GetECSContainer(ctx context.Context) (ECSContainer, error) {
+	var c ECSContainer
+	err := getECSMetadata(ctx, "", &c)
+	return c, err
+}
+
+// GetECSTask returns the metadata of the current task from the ECS task
+// metadata endpoint. It returns ErrNotECS outside of ECS.
+func GetECSTask(ctx context.Context) (ECSTask, error) {
+	var t ECSTask
+	err := getECSMetadata(ctx, "/task", &t)
+	return t, err
+}
+
+// getECSMetadata decodes the JSON response of the metadata endpoint path
+// into v.
+func getECSMetadata(ctx context.Context, path string, v any) error {
+	base := os.Getenv(ECSMetadataEnv)
+	if base == "" {
+		return ErrNotECS
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ecsRequestTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ecsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query ECS metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ECS metadata %s: unexpected status %s", base+path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode ECS metadata %s: %w", base+path, err)
+	}
+	return nil
+}
+
+// ecsProvider reads the container ID from the ECS task metadata endpoint.
+// It is skipped when bound to another filesystem, since the endpoint only
+// describes the current process.
+type ecsProvider struct {
+	skip bool
+}
+
+// ECSProvider detects the container ID on AWS ECS and Fargate from the task
+// metadata endpoint named by ECS_CONTAINER_METADATA_URI_V4. It finds the ID
+// on Fargate, where the cgroup paths do not contain a 64-character hex ID.
+// Outside of ECS it fails without a request.
+func ECSProvider() Provider {
+	return ecsProvider{}
+}
+
+func (p ecsProvider) Name() string   { return "ecs" }
+func (p ecsProvider) source() string { return "env:" + ECSMetadataEnv }
+
+func (p ecsProvider) Detect(ctx context.Context) (string, error) {
+	if p.skip {
+		return "", fmt.Errorf("ECS metadata describes the current process, not a custom filesystem: %w", ErrContainerIDNotFound)
+	}
+	c, err := GetECSContainer(ctx)
+	if errors.Is(err, ErrNotECS) {
+		return "", fmt.Errorf("%w: %w", err, ErrContainerIDNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	if c.DockerID == "" {
+		return "", fmt.Errorf("ECS metadata has no DockerId: %w", ErrContainerIDNotFound)
+	}
+	return c.DockerID, nil
+}
+
+func (p ecsProvider) withFS(fs.FS) Provider {
+	return ecsProvider{skip: true}
+}
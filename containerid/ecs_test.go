@@ -0,0 +1,98 @@
+package containerid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	ecsFargateID = "cd189a933e5849daa93386466019ab50-2495160603"
+	ecsTaskARN   = "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c"
+)
+
+// serveECS serves a fake ECS task metadata endpoint and points
+// ECS_CONTAINER_METADATA_URI_V4 at it.
+func serveECS(t *testing.T) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v4/meta", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"DockerId":"` + ecsFargateID + `","Name":"curl","DockerName":"curl","Image":"111122223333.dkr.ecr.us-west-2.amazonaws.com/curl:latest","Labels":{"com.amazonaws.ecs.task-arn":"` + ecsTaskARN + `"}}`))
+	})
+	mux.HandleFunc("GET /v4/meta/task", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Cluster":"default","TaskARN":"` + ecsTaskARN + `","Family":"curltest","Revision":"3","AvailabilityZone":"us-west-2d","LaunchType":"FARGATE","Containers":[{"DockerId":"` + ecsFargateID + `","Name":"curl"}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Setenv(ECSMetadataEnv, srv.URL+"/v4/meta")
+}
+
+func TestECSProvider(t *testing.T) {
+	serveECS(t)
+
+	got, err := ECSProvider().Detect(context.Background())
+	if err != nil || got != ecsFargateID {
+		t.Fatalf("Detect = %q, %v, want %q", got, err, ecsFargateID)
+	}
+}
+
+func TestECSProviderNotECS(t *testing.T) {
+	t.Setenv(ECSMetadataEnv, "")
+
+	_, err := ECSProvider().Detect(context.Background())
+	if !errors.Is(err, ErrContainerIDNotFound) || !errors.Is(err, ErrNotECS) {
+		t.Errorf("Detect error = %v, want ErrNotECS and ErrContainerIDNotFound", err)
+	}
+}
+
+func TestECSProviderSkippedWithFS(t *testing.T) {
+	serveECS(t)
+
+	chain := Chain{ECSProvider()}.withFS(fstest.MapFS{})
+	if _, err := chain.Detect(context.Background()); !errors.Is(err, ErrContainerIDNotFound) {
+		t.Errorf("Detect with custom filesystem error = %v, want ErrContainerIDNotFound", err)
+	}
+}
+
+func TestGetECSTask(t *testing.T) {
+	serveECS(t)
+
+	task, err := GetECSTask(context.Background())
+	if err != nil {
+		t.Fatalf("GetECSTask error = %v", err)
+	}
+	if task.TaskARN != ecsTaskARN || task.LaunchType != "FARGATE" || len(task.Containers) != 1 {
+		t.Errorf("GetECSTask = %+v, want the FARGATE task with one container", task)
+	}
+
+	c, err := GetECSContainer(context.Background())
+	if err != nil || c.TaskARN() != ecsTaskARN || c.Name != "curl" {
+		t.Errorf("GetECSContainer = %+v, %v, want container curl of %s", c, err, ecsTaskARN)
+	}
+}
+
+func TestGetECSMetadataStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	t.Setenv(ECSMetadataEnv, srv.URL)
+
+	if _, err := GetECSTask(context.Background()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("GetECSTask error = %v, want unexpected status 404", err)
+	}
+}
+
+func TestGetWithECS(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+	serveECS(t)
+
+	got, err := Get()
+	if err != nil || got != ecsFargateID {
+		t.Fatalf("Get = %q, %v, want %q", got, err, ecsFargateID)
+	}
+}
@@ -0,0 +1,46 @@
+package containerid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceError records why one source did not yield a container ID.
+type SourceError struct {
+	// Source is the file or endpoint that was scanned.
+	Source string
+	Err    error
+}
+
+// DetectionError is returned by Get when no source yielded a container ID.
+// Retrieve it with errors.As to log every source that was scanned and why
+// each one failed. errors.Is still matches the underlying per-source errors,
+// and ErrIDUnavailable or ErrNotInContainer depending on Evidence.
+type DetectionError struct {
+	Sources []SourceError
+
+	// Evidence is the ContainerEvidence found after every source failed,
+	// or "" if the process does not appear to run in a container.
+	Evidence string
+}
+
+func (e *DetectionError) Error() string {
+	parts := make([]string, len(e.Sources))
+	for i, s := range e.Sources {
+		parts[i] = fmt.Sprintf("%s: %v", s.Source, s.Err)
+	}
+	return "containerid: container ID not detected (scanned " + strings.Join(parts, "; ") + ")"
+}
+
+// Unwrap returns the per-source errors, followed by ErrIDUnavailable if
+// there is Evidence of a container and ErrNotInContainer otherwise.
+func (e *DetectionError) Unwrap() []error {
+	errs := make([]error, len(e.Sources), len(e.Sources)+1)
+	for i, s := range e.Sources {
+		errs[i] = s.Err
+	}
+	if e.Evidence != "" {
+		return append(errs, ErrIDUnavailable)
+	}
+	return append(errs, ErrNotInContainer)
+}
@@ -0,0 +1,123 @@
+package containerid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// ErrIDUnavailable is returned, wrapped in a *DetectionError, when no
+// provider finds the container ID although the process evidently runs in a
+// container, e.g. because a cgroup namespace hides the ID. Outside of a
+// container the *DetectionError wraps ErrNotInContainer instead.
+var ErrIDUnavailable = errors.New("running in a container, but its ID is not visible")
+
+const (
+	// initCgroupPath is the cgroup membership of PID 1.
+	initCgroupPath = "/proc/1/cgroup"
+
+	// initStatPath is the status of PID 1, for its controlling terminal.
+	initStatPath = "/proc/1/stat"
+
+	// initEnvironPath is the environment of PID 1.
+	initEnvironPath = "/proc/1/environ"
+
+	// systemdContainerPath is where systemd-nspawn and other runtimes
+	// following the systemd container interface name themselves.
+	systemdContainerPath = "/run/systemd/container"
+
+	// serviceAccountPath is mounted into every Kubernetes pod that does not
+	// opt out of the service account token.
+	serviceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// ptsMajorFirst to ptsMajorLast are the device majors of Unix98
+// pseudo-terminals, see Documentation/admin-guide/devices.txt.
+const ptsMajorFirst, ptsMajorLast = 136, 143
+
+// ContainerEvidence returns the first sign that the process runs in a
+// container, or "" if there is none. In order it checks the marker files
+// /.dockerenv, /run/.containerenv and /run/systemd/container, the Kubernetes
+// service account mount, the container variable systemd-style runtimes set
+// for PID 1, runtime names in the cgroup paths of the process and of PID 1,
+// and whether PID 1 has a pseudo-terminal as its controlling terminal, as
+// with docker run -t; the init process of a host never has one. A PID 1 in
+// systemd's init.scope is taken as the host's init. Only WithFS is
+// honoured.
+func ContainerEvidence(opts ...Option) string {
+	o := newOptions(opts)
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	return containerEvidence(fsys)
+}
+
+func containerEvidence(fsys fs.FS) string {
+	for _, path := range []string{DockerEnvPath, ContainerEnvPath, systemdContainerPath, serviceAccountPath} {
+		if _, err := fs.Stat(fsys, strings.TrimPrefix(path, "/")); err == nil {
+			return path + " exists"
+		}
+	}
+
+	if b, err := fs.ReadFile(fsys, strings.TrimPrefix(initEnvironPath, "/")); err == nil {
+		for _, kv := range bytes.Split(b, []byte{0}) {
+			if v, ok := bytes.CutPrefix(kv, []byte("container=")); ok && len(v) > 0 {
+				return "PID 1 has container=" + string(v)
+			}
+		}
+	}
+
+	// PID 1 in systemd's init.scope is the host's init, so a runtime name
+	// in the process's own cgroup, e.g. docker.service, does not count.
+	for _, path := range []string{initCgroupPath, CgroupPath} {
+		evidence, host := cgroupEvidence(fsys, path)
+		if host {
+			return ""
+		}
+		if evidence != "" {
+			return evidence
+		}
+	}
+
+	if b, err := fs.ReadFile(fsys, strings.TrimPrefix(initStatPath, "/")); err == nil {
+		// The command name may contain spaces and parentheses; the fields
+		// after it start with the state, so tty_nr is the fifth.
+		if i := bytes.LastIndexByte(b, ')'); i >= 0 {
+			fields := strings.Fields(string(b[i+1:]))
+			if len(fields) > 4 {
+				if tty, err := strconv.ParseUint(fields[4], 10, 32); err == nil {
+					if major := (tty >> 8) & 0xfff; major >= ptsMajorFirst && major <= ptsMajorLast {
+						return "PID 1 runs on a pseudo-terminal"
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// cgroupEvidence returns the first cgroup path in the cgroup file path of
+// fsys that a runtime, Kubernetes, ECS or LXC creates. host reports that the
+// file places its process in init.scope.
+func cgroupEvidence(fsys fs.FS, path string) (evidence string, host bool) {
+	scanLines(fsys, strings.TrimPrefix(path, "/"), func(line string) bool {
+		c, err := ParseCgroupLine(line)
+		if err != nil {
+			return false
+		}
+		switch {
+		case c.Path == "/init.scope":
+			host = true
+		case matchRuntime(c.Path, cgroupRuntimes) != RuntimeUnknown:
+			evidence = fmt.Sprintf("%s names %s in %s", path, matchRuntime(c.Path, cgroupRuntimes), c.Path)
+		case strings.Contains(c.Path, "/kubepods"), strings.HasPrefix(c.Path, "/ecs/"), strings.HasPrefix(c.Path, "/lxc"):
+			evidence = fmt.Sprintf("%s is in %s", path, c.Path)
+		}
+		return host || evidence != ""
+	})
+	return evidence, host
+}
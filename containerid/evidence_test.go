@@ -0,0 +1,127 @@
+package containerid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestContainerEvidence(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{name: "nothing", files: fstest.MapFS{}, want: ""},
+		{name: "dockerenv", files: fstest.MapFS{".dockerenv": {}}, want: "/.dockerenv exists"},
+		{name: "containerenv", files: fstest.MapFS{"run/.containerenv": {}}, want: "/run/.containerenv exists"},
+		{name: "systemd container", files: fstest.MapFS{"run/systemd/container": {Data: []byte("lxc\n")}}, want: "/run/systemd/container exists"},
+		{
+			name:  "service account",
+			files: fstest.MapFS{"var/run/secrets/kubernetes.io/serviceaccount/token": {}},
+			want:  "/var/run/secrets/kubernetes.io/serviceaccount exists",
+		},
+		{
+			name:  "init environ",
+			files: fstest.MapFS{"proc/1/environ": {Data: []byte("PATH=/bin\x00container=podman\x00")}},
+			want:  "PID 1 has container=podman",
+		},
+		{
+			name:  "self cgroup runtime",
+			files: fstest.MapFS{"proc/self/cgroup": {Data: []byte("0::/system.slice/docker-abc.scope\n")}},
+			want:  "/proc/self/cgroup names docker in /system.slice/docker-abc.scope",
+		},
+		{
+			name:  "init cgroup kubepods",
+			files: fstest.MapFS{"proc/1/cgroup": {Data: []byte("0::/kubepods.slice/kubepods-burstable.slice\n")}},
+			want:  "/proc/1/cgroup is in /kubepods.slice/kubepods-burstable.slice",
+		},
+		{
+			name: "host init scope",
+			files: fstest.MapFS{
+				"proc/1/cgroup":    {Data: []byte("0::/init.scope\n")},
+				"proc/self/cgroup": {Data: []byte("0::/system.slice/docker.service\n")},
+			},
+			want: "",
+		},
+		{
+			name:  "init on pseudo-terminal",
+			files: fstest.MapFS{"proc/1/stat": {Data: []byte("1 (sh (x)) S 0 1 1 34816 1 4194560 0 0\n")}},
+			want:  "PID 1 runs on a pseudo-terminal",
+		},
+		{
+			name:  "init without terminal",
+			files: fstest.MapFS{"proc/1/stat": {Data: []byte("1 (systemd) S 0 1 1 0 -1 4194560 0 0\n")}},
+			want:  "",
+		},
+		{
+			name:  "namespaced cgroup",
+			files: fstest.MapFS{"proc/1/cgroup": {Data: []byte("0::/\n")}, "proc/self/cgroup": {Data: []byte("0::/\n")}},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContainerEvidence(WithFS(tt.files)); got != tt.want {
+				t.Errorf("ContainerEvidence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectionErrorClassification(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  error
+		not   error
+	}{
+		{
+			name:  "host",
+			files: fstest.MapFS{"proc/self/mountinfo": {Data: []byte("")}, "proc/self/cgroup": {Data: []byte("0::/user.slice\n")}},
+			want:  ErrNotInContainer,
+			not:   ErrIDUnavailable,
+		},
+		{
+			name: "hidden ID",
+			files: fstest.MapFS{
+				".dockerenv":          {},
+				"proc/self/mountinfo": {Data: []byte("")},
+				"proc/self/cgroup":    {Data: []byte("0::/\n")},
+			},
+			want: ErrIDUnavailable,
+			not:  ErrNotInContainer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Get(WithFS(tt.files))
+			var detErr *DetectionError
+			if !errors.As(err, &detErr) {
+				t.Fatalf("Get error = %v, want *DetectionError", err)
+			}
+			if !errors.Is(err, tt.want) || errors.Is(err, tt.not) {
+				t.Errorf("Get error = %v, want %v and not %v", err, tt.want, tt.not)
+			}
+			if !errors.Is(err, ErrContainerIDNotFound) {
+				t.Errorf("Get error = %v, want ErrContainerIDNotFound", err)
+			}
+			if (detErr.Evidence != "") != errors.Is(tt.want, ErrIDUnavailable) {
+				t.Errorf("Evidence = %q", detErr.Evidence)
+			}
+		})
+	}
+}
+
+func TestDockerDesktopProviderHiddenID(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/sys/kernel/osrelease": {Data: []byte("6.10.14-linuxkit\n")},
+		"run/.containerenv":         {},
+		"etc/hostname":              {Data: []byte(strings.Repeat("a", 12) + "\n")},
+	}
+	_, err := DockerDesktopProvider(fsys).Detect(t.Context())
+	if !errors.Is(err, ErrContainerIDNotFound) || errors.Is(err, ErrNotInContainer) {
+		t.Errorf("Detect error = %v, want ErrContainerIDNotFound without ErrNotInContainer", err)
+	}
+}
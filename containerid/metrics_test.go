@@ -0,0 +1,69 @@
+package containerid
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMetricsRecordsDetection(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{}
+	getCgroupFunc = getCgroup
+	getContainerEnvFunc = getContainerEnv
+	getCpusetFunc = getCpuset
+
+	before := Metrics().Snapshot()
+
+	if _, err := Get(); err == nil {
+		t.Fatal("Get with missing mountinfo returned nil error")
+	}
+	rootFS = fstest.MapFS{"proc/self/mountinfo": {Data: []byte("1246 1234 8:1 /var/lib/docker/containers/" + strings.Repeat("a", 64) + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n")}}
+	if got, err := Get(); err != nil || got != strings.Repeat("a", 64) {
+		t.Fatalf("Get = %q, %v, want %q", got, err, strings.Repeat("a", 64))
+	}
+	if _, err := Get(); err != nil {
+		t.Fatalf("Get cached call returned error: %v", err)
+	}
+
+	after := Metrics().Snapshot()
+	checks := []struct {
+		name        string
+		got, wantUp uint64
+	}{
+		{"attempts", after.Attempts - before.Attempts, 8},
+		{"successes", after.Successes - before.Successes, 1},
+		{"source_missing", after.Failures[ReasonSourceMissing] - before.Failures[ReasonSourceMissing], 5},
+		{"cache misses", after.CacheMisses - before.CacheMisses, 2},
+		{"cache hits", after.CacheHits - before.CacheHits, 1},
+	}
+	for _, c := range checks {
+		if c.got != c.wantUp {
+			t.Errorf("%s increased by %d, want %d", c.name, c.got, c.wantUp)
+		}
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrContainerIDNotFound, ReasonNotFound},
+		{fmt.Errorf("open: %w", fs.ErrNotExist), ReasonSourceMissing},
+		{fmt.Errorf("open: %w", fs.ErrPermission), ReasonPermissionDenied},
+		{errors.New("boom"), ReasonReadError},
+	}
+	for _, tt := range tests {
+		if got := failureReason(tt.err); got != tt.want {
+			t.Errorf("failureReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
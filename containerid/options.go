@@ -0,0 +1,100 @@
+package containerid
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+)
+
+// Option configures a call to Get.
+type Option func(*options)
+
+type options struct {
+	logger *slog.Logger
+	fsys   fs.FS
+	chain  Chain
+
+	dockerSocket string
+	criSocket    string
+}
+
+// WithLogger makes Get emit debug-level records about the providers it runs
+// and what they found. Without it, Get does not log.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithFS makes Get read /proc from fsys instead of the host root, for example
+// os.DirFS("/host") when the host's /proc is mounted elsewhere. Results read
+// through a custom filesystem are not cached.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithChain makes Get run chain instead of DefaultChain, for example to try
+// an EnvProvider first or to skip providers. Results are not cached.
+func WithChain(chain Chain) Option {
+	return func(o *options) {
+		o.chain = chain
+	}
+}
+
+// WithDockerSocket makes Get fall back to querying the Docker Engine API at
+// path, usually DockerSocketPath, when no other provider finds the container
+// ID. See DockerSocketProvider. Results are not cached.
+func WithDockerSocket(path string) Option {
+	return func(o *options) {
+		o.dockerSocket = path
+	}
+}
+
+// WithCRISocket makes Get fall back to querying the CRI runtime service at
+// path when no other provider finds the container ID, even if DefaultChain
+// leaves it out because nothing listens on CRISocketPath. See CRIProvider.
+// Results are not cached.
+func WithCRISocket(path string) Option {
+	return func(o *options) {
+		o.criSocket = path
+	}
+}
+
+// resolveChain returns the chain to run, with the built-in providers bound
+// to the WithFS filesystem if one was given, then the CRI provider if
+// WithCRISocket was given and the chain does not already query that socket,
+// and the Docker provider last if WithDockerSocket was given.
+func (o options) resolveChain() Chain {
+	chain := o.chain
+	if chain == nil {
+		chain = DefaultChain()
+	}
+	if o.criSocket != "" && !chain.queries(o.criSocket) {
+		chain = append(chain[:len(chain):len(chain)], CRIProvider(o.criSocket))
+	}
+	if o.fsys != nil {
+		chain = chain.withFS(o.fsys)
+	}
+	if o.dockerSocket != "" {
+		chain = append(chain[:len(chain):len(chain)], DockerSocketProvider(o.dockerSocket))
+	}
+	return chain
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// debug logs msg at debug level if a logger was configured.
+func (o options) debug(msg string, attrs ...slog.Attr) {
+	if o.logger == nil {
+		return
+	}
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
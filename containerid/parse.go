@@ -0,0 +1,61 @@
+package containerid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+// ErrMalformedLine is returned, wrapped, when a line does not follow the
+// expected file format. It is mountinfo.ErrMalformedLine, so errors of
+// both packages match it.
+var ErrMalformedLine = mountinfo.ErrMalformedLine
+
+// Cgroup is one line of /proc/<pid>/cgroup. See cgroups(7).
+type Cgroup struct {
+	HierarchyID int
+	// Controllers is empty for the cgroup v2 unified hierarchy.
+	Controllers []string
+	Path        string
+}
+
+// ParseCgroupLine parses a single /proc/<pid>/cgroup line of the form
+// "hierarchy-ID:controller-list:cgroup-path". The path may itself contain
+// colons. Lines without two colons or with a non-numeric hierarchy ID
+// return ErrMalformedLine.
+func ParseCgroupLine(line string) (Cgroup, error) {
+	parts := strings.SplitN(strings.TrimRight(line, "\r\n"), ":", 3)
+	if len(parts) != 3 {
+		return Cgroup{}, fmt.Errorf("cgroup: %w: %q", ErrMalformedLine, line)
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Cgroup{}, fmt.Errorf("cgroup: %w: hierarchy ID %q", ErrMalformedLine, parts[0])
+	}
+
+	c := Cgroup{HierarchyID: id, Path: parts[2]}
+	if parts[1] != "" {
+		c.Controllers = strings.Split(parts[1], ",")
+	}
+	return c, nil
+}
+
+// ExtractContainerID returns the container ID referenced by line, using the
+// same rule Get applies to mountinfo: a 64-character lowercase hex directory
+// holding a hostname, hosts or resolv.conf file. The line does not need to be
+// a valid mountinfo line, so log and archive data can be searched as well.
+// Digests such as sha256/<hex>/hosts and containerd's pod sandbox
+// directories are skipped, as are, with SetStrict, directories that no known
+// runtime uses for containers. It returns false if line contains no such
+// reference.
+func ExtractContainerID(line string) (string, bool) {
+	for _, m := range reGeneric.FindAllStringSubmatchIndex(line, -1) {
+		if validContext(line[:m[2]]) {
+			return line[m[2]:m[3]], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,98 @@
+package containerid
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCgroupLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Cgroup
+	}{
+		{"0::/", Cgroup{HierarchyID: 0, Path: "/"}},
+		{"4:cpu,cpuacct:/docker/abc\n", Cgroup{HierarchyID: 4, Controllers: []string{"cpu", "cpuacct"}, Path: "/docker/abc"}},
+		{"1:name=systemd:/a:b", Cgroup{HierarchyID: 1, Controllers: []string{"name=systemd"}, Path: "/a:b"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCgroupLine(tt.line)
+		if err != nil {
+			t.Errorf("ParseCgroupLine(%q) returned error: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCgroupLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "0:/", "x::/"} {
+		if _, err := ParseCgroupLine(line); !errors.Is(err, ErrMalformedLine) {
+			t.Errorf("ParseCgroupLine(%q) error = %v, want ErrMalformedLine", line, err)
+		}
+	}
+}
+
+func TestExtractContainerID(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"1246 1234 8:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw", id, true},
+		{`time=... msg="mounted /var/lib/docker/containers/` + id + `/resolv.conf"`, id, true},
+		{"0::/system.slice/docker-" + id + ".scope", "", false},
+		{"/var/lib/docker/containers/" + strings.ToUpper(id) + "/hosts", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ExtractContainerID(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ExtractContainerID(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func FuzzParseCgroupLine(f *testing.F) {
+	f.Add("0::/")
+	f.Add("4:cpu,cpuacct:/docker/abc")
+	f.Add("1:name=systemd:/a:b")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		c, err := ParseCgroupLine(line)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedLine) {
+				t.Fatalf("ParseCgroupLine(%q) error = %v, want ErrMalformedLine", line, err)
+			}
+			return
+		}
+		if !strings.Contains(line, c.Path) {
+			t.Fatalf("ParseCgroupLine(%q).Path = %q, not part of the line", line, c.Path)
+		}
+	})
+}
+
+func FuzzExtractContainerID(f *testing.F) {
+	f.Add("/var/lib/docker/containers/" + strings.Repeat("a", 64) + "/hostname /etc/hostname")
+	f.Add("0::/system.slice/docker-" + strings.Repeat("b", 64) + ".scope")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		id, ok := ExtractContainerID(line)
+		if !ok {
+			if id != "" {
+				t.Fatalf("ExtractContainerID(%q) = %q, false, want empty ID", line, id)
+			}
+			return
+		}
+		if len(id) != 64 || strings.Trim(id, "0123456789abcdef") != "" {
+			t.Fatalf("ExtractContainerID(%q) = %q, not a 64-character hex ID", line, id)
+		}
+		if !strings.Contains(line, id) {
+			t.Fatalf("ExtractContainerID(%q) = %q, not part of the line", line, id)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package containerid
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ming-go/lab/get-container-id/mountinfo"
+)
+
+// ContainerEnvPath is where podman bind-mounts its container environment
+// file.
+const ContainerEnvPath = "/run/.containerenv"
+
+var (
+	// reContainerID matches a whole full-length container ID.
+	reContainerID = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+	// reUserdata matches podman's per-container userdata directory, rootful
+	// (/var/lib/containers/storage/...) or rootless (~/.local/share/containers/storage/...).
+	reUserdata = regexp.MustCompile(`/overlay-containers/([0-9a-f]{64})/userdata/`)
+
+	// containerEnvName is ContainerEnvPath as an fs.FS path.
+	containerEnvName = strings.TrimPrefix(ContainerEnvPath, "/")
+)
+
+// GetFromContainerEnvFS retrieves the container ID of a podman container,
+// rootful or rootless, from fsys. It reads the id field of the container
+// environment file name, which podman only fills in for some containers,
+// and otherwise finds the container's userdata directory in the source of
+// the file's bind mount in /proc/self/mountinfo. The hostname and hosts
+// mounts cannot be used for this, because CRI-O mounts them from the pod
+// sandbox's userdata directory.
+func GetFromContainerEnvFS(fsys fs.FS, name string) (string, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read container environment: %w", err)
+	}
+	if id := containerEnvID(string(b)); id != "" {
+		return id, nil
+	}
+
+	file, err := fsys.Open(mountInfoName)
+	if err != nil {
+		return "", fmt.Errorf("failed to open mountinfo: %w", err)
+	}
+	defer file.Close()
+
+	var id string
+	err = mountinfo.Scan(file, func(m mountinfo.Mount) bool {
+		if m.MountPoint != ContainerEnvPath {
+			return false
+		}
+		if matches := reUserdata.FindStringSubmatch(m.Root); len(matches) > 1 {
+			id = matches[1]
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return "", fmt.Errorf("error reading mountinfo: %w", err)
+	}
+	if id == "" {
+		return "", ErrContainerIDNotFound
+	}
+	return id, nil
+}
+
+// containerEnvID returns the id field of a .containerenv file, or "" if it
+// has none. Values are double-quoted.
+func containerEnvID(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || key != "id" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if reContainerID.MatchString(value) {
+			return value
+		}
+	}
+	return ""
+}
+
+// getContainerEnv reads the container environment file from the default path.
+func getContainerEnv() (string, error) {
+	return GetFromContainerEnvFS(rootFS, containerEnvName)
+}
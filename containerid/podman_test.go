@@ -0,0 +1,65 @@
+package containerid
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetFromContainerEnvFS(t *testing.T) {
+	id := strings.Repeat("5", 64)
+	sandbox := strings.Repeat("6", 64)
+	mount := func(source, target string) string {
+		return "813 800 0:48 " + source + " " + target + " rw,nosuid,nodev,relatime - tmpfs tmpfs rw,uid=1000,gid=1000\n"
+	}
+	tests := []struct {
+		name      string
+		env       string
+		mountinfo string
+		want      string
+		wantErr   error
+		noEnvFile bool
+		noMountFS bool
+	}{
+		{name: "id field", env: "engine=\"podman-4.9.3\"\nname=\"web\"\nid=\"" + id + "\"\nrootless=1\n", noMountFS: true, want: id},
+		{name: "rootless mount", mountinfo: mount("/containers/overlay-containers/"+id+"/userdata/.containerenv", "/run/.containerenv"), want: id},
+		{name: "rootful mount", mountinfo: mount("/containers/storage/overlay-containers/"+id+"/userdata/.containerenv", "/run/.containerenv"), want: id},
+		{name: "hostname mount ignored", mountinfo: mount("/containers/storage/overlay-containers/"+sandbox+"/userdata/hostname", "/etc/hostname"), wantErr: ErrContainerIDNotFound},
+		{name: "malformed id", env: "id=\"not-an-id\"\n", mountinfo: "", wantErr: ErrContainerIDNotFound},
+		{name: "not podman", noEnvFile: true, wantErr: fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			if !tt.noEnvFile {
+				fsys["run/.containerenv"] = &fstest.MapFile{Data: []byte(tt.env)}
+			}
+			if !tt.noMountFS {
+				fsys["proc/self/mountinfo"] = &fstest.MapFile{Data: []byte(tt.mountinfo)}
+			}
+			got, err := GetFromContainerEnvFS(fsys, "run/.containerenv")
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetFromContainerEnvFS() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetFallsBackToPodman(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := strings.Repeat("8", 64)
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("813 800 0:48 /containers/overlay-containers/" + want + "/userdata/.containerenv /run/.containerenv rw - tmpfs tmpfs rw\n")},
+		"proc/self/cgroup":    {Data: []byte("0::/\n")},
+		"run/.containerenv":   {Data: []byte("")},
+	}
+
+	got, err := Get(WithFS(fsys))
+	if err != nil || got != want {
+		t.Fatalf("Get(WithFS) = %q, %v, want %q from the .containerenv mount", got, err, want)
+	}
+}
@@ -0,0 +1,251 @@
+package containerid
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// CpusetPath is the default path to the cpuset membership file.
+const CpusetPath = "/proc/self/cpuset"
+
+var (
+	// registered holds the providers added with RegisterProvider.
+	registered   []Provider
+	registeredMu sync.RWMutex
+
+	// cpusetName is CpusetPath as an fs.FS path.
+	cpusetName = strings.TrimPrefix(CpusetPath, "/")
+
+	getCpusetFunc = getCpuset
+)
+
+// Provider is one container ID detection strategy. Detect returns the full
+// container ID, or an error wrapping ErrContainerIDNotFound if its source
+// holds none.
+type Provider interface {
+	Name() string
+	Detect(ctx context.Context) (string, error)
+}
+
+// sourcer is implemented by providers that read a file, so DetectionError
+// can name the file instead of the provider.
+type sourcer interface {
+	source() string
+}
+
+// rebinder is implemented by the built-in providers so WithFS can point them
+// at another filesystem.
+type rebinder interface {
+	withFS(fs.FS) Provider
+}
+
+// Chain runs providers in order and returns the first container ID found.
+type Chain []Provider
+
+// Detect runs each provider until one succeeds. If none does, the error is a
+// *DetectionError with one entry per provider and the ContainerEvidence of
+// the host root. It stops early if ctx is done.
+func (c Chain) Detect(ctx context.Context) (string, error) {
+	return c.detect(ctx, options{})
+}
+
+func (c Chain) detect(ctx context.Context, o options) (string, error) {
+	var sources []SourceError
+	for _, p := range c {
+		if err := ctx.Err(); err != nil {
+			sources = append(sources, SourceError{Source: sourceOf(p), Err: err})
+			break
+		}
+
+		o.debug("containerid: running provider", slog.String("provider", p.Name()), slog.String("path", sourceOf(p)))
+		id, err := runProvider(func() (string, error) { return p.Detect(ctx) })
+		if err != nil {
+			o.debug("containerid: provider failed", slog.String("provider", p.Name()), slog.Any("error", err))
+			sources = append(sources, SourceError{Source: sourceOf(p), Err: err})
+			continue
+		}
+		o.debug("containerid: provider found container ID", slog.String("provider", p.Name()), slog.String("id", id))
+		return id, nil
+	}
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	return "", &DetectionError{Sources: sources, Evidence: containerEvidence(fsys)}
+}
+
+func sourceOf(p Provider) string {
+	if s, ok := p.(sourcer); ok {
+		return s.source()
+	}
+	return p.Name()
+}
+
+// queries reports whether c has a CRI provider for the socket at path.
+func (c Chain) queries(path string) bool {
+	for _, p := range c {
+		if cri, ok := p.(criProvider); ok && cri.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// withFS returns c with every built-in provider reading fsys. Registered
+// providers are kept as they are.
+func (c Chain) withFS(fsys fs.FS) Chain {
+	bound := make(Chain, len(c))
+	for i, p := range c {
+		if r, ok := p.(rebinder); ok {
+			p = r.withFS(fsys)
+		}
+		bound[i] = p
+	}
+	return bound
+}
+
+// DefaultChain returns the chain Get runs: cpuset, then mountinfo, then
+// cgroup, then podman, then ECS, then Docker Desktop, then CRI if a socket
+// exists at CRISocketPath, then any providers added with RegisterProvider. Reorder or
+// extend the returned chain and pass it to WithChain to change the
+// detection order.
+func DefaultChain() Chain {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+
+	chain := Chain{CpusetProvider(nil), MountInfoProvider(nil), CgroupProvider(nil), PodmanProvider(nil), ECSProvider(), DockerDesktopProvider(nil)}
+	if hasCRISocket() {
+		chain = append(chain, CRIProvider(CRISocketPath))
+	}
+	return append(chain, registered...)
+}
+
+// RegisterProvider appends p to the default chain, so Get tries it after the
+// built-in providers. It is meant to be called from an init function. A
+// container ID that was already cached is not re-detected.
+func RegisterProvider(p Provider) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	registered = append(registered, p)
+}
+
+// fileProvider reads one file with scan. A nil fsys means the host root, read
+// through host so tests can stub the cached lookup. detail, if set, is scan
+// with the line the ID was found in, for GetDetailed.
+type fileProvider struct {
+	name   string
+	path   string
+	fsys   fs.FS
+	host   func() (string, error)
+	scan   func(fs.FS, string) (string, error)
+	detail func(fs.FS, string) (Detection, error)
+}
+
+func (p fileProvider) Name() string   { return p.name }
+func (p fileProvider) source() string { return p.path }
+
+func (p fileProvider) Detect(ctx context.Context) (string, error) {
+	if p.fsys == nil && p.host != nil {
+		return p.host()
+	}
+	fsys := p.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	return p.scan(fsys, strings.TrimPrefix(p.path, "/"))
+}
+
+func (p fileProvider) detectDetailed(ctx context.Context) (Detection, error) {
+	if p.detail == nil {
+		id, err := p.Detect(ctx)
+		return Detection{ID: id}, err
+	}
+	fsys := p.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	return p.detail(fsys, strings.TrimPrefix(p.path, "/"))
+}
+
+func (p fileProvider) withFS(fsys fs.FS) Provider {
+	p.fsys = fsys
+	p.host = nil
+	return p
+}
+
+// MountInfoProvider detects the container ID from the hostname, hosts and
+// resolv.conf bind mounts in /proc/self/mountinfo of fsys. A nil fsys reads
+// the host root.
+func MountInfoProvider(fsys fs.FS) Provider {
+	return fileProvider{name: "mountinfo", path: MountInfoPath, fsys: fsys, host: getFunc, scan: GetFromFS, detail: detectMountInfoFS}
+}
+
+// CgroupProvider detects the container ID from the cgroup paths in
+// /proc/self/cgroup of fsys. A nil fsys reads the host root.
+func CgroupProvider(fsys fs.FS) Provider {
+	return fileProvider{name: "cgroup", path: CgroupPath, fsys: fsys, host: getCgroupFunc, scan: GetFromCgroupFS, detail: detectCgroupFS}
+}
+
+// PodmanProvider detects the container ID of a podman container, rootful or
+// rootless, from /run/.containerenv and its bind mount in fsys. It finds the
+// ID when the cgroup namespace hides it and podman mounts the hostname from
+// the container's userdata directory. A nil fsys reads the host root.
+func PodmanProvider(fsys fs.FS) Provider {
+	return fileProvider{name: "podman", path: ContainerEnvPath, fsys: fsys, host: getContainerEnvFunc, scan: GetFromContainerEnvFS}
+}
+
+// CpusetProvider detects the container ID from the cgroup v1 cpuset path in
+// /proc/self/cpuset of fsys, as written by Docker with the cgroupfs driver.
+// A nil fsys reads the host root.
+func CpusetProvider(fsys fs.FS) Provider {
+	return fileProvider{name: "cpuset", path: CpusetPath, fsys: fsys, host: getCpusetFunc, scan: getFromCpusetFS, detail: detectCpusetFS}
+}
+
+func getCpuset() (string, error) {
+	return getFromCpusetFS(rootFS, cpusetName)
+}
+
+func getFromCpusetFS(fsys fs.FS, name string) (string, error) {
+	d, err := detectCpusetFS(fsys, name)
+	return d.ID, err
+}
+
+// detectCpusetFS reads the cpuset path, which only cgroup v1 sets.
+func detectCpusetFS(fsys fs.FS, name string) (Detection, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to read cpuset: %w", err)
+	}
+	path := strings.TrimSpace(string(b))
+	if id, ok := ExtractContainerIDFromCgroup(path); ok {
+		return Detection{ID: id, Line: path, CgroupVersion: 1}, nil
+	}
+	return Detection{}, ErrContainerIDNotFound
+}
+
+// envProvider reads the container ID from an environment variable.
+type envProvider struct {
+	key string
+}
+
+// EnvProvider detects the container ID from the environment variable key,
+// for runtimes that inject it, e.g. through a downward API or an entrypoint
+// script.
+func EnvProvider(key string) Provider {
+	return envProvider{key: key}
+}
+
+func (p envProvider) Name() string { return "env:" + p.key }
+
+func (p envProvider) Detect(ctx context.Context) (string, error) {
+	if v := strings.TrimSpace(os.Getenv(p.key)); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s is not set: %w", p.key, ErrContainerIDNotFound)
+}
@@ -0,0 +1,157 @@
+package containerid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// stubProvider returns a fixed result and counts its calls.
+type stubProvider struct {
+	name  string
+	id    string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Detect(ctx context.Context) (string, error) {
+	p.calls++
+	return p.id, p.err
+}
+
+func TestChainDetectOrder(t *testing.T) {
+	first := &stubProvider{name: "first", err: ErrContainerIDNotFound}
+	second := &stubProvider{name: "second", id: "from-second"}
+	third := &stubProvider{name: "third", id: "from-third"}
+
+	got, err := Chain{first, second, third}.Detect(context.Background())
+	if err != nil || got != "from-second" {
+		t.Fatalf("Detect = %q, %v, want from-second", got, err)
+	}
+	if first.calls != 1 || second.calls != 1 || third.calls != 0 {
+		t.Errorf("calls = %d, %d, %d, want 1, 1, 0", first.calls, second.calls, third.calls)
+	}
+}
+
+func TestChainDetectError(t *testing.T) {
+	custom := &stubProvider{name: "custom", err: errors.New("runtime API down")}
+	chain := Chain{CpusetProvider(fstest.MapFS{}), custom}
+
+	_, err := chain.Detect(context.Background())
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Detect error = %v, want *DetectionError", err)
+	}
+	if len(detErr.Sources) != 2 || detErr.Sources[0].Source != CpusetPath || detErr.Sources[1].Source != "custom" {
+		t.Errorf("DetectionError.Sources = %+v, want %s and custom", detErr.Sources, CpusetPath)
+	}
+	if !errors.Is(err, custom.err) {
+		t.Errorf("Detect error = %v, want it to wrap the provider error", err)
+	}
+}
+
+func TestChainDetectCanceled(t *testing.T) {
+	p := &stubProvider{name: "never", id: "x"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := (Chain{p}).Detect(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Detect error = %v, want context.Canceled", err)
+	}
+	if p.calls != 0 {
+		t.Errorf("provider called %d times after cancel, want 0", p.calls)
+	}
+}
+
+func TestCpusetProvider(t *testing.T) {
+	want := strings.Repeat("a", 64)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr error
+	}{
+		{"docker cgroupfs", "/docker/" + want + "\n", want, nil},
+		{"root", "/\n", "", ErrContainerIDNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CpusetProvider(fstest.MapFS{"proc/self/cpuset": {Data: []byte(tt.content)}})
+			got, err := p.Detect(context.Background())
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Detect = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	p := EnvProvider("TEST_CONTAINER_ID")
+	if p.Name() != "env:TEST_CONTAINER_ID" {
+		t.Errorf("Name = %q, want env:TEST_CONTAINER_ID", p.Name())
+	}
+
+	t.Setenv("TEST_CONTAINER_ID", "")
+	if _, err := p.Detect(context.Background()); !errors.Is(err, ErrContainerIDNotFound) {
+		t.Errorf("Detect with unset variable error = %v, want ErrContainerIDNotFound", err)
+	}
+
+	t.Setenv("TEST_CONTAINER_ID", "abc123\n")
+	if got, err := p.Detect(context.Background()); err != nil || got != "abc123" {
+		t.Errorf("Detect = %q, %v, want abc123", got, err)
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	origRegistered := registered
+	defer func() { registered = origRegistered }()
+
+	getFunc = func() (string, error) { return "", ErrContainerIDNotFound }
+	custom := &stubProvider{name: "custom", id: "from-custom"}
+	RegisterProvider(custom)
+
+	chain := DefaultChain()
+	if len(chain) != 7 || chain[0].Name() != "cpuset" || chain[1].Name() != "mountinfo" || chain[2].Name() != "cgroup" || chain[3].Name() != "podman" || chain[4].Name() != "ecs" || chain[5].Name() != "docker-desktop" || chain[6] != Provider(custom) {
+		t.Fatalf("DefaultChain = %v, want cpuset, mountinfo, cgroup, podman, ecs, docker-desktop, custom", chain)
+	}
+
+	got, err := Get()
+	if err != nil || got != "from-custom" {
+		t.Fatalf("Get = %q, %v, want from-custom", got, err)
+	}
+}
+
+func TestGetWithChain(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	getFunc = func() (string, error) { return "from-mountinfo", nil }
+	t.Setenv("TEST_CONTAINER_ID", "from-env")
+
+	got, err := Get(WithChain(Chain{EnvProvider("TEST_CONTAINER_ID"), MountInfoProvider(nil)}))
+	if err != nil || got != "from-env" {
+		t.Fatalf("Get(WithChain) = %q, %v, want from-env", got, err)
+	}
+
+	// A custom chain does not populate the cache used by Get.
+	if got, _ := Get(); got != "from-mountinfo" {
+		t.Errorf("Get after WithChain = %q, want from-mountinfo", got)
+	}
+}
+
+func TestGetWithChainAndFS(t *testing.T) {
+	want := strings.Repeat("b", 64)
+	fsys := fstest.MapFS{"proc/self/cpuset": {Data: []byte("/docker/" + want + "\n")}}
+
+	got, err := Get(WithFS(fsys), WithChain(Chain{MountInfoProvider(nil), CpusetProvider(nil)}))
+	if err != nil || got != want {
+		t.Fatalf("Get(WithFS, WithChain) = %q, %v, want %q from the cpuset in fsys", got, err, want)
+	}
+}
@@ -0,0 +1,182 @@
+package containerid
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// DockerEnvPath is the marker file Docker creates in every container.
+const DockerEnvPath = "/.dockerenv"
+
+// ErrRuntimeNotDetected is returned by Runtime when no container runtime
+// could be identified, for example outside of a container or when the
+// cgroup namespace and mounts reveal nothing about the runtime.
+var ErrRuntimeNotDetected = errors.New("container runtime not detected")
+
+// RuntimeKind is a container runtime.
+type RuntimeKind int
+
+// The container runtimes Runtime can identify.
+const (
+	RuntimeUnknown RuntimeKind = iota
+	RuntimeDocker
+	RuntimeContainerd
+	RuntimeCRIO
+	RuntimePodman
+	RuntimeGarden
+)
+
+var runtimeNames = [...]string{
+	RuntimeUnknown:    "unknown",
+	RuntimeDocker:     "docker",
+	RuntimeContainerd: "containerd",
+	RuntimeCRIO:       "cri-o",
+	RuntimePodman:     "podman",
+	RuntimeGarden:     "garden",
+}
+
+// String returns the lowercase name of the runtime, e.g. "cri-o".
+func (k RuntimeKind) String() string {
+	if k < 0 || int(k) >= len(runtimeNames) {
+		return fmt.Sprintf("RuntimeKind(%d)", int(k))
+	}
+	return runtimeNames[k]
+}
+
+// MarshalText encodes the runtime as its name, so it reads naturally in JSON.
+func (k RuntimeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+var (
+	// cgroupRuntimes maps cgroup path fragments to a runtime, most specific
+	// first: cri-containerd must win over docker for Kubernetes on
+	// containerd, and crio- also covers CRI-O's conmon scopes.
+	cgroupRuntimes = []runtimeFragment{
+		{"/crio-", RuntimeCRIO},
+		{"cri-containerd", RuntimeContainerd},
+		{"/libpod-", RuntimePodman},
+		{"/libpod_parent/", RuntimePodman},
+		{"/garden/", RuntimeGarden},
+		{"docker", RuntimeDocker},
+		{"containerd", RuntimeContainerd},
+	}
+
+	// mountRuntimes maps fragments of mountinfo lines to a runtime. Podman
+	// and CRI-O share the containers/storage layout, so overlay-containers
+	// is resolved with the container environment file instead.
+	mountRuntimes = []runtimeFragment{
+		{"/docker/containers/", RuntimeDocker},
+		{"/io.containerd.", RuntimeContainerd},
+		{"/containerd/", RuntimeContainerd},
+		{"/garden/", RuntimeGarden},
+	}
+
+	// dockerEnvName is DockerEnvPath as an fs.FS path.
+	dockerEnvName = strings.TrimPrefix(DockerEnvPath, "/")
+)
+
+// Runtime identifies the container runtime the process runs under. It
+// checks, in order, the runtime names in /proc/self/cgroup, the container
+// environment file podman and CRI-O bind-mount at /run/.containerenv, the
+// /.dockerenv marker, and the host paths of the bind mounts in
+// /proc/self/mountinfo. A /run/.containerenv is attributed to CRI-O when the
+// mounts show a kubelet pod directory and to podman otherwise.
+//
+// Only WithFS is honoured; the result is not cached. It returns
+// RuntimeUnknown and ErrRuntimeNotDetected if no source identifies a runtime.
+func Runtime(opts ...Option) (RuntimeKind, error) {
+	o := newOptions(opts)
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	return detectRuntime(fsys)
+}
+
+func detectRuntime(fsys fs.FS) (RuntimeKind, error) {
+	kind := RuntimeUnknown
+	err := scanLines(fsys, cgroupName, func(line string) bool {
+		kind = matchRuntime(line, cgroupRuntimes)
+		return kind != RuntimeUnknown
+	})
+	if kind != RuntimeUnknown || err != nil {
+		return kind, err
+	}
+
+	// The whole file is scanned: a kubelet pod mount may follow the first
+	// runtime match.
+	var kubelet, overlay bool
+	err = scanLines(fsys, mountInfoName, func(line string) bool {
+		kubelet = kubelet || strings.Contains(line, "/kubelet/pods/")
+		overlay = overlay || strings.Contains(line, "/overlay-containers/")
+		if kind == RuntimeUnknown {
+			kind = matchRuntime(line, mountRuntimes)
+		}
+		return false
+	})
+	if err != nil {
+		return RuntimeUnknown, err
+	}
+
+	if _, err := fs.Stat(fsys, containerEnvName); err == nil {
+		if kubelet {
+			return RuntimeCRIO, nil
+		}
+		return RuntimePodman, nil
+	}
+	if _, err := fs.Stat(fsys, dockerEnvName); err == nil {
+		return RuntimeDocker, nil
+	}
+	if kind != RuntimeUnknown {
+		return kind, nil
+	}
+	if overlay {
+		return RuntimePodman, nil
+	}
+	return RuntimeUnknown, ErrRuntimeNotDetected
+}
+
+// runtimeFragment maps a path fragment to the runtime it identifies.
+type runtimeFragment struct {
+	fragment string
+	kind     RuntimeKind
+}
+
+// matchRuntime returns the runtime of the first fragment line contains.
+func matchRuntime(line string, fragments []runtimeFragment) RuntimeKind {
+	for _, f := range fragments {
+		if strings.Contains(line, f.fragment) {
+			return f.kind
+		}
+	}
+	return RuntimeUnknown
+}
+
+// scanLines calls fn for each line of the file name in fsys until it returns
+// true. A missing file has no lines.
+func scanLines(fsys fs.FS, name string, fn func(line string) bool) error {
+	file, err := fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open /%s: %w", name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if fn(scanner.Text()) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading /%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package containerid
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRuntime(t *testing.T) {
+	id := strings.Repeat("a", 64)
+	mount := func(root, target string) string {
+		return "600 500 8:1 " + root + " " + target + " rw,relatime - ext4 /dev/sda1 rw\n"
+	}
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  RuntimeKind
+	}{
+		{"docker cgroup v1", map[string]string{"proc/self/cgroup": "12:memory:/docker/" + id + "\n"}, RuntimeDocker},
+		{"docker systemd", map[string]string{"proc/self/cgroup": "0::/system.slice/docker-" + id + ".scope\n"}, RuntimeDocker},
+		{"kubernetes containerd", map[string]string{"proc/self/cgroup": "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod1.slice/cri-containerd-" + id + ".scope\n"}, RuntimeContainerd},
+		{"kubernetes cri-o", map[string]string{"proc/self/cgroup": "0::/kubepods.slice/kubepods-pod1.slice/crio-" + id + ".scope/container\n"}, RuntimeCRIO},
+		{"podman cgroup", map[string]string{"proc/self/cgroup": "0::/machine.slice/libpod-" + id + ".scope/container\n"}, RuntimePodman},
+		{"garden cgroup", map[string]string{"proc/self/cgroup": "4:memory:/garden/5c7f4b2e-1d6a-4f1e-6b9d-0c2a\n"}, RuntimeGarden},
+		{"dockerenv marker", map[string]string{"proc/self/cgroup": "0::/\n", ".dockerenv": ""}, RuntimeDocker},
+		{"containerenv marker", map[string]string{"proc/self/cgroup": "0::/\n", "run/.containerenv": "engine=\"podman-4.9.3\"\n"}, RuntimePodman},
+		{"containerenv in kubelet pod", map[string]string{
+			"proc/self/cgroup":    "0::/\n",
+			"run/.containerenv":   "",
+			"proc/self/mountinfo": mount("/var/lib/kubelet/pods/1/etc-hosts", "/etc/hosts"),
+		}, RuntimeCRIO},
+		{"docker mounts", map[string]string{"proc/self/mountinfo": mount("/var/lib/docker/containers/"+id+"/hostname", "/etc/hostname")}, RuntimeDocker},
+		{"containerd mounts", map[string]string{"proc/self/mountinfo": mount("/var/lib/containerd/io.containerd.grpc.v1.cri/sandboxes/"+id+"/hostname", "/etc/hostname")}, RuntimeContainerd},
+		{"garden mounts", map[string]string{"proc/self/mountinfo": mount("/var/vcap/data/garden/depot/1/hosts", "/etc/hosts")}, RuntimeGarden},
+		{"containers storage mounts", map[string]string{"proc/self/mountinfo": mount("/containers/storage/overlay-containers/"+id+"/userdata/hostname", "/etc/hostname")}, RuntimePodman},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for name, data := range tt.files {
+				fsys[name] = &fstest.MapFile{Data: []byte(data)}
+			}
+			got, err := Runtime(WithFS(fsys))
+			if err != nil || got != tt.want {
+				t.Errorf("Runtime() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntime_NotDetected(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/self/cgroup":    {Data: []byte("0::/user.slice/user-1000.slice/session-1.scope\n")},
+		"proc/self/mountinfo": {Data: []byte("22 1 8:1 / / rw,relatime - ext4 /dev/sda1 rw\n")},
+	}
+	got, err := Runtime(WithFS(fsys))
+	if got != RuntimeUnknown || !errors.Is(err, ErrRuntimeNotDetected) {
+		t.Errorf("Runtime() = %v, %v, want %v, %v", got, err, RuntimeUnknown, ErrRuntimeNotDetected)
+	}
+}
+
+func TestRuntimeKind_String(t *testing.T) {
+	if got := RuntimeCRIO.String(); got != "cri-o" {
+		t.Errorf("RuntimeCRIO.String() = %q, want %q", got, "cri-o")
+	}
+	if got := RuntimeKind(42).String(); got != "RuntimeKind(42)" {
+		t.Errorf("RuntimeKind(42).String() = %q", got)
+	}
+	b, err := json.Marshal(map[string]RuntimeKind{"runtime": RuntimeGarden})
+	if err != nil || string(b) != `{"runtime":"garden"}` {
+		t.Errorf("json.Marshal() = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,51 @@
+package containerid
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+var (
+	// reDigestContext matches the end of the text before a 64-character hex
+	// string that makes it an image, layer or content digest rather than a
+	// container ID: sha256:<hex>, .../sha256/<hex>, sha256-<hex>, and the layer
+	// directories of Docker's overlay2 driver and of containers/storage.
+	reDigestContext = regexp.MustCompile(`(?:sha256[:/-]|/overlay2/|/overlay/|/overlay-layers/|/overlay-images/)$`)
+
+	// reSandboxContext matches the end of the text before the ID of a
+	// containerd CRI pod sandbox, which holds the pod's pause container and
+	// the hostname and resolv.conf shared by every container of the pod, so
+	// it never names the container the process runs in.
+	reSandboxContext = regexp.MustCompile(`/sandboxes/$`)
+
+	// reContainerContext matches the end of the text before a container ID
+	// in the directories and cgroups that runtimes create per container:
+	// Docker's and nerdctl's containers/[<namespace>/] and etchosts/<namespace>/,
+	// containers/storage's overlay-containers/, the cgroupfs paths /docker/, /ecs/<task>/ and /kubepods/.../pod<uid>/,
+	// and the systemd scopes of Docker, containerd, CRI-O, podman and nerdctl.
+	reContainerContext = regexp.MustCompile(`(?:/containers/(?:[^/]+/)?|/overlay-containers/|/etchosts/[^/]+/|/docker/|/ecs/[^/]+/|/kubepods(?:/[^/]+)?/pod[^/]+/|docker-|cri-containerd-|crio-|libpod-|nerdctl-)$`)
+
+	// strict is set by SetStrict.
+	strict atomic.Bool
+)
+
+// SetStrict makes Get and the Extract functions accept a 64-character hex
+// string as a container ID only where a known runtime puts one: under
+// /containers/ or /overlay-containers/, in a /docker/, /ecs/ or
+// /kubepods/ cgroup, or in a docker-, cri-containerd-, crio-, libpod- or
+// nerdctl- scope. Without it, only strings known to be image, layer or
+// content digests are rejected, so IDs from unknown runtimes are still
+// found. A container ID that was already cached is not validated again; call
+// Refresh after changing the mode.
+func SetStrict(enabled bool) {
+	strict.Store(enabled)
+}
+
+// validContext reports whether a 64-character hex string that follows
+// prefix may be a container ID.
+func validContext(prefix string) bool {
+	if reDigestContext.MatchString(prefix) || reSandboxContext.MatchString(prefix) {
+		return false
+	}
+	return !strict.Load() || reContainerContext.MatchString(prefix)
+}
@@ -0,0 +1,83 @@
+package containerid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractRejectsDigests(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	digest := strings.Repeat("cd", 32)
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"layerdb", "/var/lib/docker/image/overlay2/layerdb/sha256/" + digest + "/hosts", ""},
+		{"image reference", `pulled image@sha256:` + digest + `/resolv.conf`, ""},
+		{"overlay2 layer", "/var/lib/docker/overlay2/" + digest + "/hostname", ""},
+		{"containers/storage layer", "/var/lib/containers/storage/overlay/" + digest + "/hosts", ""},
+		{"containerd pod sandbox", "/var/lib/containerd/io.containerd.grpc.v1.cri/sandboxes/" + digest + "/resolv.conf", ""},
+		{"digest before container", "/sha256/" + digest + "/hosts /var/lib/docker/containers/" + id + "/hosts", id},
+		{"container", "/var/lib/docker/containers/" + id + "/hostname", id},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractContainerID(tt.line)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("ExtractContainerID(%q) = %q, %v, want %q", tt.line, got, ok, tt.want)
+			}
+		})
+	}
+
+	if got, ok := ExtractContainerIDFromCgroup("/system.slice/sha256-" + digest); ok {
+		t.Errorf("ExtractContainerIDFromCgroup of a digest = %q, want none", got)
+	}
+}
+
+func TestSetStrict(t *testing.T) {
+	defer SetStrict(false)
+
+	id := strings.Repeat("ab", 32)
+	mounts := []struct {
+		line   string
+		strict bool
+	}{
+		{"/var/lib/docker/containers/" + id + "/hostname", true},
+		{"/var/lib/nerdctl/1935db59/containers/default/" + id + "/hostname", true},
+		{"/var/lib/nerdctl/1935db59/etchosts/default/" + id + "/hosts", true},
+		{"/var/lib/custom-runtime/" + id + "/hosts", false},
+		{"/home/dev/builds/" + id + "/hostname", false},
+	}
+	cgroups := []struct {
+		path   string
+		strict bool
+	}{
+		{"/docker/" + id, true},
+		{"/system.slice/docker-" + id + ".scope", true},
+		{"/kubepods/besteffort/pod036da4f7-d553-4eb6-9802-90f81041a412/" + id, true},
+		{"/kubepods/pod036da4f7-d553-4eb6-9802-90f81041a412/" + id, true},
+		{"/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + id + ".scope", true},
+		{"/machine.slice/libpod-" + id + ".scope/container", true},
+		{"/system.slice/nerdctl-" + id + ".scope", true},
+		{"/ecs/f1a9e839391d222b03c675b0a8cce7fa/" + id, true},
+		{"/user.slice/build-" + id + ".scope", false},
+		{"/" + id, false},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		SetStrict(enabled)
+		for _, tt := range mounts {
+			want := !enabled || tt.strict
+			if _, ok := ExtractContainerID(tt.line); ok != want {
+				t.Errorf("strict=%v: ExtractContainerID(%q) ok = %v, want %v", enabled, tt.line, ok, want)
+			}
+		}
+		for _, tt := range cgroups {
+			want := !enabled || tt.strict
+			if _, ok := ExtractContainerIDFromCgroup(tt.path); ok != want {
+				t.Errorf("strict=%v: ExtractContainerIDFromCgroup(%q) ok = %v, want %v", enabled, tt.path, ok, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package collector exposes detectmetrics recorders as a Prometheus client
+// library Collector, for applications that serve their metrics from a
+// prometheus.Registry.
+//
+// The metric families and labels match detectmetrics.WritePrometheus.
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ming-go/lab/get-container-id/detectmetrics"
+)
+
+var (
+	attemptsDesc = prometheus.NewDesc("gcid_detection_attempts_total",
+		"Detection attempts that ran a provider.", []string{"detector"}, nil)
+	successesDesc = prometheus.NewDesc("gcid_detection_successes_total",
+		"Detection attempts that found an ID.", []string{"detector"}, nil)
+	failuresDesc = prometheus.NewDesc("gcid_detection_failures_total",
+		"Detection attempts that failed, by reason.", []string{"detector", "reason"}, nil)
+	cacheHitsDesc = prometheus.NewDesc("gcid_detection_cache_hits_total",
+		"Lookups served from the cache.", []string{"detector"}, nil)
+	cacheMissesDesc = prometheus.NewDesc("gcid_detection_cache_misses_total",
+		"Lookups that had to run detection.", []string{"detector"}, nil)
+	durationDesc = prometheus.NewDesc("gcid_detection_duration_seconds",
+		"Time spent running detection providers.", []string{"detector"}, nil)
+)
+
+// Collector reports the metrics of one or more recorders, labelled with
+// their detector names.
+type Collector struct {
+	recorders []*detectmetrics.Recorder
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// New returns a Collector for recorders, usually containerid.Metrics() and
+// podid.Metrics().
+func New(recorders ...*detectmetrics.Recorder) *Collector {
+	return &Collector{recorders: recorders}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{attemptsDesc, successesDesc, failuresDesc, cacheHitsDesc, cacheMissesDesc, durationDesc} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector. Each recorder is read once, so
+// the metrics of one detector are consistent with each other.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, r := range c.recorders {
+		s := r.Snapshot()
+		name := r.Name()
+
+		ch <- prometheus.MustNewConstMetric(attemptsDesc, prometheus.CounterValue, float64(s.Attempts), name)
+		ch <- prometheus.MustNewConstMetric(successesDesc, prometheus.CounterValue, float64(s.Successes), name)
+		for reason, n := range s.Failures {
+			ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.CounterValue, float64(n), name, reason)
+		}
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(s.CacheHits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(s.CacheMisses), name)
+
+		buckets := make(map[float64]uint64, len(detectmetrics.DurationBuckets))
+		for i, le := range detectmetrics.DurationBuckets {
+			buckets[le] = s.DurationBuckets[i]
+		}
+		ch <- prometheus.MustNewConstHistogram(durationDesc, s.Attempts, s.DurationSum, buckets, name)
+	}
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ming-go/lab/get-container-id/detectmetrics"
+)
+
+// Test the collector reports the same families as WritePrometheus
+func TestCollector(t *testing.T) {
+	a, b := detectmetrics.New("containerid"), detectmetrics.New("podid")
+	a.CacheHit()
+	a.Success(time.Millisecond)
+	b.CacheMiss()
+	b.Failure("source_missing", time.Millisecond)
+
+	want := `
+# HELP gcid_detection_attempts_total Detection attempts that ran a provider.
+# TYPE gcid_detection_attempts_total counter
+gcid_detection_attempts_total{detector="containerid"} 1
+gcid_detection_attempts_total{detector="podid"} 1
+# HELP gcid_detection_cache_misses_total Lookups that had to run detection.
+# TYPE gcid_detection_cache_misses_total counter
+gcid_detection_cache_misses_total{detector="containerid"} 0
+gcid_detection_cache_misses_total{detector="podid"} 1
+# HELP gcid_detection_failures_total Detection attempts that failed, by reason.
+# TYPE gcid_detection_failures_total counter
+gcid_detection_failures_total{detector="podid",reason="source_missing"} 1
+`
+	err := testutil.CollectAndCompare(New(a, b), strings.NewReader(want),
+		"gcid_detection_attempts_total", "gcid_detection_cache_misses_total", "gcid_detection_failures_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// Test the duration histogram uses the detectmetrics buckets
+func TestCollector_Duration(t *testing.T) {
+	r := detectmetrics.New("containerid")
+	r.Success(2 * time.Millisecond)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(New(r))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != "gcid_detection_duration_seconds" {
+			continue
+		}
+		h := f.GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 1 || len(h.GetBucket()) != len(detectmetrics.DurationBuckets) {
+			t.Errorf("histogram count/buckets = %d/%d, want 1/%d", h.GetSampleCount(), len(h.GetBucket()), len(detectmetrics.DurationBuckets))
+		}
+		return
+	}
+	t.Error("gcid_detection_duration_seconds not gathered")
+}
@@ -0,0 +1,198 @@
+// Package detectmetrics records detection attempts, outcomes, cache use and
+// latency for the detector packages, and exposes them in the Prometheus text
+// exposition format.
+//
+// Host applications can serve WritePrometheus output directly, or register
+// the Collector of the nested detectmetrics/collector module with their own
+// prometheus.Registry.
+package detectmetrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the detection
+// duration histogram.
+var DurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// Recorder accumulates detection metrics for one detector. It is safe for
+// concurrent use.
+type Recorder struct {
+	name string
+
+	mu          sync.Mutex
+	attempts    uint64
+	successes   uint64
+	failures    map[string]uint64
+	cacheHits   uint64
+	cacheMisses uint64
+	buckets     []uint64
+	durationSum float64
+}
+
+// New returns a Recorder whose metrics are labelled detector=name.
+func New(name string) *Recorder {
+	return &Recorder{
+		name:     name,
+		failures: make(map[string]uint64),
+		buckets:  make([]uint64, len(DurationBuckets)),
+	}
+}
+
+// Name returns the detector name the Recorder was created with.
+func (r *Recorder) Name() string {
+	return r.name
+}
+
+// CacheHit records a lookup served from the cache.
+func (r *Recorder) CacheHit() {
+	r.mu.Lock()
+	r.cacheHits++
+	r.mu.Unlock()
+}
+
+// CacheMiss records a lookup that had to run detection.
+func (r *Recorder) CacheMiss() {
+	r.mu.Lock()
+	r.cacheMisses++
+	r.mu.Unlock()
+}
+
+// Success records a detection attempt that found an ID.
+func (r *Recorder) Success(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	r.successes++
+	r.observe(d)
+}
+
+// Failure records a detection attempt that failed for reason.
+func (r *Recorder) Failure(reason string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	r.failures[reason]++
+	r.observe(d)
+}
+
+func (r *Recorder) observe(d time.Duration) {
+	secs := d.Seconds()
+	r.durationSum += secs
+	for i, le := range DurationBuckets {
+		if secs <= le {
+			r.buckets[i]++
+		}
+	}
+}
+
+// Snapshot is a point-in-time copy of a Recorder.
+type Snapshot struct {
+	Attempts    uint64
+	Successes   uint64
+	Failures    map[string]uint64
+	CacheHits   uint64
+	CacheMisses uint64
+	// DurationBuckets holds cumulative counts for each DurationBuckets bound.
+	DurationBuckets []uint64
+	DurationSum     float64
+}
+
+// Snapshot returns a copy of the current values.
+func (r *Recorder) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures := make(map[string]uint64, len(r.failures))
+	for k, v := range r.failures {
+		failures[k] = v
+	}
+	return Snapshot{
+		Attempts:        r.attempts,
+		Successes:       r.successes,
+		Failures:        failures,
+		CacheHits:       r.cacheHits,
+		CacheMisses:     r.cacheMisses,
+		DurationBuckets: append([]uint64(nil), r.buckets...),
+		DurationSum:     r.durationSum,
+	}
+}
+
+// WritePrometheus writes the metrics of every recorder in the Prometheus
+// text exposition format, one family per metric with a detector label.
+func WritePrometheus(w io.Writer, recorders ...*Recorder) error {
+	snaps := make([]Snapshot, len(recorders))
+	for i, r := range recorders {
+		snaps[i] = r.Snapshot()
+	}
+
+	ew := &errWriter{w: w}
+
+	ew.printf("# HELP gcid_detection_attempts_total Detection attempts that ran a provider.\n")
+	ew.printf("# TYPE gcid_detection_attempts_total counter\n")
+	for i, r := range recorders {
+		ew.printf("gcid_detection_attempts_total{detector=%q} %d\n", r.name, snaps[i].Attempts)
+	}
+
+	ew.printf("# HELP gcid_detection_successes_total Detection attempts that found an ID.\n")
+	ew.printf("# TYPE gcid_detection_successes_total counter\n")
+	for i, r := range recorders {
+		ew.printf("gcid_detection_successes_total{detector=%q} %d\n", r.name, snaps[i].Successes)
+	}
+
+	ew.printf("# HELP gcid_detection_failures_total Detection attempts that failed, by reason.\n")
+	ew.printf("# TYPE gcid_detection_failures_total counter\n")
+	for i, r := range recorders {
+		reasons := make([]string, 0, len(snaps[i].Failures))
+		for reason := range snaps[i].Failures {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			ew.printf("gcid_detection_failures_total{detector=%q,reason=%q} %d\n", r.name, reason, snaps[i].Failures[reason])
+		}
+	}
+
+	ew.printf("# HELP gcid_detection_cache_hits_total Lookups served from the cache.\n")
+	ew.printf("# TYPE gcid_detection_cache_hits_total counter\n")
+	for i, r := range recorders {
+		ew.printf("gcid_detection_cache_hits_total{detector=%q} %d\n", r.name, snaps[i].CacheHits)
+	}
+
+	ew.printf("# HELP gcid_detection_cache_misses_total Lookups that had to run detection.\n")
+	ew.printf("# TYPE gcid_detection_cache_misses_total counter\n")
+	for i, r := range recorders {
+		ew.printf("gcid_detection_cache_misses_total{detector=%q} %d\n", r.name, snaps[i].CacheMisses)
+	}
+
+	ew.printf("# HELP gcid_detection_duration_seconds Time spent running detection providers.\n")
+	ew.printf("# TYPE gcid_detection_duration_seconds histogram\n")
+	for i, r := range recorders {
+		s := snaps[i]
+		for j, le := range DurationBuckets {
+			ew.printf("gcid_detection_duration_seconds_bucket{detector=%q,le=\"%g\"} %d\n", r.name, le, s.DurationBuckets[j])
+		}
+		ew.printf("gcid_detection_duration_seconds_bucket{detector=%q,le=\"+Inf\"} %d\n", r.name, s.Attempts)
+		ew.printf("gcid_detection_duration_seconds_sum{detector=%q} %g\n", r.name, s.DurationSum)
+		ew.printf("gcid_detection_duration_seconds_count{detector=%q} %d\n", r.name, s.Attempts)
+	}
+
+	return ew.err
+}
+
+// errWriter keeps the first write error so callers check it once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,83 @@
+package detectmetrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test Snapshot reflects recorded events
+func TestRecorder_Snapshot(t *testing.T) {
+	r := New("test")
+	r.CacheMiss()
+	r.Failure("not_found", 2*time.Millisecond)
+	r.CacheMiss()
+	r.Success(50 * time.Microsecond)
+	r.CacheHit()
+
+	s := r.Snapshot()
+	if s.Attempts != 2 || s.Successes != 1 || s.Failures["not_found"] != 1 {
+		t.Errorf("attempts/successes/failures = %d/%d/%v, want 2/1/map[not_found:1]", s.Attempts, s.Successes, s.Failures)
+	}
+	if s.CacheHits != 1 || s.CacheMisses != 2 {
+		t.Errorf("cache hits/misses = %d/%d, want 1/2", s.CacheHits, s.CacheMisses)
+	}
+
+	// 50µs falls in every bucket, 2ms only from the 0.005 bound up.
+	want := []uint64{1, 1, 1, 2, 2, 2, 2, 2, 2}
+	for i, n := range want {
+		if s.DurationBuckets[i] != n {
+			t.Errorf("bucket le=%g = %d, want %d", DurationBuckets[i], s.DurationBuckets[i], n)
+		}
+	}
+
+	// The snapshot is a copy.
+	s.Failures["not_found"] = 99
+	if r.Snapshot().Failures["not_found"] != 1 {
+		t.Error("modifying a Snapshot changed the Recorder")
+	}
+}
+
+// Test WritePrometheus emits every family with detector labels
+func TestWritePrometheus(t *testing.T) {
+	a, b := New("containerid"), New("podid")
+	a.CacheHit()
+	a.Success(time.Millisecond)
+	b.Failure("source_missing", time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, a, b); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	out := buf.String()
+
+	for _, line := range []string{
+		"# TYPE gcid_detection_attempts_total counter",
+		`gcid_detection_attempts_total{detector="containerid"} 1`,
+		`gcid_detection_successes_total{detector="containerid"} 1`,
+		`gcid_detection_failures_total{detector="podid",reason="source_missing"} 1`,
+		`gcid_detection_cache_hits_total{detector="containerid"} 1`,
+		`gcid_detection_cache_misses_total{detector="podid"} 0`,
+		"# TYPE gcid_detection_duration_seconds histogram",
+		`gcid_detection_duration_seconds_bucket{detector="podid",le="0.001"} 1`,
+		`gcid_detection_duration_seconds_bucket{detector="podid",le="+Inf"} 1`,
+		`gcid_detection_duration_seconds_count{detector="containerid"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output missing %q:\n%s", line, out)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+// Test WritePrometheus reports write errors
+func TestWritePrometheus_WriteError(t *testing.T) {
+	if err := WritePrometheus(failingWriter{}, New("x")); err == nil {
+		t.Error("WritePrometheus() error = nil, want write error")
+	}
+}
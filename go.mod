@@ -1,3 +1,3 @@
 module github.com/ming-go/lab/get-container-id
 
-go 1.22
+go 1.24
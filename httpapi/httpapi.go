@@ -0,0 +1,181 @@
+// Package httpapi implements the JSON response contract of the
+// get-container-id HTTP server, so that endpoints built outside this
+// repository answer the same way.
+//
+// Successful responses are wrapped as {"data": ...} and failures as
+// {"errors": {"message": ..., "code": ...}}. Handlers can write them directly
+// with WriteSuccess and WriteError, or return a value and an error from a
+// HandlerFunc and let it pick the envelope and status code.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ContentType is the Content-Type of every response written by this package.
+const ContentType = "application/json"
+
+// Code is a stable, machine-readable error code. Clients should branch on it
+// rather than on the message.
+type Code string
+
+// Error codes for the status codes the server returns.
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeConflict         Code = "conflict"
+	CodeTooLarge         Code = "too_large"
+	CodeTooManyRequests  Code = "too_many_requests"
+	CodeInternal         Code = "internal"
+	CodeNotImplemented   Code = "not_implemented"
+	CodeBadGateway       Code = "bad_gateway"
+	CodeUnavailable      Code = "unavailable"
+	CodeGatewayTimeout   Code = "gateway_timeout"
+	CodeUnprocessable    Code = "unprocessable"
+)
+
+// CodeForStatus returns the default error code for an HTTP status code.
+// Statuses without a dedicated code map to CodeBadRequest for 4xx and
+// CodeInternal otherwise.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeBadGateway
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeGatewayTimeout
+	}
+	if status >= 400 && status < 500 {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
+
+// Response is the envelope of a successful response.
+type Response struct {
+	Data any `json:"data"`
+}
+
+// ErrorResponse is the envelope of a failed response.
+type ErrorResponse struct {
+	Errors ErrorBody `json:"errors"`
+}
+
+// ErrorBody describes why a request failed.
+type ErrorBody struct {
+	Message string `json:"message"`
+	Code    Code   `json:"code,omitempty"`
+}
+
+// WriteJSON marshals data to JSON and writes it to the response with the
+// given status code. If marshaling fails, it writes an HTTP 500 error instead.
+func WriteJSON(w http.ResponseWriter, data any, status int) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// WriteSuccess wraps data in a Response and writes it with HTTP 200.
+func WriteSuccess(w http.ResponseWriter, data any) {
+	WriteJSON(w, Response{Data: data}, http.StatusOK)
+}
+
+// WriteError wraps message in an ErrorResponse with the default code for
+// status and writes it with that status.
+func WriteError(w http.ResponseWriter, message string, status int) {
+	WriteJSON(w, ErrorResponse{Errors: ErrorBody{Message: message, Code: CodeForStatus(status)}}, status)
+}
+
+// Error is an error that carries the HTTP status and code it should be
+// reported with. Return it from a HandlerFunc; any other error is reported as
+// HTTP 500.
+type Error struct {
+	Status int
+	// Code defaults to CodeForStatus(Status) when empty.
+	Code Code
+	Err  error
+}
+
+// NewError returns an Error reporting err with status.
+func NewError(status int, err error) *Error {
+	return &Error{Status: status, Err: err}
+}
+
+// Errorf returns an Error reporting a formatted message with status. The %w
+// verb wraps errors as in fmt.Errorf.
+func Errorf(status int, format string, args ...any) *Error {
+	return &Error{Status: status, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WriteErr writes err as an ErrorResponse. Errors wrapping an *Error use its
+// status and code; all others are reported as HTTP 500 with err's message.
+func WriteErr(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		WriteError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	code := apiErr.Code
+	if code == "" {
+		code = CodeForStatus(apiErr.Status)
+	}
+	WriteJSON(w, ErrorResponse{Errors: ErrorBody{Message: err.Error(), Code: code}}, apiErr.Status)
+}
+
+// HandlerFunc is an http.Handler that returns its result instead of writing
+// it. A nil error writes the value as a Response with HTTP 200; a non-nil
+// error is written as by WriteErr. A handler that has already written the
+// response itself returns (nil, nil) after writing.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) (any, error)
+
+// ServeHTTP calls f and writes its result.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := f(w, r)
+	switch {
+	case err != nil:
+		WriteErr(w, err)
+	case data != nil:
+		WriteSuccess(w, data)
+	}
+}
@@ -0,0 +1,236 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test WriteSuccess
+func TestWriteSuccess(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+		want string
+	}{
+		{
+			name: "string data",
+			data: "hello",
+			want: `{"data":"hello"}`,
+		},
+		{
+			name: "number data",
+			data: 42,
+			want: `{"data":42}`,
+		},
+		{
+			name: "map data",
+			data: map[string]string{"key": "value"},
+			want: `{"data":{"key":"value"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteSuccess(w, tt.data)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("WriteSuccess() status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			contentType := w.Header().Get("Content-Type")
+			if contentType != "application/json" {
+				t.Errorf("WriteSuccess() Content-Type = %q, want %q", contentType, "application/json")
+			}
+
+			got := strings.TrimSpace(w.Body.String())
+			if got != tt.want {
+				t.Errorf("WriteSuccess() body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test WriteError
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		statusCode int
+	}{
+		{
+			name:       "not found error",
+			message:    "resource not found",
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "internal error",
+			message:    "something went wrong",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "bad request",
+			message:    "invalid input",
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, tt.message, tt.statusCode)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("WriteError() status = %d, want %d", w.Code, tt.statusCode)
+			}
+
+			contentType := w.Header().Get("Content-Type")
+			if contentType != "application/json" {
+				t.Errorf("WriteError() Content-Type = %q, want %q", contentType, "application/json")
+			}
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("WriteError() body unmarshal error: %v", err)
+			}
+
+			if resp.Errors.Message != tt.message {
+				t.Errorf("WriteError() message = %q, want %q", resp.Errors.Message, tt.message)
+			}
+		})
+	}
+}
+
+// Test WriteJSON with invalid data (should return 500)
+func TestWriteJSON_MarshalError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	// channels cannot be marshaled to JSON
+	invalidData := make(chan int)
+	WriteJSON(w, invalidData, http.StatusOK)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("WriteJSON() with invalid data: status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "json") {
+		t.Errorf("WriteJSON() with invalid data: expected JSON error in body, got %q", body)
+	}
+}
+
+// Test WriteError sets the default code for the status
+func TestWriteError_Code(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, "gone", http.StatusNotFound)
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("WriteError() body unmarshal error: %v", err)
+	}
+	if resp.Errors.Code != CodeNotFound {
+		t.Errorf("WriteError() code = %q, want %q", resp.Errors.Code, CodeNotFound)
+	}
+}
+
+// Test CodeForStatus falls back by status class
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   Code
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusTeapot, CodeBadRequest},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusHTTPVersionNotSupported, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := CodeForStatus(tt.status); got != tt.want {
+			t.Errorf("CodeForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+// Test HandlerFunc writes the returned value or error
+func TestHandlerFunc(t *testing.T) {
+	errMissing := errors.New("missing")
+
+	tests := []struct {
+		name       string
+		handler    HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "value",
+			handler:    func(w http.ResponseWriter, r *http.Request) (any, error) { return "ok", nil },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":"ok"}`,
+		},
+		{
+			name:       "plain error",
+			handler:    func(w http.ResponseWriter, r *http.Request) (any, error) { return nil, errMissing },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"errors":{"message":"missing","code":"internal"}}`,
+		},
+		{
+			name: "wrapped api error",
+			handler: func(w http.ResponseWriter, r *http.Request) (any, error) {
+				return nil, fmt.Errorf("lookup: %w", NewError(http.StatusNotFound, errMissing))
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"errors":{"message":"lookup: missing","code":"not_found"}}`,
+		},
+		{
+			name: "custom code",
+			handler: func(w http.ResponseWriter, r *http.Request) (any, error) {
+				return nil, &Error{Status: http.StatusConflict, Code: "uid_mismatch", Err: errMissing}
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"errors":{"message":"missing","code":"uid_mismatch"}}`,
+		},
+		{
+			name: "written by handler",
+			handler: func(w http.ResponseWriter, r *http.Request) (any, error) {
+				w.WriteHeader(http.StatusAccepted)
+				return nil, nil
+			},
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("HandlerFunc status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("HandlerFunc body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+// Test Errorf wraps with %w
+func TestErrorf(t *testing.T) {
+	errMissing := errors.New("missing")
+	err := Errorf(http.StatusNotFound, "pod %s: %w", "web-0", errMissing)
+
+	if !errors.Is(err, errMissing) {
+		t.Error("Errorf() should wrap the %w argument")
+	}
+	if err.Error() != "pod web-0: missing" {
+		t.Errorf("Errorf() message = %q, want %q", err.Error(), "pod web-0: missing")
+	}
+}
@@ -0,0 +1,157 @@
+package identity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// conformanceDir holds one directory per platform, each laid out as a
+// filesystem root containing the files the detectors read.
+const conformanceDir = "../testdata/conformance"
+
+// conformanceCase is the expected identity for one platform capture. An
+// empty field means the platform does not expose it to the container.
+type conformanceCase struct {
+	platform  string
+	container string
+	pod       string
+	namespace string
+	runtime   string
+	cloud     string
+}
+
+var conformanceCases = []conformanceCase{
+	{
+		platform:  "docker-cgroupv1",
+		container: "a39798193d9dcfffe1a101b19561181af71d23f035a9e95ad2219f3ee899e285",
+		runtime:   "docker",
+	},
+	{
+		platform:  "docker-cgroupv2",
+		container: "5400841d228a911190db096a8333820b24e64d25bd15a6345a1451549b2a9229",
+		runtime:   "docker",
+	},
+	{
+		platform:  "docker-cgroupv2-hostns",
+		container: "ac47f0a67ab176bfefdc386c97ae4fd6df7f0b7bca7544d5fdc917a5eb49c187",
+		runtime:   "docker",
+	},
+	{
+		platform:  "containerd-nerdctl",
+		container: "40823ee94a5a4342cde4b34934a507a2eee049dc74c8371db9362527314d05a5",
+		runtime:   "containerd",
+	},
+	{
+		platform:  "crio-k8s",
+		container: "c64064614423cb790e88b3e5492ff5ccf673b25ae823089f7a60c65cda24d809",
+		pod:       "5b9c2a4e-7f61-4d3a-9c1e-2b8f0d7a6e13",
+		namespace: "default",
+		runtime:   "cri-o",
+	},
+	{
+		platform:  "podman-root",
+		container: "2ec0e05312fed02434ea42394a7b1f5a127927c6c04edd50fa4ef05ac645ceac",
+		runtime:   "podman",
+	},
+	{
+		platform:  "podman-rootless",
+		container: "56dfa3bc944415a972b0fe05883435f6f061150cc65600d923a24fd565932362",
+		runtime:   "podman",
+	},
+	{
+		platform:  "k3s",
+		container: "86bd33ebf64e01aaafb7dab419436ac025d04abd933bbbb124ddb25c30b59dd7",
+		pod:       "a3f4c2d1-8e7b-4c5a-9d6e-1f2a3b4c5d6e",
+		namespace: "kube-system",
+		runtime:   "containerd",
+	},
+	{
+		platform: "kind",
+		// mountinfo only has the sandbox ID and the cgroup namespace
+		// hides the container's cgroup path.
+		pod:       "0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f",
+		namespace: "default",
+		runtime:   "containerd",
+	},
+	{
+		platform: "gke",
+		// As for kind.
+		pod:       "6e5d4c3b-2a19-4807-b6f5-e4d3c2b1a098",
+		namespace: "production",
+		runtime:   "containerd",
+		cloud:     "gcp",
+	},
+	{
+		platform:  "docker-desktop",
+		container: "3f9c2b7d41e8",
+		runtime:   "docker",
+	},
+	{
+		platform:  "wsl2",
+		container: "b81d0e5a7c3f4d92a6e1c8b5f3d7a9e2c4b6d8f0a1e3c5b7d9f2a4c6e8b0d1f3",
+		runtime:   "docker",
+	},
+	{
+		platform:  "ecs",
+		container: "d940b56413af584806504ea58f1f7c0b6da2a1ea2dc6733d32117427c7c83936",
+		runtime:   "docker",
+		cloud:     "aws",
+	},
+}
+
+// Test every platform capture in testdata/conformance against its expected identity
+func TestConformance(t *testing.T) {
+	origGetenv := getenv
+	getenv = func(string) string { return "" }
+	defer func() { getenv = origGetenv }()
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("NODE_NAME", "")
+
+	for _, tc := range conformanceCases {
+		t.Run(tc.platform, func(t *testing.T) {
+			dir := filepath.Join(conformanceDir, tc.platform)
+			if _, err := os.Stat(dir); err != nil {
+				t.Fatalf("missing fixture: %v", err)
+			}
+
+			id, _ := Get(context.Background(), WithFS(os.DirFS(dir)))
+
+			fields := []struct {
+				name string
+				got  string
+				want string
+			}{
+				{"container", id.Container.Value, tc.container},
+				{"pod", id.Pod.Value, tc.pod},
+				{"namespace", id.Namespace.Value, tc.namespace},
+				{"runtime", id.Runtime.Value, tc.runtime},
+				{"cloud", id.Cloud.Value, tc.cloud},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %q, want %q", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
+
+// Test every fixture directory has a matrix entry
+func TestConformance_AllFixturesCovered(t *testing.T) {
+	entries, err := os.ReadDir(conformanceDir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+
+	covered := make(map[string]bool, len(conformanceCases))
+	for _, tc := range conformanceCases {
+		covered[tc.platform] = true
+	}
+	for _, e := range entries {
+		if e.IsDir() && !covered[e.Name()] {
+			t.Errorf("fixture %s has no conformance case", e.Name())
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package identity combines the container, pod, and host detectors into a
+// single description of where the current process is running.
+//
+// Each field records the value that was found together with the source it
+// came from, so consumers can tell an environment override from a value read
+// off the filesystem.
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/idgen"
+	"github.com/ming-go/lab/get-container-id/nodeid"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+const (
+	// SourceGenerated marks a value generated by this process.
+	SourceGenerated = "generated"
+
+	// SourceRuntime marks a runtime classified by containerid.Runtime from the
+	// cgroup paths, marker files and mounts.
+	SourceRuntime = "containerid.Runtime"
+
+	// DMIPath is the directory exposing SMBIOS vendor information.
+	DMIPath = "/sys/class/dmi/id"
+)
+
+// ErrNotDetected is returned, wrapped, for each field that no detector could
+// populate.
+var ErrNotDetected = errors.New("not detected")
+
+// Field is a detected value and where it came from.
+type Field struct {
+	Value  string `json:"value,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// Identity describes the current process and its surroundings.
+type Identity struct {
+	Instance  Field `json:"instance"`
+	Container Field `json:"container"`
+	Pod       Field `json:"pod"`
+	Namespace Field `json:"namespace"`
+	Node      Field `json:"node"`
+	Runtime   Field `json:"runtime"`
+	Cloud     Field `json:"cloud"`
+}
+
+var (
+	// Instance ID generated when INSTANCE_ID is not set
+	instanceOnce sync.Once
+	instanceID   string
+	instanceErr  error
+
+	// cloudVendors maps DMI vendor strings to a cloud provider.
+	cloudVendors = []struct{ vendor, cloud string }{
+		{"amazon", "aws"},
+		{"google", "gcp"},
+		{"microsoft corporation", "azure"},
+		{"alibaba cloud", "alibaba"},
+		{"digitalocean", "digitalocean"},
+		{"hetzner", "hetzner"},
+		{"oraclecloud", "oracle"},
+	}
+
+	getenv        = os.Getenv
+	newInstanceID = idgen.NewV7
+
+	// rootFS is the host filesystem that detectors read from by default.
+	rootFS fs.FS = os.DirFS("/")
+)
+
+// containerIDFunc and podIDFunc use the cached host lookups unless a custom
+// filesystem was supplied, and give up once ctx is done.
+var (
+	containerIDFunc = func(ctx context.Context, fsys fs.FS) (containerid.Detection, error) {
+		if fsys == nil {
+			return containerid.GetDetailed(ctx)
+		}
+		return containerid.GetDetailed(ctx, containerid.WithFS(fsys))
+	}
+	podIDFunc = func(ctx context.Context, fsys fs.FS) (string, error) {
+		if fsys == nil {
+			return podid.GetContext(ctx)
+		}
+		return podid.GetContext(ctx, podid.WithFS(fsys))
+	}
+)
+
+// namespaceFunc and nodeFunc use the podid and nodeid lookups, with their
+// caches, unless a custom filesystem was supplied.
+var (
+	namespaceFunc = func(fsys fs.FS) (string, error) {
+		if fsys == nil {
+			return podid.GetNamespace()
+		}
+		return podid.GetNamespace(podid.WithFS(fsys))
+	}
+	nodeFunc = func(fsys fs.FS) (string, error) {
+		if fsys == nil {
+			return nodeid.GetNodeName()
+		}
+		return nodeid.GetNodeName(nodeid.WithFS(fsys))
+	}
+)
+
+// Option configures a call to Get.
+type Option func(*options)
+
+type options struct {
+	fsys fs.FS
+}
+
+// WithFS makes Get read every file through fsys instead of the host root,
+// for example os.DirFS("/host") or a testing/fstest.MapFS.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// root returns the filesystem detectors read from.
+func (o options) root() fs.FS {
+	if o.fsys != nil {
+		return o.fsys
+	}
+	return rootFS
+}
+
+// fsName converts an absolute path to an fs.FS name.
+func fsName(path string) string {
+	return strings.TrimPrefix(path, "/")
+}
+
+// Get runs every detector and returns what was found.
+//
+// Get has partial-result semantics: the returned Identity always holds every
+// field that could be detected, and the error joins one entry per field that
+// could not. Callers that only need some fields can ignore the error. ctx is
+// passed to the container and pod ID lookups; once it is done, the remaining
+// detectors are skipped and ctx.Err() is included.
+func Get(ctx context.Context, opts ...Option) (Identity, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var id Identity
+	var errs []error
+
+	detectors := []struct {
+		name   string
+		field  *Field
+		detect func(context.Context, options) (Field, error)
+	}{
+		{"instance", &id.Instance, detectInstance},
+		{"container", &id.Container, detectContainer},
+		{"pod", &id.Pod, detectPod},
+		{"namespace", &id.Namespace, detectNamespace},
+		{"node", &id.Node, detectNode},
+		{"runtime", &id.Runtime, detectRuntime},
+		{"cloud", &id.Cloud, detectCloud},
+	}
+
+	for _, d := range detectors {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		f, err := d.detect(ctx, o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("identity: %s: %w", d.name, err))
+			continue
+		}
+		*d.field = f
+	}
+
+	return id, errors.Join(errs...)
+}
+
+// detectInstance returns INSTANCE_ID, or an ID generated once per process.
+func detectInstance(context.Context, options) (Field, error) {
+	if v := getenv("INSTANCE_ID"); v != "" {
+		return Field{Value: v, Source: "env:INSTANCE_ID"}, nil
+	}
+
+	instanceOnce.Do(func() {
+		instanceID, instanceErr = newInstanceID()
+	})
+	if instanceErr != nil {
+		return Field{}, instanceErr
+	}
+	return Field{Value: instanceID, Source: SourceGenerated}, nil
+}
+
+// detectContainer reports the file, or the environment variable, that the
+// containerid provider which found the ID read.
+func detectContainer(ctx context.Context, o options) (Field, error) {
+	d, err := containerIDFunc(ctx, o.fsys)
+	if err != nil {
+		return Field{}, err
+	}
+	source := d.Source
+	if strings.HasPrefix(source, "/") {
+		source = "file:" + source
+	}
+	return Field{Value: d.ID, Source: source}, nil
+}
+
+func detectPod(ctx context.Context, o options) (Field, error) {
+	id, err := podIDFunc(ctx, o.fsys)
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{Value: id, Source: "file:" + podid.MountInfoPath}, nil
+}
+
+// detectNamespace returns the namespace podid.GetNamespace finds in
+// POD_NAMESPACE, a Downward API volume or the service account namespace file.
+func detectNamespace(_ context.Context, o options) (Field, error) {
+	v, err := namespaceFunc(o.fsys)
+	if errors.Is(err, podid.ErrNamespaceNotFound) {
+		return Field{}, fmt.Errorf("%w: %w", ErrNotDetected, err)
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{Value: v, Source: lookupSource(o.root(), v, "POD_NAMESPACE", podid.PodInfoDir+"/namespace", podid.NamespacePath)}, nil
+}
+
+// detectNode returns the node name nodeid.GetNodeName finds in NODE_NAME or
+// the kubelet client certificate.
+func detectNode(_ context.Context, o options) (Field, error) {
+	v, err := nodeFunc(o.fsys)
+	if errors.Is(err, nodeid.ErrNodeNameNotFound) {
+		return Field{}, fmt.Errorf("%w: %w", ErrNotDetected, err)
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{Value: v, Source: lookupSource(o.root(), v, "NODE_NAME", nodeid.KubeletClientCertPath)}, nil
+}
+
+// lookupSource names where a delegated lookup found value: the environment
+// variable key if it holds value, otherwise the first of paths, which are
+// in the order the lookup reads them, that holds it, or else the last one.
+func lookupSource(root fs.FS, value, key string, paths ...string) string {
+	if strings.TrimSpace(getenv(key)) == value {
+		return "env:" + key
+	}
+	for _, path := range paths[:len(paths)-1] {
+		if data, err := fs.ReadFile(root, fsName(path)); err == nil && strings.TrimSpace(string(data)) == value {
+			return "file:" + path
+		}
+	}
+	return "file:" + paths[len(paths)-1]
+}
+
+// detectRuntime classifies the runtime with containerid.Runtime, so it
+// agrees with /runtime.
+func detectRuntime(_ context.Context, o options) (Field, error) {
+	kind, err := containerid.Runtime(containerid.WithFS(o.root()))
+	if errors.Is(err, containerid.ErrRuntimeNotDetected) {
+		return Field{}, ErrNotDetected
+	}
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{Value: kind.String(), Source: SourceRuntime}, nil
+}
+
+// detectCloud identifies the cloud provider from SMBIOS vendor strings, which
+// avoids a network round trip to a metadata service.
+func detectCloud(_ context.Context, o options) (Field, error) {
+	for _, name := range []string{"sys_vendor", "board_vendor", "bios_vendor", "product_name"} {
+		path := DMIPath + "/" + name
+		data, err := fs.ReadFile(o.root(), fsName(path))
+		if err != nil {
+			continue
+		}
+
+		vendor := strings.ToLower(strings.TrimSpace(string(data)))
+		for _, c := range cloudVendors {
+			if strings.Contains(vendor, c.vendor) {
+				return Field{Value: c.cloud, Source: "file:" + path}, nil
+			}
+		}
+	}
+	return Field{}, ErrNotDetected
+}
@@ -0,0 +1,335 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/nodeid"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// stubDetectors points every detector at an empty in-memory filesystem,
+// which it returns, and at env, and restores the originals when the test
+// finishes. The namespace and node lookups only read env.
+func stubDetectors(t *testing.T, env map[string]string) fstest.MapFS {
+	t.Helper()
+
+	fsys := fstest.MapFS{}
+	origGetenv, origContainer, origPod, origNewID, origRoot := getenv, containerIDFunc, podIDFunc, newInstanceID, rootFS
+	origNamespace, origNode := namespaceFunc, nodeFunc
+
+	getenv = func(key string) string { return env[key] }
+	containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+		return containerid.Detection{}, errors.New("no container")
+	}
+	podIDFunc = func(context.Context, fs.FS) (string, error) { return "", errors.New("no pod") }
+	namespaceFunc = func(fs.FS) (string, error) {
+		if v := env["POD_NAMESPACE"]; v != "" {
+			return v, nil
+		}
+		return "", podid.ErrNamespaceNotFound
+	}
+	nodeFunc = func(fs.FS) (string, error) {
+		if v := env["NODE_NAME"]; v != "" {
+			return v, nil
+		}
+		return "", nodeid.ErrNodeNameNotFound
+	}
+	newInstanceID = func() (string, error) { return "generated-id", nil }
+	rootFS = fsys
+	instanceOnce = sync.Once{}
+
+	t.Cleanup(func() {
+		getenv, containerIDFunc, podIDFunc, newInstanceID, rootFS = origGetenv, origContainer, origPod, origNewID, origRoot
+		namespaceFunc, nodeFunc = origNamespace, origNode
+		instanceOnce = sync.Once{}
+	})
+	return fsys
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+// Test Get populates every field with its source
+func TestGet_AllDetected(t *testing.T) {
+	fsys := stubDetectors(t, map[string]string{"NODE_NAME": "node-1"})
+	containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+		return containerid.Detection{ID: "abc123", Provider: "cgroup", Source: containerid.CgroupPath}, nil
+	}
+	podIDFunc = func(context.Context, fs.FS) (string, error) { return "036da4f7-d553-4eb6-9802-90f81041a412", nil }
+	namespaceFunc = func(fs.FS) (string, error) { return "default", nil }
+	fsys["var/run/secrets/kubernetes.io/serviceaccount/namespace"] = file("default\n")
+	fsys["proc/self/cgroup"] = file("0::/kubepods/besteffort/pod1/cri-containerd-abc123.scope\n")
+	fsys["sys/class/dmi/id/sys_vendor"] = file("Amazon EC2\n")
+
+	id, err := Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		got   Field
+		value string
+		src   string
+	}{
+		{"instance", id.Instance, "generated-id", SourceGenerated},
+		{"container", id.Container, "abc123", "file:/proc/self/cgroup"},
+		{"pod", id.Pod, "036da4f7-d553-4eb6-9802-90f81041a412", "file:/proc/self/mountinfo"},
+		{"namespace", id.Namespace, "default", "file:" + podid.NamespacePath},
+		{"node", id.Node, "node-1", "env:NODE_NAME"},
+		{"runtime", id.Runtime, "containerd", SourceRuntime},
+		{"cloud", id.Cloud, "aws", "file:" + DMIPath + "/sys_vendor"},
+	}
+	for _, tt := range tests {
+		if tt.got.Value != tt.value || tt.got.Source != tt.src {
+			t.Errorf("%s = %+v, want {Value:%s Source:%s}", tt.name, tt.got, tt.value, tt.src)
+		}
+	}
+}
+
+// Test Get returns detected fields alongside errors for the missing ones
+func TestGet_Partial(t *testing.T) {
+	stubDetectors(t, map[string]string{"INSTANCE_ID": "fixed", "POD_NAMESPACE": "prod"})
+
+	id, err := Get(context.Background())
+	if err == nil {
+		t.Fatal("Get() error = nil, want error for missing fields")
+	}
+
+	if id.Instance != (Field{Value: "fixed", Source: "env:INSTANCE_ID"}) {
+		t.Errorf("Instance = %+v, want env override", id.Instance)
+	}
+	if id.Namespace != (Field{Value: "prod", Source: "env:POD_NAMESPACE"}) {
+		t.Errorf("Namespace = %+v, want env override", id.Namespace)
+	}
+	if id.Container != (Field{}) || id.Node != (Field{}) {
+		t.Errorf("undetected fields should be empty, got container=%+v node=%+v", id.Container, id.Node)
+	}
+
+	for _, name := range []string{"container", "pod", "node", "runtime", "cloud"} {
+		if !strings.Contains(err.Error(), "identity: "+name+":") {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+	if !errors.Is(err, ErrNotDetected) {
+		t.Errorf("errors.Is(err, ErrNotDetected) = false, want true")
+	}
+}
+
+// Test the generated instance ID is stable across calls
+func TestGet_InstanceStable(t *testing.T) {
+	stubDetectors(t, nil)
+	calls := 0
+	newInstanceID = func() (string, error) {
+		calls++
+		return "id", nil
+	}
+
+	for i := 0; i < 3; i++ {
+		id, _ := Get(context.Background())
+		if id.Instance.Value != "id" {
+			t.Fatalf("Instance = %q, want %q", id.Instance.Value, "id")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("newInstanceID called %d times, want 1", calls)
+	}
+}
+
+// Test Get stops at a cancelled context
+func TestGet_Cancelled(t *testing.T) {
+	stubDetectors(t, map[string]string{"INSTANCE_ID": "fixed"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	id, err := Get(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Get() error = %v, want context.Canceled", err)
+	}
+	if id.Instance != (Field{}) {
+		t.Errorf("Instance = %+v, want empty", id.Instance)
+	}
+}
+
+// Test the container and pod lookups receive the caller's context
+func TestGet_PassesContext(t *testing.T) {
+	stubDetectors(t, nil)
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "caller")
+
+	var got []any
+	containerIDFunc = func(ctx context.Context, _ fs.FS) (containerid.Detection, error) {
+		got = append(got, ctx.Value(key{}))
+		return containerid.Detection{}, ctx.Err()
+	}
+	podIDFunc = func(ctx context.Context, _ fs.FS) (string, error) {
+		got = append(got, ctx.Value(key{}))
+		return "", ctx.Err()
+	}
+
+	Get(ctx)
+	if len(got) != 2 || got[0] != "caller" || got[1] != "caller" {
+		t.Errorf("lookups received context values %v, want the caller's context twice", got)
+	}
+}
+
+// Test the container source names what the detecting provider read
+func TestDetectContainer_Source(t *testing.T) {
+	tests := []struct {
+		provider, source, want string
+	}{
+		{"mountinfo", containerid.MountInfoPath, "file:" + containerid.MountInfoPath},
+		{"podman", "/run/.containerenv", "file:/run/.containerenv"},
+		{"ecs", "env:" + containerid.ECSMetadataEnv, "env:" + containerid.ECSMetadataEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			stubDetectors(t, nil)
+			containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+				return containerid.Detection{ID: "abc123", Provider: tt.provider, Source: tt.source}, nil
+			}
+
+			got, err := detectContainer(context.Background(), options{})
+			if err != nil {
+				t.Fatalf("detectContainer() error = %v", err)
+			}
+			if got != (Field{Value: "abc123", Source: tt.want}) {
+				t.Errorf("detectContainer() = %+v, want source %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test the namespace and node sources name where podid and nodeid found them
+func TestDetectNamespaceAndNode_Source(t *testing.T) {
+	fsys := stubDetectors(t, map[string]string{"NODE_NAME": "node-1"})
+	fsys["etc/podinfo/namespace"] = file("team-a\n")
+	fsys["var/run/secrets/kubernetes.io/serviceaccount/namespace"] = file("default\n")
+	namespaceFunc = func(fs.FS) (string, error) { return "team-a", nil }
+
+	if got, _ := detectNamespace(context.Background(), options{}); got != (Field{Value: "team-a", Source: "file:/etc/podinfo/namespace"}) {
+		t.Errorf("detectNamespace() = %+v, want the Downward API volume", got)
+	}
+	if got, _ := detectNode(context.Background(), options{}); got != (Field{Value: "node-1", Source: "env:NODE_NAME"}) {
+		t.Errorf("detectNode() = %+v, want NODE_NAME", got)
+	}
+
+	// Without NODE_NAME the name can only come from the kubelet certificate.
+	getenv = func(string) string { return "" }
+	nodeFunc = func(fs.FS) (string, error) { return "node-2", nil }
+	if got, _ := detectNode(context.Background(), options{}); got != (Field{Value: "node-2", Source: "file:" + nodeid.KubeletClientCertPath}) {
+		t.Errorf("detectNode() = %+v, want the kubelet certificate", got)
+	}
+}
+
+// Test runtime detection through containerid.Runtime
+func TestDetectRuntime(t *testing.T) {
+	tests := []struct {
+		name   string
+		cgroup string
+		want   string
+	}{
+		{"crio", "0::/kubepods.slice/crio-0123.scope\n", "cri-o"},
+		{"docker", "12:pids:/docker/0123\n", "docker"},
+		{"podman", "0::/machine.slice/libpod-0123.scope\n", "podman"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := stubDetectors(t, nil)
+			fsys["proc/self/cgroup"] = file(tt.cgroup)
+
+			got, err := detectRuntime(context.Background(), options{})
+			if err != nil {
+				t.Fatalf("detectRuntime() error = %v", err)
+			}
+			if got.Value != tt.want {
+				t.Errorf("detectRuntime() = %q, want %q", got.Value, tt.want)
+			}
+		})
+	}
+
+	t.Run("marker", func(t *testing.T) {
+		fsys := stubDetectors(t, nil)
+		fsys[".dockerenv"] = file("")
+
+		got, err := detectRuntime(context.Background(), options{})
+		if err != nil {
+			t.Fatalf("detectRuntime() error = %v", err)
+		}
+		if got != (Field{Value: "docker", Source: SourceRuntime}) {
+			t.Errorf("detectRuntime() = %+v, want docker from marker", got)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		fsys := stubDetectors(t, nil)
+		fsys["proc/self/cgroup"] = file("0::/\n")
+
+		if _, err := detectRuntime(context.Background(), options{}); !errors.Is(err, ErrNotDetected) {
+			t.Errorf("detectRuntime() error = %v, want ErrNotDetected", err)
+		}
+	})
+}
+
+// Test cloud detection from DMI vendor strings
+func TestDetectCloud(t *testing.T) {
+	tests := []struct {
+		file, vendor, want string
+	}{
+		{"sys_vendor", "Google\n", "gcp"},
+		{"sys_vendor", "Microsoft Corporation\n", "azure"},
+		{"board_vendor", "Amazon EC2\n", "aws"},
+		{"product_name", "Alibaba Cloud ECS\n", "alibaba"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			fsys := stubDetectors(t, nil)
+			fsys["sys/class/dmi/id/"+tt.file] = file(tt.vendor)
+
+			got, err := detectCloud(context.Background(), options{})
+			if err != nil {
+				t.Fatalf("detectCloud() error = %v", err)
+			}
+			if got.Value != tt.want {
+				t.Errorf("detectCloud() = %q, want %q", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+// Test WithFS redirects every file read to the given filesystem
+func TestGet_WithFS(t *testing.T) {
+	stubDetectors(t, nil)
+	t.Setenv("POD_NAMESPACE", "")
+	namespaceFunc = func(fsys fs.FS) (string, error) { return podid.GetNamespace(podid.WithFS(fsys)) }
+	var gotFS fs.FS
+	containerIDFunc = func(_ context.Context, fsys fs.FS) (containerid.Detection, error) {
+		gotFS = fsys
+		return containerid.Detection{ID: "abc123"}, nil
+	}
+
+	host := fstest.MapFS{
+		"var/run/secrets/kubernetes.io/serviceaccount/namespace": file("kube-system"),
+		"run/.containerenv": file(""),
+	}
+	id, _ := Get(context.Background(), WithFS(host))
+
+	if id.Namespace.Value != "kube-system" {
+		t.Errorf("Namespace = %q, want %q", id.Namespace.Value, "kube-system")
+	}
+	if id.Runtime != (Field{Value: "podman", Source: SourceRuntime}) {
+		t.Errorf("Runtime = %+v, want podman from marker", id.Runtime)
+	}
+	if gotFS == nil {
+		t.Error("container detector did not receive the custom filesystem")
+	}
+}
@@ -0,0 +1,144 @@
+package identity
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWatchInterval is the default delay between re-detection attempts.
+	DefaultWatchInterval = 5 * time.Second
+
+	// DefaultWatchAttempts is the default number of re-detection attempts.
+	DefaultWatchAttempts = 60
+)
+
+var (
+	watchMu       sync.Mutex
+	subscribers   = map[int]func(Identity){}
+	nextSubID     int
+	watching      bool
+	watchInterval = DefaultWatchInterval
+	watchAttempts = DefaultWatchAttempts
+)
+
+// SetWatchPolicy configures the background re-detection loop started by
+// Subscribe. The loop runs at most attempts times, interval apart. Changes
+// take effect the next time a loop starts.
+func SetWatchPolicy(interval time.Duration, attempts int) {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+	watchInterval = interval
+	watchAttempts = attempts
+}
+
+// Subscribe registers fn to be called whenever a field that was previously
+// missing from the Identity becomes available, for example a container ID
+// that only appears once a slow mount is ready.
+//
+// The first subscriber starts a bounded background loop that re-runs Get
+// according to the watch policy. The loop stops once every field is known,
+// the attempts are exhausted, or no subscribers remain; a later Subscribe
+// starts a new one. fn is called from the loop's goroutine and must not
+// block. The returned function removes the subscription.
+func Subscribe(fn func(Identity)) (unsubscribe func()) {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+
+	id := nextSubID
+	nextSubID++
+	subscribers[id] = fn
+
+	if !watching {
+		watching = true
+		go watch(watchInterval, watchAttempts)
+	}
+
+	return func() {
+		watchMu.Lock()
+		delete(subscribers, id)
+		watchMu.Unlock()
+	}
+}
+
+// watch re-detects the identity and notifies subscribers of new fields.
+func watch(interval time.Duration, attempts int) {
+	last, _ := Get(context.Background())
+	for i := 0; i < attempts && !complete(last); i++ {
+		time.Sleep(interval)
+
+		fns, ok := currentSubscribers()
+		if !ok {
+			return
+		}
+
+		cur, _ := Get(context.Background())
+		if !gained(last, cur) {
+			continue
+		}
+		last = cur
+		for _, fn := range fns {
+			fn(cur)
+		}
+	}
+
+	watchMu.Lock()
+	watching = false
+	watchMu.Unlock()
+}
+
+// currentSubscribers returns the registered callbacks in subscription order.
+// If there are none it marks the loop stopped, under the same lock, so a
+// concurrent Subscribe starts a new one, and returns false.
+func currentSubscribers() ([]func(Identity), bool) {
+	watchMu.Lock()
+	defer watchMu.Unlock()
+
+	if len(subscribers) == 0 {
+		watching = false
+		return nil, false
+	}
+
+	ids := make([]int, 0, len(subscribers))
+	for id := range subscribers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	fns := make([]func(Identity), len(ids))
+	for i, id := range ids {
+		fns[i] = subscribers[id]
+	}
+	return fns, true
+}
+
+// fields returns the values of every field in a fixed order.
+func (id Identity) fields() []string {
+	return []string{
+		id.Instance.Value, id.Container.Value, id.Pod.Value, id.Namespace.Value,
+		id.Node.Value, id.Runtime.Value, id.Cloud.Value,
+	}
+}
+
+// complete reports whether every field of id is populated.
+func complete(id Identity) bool {
+	for _, v := range id.fields() {
+		if v == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// gained reports whether cur has a value for a field that was empty in prev.
+func gained(prev, cur Identity) bool {
+	p, c := prev.fields(), cur.fields()
+	for i := range p {
+		if p[i] == "" && c[i] != "" {
+			return true
+		}
+	}
+	return false
+}
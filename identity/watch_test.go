@@ -0,0 +1,157 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+)
+
+// stubWatch shortens the watch policy and waits for any loop to finish
+// when the test ends.
+func stubWatch(t *testing.T, attempts int) {
+	t.Helper()
+	SetWatchPolicy(time.Millisecond, attempts)
+	t.Cleanup(func() {
+		waitWatchDone(t)
+		SetWatchPolicy(DefaultWatchInterval, DefaultWatchAttempts)
+	})
+}
+
+func waitWatchDone(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		watchMu.Lock()
+		done := !watching
+		watchMu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("watch loop did not stop")
+}
+
+// Test subscribers are notified when a missing field appears
+func TestSubscribe_LateField(t *testing.T) {
+	stubDetectors(t, map[string]string{"INSTANCE_ID": "fixed"})
+	var calls atomic.Int32
+	containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+		if calls.Add(1) < 3 {
+			return containerid.Detection{}, errors.New("not mounted yet")
+		}
+		return containerid.Detection{ID: "abc123"}, nil
+	}
+	stubWatch(t, 10)
+
+	got := make(chan Identity, 10)
+	unsubscribe := Subscribe(func(id Identity) { got <- id })
+	defer unsubscribe()
+
+	select {
+	case id := <-got:
+		if id.Container.Value != "abc123" {
+			t.Errorf("Container = %q, want %q", id.Container.Value, "abc123")
+		}
+		if id.Instance.Value != "fixed" {
+			t.Errorf("Instance = %q, want %q", id.Instance.Value, "fixed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+
+	// No further notifications while nothing new appears.
+	unsubscribe()
+	waitWatchDone(t)
+	if len(got) != 0 {
+		t.Errorf("got %d extra notifications, want 0", len(got))
+	}
+}
+
+// Test the re-detection loop stops after the configured attempts
+func TestSubscribe_Bounded(t *testing.T) {
+	stubDetectors(t, nil)
+	var calls atomic.Int32
+	containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+		calls.Add(1)
+		return containerid.Detection{}, errors.New("never")
+	}
+	stubWatch(t, 3)
+
+	unsubscribe := Subscribe(func(Identity) { t.Error("unexpected notification") })
+	defer unsubscribe()
+	waitWatchDone(t)
+
+	// One initial detection plus three attempts.
+	if got := calls.Load(); got != 4 {
+		t.Errorf("detections = %d, want 4", got)
+	}
+}
+
+// Test the loop exits once every subscriber is gone
+func TestSubscribe_Unsubscribe(t *testing.T) {
+	stubDetectors(t, nil)
+	stubWatch(t, 1000)
+
+	unsubscribe := Subscribe(func(Identity) {})
+	unsubscribe()
+	waitWatchDone(t)
+}
+
+// Test a subscriber that arrives while the loop stops still gets a loop
+func TestSubscribe_Resubscribe(t *testing.T) {
+	stubDetectors(t, nil)
+	var calls atomic.Int32
+	containerIDFunc = func(context.Context, fs.FS) (containerid.Detection, error) {
+		if calls.Add(1) < 5 {
+			return containerid.Detection{}, errors.New("not mounted yet")
+		}
+		return containerid.Detection{ID: "abc123"}, nil
+	}
+	stubWatch(t, 1000)
+
+	for range 20 {
+		Subscribe(func(Identity) {})()
+	}
+	got := make(chan Identity, 1)
+	unsubscribe := Subscribe(func(id Identity) {
+		select {
+		case got <- id:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	select {
+	case id := <-got:
+		if id.Container.Value != "abc123" {
+			t.Errorf("Container = %q, want %q", id.Container.Value, "abc123")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+}
+
+// Test gained only reports fields that were previously empty
+func TestGained(t *testing.T) {
+	prev := Identity{Instance: Field{Value: "a"}}
+	tests := []struct {
+		name string
+		cur  Identity
+		want bool
+	}{
+		{"unchanged", Identity{Instance: Field{Value: "a"}}, false},
+		{"changed value", Identity{Instance: Field{Value: "b"}}, false},
+		{"new field", Identity{Instance: Field{Value: "a"}, Pod: Field{Value: "p"}}, true},
+	}
+	for _, tt := range tests {
+		if got := gained(prev, tt.cur); got != tt.want {
+			t.Errorf("gained(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package idgen generates time-ordered unique identifiers.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// NewV7 generates a UUIDv7 identifier with timestamp and random components.
+// UUIDv7 format: xxxxxxxx-xxxx-7xxx-yxxx-xxxxxxxxxxxx
+// - First 48 bits: Unix timestamp in milliseconds
+// - Next 12 bits: sub-millisecond precision (random)
+// - Version bits: 0111 (7)
+// - Variant bits: 10
+// - Remaining 62 bits: random
+//
+// IDs generated in later milliseconds sort after earlier ones.
+func NewV7() (string, error) {
+	b := make([]byte, 16)
+
+	// Get current Unix timestamp in milliseconds (48 bits)
+	timestamp := time.Now().UnixMilli()
+
+	// Place timestamp in first 6 bytes (48 bits)
+	b[0] = byte(timestamp >> 40)
+	b[1] = byte(timestamp >> 32)
+	b[2] = byte(timestamp >> 24)
+	b[3] = byte(timestamp >> 16)
+	b[4] = byte(timestamp >> 8)
+	b[5] = byte(timestamp)
+
+	// Fill remaining bytes with random data
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+
+	// Set version bits to 7 (0111) in byte 6, high nibble
+	b[6] = (b[6] & 0x0f) | 0x70
+
+	// Set variant bits to 10 in byte 8, high 2 bits
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	// Format as UUID: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+	return fmt.Sprintf("%s-%s-%s-%s-%s",
+		hex.EncodeToString(b[0:4]),
+		hex.EncodeToString(b[4:6]),
+		hex.EncodeToString(b[6:8]),
+		hex.EncodeToString(b[8:10]),
+		hex.EncodeToString(b[10:16]),
+	), nil
+}
+
+// MustNewV7 is like NewV7 but panics if the system random source fails.
+func MustNewV7() string {
+	id, err := NewV7()
+	if err != nil {
+		panic(fmt.Sprintf("idgen: %v", err))
+	}
+	return id
+}
@@ -0,0 +1,82 @@
+package idgen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test NewV7 generates valid UUIDv7 format
+func TestNewV7(t *testing.T) {
+	// UUIDv7 regex: xxxxxxxx-xxxx-7xxx-[89ab]xxx-xxxxxxxxxxxx
+	uuidv7Regex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	for i := 0; i < 10; i++ {
+		id, err := NewV7()
+		if err != nil {
+			t.Fatalf("NewV7() returned error: %v", err)
+		}
+
+		if !uuidv7Regex.MatchString(id) {
+			t.Errorf("NewV7() = %q, does not match UUIDv7 format", id)
+		}
+
+		// Verify version 7
+		parts := strings.Split(id, "-")
+		if len(parts) != 5 {
+			t.Errorf("NewV7() = %q, expected 5 parts", id)
+		}
+		if !strings.HasPrefix(parts[2], "7") {
+			t.Errorf("NewV7() = %q, third part should start with '7' (version)", id)
+		}
+
+		// Verify variant bits (10)
+		firstChar := parts[3][0]
+		if firstChar != '8' && firstChar != '9' && firstChar != 'a' && firstChar != 'b' {
+			t.Errorf("NewV7() = %q, fourth part should start with 8/9/a/b (variant)", id)
+		}
+	}
+}
+
+// Test that UUIDv7s are sortable by time
+func TestNewV7_Sortable(t *testing.T) {
+	id1, err := NewV7()
+	if err != nil {
+		t.Fatalf("NewV7() error: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	id2, err := NewV7()
+	if err != nil {
+		t.Fatalf("NewV7() error: %v", err)
+	}
+
+	// UUIDs generated later should sort after earlier ones
+	if id1 >= id2 {
+		t.Errorf("NewV7() not sortable: %q should be less than %q", id1, id2)
+	}
+}
+
+// Test that NewV7 generates unique IDs
+func TestNewV7_Unique(t *testing.T) {
+	ids := make(map[string]bool)
+	count := 1000
+
+	for i := 0; i < count; i++ {
+		id, err := NewV7()
+		if err != nil {
+			t.Fatalf("NewV7() error: %v", err)
+		}
+
+		if ids[id] {
+			t.Errorf("NewV7() generated duplicate ID: %q", id)
+		}
+		ids[id] = true
+	}
+
+	if len(ids) != count {
+		t.Errorf("NewV7() expected %d unique IDs, got %d", count, len(ids))
+	}
+}
@@ -0,0 +1,112 @@
+// Package jsonvalue decodes JSON into a tree that keeps the order of object
+// members, so a JSON response can be re-encoded in another format without
+// reordering its fields.
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Kind is the JSON type of a Value.
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Array
+	Object
+)
+
+// Value is a decoded JSON value. Only the fields of its Kind are set.
+type Value struct {
+	Kind Kind
+	Bool bool
+	// Number is the literal of a number, so integers keep their precision.
+	Number json.Number
+	String string
+	// Items are the elements of an array.
+	Items []Value
+	// Members are the members of an object in document order.
+	Members []Member
+}
+
+// Member is a name and value pair of an object.
+type Member struct {
+	Name  string
+	Value Value
+}
+
+// Parse decodes a single JSON value.
+func Parse(data []byte) (Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := parse(dec)
+	if err != nil {
+		return Value{}, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return Value{}, errors.New("jsonvalue: data after the top-level value")
+	}
+	return v, nil
+}
+
+func parse(dec *json.Decoder) (Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return Value{}, fmt.Errorf("jsonvalue: %w", err)
+	}
+	switch tok := tok.(type) {
+	case nil:
+		return Value{Kind: Null}, nil
+	case bool:
+		return Value{Kind: Bool, Bool: tok}, nil
+	case json.Number:
+		return Value{Kind: Number, Number: tok}, nil
+	case string:
+		return Value{Kind: String, String: tok}, nil
+	case json.Delim:
+		if tok == '[' {
+			v := Value{Kind: Array, Items: []Value{}}
+			for dec.More() {
+				item, err := parse(dec)
+				if err != nil {
+					return Value{}, err
+				}
+				v.Items = append(v.Items, item)
+			}
+			_, err := dec.Token()
+			return v, err
+		}
+		v := Value{Kind: Object, Members: []Member{}}
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return Value{}, fmt.Errorf("jsonvalue: %w", err)
+			}
+			value, err := parse(dec)
+			if err != nil {
+				return Value{}, err
+			}
+			v.Members = append(v.Members, Member{Name: name.(string), Value: value})
+		}
+		_, err := dec.Token()
+		return v, err
+	}
+	return Value{}, fmt.Errorf("jsonvalue: unexpected token %v", tok)
+}
+
+// Get returns the value of the member name of an object.
+func (v Value) Get(name string) (Value, bool) {
+	for _, m := range v.Members {
+		if m.Name == name {
+			return m.Value, true
+		}
+	}
+	return Value{}, false
+}
@@ -0,0 +1,49 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	v, err := Parse([]byte(`{"z":1,"a":[true,null,"s",12345678901234567890],"m":{}}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if v.Kind != Object || len(v.Members) != 3 {
+		t.Fatalf("Parse() = %+v, want an object with 3 members", v)
+	}
+	if v.Members[0].Name != "z" || v.Members[1].Name != "a" || v.Members[2].Name != "m" {
+		t.Errorf("member order = %q, %q, %q; want z, a, m", v.Members[0].Name, v.Members[1].Name, v.Members[2].Name)
+	}
+
+	a, ok := v.Get("a")
+	if !ok || a.Kind != Array || len(a.Items) != 4 {
+		t.Fatalf("Get(a) = %+v, %v; want an array of 4", a, ok)
+	}
+	if a.Items[0].Kind != Bool || !a.Items[0].Bool {
+		t.Errorf("a[0] = %+v, want true", a.Items[0])
+	}
+	if a.Items[1].Kind != Null {
+		t.Errorf("a[1] = %+v, want null", a.Items[1])
+	}
+	if a.Items[2].Kind != String || a.Items[2].String != "s" {
+		t.Errorf("a[2] = %+v, want \"s\"", a.Items[2])
+	}
+	if a.Items[3].Kind != Number || a.Items[3].Number != "12345678901234567890" {
+		t.Errorf("a[3] = %+v, want the number literal", a.Items[3])
+	}
+	if m, _ := v.Get("m"); m.Kind != Object || len(m.Members) != 0 {
+		t.Errorf("Get(m) = %+v, want an empty object", m)
+	}
+	if _, ok := v.Get("missing"); ok {
+		t.Error("Get(missing) found a member")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{"", "{", `{"a":}`, `[1,]`, `1 2`} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", data)
+		}
+	}
+}
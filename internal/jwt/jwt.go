@@ -0,0 +1,109 @@
+// Package jwt decodes JSON Web Tokens without verifying their signature.
+//
+// It exists for debugging: the decoded header and claims show what a token
+// says about itself, not whether that can be trusted. Nothing decoded here
+// may be used to make an authorization decision.
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is returned, wrapped, for strings that are not a JWS compact
+// serialization with a JSON header and claims.
+var ErrMalformed = errors.New("malformed JWT")
+
+// Token is a decoded, unverified JWT.
+type Token struct {
+	Header map[string]any
+	Claims map[string]any
+	// Signature is the raw signature, empty for unsecured ("alg": "none")
+	// tokens.
+	Signature []byte
+}
+
+// Decode splits s into its header, claims and signature and decodes them.
+// The signature is not verified.
+func Decode(s string) (Token, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 3 {
+		return Token{}, fmt.Errorf("%w: want 3 dot-separated parts, got %d", ErrMalformed, len(parts))
+	}
+
+	var t Token
+	if err := decodeJSON(parts[0], &t.Header); err != nil {
+		return Token{}, fmt.Errorf("%w: header: %v", ErrMalformed, err)
+	}
+	if err := decodeJSON(parts[1], &t.Claims); err != nil {
+		return Token{}, fmt.Errorf("%w: claims: %v", ErrMalformed, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Token{}, fmt.Errorf("%w: signature: %v", ErrMalformed, err)
+	}
+	t.Signature = sig
+	return t, nil
+}
+
+// decodeJSON decodes a base64url JSON object, keeping numbers exact so
+// large integer claims survive.
+func decodeJSON(part string, v *map[string]any) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if *v == nil {
+		return errors.New("not a JSON object")
+	}
+	return nil
+}
+
+// String returns the string claim name, or "".
+func (t Token) String(name string) string {
+	s, _ := t.Claims[name].(string)
+	return s
+}
+
+// Time returns the NumericDate claim name, such as exp, iat or nbf, and
+// whether it is present and numeric.
+func (t Token) Time(name string) (time.Time, bool) {
+	n, ok := t.Claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
+
+// Audiences returns the aud claim, which may be a single string or a list.
+func (t Token) Audiences() []string {
+	switch aud := t.Claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		var auds []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+		return auds
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// encode builds an unsigned token from raw JSON header and claims.
+func encode(header, claims, sig string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(claims)) + "." + enc.EncodeToString([]byte(sig))
+}
+
+func TestDecode(t *testing.T) {
+	token := encode(`{"alg":"RS256","kid":"k1"}`, `{"iss":"https://issuer","aud":["api","vault"],"exp":1792051200,"iat":1792047600.5,"big":12345678901234567890}`, "sig")
+
+	got, err := Decode(" " + token + "\n")
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Header["alg"] != "RS256" || got.Header["kid"] != "k1" || string(got.Signature) != "sig" {
+		t.Errorf("Decode() = %+v, want the RS256 header and signature", got)
+	}
+	if got.String("iss") != "https://issuer" || got.String("exp") != "" {
+		t.Errorf("String() = %q, %q; want the issuer and no string exp", got.String("iss"), got.String("exp"))
+	}
+	if got.Claims["big"] != json.Number("12345678901234567890") {
+		t.Errorf("big claim = %v, want it kept exact", got.Claims["big"])
+	}
+	if auds := got.Audiences(); !reflect.DeepEqual(auds, []string{"api", "vault"}) {
+		t.Errorf("Audiences() = %v, want [api vault]", auds)
+	}
+
+	exp, ok := got.Time("exp")
+	if !ok || !exp.Equal(time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time(exp) = %v, %v; want 2026-10-15T08:00:00Z", exp, ok)
+	}
+	iat, ok := got.Time("iat")
+	if !ok || iat.Nanosecond() != 5e8 {
+		t.Errorf("Time(iat) = %v, %v; want half a second", iat, ok)
+	}
+	if _, ok := got.Time("nbf"); ok {
+		t.Error("Time(nbf) ok = true, want false for a missing claim")
+	}
+}
+
+func TestDecode_SingleAudienceAndUnsecured(t *testing.T) {
+	got, err := Decode(encode(`{"alg":"none"}`, `{"aud":"api"}`, ""))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if auds := got.Audiences(); !reflect.DeepEqual(auds, []string{"api"}) || len(got.Signature) != 0 {
+		t.Errorf("Decode() = %+v with audiences %v, want [api] and no signature", got, auds)
+	}
+}
+
+func TestDecode_Malformed(t *testing.T) {
+	tests := map[string]string{
+		"two parts":         "a.b",
+		"bad base64":        "!!.e30.",
+		"header not json":   encode(`nope`, `{}`, ""),
+		"claims not object": encode(`{}`, `[1]`, ""),
+		"claims null":       encode(`{}`, `null`, ""),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(token); !errors.Is(err, ErrMalformed) {
+				t.Errorf("Decode(%q) error = %v, want ErrMalformed", token, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package msgpack encodes JSON values as MessagePack.
+//
+// It writes the subset of the MessagePack format that JSON values map to:
+// nil, booleans, integers, float64, strings, arrays and maps with string
+// keys.
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+)
+
+// Marshal encodes v. Numbers are written as the smallest integer type that
+// holds them, or as float64 if they are not integers.
+func Marshal(v jsonvalue.Value) []byte {
+	return Append(nil, v)
+}
+
+// Append appends the encoding of v to b.
+func Append(b []byte, v jsonvalue.Value) []byte {
+	switch v.Kind {
+	case jsonvalue.Bool:
+		if v.Bool {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case jsonvalue.Number:
+		if i, err := strconv.ParseInt(string(v.Number), 10, 64); err == nil {
+			return AppendInt(b, i)
+		}
+		if u, err := strconv.ParseUint(string(v.Number), 10, 64); err == nil {
+			return AppendUint(b, u)
+		}
+		f, _ := v.Number.Float64()
+		return AppendFloat(b, f)
+	case jsonvalue.String:
+		return AppendString(b, v.String)
+	case jsonvalue.Array:
+		b = appendHeader(b, len(v.Items), 0x90, 0xdc)
+		for _, item := range v.Items {
+			b = Append(b, item)
+		}
+		return b
+	case jsonvalue.Object:
+		b = appendHeader(b, len(v.Members), 0x80, 0xde)
+		for _, m := range v.Members {
+			b = AppendString(b, m.Name)
+			b = Append(b, m.Value)
+		}
+		return b
+	}
+	return append(b, 0xc0)
+}
+
+// AppendInt appends i in the smallest integer format that holds it.
+func AppendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return AppendUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// AppendUint appends u in the smallest integer format that holds it.
+func AppendUint(b []byte, u uint64) []byte {
+	switch {
+	case u < 0x80:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+}
+
+// AppendFloat appends f as a float64.
+func AppendFloat(b []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+}
+
+// AppendString appends s as a str.
+func AppendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendHeader appends the header of an array or map of n elements: the
+// fix format for up to 15, else the 16 or 32 bit format, which follows
+// the 16 bit one.
+func appendHeader(b []byte, n int, fix, format16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, format16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, format16+1), uint32(n))
+}
@@ -0,0 +1,70 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		json string
+		want []byte
+	}{
+		{`null`, []byte{0xc0}},
+		{`true`, []byte{0xc3}},
+		{`false`, []byte{0xc2}},
+		{`0`, []byte{0x00}},
+		{`127`, []byte{0x7f}},
+		{`128`, []byte{0xcc, 0x80}},
+		{`65536`, []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{`18446744073709551615`, []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{`-1`, []byte{0xff}},
+		{`-33`, []byte{0xd0, 0xdf}},
+		{`-129`, []byte{0xd1, 0xff, 0x7f}},
+		{`1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{`"abc"`, []byte{0xa3, 'a', 'b', 'c'}},
+		{`[1,"a"]`, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{`{"a":1,"b":[]}`, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x90}},
+	}
+	for _, tt := range tests {
+		v, err := jsonvalue.Parse([]byte(tt.json))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", tt.json, err)
+		}
+		if got := Marshal(v); !bytes.Equal(got, tt.want) {
+			t.Errorf("Marshal(%s) = %x, want %x", tt.json, got, tt.want)
+		}
+	}
+}
+
+func TestAppendString_Lengths(t *testing.T) {
+	tests := []struct {
+		n      int
+		header []byte
+	}{
+		{31, []byte{0xbf}},
+		{32, []byte{0xd9, 32}},
+		{256, []byte{0xda, 0x01, 0x00}},
+		{65536, []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		s := strings.Repeat("x", tt.n)
+		got := AppendString(nil, s)
+		if !bytes.HasPrefix(got, tt.header) || len(got) != len(tt.header)+tt.n {
+			t.Errorf("AppendString(%d bytes) header = %x, want %x", tt.n, got[:min(len(got), 5)], tt.header)
+		}
+	}
+}
+
+func TestMarshal_LargeArray(t *testing.T) {
+	items := make([]int, 16)
+	data, _ := json.Marshal(items)
+	v, _ := jsonvalue.Parse(data)
+	if got := Marshal(v); !bytes.HasPrefix(got, []byte{0xdc, 0x00, 0x10}) || len(got) != 3+16 {
+		t.Errorf("Marshal(16 items) = %x, want an array16 header", got)
+	}
+}
@@ -0,0 +1,154 @@
+// Package protowire encodes and decodes the protocol buffers wire format.
+//
+// It covers the varint and length-delimited field types that the gRPC
+// identity service and server reflection use. It is a small subset of
+// google.golang.org/protobuf/encoding/protowire.
+package protowire
+
+import (
+	"errors"
+	"math"
+)
+
+// Wire types.
+const (
+	VarintType  = 0
+	Fixed64Type = 1
+	BytesType   = 2
+	Fixed32Type = 5
+)
+
+// ErrTruncated is returned when a message ends in the middle of a field.
+var ErrTruncated = errors.New("protowire: truncated message")
+
+// ErrWireType is returned for wire types this package cannot skip.
+var ErrWireType = errors.New("protowire: unsupported wire type")
+
+// AppendVarint appends v as a base-128 varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// AppendTag appends the key of field num with wire type typ.
+func AppendTag(b []byte, num int, typ int) []byte {
+	return AppendVarint(b, uint64(num)<<3|uint64(typ))
+}
+
+// AppendBytes appends field num holding v. Empty values are omitted, as
+// proto3 does for scalar fields.
+func AppendBytes(b []byte, num int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return AppendMessage(b, num, v)
+}
+
+// AppendString appends string field num; empty strings are omitted.
+func AppendString(b []byte, num int, v string) []byte {
+	return AppendBytes(b, num, []byte(v))
+}
+
+// AppendMessage appends an embedded message or repeated bytes element.
+// Unlike AppendBytes it keeps empty values, which are meaningful there.
+func AppendMessage(b []byte, num int, v []byte) []byte {
+	b = AppendTag(b, num, BytesType)
+	b = AppendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// AppendUint appends varint field num; zero is omitted.
+func AppendUint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return AppendVarint(AppendTag(b, num, VarintType), v)
+}
+
+// AppendBool appends bool field num; false is omitted.
+func AppendBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return AppendUint(b, num, 1)
+}
+
+// AppendDouble appends double field num; zero is omitted.
+func AppendDouble(b []byte, num int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = AppendTag(b, num, Fixed64Type)
+	u := math.Float64bits(v)
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(u>>(8*i)))
+	}
+	return b
+}
+
+// Field is one decoded field. Varint holds the value of varint fields and
+// Bytes the payload of length-delimited ones.
+type Field struct {
+	Num    int
+	Type   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// Fields decodes the top-level fields of a message in order. Fixed-width
+// fields are skipped.
+func Fields(b []byte) ([]Field, error) {
+	var fields []Field
+	for len(b) > 0 {
+		key, n := consumeVarint(b)
+		if n == 0 {
+			return nil, ErrTruncated
+		}
+		b = b[n:]
+		f := Field{Num: int(key >> 3), Type: int(key & 7)}
+		switch f.Type {
+		case VarintType:
+			if f.Varint, n = consumeVarint(b); n == 0 {
+				return nil, ErrTruncated
+			}
+			b = b[n:]
+		case BytesType:
+			l, n := consumeVarint(b)
+			if n == 0 || uint64(len(b)-n) < l {
+				return nil, ErrTruncated
+			}
+			f.Bytes = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case Fixed64Type, Fixed32Type:
+			size := 8
+			if f.Type == Fixed32Type {
+				size = 4
+			}
+			if len(b) < size {
+				return nil, ErrTruncated
+			}
+			b = b[size:]
+			continue
+		default:
+			return nil, ErrWireType
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// consumeVarint decodes a varint and returns it with its length, or a zero
+// length if b ends before the varint does or it overflows 64 bits.
+func consumeVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
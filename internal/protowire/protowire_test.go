@@ -0,0 +1,84 @@
+package protowire
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAppendVarint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{150, []byte{0x96, 0x01}},
+		{1 << 63, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}},
+	}
+
+	for _, tt := range tests {
+		if got := AppendVarint(nil, tt.v); !bytes.Equal(got, tt.want) {
+			t.Errorf("AppendVarint(%d) = %x, want %x", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestFields_RoundTrip(t *testing.T) {
+	var b []byte
+	b = AppendString(b, 1, "testing")
+	b = AppendUint(b, 2, 150)
+	b = AppendDouble(b, 3, 1.5)
+	b = AppendMessage(b, 4, nil)
+	b = AppendString(b, 5, "")
+	b = AppendBool(b, 6, true)
+
+	fields, err := Fields(b)
+	if err != nil {
+		t.Fatalf("Fields() error = %v", err)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("Fields() returned %d fields, want 4 (doubles skipped, empty strings omitted): %+v", len(fields), fields)
+	}
+	if f := fields[0]; f.Num != 1 || f.Type != BytesType || string(f.Bytes) != "testing" {
+		t.Errorf("field 1 = %+v, want string %q", f, "testing")
+	}
+	if f := fields[1]; f.Num != 2 || f.Type != VarintType || f.Varint != 150 {
+		t.Errorf("field 2 = %+v, want varint 150", f)
+	}
+	if f := fields[2]; f.Num != 4 || len(f.Bytes) != 0 {
+		t.Errorf("field 4 = %+v, want empty message", f)
+	}
+	if f := fields[3]; f.Num != 6 || f.Varint != 1 {
+		t.Errorf("field 6 = %+v, want true", f)
+	}
+}
+
+func TestFields_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want error
+	}{
+		{"truncated key", []byte{0x80}, ErrTruncated},
+		{"truncated varint", []byte{0x08, 0x96}, ErrTruncated},
+		{"length past end", []byte{0x0a, 0x05, 'a'}, ErrTruncated},
+		{"truncated fixed64", []byte{0x09, 0x00}, ErrTruncated},
+		{"group wire type", []byte{0x0b}, ErrWireType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Fields(tt.b); !errors.Is(err, tt.want) {
+				t.Errorf("Fields(%x) error = %v, want %v", tt.b, err, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzFields(f *testing.F) {
+	f.Add(AppendString(AppendUint(nil, 2, 150), 1, "testing"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		Fields(b)
+	})
+}
@@ -0,0 +1,78 @@
+// Package singleflight suppresses duplicate concurrent calls.
+//
+// It is a small generic version of golang.org/x/sync/singleflight.
+package singleflight
+
+import "sync"
+
+// call is an in-flight or completed Do call.
+type call[T any] struct {
+	wg  sync.WaitGroup
+	val T
+	err error
+
+	// dups counts callers waiting on this call; guarded by Group.mu.
+	dups int
+}
+
+// Group deduplicates calls by key. The zero value is ready to use.
+type Group[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*call[T]
+}
+
+// Do runs fn and returns its result, making sure only one execution is in
+// flight for a given key at a time. Callers that arrive while fn is running
+// wait for it and receive the same result; shared reports whether the result
+// was given to more than one caller.
+func (g *Group[T]) Do(key string, fn func() (T, error)) (v T, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call[T])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &call[T]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// Forget the call even if fn panics so later callers do not block forever.
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+
+	g.mu.Lock()
+	shared = c.dups > 0
+	g.mu.Unlock()
+	return c.val, c.err, shared
+}
+
+// Result holds the outcome of a Do call, delivered by DoChan.
+type Result[T any] struct {
+	Val    T
+	Err    error
+	Shared bool
+}
+
+// DoChan is like Do but returns a channel that receives the result when it
+// is ready, so callers can stop waiting when their context is done. fn keeps
+// running for the other callers.
+func (g *Group[T]) DoChan(key string, fn func() (T, error)) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+	go func() {
+		v, err, shared := g.Do(key, fn)
+		ch <- Result[T]{Val: v, Err: err, Shared: shared}
+	}()
+	return ch
+}
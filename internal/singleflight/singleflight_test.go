@@ -0,0 +1,138 @@
+package singleflight
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Test Do returns the value and error of fn
+func TestDo(t *testing.T) {
+	var g Group[string]
+
+	v, err, shared := g.Do("key", func() (string, error) { return "bar", nil })
+	if v != "bar" || err != nil || shared {
+		t.Errorf("Do() = %q, %v, %v, want %q, nil, false", v, err, shared, "bar")
+	}
+
+	testErr := errors.New("boom")
+	_, err, _ = g.Do("key", func() (string, error) { return "", testErr })
+	if !errors.Is(err, testErr) {
+		t.Errorf("Do() error = %v, want %v", err, testErr)
+	}
+}
+
+// Test concurrent callers share a single execution
+func TestDo_Dedup(t *testing.T) {
+	var g Group[int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	fn := func() (int, error) {
+		if calls.Add(1) == 1 {
+			close(entered)
+		}
+		<-release
+		return 42, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan int, n)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v, _, _ := g.Do("key", fn)
+		results <- v
+	}()
+	<-entered
+
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, _ := g.Do("key", fn)
+			results <- v
+		}()
+	}
+
+	// Wait until every follower has joined the in-flight call.
+	for {
+		g.mu.Lock()
+		dups := g.calls["key"].dups
+		g.mu.Unlock()
+		if dups == n-1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != 42 {
+			t.Errorf("Do() = %d, want 42", v)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn called %d times, want 1", got)
+	}
+}
+
+// Test a panicking fn does not wedge later callers
+func TestDo_Panic(t *testing.T) {
+	var g Group[string]
+
+	func() {
+		defer func() { _ = recover() }()
+		g.Do("key", func() (string, error) { panic("boom") })
+	}()
+
+	v, err, _ := g.Do("key", func() (string, error) { return "ok", nil })
+	if v != "ok" || err != nil {
+		t.Errorf("Do() after panic = %q, %v, want %q, nil", v, err, "ok")
+	}
+}
+
+// Test DoChan delivers the result of fn and shares it with Do callers
+func TestDoChan(t *testing.T) {
+	var g Group[string]
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	ch := g.DoChan("key", func() (string, error) {
+		close(entered)
+		<-release
+		return "bar", nil
+	})
+	<-entered
+
+	done := make(chan string)
+	go func() {
+		v, _, _ := g.Do("key", func() (string, error) { return "other", nil })
+		done <- v
+	}()
+	for {
+		g.mu.Lock()
+		dups := g.calls["key"].dups
+		g.mu.Unlock()
+		if dups == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+
+	res := <-ch
+	if res.Val != "bar" || res.Err != nil || !res.Shared {
+		t.Errorf("DoChan() = %+v, want {bar <nil> true}", res)
+	}
+	if v := <-done; v != "bar" {
+		t.Errorf("Do() during DoChan = %q, want %q", v, "bar")
+	}
+}
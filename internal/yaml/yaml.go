@@ -0,0 +1,105 @@
+// Package yaml encodes JSON values as YAML block-style documents.
+//
+// Strings are written plain when YAML reads them back as the same string,
+// and double-quoted otherwise, so the output always decodes to the JSON
+// value it was made from.
+package yaml
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+)
+
+// Marshal encodes v as a YAML document. Object members keep their order.
+func Marshal(v jsonvalue.Value) []byte {
+	if !nested(v) {
+		return append(appendScalar(nil, v), '\n')
+	}
+	return appendBlock(nil, v, 0, false)
+}
+
+// nested reports whether v is written as a block: a non-empty array or
+// object.
+func nested(v jsonvalue.Value) bool {
+	return (v.Kind == jsonvalue.Array && len(v.Items) > 0) || (v.Kind == jsonvalue.Object && len(v.Members) > 0)
+}
+
+// appendBlock writes the array or object v indented by indent spaces. With
+// inline the indentation of the first line has already been written, as
+// after the "- " of a sequence entry.
+func appendBlock(b []byte, v jsonvalue.Value, indent int, inline bool) []byte {
+	pad := strings.Repeat(" ", indent)
+	for i := range max(len(v.Items), len(v.Members)) {
+		if i > 0 || !inline {
+			b = append(b, pad...)
+		}
+		var value jsonvalue.Value
+		if v.Kind == jsonvalue.Array {
+			value = v.Items[i]
+			b = append(b, '-')
+			if value.Kind == jsonvalue.Object && nested(value) {
+				b = appendBlock(append(b, ' '), value, indent+2, true)
+				continue
+			}
+		} else {
+			value = v.Members[i].Value
+			b = append(appendString(b, v.Members[i].Name), ':')
+		}
+		if nested(value) {
+			b = appendBlock(append(b, '\n'), value, indent+2, false)
+			continue
+		}
+		b = append(appendScalar(append(b, ' '), value), '\n')
+	}
+	return b
+}
+
+// appendScalar writes a scalar or an empty array or object in flow style.
+func appendScalar(b []byte, v jsonvalue.Value) []byte {
+	switch v.Kind {
+	case jsonvalue.Bool:
+		return strconv.AppendBool(b, v.Bool)
+	case jsonvalue.Number:
+		return append(b, v.Number...)
+	case jsonvalue.String:
+		return appendString(b, v.String)
+	case jsonvalue.Array:
+		return append(b, "[]"...)
+	case jsonvalue.Object:
+		return append(b, "{}"...)
+	}
+	return append(b, "null"...)
+}
+
+func appendString(b []byte, s string) []byte {
+	if plain(s) {
+		return append(b, s...)
+	}
+	return strconv.AppendQuote(b, s)
+}
+
+// plain reports whether s can be written unquoted. It is conservative:
+// strings that could read as another type, such as numbers, booleans, null
+// or timestamps, start with an indicator character or contain ": " or " #"
+// are quoted.
+func plain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`.+0123456789") {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return false
+	}
+	return true
+}
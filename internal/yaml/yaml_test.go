@@ -0,0 +1,62 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/ming-go/lab/get-container-id/internal/jsonvalue"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"scalar", `"abc"`, "abc\n"},
+		{"number", `42`, "42\n"},
+		{"empty object", `{}`, "{}\n"},
+		{"flat object", `{"b":1,"a":"x","c":null,"d":true}`, "b: 1\na: x\nc: null\nd: true\n"},
+		{"nested object", `{"data":{"id":"x","tags":{}}}`, "data:\n  id: x\n  tags: {}\n"},
+		{"list", `{"l":["a",2,[]]}`, "l:\n  - a\n  - 2\n  - []\n"},
+		{"list of objects", `[{"a":1,"b":{"c":2}},{"a":3}]`, "- a: 1\n  b:\n    c: 2\n- a: 3\n"},
+		{"nested list", `[[1,2],3]`, "-\n  - 1\n  - 2\n- 3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := jsonvalue.Parse([]byte(tt.json))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := string(Marshal(v)); got != tt.want {
+				t.Errorf("Marshal(%s) = %q, want %q", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshal_Quoting(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain text", "plain text\n"},
+		{"v1.0.0", "v1.0.0\n"},
+		{"", "\"\"\n"},
+		{"4b8e0f1c", "\"4b8e0f1c\"\n"},
+		{"2025-01-15T10:30:45Z", "\"2025-01-15T10:30:45Z\"\n"},
+		{"true", "\"true\"\n"},
+		{"No", "\"No\"\n"},
+		{"null", "\"null\"\n"},
+		{"-x", "\"-x\"\n"},
+		{"a: b", "\"a: b\"\n"},
+		{"a #b", "\"a #b\"\n"},
+		{" padded", "\" padded\"\n"},
+		{"two\nlines", "\"two\\nlines\"\n"},
+		{"*alias", "\"*alias\"\n"},
+	}
+	for _, tt := range tests {
+		if got := string(Marshal(jsonvalue.Value{Kind: jsonvalue.String, String: tt.s})); got != tt.want {
+			t.Errorf("Marshal(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package k8sclient is a minimal Kubernetes API client for reading the
+// current pod, using the in-cluster service account configuration.
+//
+// It decodes the subset of the Pod object that describes the pod's identity: metadata, owner references and
+// container statuses. The service account needs RBAC permission to get pods
+// in its namespace.
+package k8sclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ServiceAccountDir is where Kubernetes mounts the service account
+	// token and the cluster CA certificate.
+	ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// TokenPath is the service account token, rotated by the kubelet.
+	TokenPath = ServiceAccountDir + "/token"
+
+	// CAPath is the CA certificate of the API server.
+	CAPath = ServiceAccountDir + "/ca.crt"
+
+	// requestTimeout bounds each request when the caller's context has no
+	// deadline.
+	requestTimeout = 5 * time.Second
+)
+
+var (
+	// ErrNotInCluster is returned by NewInCluster outside of a pod.
+	ErrNotInCluster = errors.New("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+
+	// ErrNotFound is returned, wrapped, when the API server answers 404.
+	ErrNotFound = errors.New("not found")
+
+	// ErrForbidden is returned, wrapped, when the service account lacks the
+	// RBAC permission for a request.
+	ErrForbidden = errors.New("forbidden")
+
+	getenv = os.Getenv
+)
+
+// Client reads objects from the Kubernetes API server.
+type Client struct {
+	host      string
+	tokenPath string
+	client    *http.Client
+}
+
+// New returns a client for the API server at host, e.g.
+// "https://10.96.0.1:443", that authenticates with the bearer token in
+// tokenPath and sends requests with client. The token file is read for
+// every request, so rotated tokens are picked up. An empty tokenPath sends
+// no token.
+func New(host, tokenPath string, client *http.Client) *Client {
+	return &Client{host: strings.TrimSuffix(host, "/"), tokenPath: tokenPath, client: client}
+}
+
+// NewInCluster returns a client configured from the service account Kubernetes
+// mounts into every pod: the API server address from KUBERNETES_SERVICE_HOST
+// and KUBERNETES_SERVICE_PORT, the token at TokenPath and the CA at CAPath.
+func NewInCluster() (*Client, error) {
+	host, port := getenv("KUBERNETES_SERVICE_HOST"), getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+	if _, err := os.Stat(TokenPath); err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	ca, err := os.ReadFile(CAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse cluster CA %s", CAPath)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+	return New("https://"+net.JoinHostPort(host, port), TokenPath, client), nil
+}
+
+// GetPod returns the pod name in namespace.
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (Pod, error) {
+	var pod Pod
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods/" + url.PathEscape(name)
+	err := c.get(ctx, path, &pod)
+	return pod, err
+}
+
+// get decodes the JSON response of an API GET request into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.host+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.tokenPath != "" {
+		token, err := os.ReadFile(c.tokenPath)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query the Kubernetes API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(path, resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// statusError converts a non-200 response to an error, using the message of
+// the Status object the API server returns.
+func statusError(path string, resp *http.Response) error {
+	var status struct {
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(body, &status) != nil || status.Message == "" {
+		status.Message = resp.Status
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %w: %s", path, ErrNotFound, status.Message)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%s: %w: %s", path, ErrForbidden, status.Message)
+	}
+	return fmt.Errorf("%s: unexpected status %s: %s", path, resp.Status, status.Message)
+}
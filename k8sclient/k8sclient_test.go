@@ -0,0 +1,111 @@
+package k8sclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const podJSON = `{
+  "metadata": {
+    "name": "web-7d9f8b6c4-x2k4p",
+    "namespace": "default",
+    "uid": "9f1c2d3a-5b6e-4f70-8a9b-0c1d2e3f4a5b",
+    "labels": {"app": "web"},
+    "ownerReferences": [{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-7d9f8b6c4", "uid": "1a2b", "controller": true}],
+    "creationTimestamp": "2026-10-15T08:00:00Z"
+  },
+  "spec": {"nodeName": "node-1"},
+  "status": {
+    "phase": "Running",
+    "containerStatuses": [
+      {"name": "web", "containerID": "containerd://4b8e0f1c", "image": "web:1", "imageID": "sha256:ab", "ready": true, "restartCount": 2, "state": {"running": {"startedAt": "2026-10-15T08:00:05Z"}}},
+      {"name": "sidecar", "image": "sidecar:1", "imageID": "", "ready": false, "restartCount": 5, "state": {"waiting": {"reason": "CrashLoopBackOff"}}}
+    ]
+  }
+}`
+
+func writeToken(t *testing.T, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGetPod(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, `{"kind":"Status","message":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods/web-7d9f8b6c4-x2k4p":
+			w.Write([]byte(podJSON))
+		case "/api/v1/namespaces/restricted/pods/web":
+			http.Error(w, `{"kind":"Status","message":"pods \"web\" is forbidden: User \"system:serviceaccount:restricted:default\" cannot get resource \"pods\""}`, http.StatusForbidden)
+		default:
+			http.Error(w, `{"kind":"Status","message":"pods \"gone\" not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", writeToken(t, "secret"), srv.Client())
+	pod, err := c.GetPod(context.Background(), "default", "web-7d9f8b6c4-x2k4p")
+	if err != nil {
+		t.Fatalf("GetPod error = %v", err)
+	}
+	if pod.Metadata.UID != "9f1c2d3a-5b6e-4f70-8a9b-0c1d2e3f4a5b" || pod.Metadata.Labels["app"] != "web" || pod.Spec.NodeName != "node-1" {
+		t.Errorf("GetPod metadata = %+v, spec = %+v", pod.Metadata, pod.Spec)
+	}
+	if len(pod.Metadata.OwnerReferences) != 1 || pod.Metadata.OwnerReferences[0].Kind != "ReplicaSet" || !pod.Metadata.OwnerReferences[0].Controller {
+		t.Errorf("OwnerReferences = %+v, want the controlling ReplicaSet", pod.Metadata.OwnerReferences)
+	}
+	if len(pod.Status.ContainerStatuses) != 2 || pod.Status.ContainerStatuses[0].State.String() != "running" || pod.Status.ContainerStatuses[1].State.String() != "waiting: CrashLoopBackOff" {
+		t.Errorf("ContainerStatuses = %+v", pod.Status.ContainerStatuses)
+	}
+
+	if _, err := c.GetPod(context.Background(), "default", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPod(gone) error = %v, want ErrNotFound", err)
+	}
+	if _, err := c.GetPod(context.Background(), "restricted", "web"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("GetPod(restricted) error = %v, want ErrForbidden", err)
+	}
+
+	anonymous := New(srv.URL, "", srv.Client())
+	if _, err := anonymous.GetPod(context.Background(), "default", "web-7d9f8b6c4-x2k4p"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("GetPod without token error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestNewInClusterOutsideCluster(t *testing.T) {
+	origGetenv := getenv
+	defer func() { getenv = origGetenv }()
+	getenv = func(string) string { return "" }
+
+	if _, err := NewInCluster(); !errors.Is(err, ErrNotInCluster) {
+		t.Errorf("NewInCluster error = %v, want ErrNotInCluster", err)
+	}
+}
+
+func TestContainerStateString(t *testing.T) {
+	tests := []struct {
+		state ContainerState
+		want  string
+	}{
+		{ContainerState{}, "unknown"},
+		{ContainerState{Running: &ContainerStateRunning{}}, "running"},
+		{ContainerState{Waiting: &ContainerStateWaiting{}}, "waiting"},
+		{ContainerState{Terminated: &ContainerStateTerminated{Reason: "Completed"}}, "terminated: Completed"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package k8sclient
+
+import "time"
+
+// Pod is the subset of a core/v1 Pod that describes its identity.
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+	Status   PodStatus  `json:"status"`
+}
+
+// ObjectMeta is the subset of the object metadata used by Pod.
+type ObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	UID               string            `json:"uid"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+}
+
+// OwnerReference identifies the object that owns the pod, such as a
+// ReplicaSet, StatefulSet, DaemonSet or Job.
+type OwnerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller bool   `json:"controller,omitempty"`
+}
+
+// PodSpec is the subset of the pod spec used by Pod.
+type PodSpec struct {
+	NodeName           string `json:"nodeName,omitempty"`
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// PodStatus is the subset of the pod status used by Pod.
+type PodStatus struct {
+	Phase             string            `json:"phase"`
+	PodIP             string            `json:"podIP,omitempty"`
+	HostIP            string            `json:"hostIP,omitempty"`
+	StartTime         *time.Time        `json:"startTime,omitempty"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+}
+
+// ContainerStatus is the status of one container of the pod. ContainerID
+// has the form "<runtime>://<id>", e.g. "containerd://4b8e0f1c...".
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	ContainerID  string         `json:"containerID,omitempty"`
+	Image        string         `json:"image"`
+	ImageID      string         `json:"imageID"`
+	Ready        bool           `json:"ready"`
+	RestartCount int32          `json:"restartCount"`
+	State        ContainerState `json:"state"`
+}
+
+// ContainerState holds exactly one of Waiting, Running or Terminated.
+type ContainerState struct {
+	Waiting    *ContainerStateWaiting    `json:"waiting,omitempty"`
+	Running    *ContainerStateRunning    `json:"running,omitempty"`
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty"`
+}
+
+// ContainerStateWaiting is a container that has not started yet.
+type ContainerStateWaiting struct {
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ContainerStateRunning is a running container.
+type ContainerStateRunning struct {
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// ContainerStateTerminated is a container that has exited.
+type ContainerStateTerminated struct {
+	ExitCode int32  `json:"exitCode"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// String returns "running", "waiting" or "terminated", followed by the
+// reason if there is one, e.g. "waiting: CrashLoopBackOff".
+func (s ContainerState) String() string {
+	switch {
+	case s.Running != nil:
+		return "running"
+	case s.Waiting != nil:
+		return withReason("waiting", s.Waiting.Reason)
+	case s.Terminated != nil:
+		return withReason("terminated", s.Terminated.Reason)
+	}
+	return "unknown"
+}
+
+func withReason(state, reason string) string {
+	if reason == "" {
+		return state
+	}
+	return state + ": " + reason
+}
@@ -0,0 +1,125 @@
+package k8sclient
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/internal/jwt"
+)
+
+// ServiceAccount is the identity the mounted service account token claims.
+// The token's signature is not verified: the fields show what the token
+// says, which is what the API server checks, not that it is genuine.
+type ServiceAccount struct {
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Subject is the sub claim, system:serviceaccount:<namespace>:<name>.
+	Subject   string   `json:"subject,omitempty"`
+	Issuer    string   `json:"issuer,omitempty"`
+	Audiences []string `json:"audiences,omitempty"`
+	// PodName, PodUID and NodeName are set for bound tokens, which are
+	// invalidated when the pod is deleted.
+	PodName  string `json:"pod_name,omitempty"`
+	PodUID   string `json:"pod_uid,omitempty"`
+	NodeName string `json:"node_name,omitempty"`
+
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	// Expiry is nil for legacy secret-based tokens, which never expire.
+	Expiry *time.Time `json:"expiry,omitempty"`
+
+	// Projected reports whether the token is a projected, time-bound token
+	// rather than a legacy token from a service account secret.
+	Projected bool `json:"projected"`
+}
+
+// kubernetesClaims is the kubernetes.io claim of projected tokens.
+type kubernetesClaims struct {
+	Namespace      string `json:"namespace"`
+	ServiceAccount struct {
+		Name string `json:"name"`
+		UID  string `json:"uid"`
+	} `json:"serviceaccount"`
+	Pod struct {
+		Name string `json:"name"`
+		UID  string `json:"uid"`
+	} `json:"pod"`
+	Node struct {
+		Name string `json:"name"`
+	} `json:"node"`
+}
+
+// ReadServiceAccount reads the token and namespace files in dir, usually
+// ServiceAccountDir, and decodes the token's claims without verifying it.
+// It understands both projected tokens and legacy secret-based tokens, and
+// falls back to the namespace file when the token does not name one.
+func ReadServiceAccount(dir string) (ServiceAccount, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, "token"))
+	if err != nil {
+		return ServiceAccount{}, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	token, err := jwt.Decode(string(raw))
+	if err != nil {
+		return ServiceAccount{}, fmt.Errorf("failed to decode service account token: %w", err)
+	}
+
+	sa := ServiceAccount{
+		Subject:   token.String("sub"),
+		Issuer:    token.String("iss"),
+		Audiences: token.Audiences(),
+	}
+	sa.IssuedAt = claimTime(token, "iat")
+	sa.NotBefore = claimTime(token, "nbf")
+	sa.Expiry = claimTime(token, "exp")
+
+	if k8s, ok := token.Claims["kubernetes.io"]; ok {
+		var claims kubernetesClaims
+		b, _ := json.Marshal(k8s)
+		if err := json.Unmarshal(b, &claims); err != nil {
+			return ServiceAccount{}, fmt.Errorf("failed to decode the kubernetes.io claim: %w", err)
+		}
+		sa.Projected = true
+		sa.Namespace = claims.Namespace
+		sa.Name = claims.ServiceAccount.Name
+		sa.UID = claims.ServiceAccount.UID
+		sa.PodName = claims.Pod.Name
+		sa.PodUID = claims.Pod.UID
+		sa.NodeName = claims.Node.Name
+	} else {
+		sa.Namespace = token.String("kubernetes.io/serviceaccount/namespace")
+		sa.Name = token.String("kubernetes.io/serviceaccount/service-account.name")
+		sa.UID = token.String("kubernetes.io/serviceaccount/service-account.uid")
+	}
+
+	if sa.Name == "" || sa.Namespace == "" {
+		// system:serviceaccount:<namespace>:<name>
+		if rest, ok := strings.CutPrefix(sa.Subject, "system:serviceaccount:"); ok {
+			if ns, name, ok := strings.Cut(rest, ":"); ok {
+				sa.Namespace = cmp.Or(sa.Namespace, ns)
+				sa.Name = cmp.Or(sa.Name, name)
+			}
+		}
+	}
+	if sa.Namespace == "" {
+		b, err := os.ReadFile(filepath.Join(dir, "namespace"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return ServiceAccount{}, fmt.Errorf("failed to read service account namespace: %w", err)
+		}
+		sa.Namespace = strings.TrimSpace(string(b))
+	}
+	return sa, nil
+}
+
+func claimTime(token jwt.Token, name string) *time.Time {
+	if t, ok := token.Time(name); ok {
+		return &t
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package k8sclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeServiceAccount writes a token with claims, and the namespace file if
+// namespace is set, to a service account directory.
+func writeServiceAccount(t *testing.T, claims, namespace string) string {
+	t.Helper()
+	dir := t.TempDir()
+	enc := base64.RawURLEncoding
+	token := enc.EncodeToString([]byte(`{"alg":"RS256","kid":"k1"}`)) + "." + enc.EncodeToString([]byte(claims)) + "." + enc.EncodeToString([]byte("sig"))
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "" {
+		if err := os.WriteFile(filepath.Join(dir, "namespace"), []byte(namespace), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadServiceAccount_Projected(t *testing.T) {
+	dir := writeServiceAccount(t, `{
+		"aud": ["https://kubernetes.default.svc.cluster.local"],
+		"exp": 1792051200, "iat": 1792047600, "nbf": 1792047600,
+		"iss": "https://kubernetes.default.svc.cluster.local",
+		"sub": "system:serviceaccount:default:web",
+		"kubernetes.io": {
+			"namespace": "default",
+			"node": {"name": "node-1", "uid": "n1"},
+			"pod": {"name": "web-7d9f8b6c4-x2k4p", "uid": "p1"},
+			"serviceaccount": {"name": "web", "uid": "s1"}
+		}
+	}`, "ignored")
+
+	got, err := ReadServiceAccount(dir)
+	if err != nil {
+		t.Fatalf("ReadServiceAccount() error = %v", err)
+	}
+	exp := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	iat := exp.Add(-time.Hour)
+	want := ServiceAccount{
+		Name:      "web",
+		UID:       "s1",
+		Namespace: "default",
+		Subject:   "system:serviceaccount:default:web",
+		Issuer:    "https://kubernetes.default.svc.cluster.local",
+		Audiences: []string{"https://kubernetes.default.svc.cluster.local"},
+		PodName:   "web-7d9f8b6c4-x2k4p",
+		PodUID:    "p1",
+		NodeName:  "node-1",
+		IssuedAt:  &iat,
+		NotBefore: &iat,
+		Expiry:    &exp,
+		Projected: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadServiceAccount() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadServiceAccount_Legacy(t *testing.T) {
+	dir := writeServiceAccount(t, `{
+		"iss": "kubernetes/serviceaccount",
+		"kubernetes.io/serviceaccount/namespace": "tools",
+		"kubernetes.io/serviceaccount/secret.name": "builder-token-x7k2q",
+		"kubernetes.io/serviceaccount/service-account.name": "builder",
+		"kubernetes.io/serviceaccount/service-account.uid": "s2",
+		"sub": "system:serviceaccount:tools:builder"
+	}`, "")
+
+	got, err := ReadServiceAccount(dir)
+	if err != nil {
+		t.Fatalf("ReadServiceAccount() error = %v", err)
+	}
+	if got.Name != "builder" || got.Namespace != "tools" || got.UID != "s2" || got.Projected || got.Expiry != nil {
+		t.Errorf("ReadServiceAccount() = %+v, want the non-expiring legacy builder token", got)
+	}
+}
+
+func TestReadServiceAccount_NamespaceFallbacks(t *testing.T) {
+	dir := writeServiceAccount(t, `{"sub":"system:serviceaccount:ops:agent"}`, "")
+	if got, err := ReadServiceAccount(dir); err != nil || got.Name != "agent" || got.Namespace != "ops" {
+		t.Errorf("ReadServiceAccount() = %+v, %v; want agent in ops from the subject", got, err)
+	}
+
+	dir = writeServiceAccount(t, `{"sub":"someone"}`, "payments\n")
+	if got, err := ReadServiceAccount(dir); err != nil || got.Namespace != "payments" {
+		t.Errorf("ReadServiceAccount() = %+v, %v; want the namespace file", got, err)
+	}
+}
+
+func TestReadServiceAccount_Errors(t *testing.T) {
+	if _, err := ReadServiceAccount(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadServiceAccount() without a token error = %v, want ErrNotExist", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("not-a-jwt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadServiceAccount(dir); err == nil {
+		t.Error("ReadServiceAccount() with a malformed token error = nil, want an error")
+	}
+}
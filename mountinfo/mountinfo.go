@@ -0,0 +1,192 @@
+// Package mountinfo parses the mount table of a process, as the kernel
+// reports it in /proc/<pid>/mountinfo. See proc(5).
+//
+// The container and pod ID detectors, the cgroup inspector and the
+// /mounts endpoint all read the mount table through this package, so they
+// agree on field boundaries and on the octal escapes the kernel uses for
+// spaces and other special characters in paths.
+package mountinfo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Path is the mount table of the current process.
+const Path = "/proc/self/mountinfo"
+
+// maxLineSize bounds a single mountinfo line. Overlay mounts list every
+// lower layer in their super options and easily outgrow bufio's default.
+const maxLineSize = 1024 * 1024
+
+// ErrMalformedLine is returned, wrapped, when a line does not follow the
+// mountinfo format.
+var ErrMalformedLine = errors.New("malformed line")
+
+// Mount is one line of a mountinfo file.
+type Mount struct {
+	// MountID is unique for the mount, though it may be reused after an
+	// unmount.
+	MountID int `json:"mount_id"`
+	// ParentID is the mount this one is mounted on; the root of the mount
+	// namespace is its own parent.
+	ParentID int `json:"parent_id"`
+	// MajorMinor is the st_dev of files on the filesystem, e.g. "8:1".
+	MajorMinor string `json:"major_minor"`
+	// Root is the directory of the filesystem that forms the root of the
+	// mount; it is not "/" for bind mounts.
+	Root       string `json:"root"`
+	MountPoint string `json:"mount_point"`
+	// Options are the per-mount options, e.g. "rw,relatime".
+	Options string `json:"options"`
+	// OptionalFields are tagged fields such as "shared:1" or "master:2".
+	OptionalFields []string `json:"optional_fields,omitempty"`
+	FSType         string   `json:"fs_type"`
+	// Source is filesystem specific, e.g. a device or "overlay".
+	Source string `json:"source"`
+	// SuperOptions are the per-superblock options.
+	SuperOptions string `json:"super_options,omitempty"`
+}
+
+// ParseLine parses a single mountinfo line. Octal escapes such as \040 in
+// Root, MountPoint and Source are decoded. Lines with too few fields, a
+// missing "-" separator, or non-numeric mount IDs return ErrMalformedLine.
+func ParseLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 || len(fields) < sep+3 {
+		return Mount{}, fmt.Errorf("mountinfo: %w: %q", ErrMalformedLine, line)
+	}
+
+	mountID, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Mount{}, fmt.Errorf("mountinfo: %w: mount ID %q", ErrMalformedLine, fields[0])
+	}
+	parentID, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Mount{}, fmt.Errorf("mountinfo: %w: parent ID %q", ErrMalformedLine, fields[1])
+	}
+
+	m := Mount{
+		MountID:    mountID,
+		ParentID:   parentID,
+		MajorMinor: fields[2],
+		Root:       unescapeOctal(fields[3]),
+		MountPoint: unescapeOctal(fields[4]),
+		Options:    fields[5],
+		FSType:     fields[sep+1],
+		Source:     unescapeOctal(fields[sep+2]),
+	}
+	if sep > 6 {
+		m.OptionalFields = fields[6:sep]
+	}
+	if len(fields) > sep+3 {
+		m.SuperOptions = fields[sep+3]
+	}
+	return m, nil
+}
+
+// Scan calls fn for every mount in r, in order, until fn returns true.
+// Malformed lines are skipped; only read errors are returned.
+func Scan(r io.Reader, fn func(Mount) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		m, err := ParseLine(scanner.Text())
+		if err != nil {
+			continue
+		}
+		if fn(m) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Parse returns every mount in r. Malformed lines are skipped.
+func Parse(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+	err := Scan(r, func(m Mount) bool {
+		mounts = append(mounts, m)
+		return false
+	})
+	return mounts, err
+}
+
+// Paths returns the path fields of m that may name a host directory: Root,
+// Source and MountPoint.
+func (m Mount) Paths() []string {
+	return []string{m.Root, m.Source, m.MountPoint}
+}
+
+// String formats m as a mountinfo line, escaping the path fields the way
+// the kernel does, so it parses back to m.
+func (m Mount) String() string {
+	fields := []string{
+		strconv.Itoa(m.MountID),
+		strconv.Itoa(m.ParentID),
+		m.MajorMinor,
+		escapeOctal(m.Root),
+		escapeOctal(m.MountPoint),
+		m.Options,
+	}
+	fields = append(fields, m.OptionalFields...)
+	fields = append(fields, "-", m.FSType, escapeOctal(m.Source))
+	if m.SuperOptions != "" {
+		fields = append(fields, m.SuperOptions)
+	}
+	return strings.Join(fields, " ")
+}
+
+// escapeOctal is the inverse of unescapeOctal.
+func escapeOctal(s string) string {
+	if !strings.ContainsAny(s, " \t\n\\") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n', '\\':
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescapeOctal decodes the \ooo escapes the kernel uses for spaces, tabs,
+// newlines and backslashes in mountinfo paths. Invalid escapes are kept as is.
+func unescapeOctal(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && s[i+1] >= '0' && s[i+1] <= '3' && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			n := (s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0')
+			b.WriteByte(n)
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
@@ -0,0 +1,140 @@
+package mountinfo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	line := `36 35 98:0 /mnt\0401 /mnt2 rw,noatime master:1 shared:2 - ext3 /dev/root rw,errors=continue`
+	want := Mount{
+		MountID:        36,
+		ParentID:       35,
+		MajorMinor:     "98:0",
+		Root:           "/mnt 1",
+		MountPoint:     "/mnt2",
+		Options:        "rw,noatime",
+		OptionalFields: []string{"master:1", "shared:2"},
+		FSType:         "ext3",
+		Source:         "/dev/root",
+		SuperOptions:   "rw,errors=continue",
+	}
+
+	got, err := ParseLine(line)
+	if err != nil {
+		t.Fatalf("ParseLine returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseLine = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLineNoOptionalFields(t *testing.T) {
+	got, err := ParseLine("1235 1234 0:315 / /proc rw,nosuid - proc proc rw")
+	if err != nil {
+		t.Fatalf("ParseLine returned error: %v", err)
+	}
+	if got.OptionalFields != nil || got.FSType != "proc" || got.MountPoint != "/proc" {
+		t.Fatalf("ParseLine = %+v, want proc on /proc without optional fields", got)
+	}
+}
+
+func TestMountString(t *testing.T) {
+	for _, line := range []string{
+		`36 35 98:0 /mnt\0401 /mnt2 rw,noatime master:1 shared:2 - ext3 /dev/root rw,errors=continue`,
+		`1235 1234 0:315 / /proc rw,nosuid - proc proc rw`,
+		`40 36 0:50 /a\134b /data\011dir rw - nfs4 server:/export`,
+	} {
+		m, err := ParseLine(line)
+		if err != nil {
+			t.Fatalf("ParseLine(%q) returned error: %v", line, err)
+		}
+		if got := m.String(); got != line {
+			t.Errorf("ParseLine(%q).String() = %q", line, got)
+		}
+	}
+}
+
+func TestUnescapeOctal(t *testing.T) {
+	tests := map[string]string{
+		`/a\040b`:  "/a b",
+		`/a\011b`:  "/a\tb",
+		`/a\134b`:  `/a\b`,
+		`/a\9b`:    `/a\9b`,
+		`/a\04`:    `/a\04`,
+		`/a\777`:   `/a\777`,
+		`/no/esc`:  "/no/esc",
+		`trail\\`:  `trail\\`,
+		`\040\040`: "  ",
+	}
+	for in, want := range tests {
+		if got := unescapeOctal(in); got != want {
+			t.Errorf("unescapeOctal(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func FuzzParseLine(f *testing.F) {
+	f.Add(`36 35 98:0 /mnt\0401 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue`)
+	f.Add("1235 1234 0:315 / /proc rw,nosuid - proc proc rw")
+	f.Add("36 35 98:0 /a /b rw - ext3")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		m, err := ParseLine(line)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedLine) {
+				t.Fatalf("ParseLine(%q) error = %v, want ErrMalformedLine", line, err)
+			}
+			return
+		}
+		if m.MajorMinor == "" || m.Options == "" || m.FSType == "" {
+			t.Fatalf("ParseLine(%q) = %+v, required fields empty", line, m)
+		}
+	})
+}
+
+func TestParseLineMalformed(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"36 35 98:0 /mnt1 /mnt2 rw",
+		"36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 ext3 /dev/root rw",
+		"36 35 98:0 /mnt1 /mnt2 rw - ext3",
+		"x 35 98:0 /mnt1 /mnt2 rw - ext3 /dev/root rw",
+		"36 y 98:0 /mnt1 /mnt2 rw - ext3 /dev/root rw",
+	} {
+		if _, err := ParseLine(line); !errors.Is(err, ErrMalformedLine) {
+			t.Errorf("ParseLine(%q) error = %v, want ErrMalformedLine", line, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := "1 0 8:1 / / rw - ext4 /dev/sda1 rw\n" +
+		"garbage\n" +
+		"2 1 0:5 / /dev rw,nosuid shared:2 - devtmpfs devtmpfs rw\n" +
+		"3 1 0:6 / /sys/fs/cgroup rw - cgroup2 cgroup2 rw," + strings.Repeat("x", 100*1024) + "\n"
+
+	mounts, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	var points []string
+	for _, m := range mounts {
+		points = append(points, m.MountPoint)
+	}
+	if want := []string{"/", "/dev", "/sys/fs/cgroup"}; !reflect.DeepEqual(points, want) {
+		t.Errorf("Parse mount points = %v, want %v without the malformed line", points, want)
+	}
+
+	var seen int
+	err = Scan(strings.NewReader(input), func(m Mount) bool {
+		seen++
+		return m.FSType == "devtmpfs"
+	})
+	if err != nil || seen != 2 {
+		t.Errorf("Scan stopped after %d mounts, error %v; want 2, nil", seen, err)
+	}
+}
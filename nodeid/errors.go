@@ -0,0 +1,40 @@
+package nodeid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceError records why one source did not yield a value.
+type SourceError struct {
+	// Source is the environment variable or file that was read.
+	Source string
+	Err    error
+}
+
+// DetectionError is returned when no source yielded a value. Retrieve it
+// with errors.As to log every source that was read and why each one failed.
+// errors.Is still matches the underlying per-source errors.
+type DetectionError struct {
+	Sources []SourceError
+
+	// what names the value that was not found.
+	what string
+}
+
+func (e *DetectionError) Error() string {
+	parts := make([]string, len(e.Sources))
+	for i, s := range e.Sources {
+		parts[i] = fmt.Sprintf("%s: %v", s.Source, s.Err)
+	}
+	return "nodeid: " + e.what + " not detected (scanned " + strings.Join(parts, "; ") + ")"
+}
+
+// Unwrap returns the per-source errors.
+func (e *DetectionError) Unwrap() []error {
+	errs := make([]error, len(e.Sources))
+	for i, s := range e.Sources {
+		errs[i] = s.Err
+	}
+	return errs
+}
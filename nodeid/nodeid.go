@@ -0,0 +1,192 @@
+// Package nodeid provides utilities to identify the node the current process
+// runs on: the Kubernetes node name and the host's machine ID.
+//
+// The node name comes from the Downward API NODE_NAME variable, or from the
+// kubelet's client certificate when the node's /var/lib/kubelet is mounted,
+// as in host agents. The machine ID comes from /etc/machine-id.
+package nodeid
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// KubeletClientCertPath is the kubelet's rotated client certificate,
+	// whose common name is "system:node:<node name>".
+	KubeletClientCertPath = "/var/lib/kubelet/pki/kubelet-client-current.pem"
+
+	// MachineIDPath is the systemd machine ID file.
+	MachineIDPath = "/etc/machine-id"
+
+	// DBusMachineIDPath is the D-Bus machine ID, used by hosts without systemd.
+	DBusMachineIDPath = "/var/lib/dbus/machine-id"
+
+	// kubeletNodePrefix prefixes the node name in a kubelet certificate.
+	kubeletNodePrefix = "system:node:"
+)
+
+var (
+	// ErrNodeNameNotFound is returned when no source holds the node name.
+	ErrNodeNameNotFound = errors.New("node name not found")
+
+	// ErrMachineIDNotFound is returned when no machine ID file is readable.
+	ErrMachineIDNotFound = errors.New("machine ID not found")
+
+	// reMachineID matches the 32 lowercase hex characters of a machine ID.
+	reMachineID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	getenv = os.Getenv
+
+	// rootFS is the host filesystem that lookups read from by default.
+	rootFS fs.FS = os.DirFS("/")
+
+	nodeName  cachedValue
+	machineID cachedValue
+
+	nodeNameSources = []source{
+		{name: "env:NODE_NAME", read: readEnv("NODE_NAME")},
+		{name: KubeletClientCertPath, read: readKubeletCert},
+	}
+	machineIDSources = []source{
+		{name: MachineIDPath, read: readMachineID(MachineIDPath)},
+		{name: DBusMachineIDPath, read: readMachineID(DBusMachineIDPath)},
+	}
+)
+
+// Get returns an identifier for the node: the node name if one is found,
+// otherwise the machine ID, so processes outside Kubernetes still get a
+// stable host identity. If neither is found the error joins both failures.
+func Get(opts ...Option) (string, error) {
+	name, nameErr := GetNodeName(opts...)
+	if nameErr == nil {
+		return name, nil
+	}
+	id, idErr := GetMachineID(opts...)
+	if idErr == nil {
+		return id, nil
+	}
+	return "", errors.Join(nameErr, idErr)
+}
+
+// GetNodeName returns the Kubernetes node name from the NODE_NAME
+// environment variable, set with a spec.nodeName fieldRef, or from the
+// common name of the kubelet client certificate. The result is cached after
+// the first successful call. Failures are reported as a *DetectionError
+// matching ErrNodeNameNotFound.
+func GetNodeName(opts ...Option) (string, error) {
+	return nodeName.get(newOptions(opts), "node name", nodeNameSources, ErrNodeNameNotFound)
+}
+
+// GetMachineID returns the host's machine ID from /etc/machine-id, or
+// /var/lib/dbus/machine-id. Inside a container these are the image's files
+// unless the host's are mounted, for example with WithFS. The result is
+// cached after the first successful call. Failures are reported as a
+// *DetectionError matching ErrMachineIDNotFound.
+func GetMachineID(opts ...Option) (string, error) {
+	return machineID.get(newOptions(opts), "machine ID", machineIDSources, ErrMachineIDNotFound)
+}
+
+// source is one place a value can be read from. read returns an error
+// wrapping notFound if the source exists but holds no value.
+type source struct {
+	name string
+	read func(fsys fs.FS, notFound error) (string, error)
+}
+
+func readEnv(key string) func(fs.FS, error) (string, error) {
+	return func(_ fs.FS, notFound error) (string, error) {
+		if v := strings.TrimSpace(getenv(key)); v != "" {
+			return v, nil
+		}
+		return "", notFound
+	}
+}
+
+func readMachineID(path string) func(fs.FS, error) (string, error) {
+	return func(fsys fs.FS, notFound error) (string, error) {
+		data, err := fs.ReadFile(fsys, strings.TrimPrefix(path, "/"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		// An empty or "uninitialized" file means systemd has not set it yet.
+		if v := strings.TrimSpace(string(data)); reMachineID.MatchString(v) {
+			return v, nil
+		}
+		return "", notFound
+	}
+}
+
+func readKubeletCert(fsys fs.FS, notFound error) (string, error) {
+	data, err := fs.ReadFile(fsys, strings.TrimPrefix(KubeletClientCertPath, "/"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", KubeletClientCertPath, err)
+	}
+	// The file holds the certificate and its private key.
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", KubeletClientCertPath, err)
+		}
+		if name, ok := strings.CutPrefix(cert.Subject.CommonName, kubeletNodePrefix); ok && name != "" {
+			return name, nil
+		}
+	}
+	return "", notFound
+}
+
+// cachedValue caches the first value found by get.
+type cachedValue struct {
+	mu    sync.RWMutex
+	value string
+	ok    bool
+}
+
+// get returns the cached value, or tries sources in order and caches the
+// first value found. Lookups through a custom filesystem bypass the cache.
+func (c *cachedValue) get(o options, what string, sources []source, notFound error) (string, error) {
+	if o.fsys == nil {
+		c.mu.RLock()
+		value, ok := c.value, c.ok
+		c.mu.RUnlock()
+		if ok {
+			o.debug("nodeid: using cached "+what, slog.String("value", value))
+			return value, nil
+		}
+	}
+
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+
+	var failed []SourceError
+	for _, s := range sources {
+		v, err := s.read(fsys, notFound)
+		if err != nil {
+			o.debug("nodeid: source failed", slog.String("source", s.name), slog.Any("error", err))
+			failed = append(failed, SourceError{Source: s.name, Err: err})
+			continue
+		}
+		o.debug("nodeid: source found "+what, slog.String("source", s.name), slog.String("value", v))
+
+		if o.fsys == nil {
+			c.mu.Lock()
+			c.value, c.ok = v, true
+			c.mu.Unlock()
+		}
+		return v, nil
+	}
+	return "", &DetectionError{Sources: failed, what: what}
+}
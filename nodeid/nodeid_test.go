@@ -0,0 +1,145 @@
+package nodeid
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const testMachineID = "0123456789abcdef0123456789abcdef"
+
+// resetTestState clears the caches and stubs the environment with env and
+// the root filesystem with files.
+func resetTestState(env map[string]string, files fstest.MapFS) func() {
+	origGetenv, origRoot := getenv, rootFS
+	nodeName, machineID = cachedValue{}, cachedValue{}
+	getenv = func(key string) string { return env[key] }
+	rootFS = files
+
+	return func() {
+		getenv, rootFS = origGetenv, origRoot
+		nodeName, machineID = cachedValue{}, cachedValue{}
+	}
+}
+
+// kubeletCert returns a PEM private key and certificate with common name cn,
+// laid out like kubelet-client-current.pem.
+func kubeletCert(t *testing.T, cn string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"system:nodes"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	return append(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+}
+
+func TestGetNodeNameSources(t *testing.T) {
+	cert := kubeletCert(t, "system:node:worker-1")
+	tests := []struct {
+		name    string
+		env     map[string]string
+		files   fstest.MapFS
+		want    string
+		wantErr error
+	}{
+		{"env", map[string]string{"NODE_NAME": "from-env"}, fstest.MapFS{"var/lib/kubelet/pki/kubelet-client-current.pem": {Data: cert}}, "from-env", nil},
+		{"kubelet certificate", nil, fstest.MapFS{"var/lib/kubelet/pki/kubelet-client-current.pem": {Data: cert}}, "worker-1", nil},
+		{"foreign certificate", nil, fstest.MapFS{"var/lib/kubelet/pki/kubelet-client-current.pem": {Data: kubeletCert(t, "admin")}}, "", ErrNodeNameNotFound},
+		{"none", nil, fstest.MapFS{}, "", fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := resetTestState(tt.env, tt.files)
+			defer restore()
+
+			got, err := GetNodeName()
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetNodeName() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetMachineID(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    string
+		wantErr error
+	}{
+		{"systemd", fstest.MapFS{"etc/machine-id": {Data: []byte(testMachineID + "\n")}}, testMachineID, nil},
+		{"dbus", fstest.MapFS{"etc/machine-id": {Data: []byte("uninitialized\n")}, "var/lib/dbus/machine-id": {Data: []byte(testMachineID)}}, testMachineID, nil},
+		{"empty", fstest.MapFS{"etc/machine-id": {Data: []byte("")}}, "", ErrMachineIDNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := resetTestState(nil, tt.files)
+			defer restore()
+
+			got, err := GetMachineID()
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetMachineID() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetFallsBackToMachineID(t *testing.T) {
+	restore := resetTestState(nil, fstest.MapFS{"etc/machine-id": {Data: []byte(testMachineID)}})
+	defer restore()
+
+	if got, err := Get(); err != nil || got != testMachineID {
+		t.Fatalf("Get() = %q, %v, want machine ID %q", got, err, testMachineID)
+	}
+
+	rootFS = fstest.MapFS{}
+	_, err := Get(WithFS(rootFS))
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get(WithFS(empty)) error = %v, want a *DetectionError matching fs.ErrNotExist", err)
+	}
+}
+
+func TestGetNodeNameCachesSuccessfulResult(t *testing.T) {
+	env := map[string]string{}
+	restore := resetTestState(env, fstest.MapFS{})
+	defer restore()
+
+	if _, err := GetNodeName(); err == nil {
+		t.Fatal("GetNodeName() with no sources returned nil error")
+	}
+
+	env["NODE_NAME"] = "node-a"
+	if got, err := GetNodeName(); err != nil || got != "node-a" {
+		t.Fatalf("GetNodeName() = %q, %v, want node-a", got, err)
+	}
+	env["NODE_NAME"] = "node-b"
+	if got, _ := GetNodeName(); got != "node-a" {
+		t.Errorf("GetNodeName() = %q, want cached node-a", got)
+	}
+}
@@ -0,0 +1,48 @@
+package nodeid
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+)
+
+// Option configures a call to Get, GetNodeName or GetMachineID.
+type Option func(*options)
+
+type options struct {
+	logger *slog.Logger
+	fsys   fs.FS
+}
+
+// WithLogger makes lookups emit debug-level records about the sources they
+// read and what they found. Without it, lookups do not log.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithFS makes lookups read files from fsys instead of the host root, for
+// example os.DirFS("/host") in a DaemonSet that mounts the node's root
+// filesystem. Results read through a custom filesystem are not cached.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// debug logs msg at debug level if a logger was configured.
+func (o options) debug(msg string, attrs ...slog.Attr) {
+	if o.logger == nil {
+		return
+	}
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
@@ -0,0 +1,99 @@
+// Package otel is an OpenTelemetry SDK resource detector for the detected
+// container and pod identity, using the semantic convention attributes
+// container.id, k8s.pod.uid, k8s.pod.name and k8s.namespace.name.
+//
+// Register Detector with resource.WithDetectors, or format the attributes
+// for the OTEL_RESOURCE_ATTRIBUTES environment variable with Encode.
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+
+	"github.com/ming-go/lab/get-container-id/containerid"
+	"github.com/ming-go/lab/get-container-id/podid"
+)
+
+// Lookups used by Attributes; tests replace them.
+var (
+	containerIDFunc = func(ctx context.Context) (string, error) { return containerid.GetContext(ctx) }
+	podUIDFunc      = func(ctx context.Context) (string, error) { return podid.GetContext(ctx) }
+	podNameFunc     = func() (string, error) { return podid.GetPodName() }
+	namespaceFunc   = func() (string, error) { return podid.GetNamespace() }
+)
+
+// Detector is a resource.Detector for the container and pod identity.
+type Detector struct{}
+
+var _ resource.Detector = Detector{}
+
+// Detect returns a resource holding the attributes that could be detected,
+// with the schema URL of the semantic conventions the SDK uses, so it merges
+// with resource.Default. If ctx is done it returns the attributes detected
+// so far and ctx.Err().
+func (Detector) Detect(ctx context.Context) (*resource.Resource, error) {
+	attrs, err := Attributes(ctx)
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), err
+}
+
+// Attributes returns the resource attributes that could be detected, in the
+// order container.id, k8s.pod.uid, k8s.pod.name, k8s.namespace.name.
+// Attributes that cannot be detected are left out, as OpenTelemetry
+// detectors do outside the environment they describe, so the result is
+// empty outside a container. The error is non-nil only if ctx is done.
+func Attributes(ctx context.Context) ([]attribute.KeyValue, error) {
+	lookups := []struct {
+		attr   func(string) attribute.KeyValue
+		detect func() (string, error)
+	}{
+		{semconv.ContainerID, func() (string, error) { return containerIDFunc(ctx) }},
+		{semconv.K8SPodUID, func() (string, error) { return podUIDFunc(ctx) }},
+		{semconv.K8SPodName, podNameFunc},
+		{semconv.K8SNamespaceName, namespaceFunc},
+	}
+
+	var attrs []attribute.KeyValue
+	for _, l := range lookups {
+		if err := ctx.Err(); err != nil {
+			return attrs, err
+		}
+		if value, err := l.detect(); err == nil && value != "" {
+			attrs = append(attrs, l.attr(value))
+		}
+	}
+	return attrs, nil
+}
+
+// Encode formats attrs as the value of OTEL_RESOURCE_ATTRIBUTES:
+// comma-separated key=value pairs with the values percent-encoded, e.g.
+// "container.id=4b8e...,k8s.pod.name=web-0".
+func Encode(attrs []attribute.KeyValue) string {
+	pairs := make([]string, len(attrs))
+	for i, a := range attrs {
+		pairs[i] = string(a.Key) + "=" + escape(a.Value.Emit())
+	}
+	return strings.Join(pairs, ",")
+}
+
+// escape percent-encodes the characters that may not appear unescaped in an
+// OTEL_RESOURCE_ATTRIBUTES value: controls, whitespace, '"', ',', ';', '\\',
+// '%' and non-ASCII bytes.
+func escape(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`",;\%`, c) >= 0 {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
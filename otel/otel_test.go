@@ -0,0 +1,99 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+)
+
+func stubLookups(t *testing.T, containerID, podUID, podName, namespace string) {
+	t.Helper()
+	origContainer, origUID, origName, origNamespace := containerIDFunc, podUIDFunc, podNameFunc, namespaceFunc
+	t.Cleanup(func() {
+		containerIDFunc, podUIDFunc, podNameFunc, namespaceFunc = origContainer, origUID, origName, origNamespace
+	})
+
+	value := func(v string) (string, error) {
+		if v == "" {
+			return "", errors.New("not found")
+		}
+		return v, nil
+	}
+	containerIDFunc = func(context.Context) (string, error) { return value(containerID) }
+	podUIDFunc = func(context.Context) (string, error) { return value(podUID) }
+	podNameFunc = func() (string, error) { return value(podName) }
+	namespaceFunc = func() (string, error) { return value(namespace) }
+}
+
+func TestAttributes(t *testing.T) {
+	stubLookups(t, "4b8e0f1c", "9f1c2d3a-5b6e-4f70-8a9b-0c1d2e3f4a5b", "web-0", "default")
+
+	got, err := Attributes(context.Background())
+	want := []attribute.KeyValue{
+		semconv.ContainerID("4b8e0f1c"),
+		semconv.K8SPodUID("9f1c2d3a-5b6e-4f70-8a9b-0c1d2e3f4a5b"),
+		semconv.K8SPodName("web-0"),
+		semconv.K8SNamespaceName("default"),
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes = %v, %v, want %v, nil", got, err, want)
+	}
+}
+
+func TestAttributesPartial(t *testing.T) {
+	stubLookups(t, "4b8e0f1c", "", "", "")
+
+	got, err := Attributes(context.Background())
+	if err != nil || !reflect.DeepEqual(got, []attribute.KeyValue{semconv.ContainerID("4b8e0f1c")}) {
+		t.Errorf("Attributes = %v, %v, want only container.id", got, err)
+	}
+}
+
+func TestAttributesCanceled(t *testing.T) {
+	stubLookups(t, "4b8e0f1c", "", "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Attributes(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Attributes error = %v, want context.Canceled", err)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	stubLookups(t, "4b8e0f1c", "", "web-0", "")
+
+	res, err := resource.New(context.Background(), resource.WithDetectors(Detector{}))
+	if err != nil {
+		t.Fatalf("resource.New error = %v", err)
+	}
+	if got := res.SchemaURL(); got != semconv.SchemaURL {
+		t.Errorf("SchemaURL = %q, want %q", got, semconv.SchemaURL)
+	}
+	want := []attribute.KeyValue{semconv.ContainerID("4b8e0f1c"), semconv.K8SPodName("web-0")}
+	if got := res.Attributes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Attributes = %v, want %v", got, want)
+	}
+
+	merged, err := resource.Merge(resource.Default(), res)
+	if err != nil {
+		t.Errorf("Merge with resource.Default error = %v", err)
+	}
+	if v, ok := merged.Set().Value(semconv.ContainerIDKey); !ok || v.AsString() != "4b8e0f1c" {
+		t.Errorf("merged container.id = %v, %v, want 4b8e0f1c", v, ok)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got := Encode([]attribute.KeyValue{
+		semconv.ContainerID("4b8e0f1c"),
+		semconv.K8SPodName("web 0,1;%"),
+	})
+	if want := "container.id=4b8e0f1c,k8s.pod.name=web%200%2C1%3B%25"; got != want {
+		t.Errorf("Encode = %q, want %q", got, want)
+	}
+}
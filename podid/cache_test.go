@@ -0,0 +1,179 @@
+package podid
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubLookup makes every detection return a new ID and counts the calls.
+func stubLookup(calls *int) {
+	getPodIDFunc = func() (string, error) {
+		*calls++
+		return "id-" + strconv.Itoa(*calls), nil
+	}
+}
+
+func TestReset(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls int
+	stubLookup(&calls)
+
+	first, _ := Get()
+	Reset()
+	second, err := Get()
+	if err != nil {
+		t.Fatalf("Get after Reset returned error: %v", err)
+	}
+	if calls != 2 || first == second {
+		t.Errorf("Get after Reset = %q (first %q) with %d lookups, want a new ID from 2 lookups", second, first, calls)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls int
+	stubLookup(&calls)
+
+	Get()
+	got, err := Refresh()
+	if err != nil || got != "id-2" {
+		t.Fatalf("Refresh() = %q, %v, want %q", got, err, "id-2")
+	}
+	if got, _ := Get(); got != "id-2" || calls != 2 {
+		t.Errorf("Get after Refresh = %q with %d lookups, want the refreshed ID from the cache", got, calls)
+	}
+}
+
+func TestSetCacheTTL(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls int
+	stubLookup(&calls)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	SetCacheTTL(time.Minute)
+
+	Get()
+	clock = clock.Add(59 * time.Second)
+	if got, _ := Get(); got != "id-1" || calls != 1 {
+		t.Fatalf("Get before expiry = %q with %d lookups, want the cached ID", got, calls)
+	}
+	clock = clock.Add(time.Second)
+	if got, _ := Get(); got != "id-2" || calls != 2 {
+		t.Errorf("Get after expiry = %q with %d lookups, want a new lookup", got, calls)
+	}
+}
+
+func TestResetDiscardsInFlightLookup(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	getPodIDFunc = func() (string, error) {
+		close(started)
+		<-release
+		return "stale", nil
+	}
+	done := make(chan struct{})
+	go func() {
+		Get()
+		close(done)
+	}()
+	<-started
+	Reset()
+	close(release)
+	<-done
+
+	mu.RLock()
+	defer mu.RUnlock()
+	if hasID {
+		t.Errorf("lookup started before Reset was cached as %q", cachedID)
+	}
+}
+
+// blockingLookup makes detection wait for release and counts the calls;
+// entered is closed once the first detection has started.
+func blockingLookup(calls *atomic.Int32) (entered, release chan struct{}) {
+	entered, release = make(chan struct{}), make(chan struct{})
+	getPodIDFunc = func() (string, error) {
+		if calls.Add(1) == 1 {
+			close(entered)
+		}
+		<-release
+		return "id", nil
+	}
+	return entered, release
+}
+
+// burst runs n concurrent calls of fn, releasing the blocked detection once
+// the first one has started, and waits for all of them.
+func burst(n int, fn func() (string, error), entered, release chan struct{}) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = fn()
+		}()
+	}
+	<-entered
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return errs
+}
+
+func TestRefreshDeduplicatesConcurrentCalls(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls atomic.Int32
+	entered, release := blockingLookup(&calls)
+	for _, err := range burst(50, func() (string, error) { return Refresh() }, entered, release) {
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Refresh ran detection %d times, want 1", got)
+	}
+}
+
+func TestGetDeduplicatesAfterExpiry(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls atomic.Int32
+	var clockMu sync.Mutex
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return clock
+	}
+	SetCacheTTL(time.Minute)
+	getPodIDFunc = func() (string, error) { return "id", nil }
+	Get()
+
+	clockMu.Lock()
+	clock = clock.Add(time.Minute)
+	clockMu.Unlock()
+	entered, release := blockingLookup(&calls)
+	for _, err := range burst(50, func() (string, error) { return Get() }, entered, release) {
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Get after expiry ran detection %d times, want 1", got)
+	}
+}
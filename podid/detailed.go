@@ -0,0 +1,43 @@
+package podid
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Detection describes where a pod ID was found, so a wrong or missing ID
+// can be reported with the line that produced it.
+type Detection struct {
+	ID string
+	// Provider is "mountinfo", the only source of the pod ID. It is empty if
+	// the mount table no longer holds the cached ID.
+	Provider string
+	// Source is the file the ID was read from.
+	Source string
+	// Line is the mountinfo line the ID was found in.
+	Line string
+}
+
+// GetDetailed is like GetContext but also reports where the pod ID came
+// from. The ID is the one Get returns, cached or not; the mount table is
+// then read again to find the line holding it. WithFS applies as it does
+// for Get.
+func GetDetailed(ctx context.Context, opts ...Option) (Detection, error) {
+	id, err := GetContext(ctx, opts...)
+	if err != nil {
+		return Detection{}, err
+	}
+
+	o := newOptions(opts)
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+	d, err := detectFS(fsys, mountInfoName)
+	if err != nil || d.ID != id {
+		return Detection{ID: id}, nil
+	}
+	d.Provider, d.Source = "mountinfo", MountInfoPath
+	o.debug("podid: found pod ID source", slog.String("line", d.Line))
+	return d, nil
+}
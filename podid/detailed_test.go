@@ -0,0 +1,36 @@
+package podid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetDetailed(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+
+	line := "29 37 0:25 /var/lib/kubelet/pods/036da4f7-d553-4eb6-9802-90f81041a412/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw"
+	fsys := fstest.MapFS{"proc/self/mountinfo": {Data: []byte("1 0 8:1 / / rw - ext4 /dev/sda1 rw\n" + line + "\n")}}
+
+	got, err := GetDetailed(context.Background(), WithFS(fsys))
+	want := Detection{ID: "036da4f7-d553-4eb6-9802-90f81041a412", Provider: "mountinfo", Source: MountInfoPath, Line: line}
+	if err != nil || got != want {
+		t.Errorf("GetDetailed = %+v, %v, want %+v", got, err, want)
+	}
+
+	// A cached ID the mount table no longer holds has no source.
+	getPodIDFunc = func() (string, error) { return "cached", nil }
+	rootFS = fstest.MapFS{}
+	got, err = GetDetailed(context.Background())
+	if err != nil || got != (Detection{ID: "cached"}) {
+		t.Errorf("GetDetailed of a stale cache = %+v, %v, want only the ID", got, err)
+	}
+
+	if _, err := GetDetailed(context.Background(), WithFS(fstest.MapFS{"proc/self/mountinfo": {Data: []byte("1 0 8:1 / / rw - ext4 /dev/sda1 rw\n")}})); !errors.Is(err, ErrPodIDNotFound) {
+		t.Errorf("GetDetailed without a kubelet mount error = %v, want ErrPodIDNotFound", err)
+	}
+}
@@ -0,0 +1,63 @@
+package podid
+
+import (
+	"context"
+	"time"
+)
+
+// ProviderResult is the outcome of one source read by Diagnose.
+type ProviderResult struct {
+	// What names the value looked up: "pod ID", "pod name", "pod
+	// namespace" or "node name".
+	What string
+	// Source is the file read, or env:<NAME> for an environment variable.
+	Source string
+	// Value is what the source held, empty if Err is set.
+	Value   string
+	Err     error
+	Elapsed time.Duration
+}
+
+// Diagnose reads every source of the pod ID, pod name, namespace and node
+// name, including those after the first one that holds a value, and reports
+// what each returned. It bypasses the caches and does not record metrics.
+// WithFS applies as it does for Get.
+func Diagnose(ctx context.Context, opts ...Option) []ProviderResult {
+	o := newOptions(opts)
+	fsys, podID := o.fsys, getPodIDFunc
+	if fsys != nil {
+		podID = func() (string, error) { return GetFromFS(fsys, mountInfoName) }
+	} else {
+		fsys = rootFS
+	}
+	results := []ProviderResult{diagnose(ctx, "pod ID", MountInfoPath, podID)}
+
+	for _, v := range []struct {
+		what     string
+		sources  []valueSource
+		notFound error
+	}{
+		{"pod name", podNameSources, ErrPodNameNotFound},
+		{"pod namespace", namespaceSources, ErrNamespaceNotFound},
+		{"node name", nodeNameSources, ErrNodeNameNotFound},
+	} {
+		for _, s := range v.sources {
+			results = append(results, diagnose(ctx, v.what, s.String(), func() (string, error) {
+				return s.read(fsys, v.notFound)
+			}))
+		}
+	}
+	return results
+}
+
+// diagnose times one lookup, skipping it once ctx is done.
+func diagnose(ctx context.Context, what, source string, lookup func() (string, error)) ProviderResult {
+	r := ProviderResult{What: what, Source: source}
+	if r.Err = ctx.Err(); r.Err != nil {
+		return r
+	}
+	start := time.Now()
+	r.Value, r.Err = lookup()
+	r.Elapsed = time.Since(start)
+	return r
+}
@@ -0,0 +1,46 @@
+package podid
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiagnose(t *testing.T) {
+	origGetenv := getenv
+	defer func() { getenv = origGetenv }()
+	getenv = func(key string) string {
+		if key == "POD_NAME" {
+			return "web-0"
+		}
+		return ""
+	}
+
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("2000 1999 0:50 /var/lib/kubelet/pods/036da4f7-d553-4eb6-9802-90f81041a412/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n")},
+		"etc/podinfo/name":    {Data: []byte("web-0\n")},
+	}
+	results := Diagnose(context.Background(), WithFS(fsys))
+
+	type key struct{ what, source string }
+	got := make(map[key]ProviderResult)
+	for _, r := range results {
+		got[key{r.What, r.Source}] = r
+	}
+	if r := got[key{"pod ID", MountInfoPath}]; r.Value != "036da4f7-d553-4eb6-9802-90f81041a412" || r.Err != nil {
+		t.Errorf("pod ID result = %+v", r)
+	}
+	if r := got[key{"pod name", "env:POD_NAME"}]; r.Value != "web-0" {
+		t.Errorf("POD_NAME result = %+v", r)
+	}
+	if r := got[key{"pod name", PodInfoDir + "/name"}]; r.Value != "web-0" {
+		t.Errorf("Downward API name result = %+v, want it read after POD_NAME matched", r)
+	}
+	if r := got[key{"node name", "env:NODE_NAME"}]; !errors.Is(r.Err, ErrNodeNameNotFound) {
+		t.Errorf("NODE_NAME result = %+v, want ErrNodeNameNotFound", r)
+	}
+	if want := 1 + len(podNameSources) + len(namespaceSources) + len(nodeNameSources); len(results) != want {
+		t.Errorf("Diagnose() returned %d results, want %d", len(results), want)
+	}
+}
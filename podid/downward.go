@@ -0,0 +1,154 @@
+package podid
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// PodInfoDir is where GetPodName and GetNamespace look for a Downward API
+	// volume exposing metadata.name as "name" and metadata.namespace as
+	// "namespace".
+	PodInfoDir = "/etc/podinfo"
+
+	// NamespacePath is where Kubernetes mounts the service account namespace.
+	NamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+var (
+	// ErrPodNameNotFound is returned when no source holds the pod name.
+	ErrPodNameNotFound = errors.New("pod name not found")
+
+	// ErrNamespaceNotFound is returned when no source holds the namespace.
+	ErrNamespaceNotFound = errors.New("pod namespace not found")
+
+	// ErrNodeNameNotFound is returned when NODE_NAME is not set.
+	ErrNodeNameNotFound = errors.New("node name not found")
+
+	getenv = os.Getenv
+
+	podName   cachedValue
+	namespace cachedValue
+	nodeName  cachedValue
+
+	podNameSources = []valueSource{
+		{env: "POD_NAME"},
+		{path: PodInfoDir + "/name"},
+	}
+	namespaceSources = []valueSource{
+		{env: "POD_NAMESPACE"},
+		{path: PodInfoDir + "/namespace"},
+		{path: NamespacePath},
+	}
+	// spec.nodeName is only available to env vars, not Downward API volumes.
+	nodeNameSources = []valueSource{
+		{env: "NODE_NAME"},
+	}
+)
+
+// GetPodName returns the name of the current pod from the POD_NAME
+// environment variable or the "name" file of a Downward API volume mounted
+// at PodInfoDir. The result is cached after the first successful call.
+// Failures are reported as a *DetectionError matching ErrPodNameNotFound.
+func GetPodName(opts ...Option) (string, error) {
+	return podName.get(newOptions(opts), "pod name", podNameSources, ErrPodNameNotFound)
+}
+
+// GetNamespace returns the namespace of the current pod from the
+// POD_NAMESPACE environment variable, the "namespace" file of a Downward API
+// volume mounted at PodInfoDir, or the service account namespace file. The
+// result is cached after the first successful call. Failures are reported as
+// a *DetectionError matching ErrNamespaceNotFound.
+func GetNamespace(opts ...Option) (string, error) {
+	return namespace.get(newOptions(opts), "pod namespace", namespaceSources, ErrNamespaceNotFound)
+}
+
+// GetNodeName returns the name of the node running the current pod from the
+// NODE_NAME environment variable, set with a spec.nodeName fieldRef. The
+// result is cached after the first successful call. Failures are reported as
+// a *DetectionError matching ErrNodeNameNotFound.
+func GetNodeName(opts ...Option) (string, error) {
+	return nodeName.get(newOptions(opts), "node name", nodeNameSources, ErrNodeNameNotFound)
+}
+
+// valueSource is an environment variable or a file holding a value.
+type valueSource struct {
+	env  string
+	path string
+}
+
+func (s valueSource) String() string {
+	if s.env != "" {
+		return "env:" + s.env
+	}
+	return s.path
+}
+
+// read returns the trimmed value of s, or an error wrapping notFound if it is
+// unset or empty.
+func (s valueSource) read(fsys fs.FS, notFound error) (string, error) {
+	var v string
+	if s.env != "" {
+		v = getenv(s.env)
+	} else {
+		data, err := fs.ReadFile(fsys, strings.TrimPrefix(s.path, "/"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", s.path, err)
+		}
+		v = string(data)
+	}
+	if v = strings.TrimSpace(v); v == "" {
+		return "", notFound
+	}
+	return v, nil
+}
+
+// cachedValue caches the first value found by get.
+type cachedValue struct {
+	mu    sync.RWMutex
+	value string
+	ok    bool
+}
+
+// get returns the cached value, or tries sources in order and caches the
+// first value found. Lookups through a custom filesystem bypass the cache.
+func (c *cachedValue) get(o options, what string, sources []valueSource, notFound error) (string, error) {
+	if o.fsys == nil {
+		c.mu.RLock()
+		value, ok := c.value, c.ok
+		c.mu.RUnlock()
+		if ok {
+			o.debug("podid: using cached "+what, slog.String("value", value))
+			return value, nil
+		}
+	}
+
+	fsys := o.fsys
+	if fsys == nil {
+		fsys = rootFS
+	}
+
+	var failed []SourceError
+	for _, s := range sources {
+		v, err := s.read(fsys, notFound)
+		if err != nil {
+			o.debug("podid: source failed", slog.String("source", s.String()), slog.Any("error", err))
+			failed = append(failed, SourceError{Source: s.String(), Err: err})
+			continue
+		}
+		o.debug("podid: source found "+what, slog.String("source", s.String()), slog.String("value", v))
+
+		if o.fsys == nil {
+			c.mu.Lock()
+			c.value, c.ok = v, true
+			c.mu.Unlock()
+		}
+		return v, nil
+	}
+	return "", &DetectionError{Sources: failed, what: what}
+}
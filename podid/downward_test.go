@@ -0,0 +1,108 @@
+package podid
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// resetDownwardState clears the cached values and stubs the environment with
+// env.
+func resetDownwardState(env map[string]string) func() {
+	origGetenv, origRoot := getenv, rootFS
+	podName, namespace, nodeName = cachedValue{}, cachedValue{}, cachedValue{}
+	getenv = func(key string) string { return env[key] }
+	rootFS = fstest.MapFS{}
+
+	return func() {
+		getenv, rootFS = origGetenv, origRoot
+		podName, namespace, nodeName = cachedValue{}, cachedValue{}, cachedValue{}
+	}
+}
+
+func TestGetNamespaceSources(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		files fstest.MapFS
+		want  string
+	}{
+		{"env", map[string]string{"POD_NAMESPACE": "from-env"}, fstest.MapFS{"etc/podinfo/namespace": {Data: []byte("from-volume")}}, "from-env"},
+		{"downward volume", nil, fstest.MapFS{"etc/podinfo/namespace": {Data: []byte("from-volume\n")}, "var/run/secrets/kubernetes.io/serviceaccount/namespace": {Data: []byte("from-sa")}}, "from-volume"},
+		{"service account", nil, fstest.MapFS{"var/run/secrets/kubernetes.io/serviceaccount/namespace": {Data: []byte("from-sa")}}, "from-sa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := resetDownwardState(tt.env)
+			defer restore()
+			rootFS = tt.files
+
+			got, err := GetNamespace()
+			if err != nil || got != tt.want {
+				t.Errorf("GetNamespace() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPodNameCachesSuccessfulResult(t *testing.T) {
+	env := map[string]string{}
+	restore := resetDownwardState(env)
+	defer restore()
+
+	if _, err := GetPodName(); !errors.Is(err, ErrPodNameNotFound) {
+		t.Fatalf("GetPodName() error = %v, want ErrPodNameNotFound", err)
+	}
+
+	env["POD_NAME"] = "web-0"
+	if got, err := GetPodName(); err != nil || got != "web-0" {
+		t.Fatalf("GetPodName() = %q, %v, want web-0 after a failed call", got, err)
+	}
+
+	env["POD_NAME"] = "web-1"
+	if got, _ := GetPodName(); got != "web-0" {
+		t.Errorf("GetPodName() = %q, want cached web-0", got)
+	}
+
+	// A custom filesystem bypasses the cache.
+	delete(env, "POD_NAME")
+	got, err := GetPodName(WithFS(fstest.MapFS{"etc/podinfo/name": {Data: []byte("web-2")}}))
+	if err != nil || got != "web-2" {
+		t.Errorf("GetPodName(WithFS) = %q, %v, want web-2", got, err)
+	}
+}
+
+func TestGetNodeNameReturnsDetectionError(t *testing.T) {
+	restore := resetDownwardState(nil)
+	defer restore()
+
+	_, err := GetNodeName()
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("GetNodeName() error = %v, want *DetectionError", err)
+	}
+	if len(detErr.Sources) != 1 || detErr.Sources[0].Source != "env:NODE_NAME" {
+		t.Errorf("DetectionError.Sources = %+v, want env:NODE_NAME", detErr.Sources)
+	}
+	if !errors.Is(err, ErrNodeNameNotFound) {
+		t.Errorf("GetNodeName() error = %v, want ErrNodeNameNotFound", err)
+	}
+	if want := "podid: node name not detected (scanned env:NODE_NAME: node name not found)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestGetNamespaceReportsEverySource(t *testing.T) {
+	restore := resetDownwardState(nil)
+	defer restore()
+
+	_, err := GetNamespace()
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) || len(detErr.Sources) != 3 {
+		t.Fatalf("GetNamespace() error = %v, want a *DetectionError with 3 sources", err)
+	}
+	if !errors.Is(err, ErrNamespaceNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetNamespace() error = %v, want it to match ErrNamespaceNotFound and fs.ErrNotExist", err)
+	}
+}
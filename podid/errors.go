@@ -0,0 +1,45 @@
+package podid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceError records why one source did not yield a pod ID.
+type SourceError struct {
+	// Source is the file or endpoint that was scanned.
+	Source string
+	Err    error
+}
+
+// DetectionError is returned by Get, GetPodName, GetNamespace and
+// GetNodeName when no source yielded a value. Retrieve it with errors.As to
+// log every source that was scanned and why each one failed. errors.Is still
+// matches the underlying per-source errors.
+type DetectionError struct {
+	Sources []SourceError
+
+	// what names the value that was not found; empty means the pod ID.
+	what string
+}
+
+func (e *DetectionError) Error() string {
+	parts := make([]string, len(e.Sources))
+	for i, s := range e.Sources {
+		parts[i] = fmt.Sprintf("%s: %v", s.Source, s.Err)
+	}
+	what := e.what
+	if what == "" {
+		what = "pod ID"
+	}
+	return "podid: " + what + " not detected (scanned " + strings.Join(parts, "; ") + ")"
+}
+
+// Unwrap returns the per-source errors.
+func (e *DetectionError) Unwrap() []error {
+	errs := make([]error, len(e.Sources))
+	for i, s := range e.Sources {
+		errs[i] = s.Err
+	}
+	return errs
+}
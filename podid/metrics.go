@@ -0,0 +1,52 @@
+package podid
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/ming-go/lab/get-container-id/detectmetrics"
+)
+
+// Failure reasons recorded in the detection metrics.
+const (
+	ReasonNotFound         = "not_found"
+	ReasonSourceMissing    = "source_missing"
+	ReasonPermissionDenied = "permission_denied"
+	ReasonReadError        = "read_error"
+)
+
+var metrics = detectmetrics.New("podid")
+
+// Metrics returns the detection metrics of this package: provider attempts,
+// successes, failures by reason, cache hits and misses, and detection
+// duration. Pass it to detectmetrics.WritePrometheus to expose them.
+func Metrics() *detectmetrics.Recorder {
+	return metrics
+}
+
+// runProvider runs lookup and records its outcome and duration.
+func runProvider(lookup func() (string, error)) (string, error) {
+	start := time.Now()
+	id, err := lookup()
+	if err != nil {
+		metrics.Failure(failureReason(err), time.Since(start))
+		return "", err
+	}
+	metrics.Success(time.Since(start))
+	return id, nil
+}
+
+// failureReason maps a provider error to a metrics reason label.
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrPodIDNotFound):
+		return ReasonNotFound
+	case errors.Is(err, fs.ErrNotExist):
+		return ReasonSourceMissing
+	case errors.Is(err, fs.ErrPermission):
+		return ReasonPermissionDenied
+	default:
+		return ReasonReadError
+	}
+}
@@ -0,0 +1,48 @@
+package podid
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+)
+
+// Option configures a call to Get, GetPodName, GetNamespace or GetNodeName.
+type Option func(*options)
+
+type options struct {
+	logger *slog.Logger
+	fsys   fs.FS
+}
+
+// WithLogger makes Get emit debug-level records about the providers it runs
+// and what they found. Without it, Get does not log.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithFS makes Get read /proc from fsys instead of the host root, for example
+// os.DirFS("/host") when the host's /proc is mounted elsewhere. Results read
+// through a custom filesystem are not cached.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// debug logs msg at debug level if a logger was configured.
+func (o options) debug(msg string, attrs ...slog.Attr) {
+	if o.logger == nil {
+		return
+	}
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
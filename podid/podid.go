@@ -1,22 +1,31 @@
 // Package podid provides utilities to extract Kubernetes Pod ID (UUID)
-// from the current pod environment by parsing /proc/self/mountinfo.
+// from the current pod environment by parsing /proc/self/mountinfo, and the
+// pod name, namespace and node name from the Downward API.
 //
 // This package works with all standard Kubernetes distributions including
 // kubeadm, MicroK8s, k3s, minikube, and others.
 package podid
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+
+	"github.com/ming-go/lab/get-container-id/internal/singleflight"
+	"github.com/ming-go/lab/get-container-id/mountinfo"
 )
 
 const (
 	// MountInfoPath is the default path to the Linux mountinfo file.
-	MountInfoPath = "/proc/self/mountinfo"
+	MountInfoPath = mountinfo.Path
 )
 
 var (
@@ -33,34 +42,84 @@ var (
 	cachedID string
 	hasID    bool
 	mu       sync.RWMutex
+	group    singleflight.Group[string]
 
 	getPodIDFunc = getPodIDFromMountInfo
+
+	// rootFS is the host filesystem that Get reads from by default.
+	rootFS fs.FS = os.DirFS("/")
+
+	// mountInfoName is MountInfoPath as an fs.FS path.
+	mountInfoName = strings.TrimPrefix(MountInfoPath, "/")
 )
 
 // Get retrieves the Kubernetes Pod ID (UUID) from /proc/self/mountinfo.
-// The result is cached after the first successful call for performance.
+// The result is cached after the first successful call for performance,
+// until Reset or the SetCacheTTL expiry.
 //
-// Returns ErrPodIDNotFound if not running in a Kubernetes pod.
-func Get() (string, error) {
+// Returns ErrPodIDNotFound if not running in a Kubernetes pod. Failures are
+// reported as a *DetectionError, which errors.Is still matches against
+// ErrPodIDNotFound. Pass WithLogger to trace the lookup.
+func Get(opts ...Option) (string, error) {
+	return GetContext(context.Background(), opts...)
+}
+
+// GetContext is like Get but stops waiting and returns ctx.Err() once ctx is
+// done. A cold-cache lookup shared with other callers keeps running after
+// ctx is done, so its result is still cached for them.
+func GetContext(ctx context.Context, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if o.fsys != nil {
+		// Lookups against a caller-supplied filesystem bypass the cache.
+		o.debug("podid: running provider", slog.String("provider", "mountinfo"), slog.String("path", MountInfoPath), slog.Bool("custom_fs", true))
+		id, err := runProvider(func() (string, error) { return GetFromFS(o.fsys, mountInfoName) })
+		if err != nil {
+			return "", &DetectionError{Sources: []SourceError{{Source: MountInfoPath, Err: err}}}
+		}
+		return id, nil
+	}
+
 	mu.RLock()
-	if hasID {
+	if cacheValid() {
 		id := cachedID
 		mu.RUnlock()
+		metrics.CacheHit()
+		o.debug("podid: using cached pod ID", slog.String("id", id))
 		return id, nil
 	}
+	gen := generation
 	mu.RUnlock()
 
-	id, err := getPodIDFunc()
-	if err != nil {
-		return "", err
-	}
+	metrics.CacheMiss()
+
+	// Concurrent callers on a cold cache share a single lookup, which is not
+	// tied to any one caller's cancellation.
+	ch := group.DoChan(strconv.FormatUint(gen, 10), func() (string, error) {
+		o.debug("podid: running provider", slog.String("provider", "mountinfo"), slog.String("path", MountInfoPath))
+		id, err := runProvider(getPodIDFunc)
+		if err != nil {
+			o.debug("podid: provider failed", slog.String("provider", "mountinfo"), slog.Any("error", err))
+			return "", &DetectionError{Sources: []SourceError{{Source: MountInfoPath, Err: err}}}
+		}
+		o.debug("podid: provider found pod ID", slog.String("provider", "mountinfo"), slog.String("id", id))
 
-	mu.Lock()
-	cachedID = id
-	hasID = true
-	mu.Unlock()
+		store(gen, id)
 
-	return id, nil
+		return id, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			o.debug("podid: shared concurrent lookup")
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		o.debug("podid: stopped waiting for lookup", slog.Any("error", ctx.Err()))
+		return "", ctx.Err()
+	}
 }
 
 // GetFromFile retrieves the Pod ID from a specific mountinfo file path.
@@ -72,38 +131,58 @@ func GetFromFile(path string) (string, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// The regex will find a path like:
-		// ".../kubelet/pods/036da4f7-d553-4eb6-9802-90f81041a412/etc-hosts..."
-		//
-		// match[0] will be the full string: "/pods/036da4f7.../"
-		// match[1] will be just the UUID: "036da4f7..."
-		match := podIDRegex.FindStringSubmatch(line)
-
-		if len(match) == 2 {
-			// We found it. match[1] is the sub-match
-			// (the part in the parentheses).
-			return match[1], nil
-		}
-	}
+	d, err := scanMountInfo(file, path)
+	return d.ID, err
+}
+
+// GetFromFS retrieves the Pod ID from the mountinfo file name in fsys.
+// Together with os.DirFS it allows reading a /proc mounted elsewhere, and
+// with testing/fstest.MapFS it allows fully in-memory tests.
+func GetFromFS(fsys fs.FS, name string) (string, error) {
+	d, err := detectFS(fsys, name)
+	return d.ID, err
+}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading %s: %w", path, err)
+// detectFS is GetFromFS with the mount the ID was found in.
+func detectFS(fsys fs.FS, name string) (Detection, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return Detection{}, fmt.Errorf("failed to open %s: %w", name, err)
 	}
+	defer file.Close()
+
+	return scanMountInfo(file, name)
+}
 
-	// We scanned the whole file and found nothing.
-	return "", ErrPodIDNotFound
+// scanMountInfo returns the first Pod ID found in the paths of the mounts of
+// a mountinfo stream, such as the kubelet's
+// /var/lib/kubelet/pods/036da4f7-d553-4eb6-9802-90f81041a412/etc-hosts,
+// and the mount's line. name is only used in error messages.
+func scanMountInfo(r io.Reader, name string) (Detection, error) {
+	var d Detection
+	err := mountinfo.Scan(r, func(m mountinfo.Mount) bool {
+		for _, path := range m.Paths() {
+			if match := podIDRegex.FindStringSubmatch(path); len(match) == 2 {
+				d = Detection{ID: match[1], Line: m.String()}
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return Detection{}, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	if d.ID == "" {
+		return Detection{}, ErrPodIDNotFound
+	}
+	return d, nil
 }
 
 // getPodIDFromMountInfo retrieves the Pod ID by parsing
 // /proc/self/mountinfo and looking for a kubelet mount
 // path containing a UUID.
 func getPodIDFromMountInfo() (string, error) {
-	return GetFromFile(MountInfoPath)
+	return GetFromFS(rootFS, mountInfoName)
 }
 
 // IsInPod checks if the current process is running inside a Kubernetes pod.
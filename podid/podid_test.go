@@ -1,27 +1,37 @@
 package podid
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func resetTestState() func() {
 	origFunc := getPodIDFunc
 	origCachedID := cachedID
 	origHasID := hasID
+	origTTL, origNow := cacheTTL, now
 
 	cachedID = ""
 	hasID = false
+	cacheTTL, now = 0, time.Now
 	mu = sync.RWMutex{}
 	getPodIDFunc = getPodIDFromMountInfo
 
 	return func() {
 		cachedID = origCachedID
 		hasID = origHasID
+		cacheTTL, now = origTTL, origNow
 		mu = sync.RWMutex{}
 		getPodIDFunc = origFunc
 	}
@@ -137,6 +147,250 @@ func TestGetDoesNotCacheFailure(t *testing.T) {
 	}
 }
 
+func TestGetDeduplicatesConcurrentCalls(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := "036da4f7-d553-4eb6-9802-90f81041a412"
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	getPodIDFunc = func() (string, error) {
+		if calls.Add(1) == 1 {
+			close(entered)
+		}
+		<-release
+		return want, nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := Get()
+			if err == nil && got != want {
+				err = fmt.Errorf("Get = %q, want %q", got, want)
+			}
+			errs <- err
+		}()
+	}
+
+	<-entered
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("Get called provider %d times, want 1", got)
+	}
+}
+
+func TestGetContextCanceled(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	var calls atomic.Int32
+	getPodIDFunc = func() (string, error) {
+		calls.Add(1)
+		return "036da4f7-d553-4eb6-9802-90f81041a412", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GetContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetContext error = %v, want context.Canceled", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("lookup ran %d times with a canceled context, want 0", calls.Load())
+	}
+}
+
+func TestGetContextStopsWaiting(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := "036da4f7-d553-4eb6-9802-90f81041a412"
+	release := make(chan struct{})
+	getPodIDFunc = func() (string, error) {
+		<-release
+		return want, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := GetContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetContext error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The abandoned lookup still completes and fills the cache.
+	close(release)
+	if got, err := Get(); err != nil || got != want {
+		t.Fatalf("Get after timeout = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestGetWithLogger(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := "036da4f7-d553-4eb6-9802-90f81041a412"
+	getPodIDFunc = func() (string, error) { return want, nil }
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := Get(WithLogger(logger)); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if _, err := Get(WithLogger(logger)); err != nil {
+		t.Fatalf("Get second call returned error: %v", err)
+	}
+
+	out := buf.String()
+	for _, msg := range []string{
+		"podid: running provider",
+		"podid: provider found pod ID",
+		"podid: using cached pod ID",
+	} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("log output missing %q:\n%s", msg, out)
+		}
+	}
+	if !strings.Contains(out, want) {
+		t.Errorf("log output missing id %q:\n%s", want, out)
+	}
+}
+
+func TestGetWithoutLoggerIsSilent(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	getPodIDFunc = func() (string, error) { return "", errors.New("not found") }
+
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(orig)
+
+	_, _ = Get()
+	if buf.Len() != 0 {
+		t.Errorf("Get without logger wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestGetFromFS(t *testing.T) {
+	want := "7f3e0b5c-1d2a-4c6b-9e8f-0a1b2c3d4e5f"
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/" + want + "/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n")},
+	}
+
+	got, err := GetFromFS(fsys, "proc/self/mountinfo")
+	if err != nil {
+		t.Fatalf("GetFromFS returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("GetFromFS = %q, want %q", got, want)
+	}
+
+	if _, err := GetFromFS(fstest.MapFS{}, "proc/self/mountinfo"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("GetFromFS on missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestGetReadsRootFS(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	want := "7f3e0b5c-1d2a-4c6b-9e8f-0a1b2c3d4e5f"
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/" + want + "/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n")},
+	}
+
+	got, err := Get()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("Get = %q, want %q", got, want)
+	}
+}
+
+func TestGetWithFSBypassesCache(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	cached := "cached"
+	getPodIDFunc = func() (string, error) { return cached, nil }
+	if _, err := Get(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	want := "7f3e0b5c-1d2a-4c6b-9e8f-0a1b2c3d4e5f"
+	fsys := fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("29 37 0:25 / /var/lib/kubelet/pods/" + want + "/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n")},
+	}
+	got, err := Get(WithFS(fsys))
+	if err != nil {
+		t.Fatalf("Get(WithFS) returned error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("Get(WithFS) = %q, want %q", got, want)
+	}
+
+	if got, _ := Get(); got != cached {
+		t.Fatalf("Get after WithFS = %q, want cached %q", got, cached)
+	}
+}
+
+func TestGetReturnsDetectionError(t *testing.T) {
+	restore := resetTestState()
+	defer restore()
+
+	origRoot := rootFS
+	defer func() { rootFS = origRoot }()
+	rootFS = fstest.MapFS{
+		"proc/self/mountinfo": {Data: []byte("1235 1234 0:315 / /proc rw - proc proc rw\n")},
+	}
+
+	_, err := Get()
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Get error = %v, want *DetectionError", err)
+	}
+	if len(detErr.Sources) != 1 || detErr.Sources[0].Source != MountInfoPath || detErr.Sources[0].Err == nil {
+		t.Fatalf("DetectionError.Sources = %+v, want one failed %s source", detErr.Sources, MountInfoPath)
+	}
+	if !strings.Contains(err.Error(), MountInfoPath) {
+		t.Fatalf("Get error = %q, want it to mention %s", err, MountInfoPath)
+	}
+	if !errors.Is(err, ErrPodIDNotFound) {
+		t.Fatalf("Get error = %v, want it to wrap ErrPodIDNotFound", err)
+	}
+}
+
+func TestGetWithFSReturnsDetectionError(t *testing.T) {
+	_, err := Get(WithFS(fstest.MapFS{}))
+
+	var detErr *DetectionError
+	if !errors.As(err, &detErr) {
+		t.Fatalf("Get error = %v, want *DetectionError", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Get error = %v, want it to wrap fs.ErrNotExist", err)
+	}
+}
+
 func TestGetFromFileHandlesLongLines(t *testing.T) {
 	want := "0f8fad5b-d9cb-469f-a165-70867728950e"
 	padding := strings.Repeat("a", 70*1024)